@@ -35,6 +35,13 @@ if [ -f /opt/ivpn/etc/firewall.sh ] || [ -f /opt/ivpn/etc/splittun.sh ]; then
     /opt/ivpn/etc/splittun.sh reset >/dev/null 2>&1         && echo "OK" || echo "NOK"
     printf "    * /opt/ivpn/etc/splittun.sh stop         : "
     /opt/ivpn/etc/splittun.sh stop >/dev/null 2>&1          && echo "OK" || echo "NOK"
+    if [ -d /sys/fs/cgroup/ivpn-exclude ]; then
+      # Split Tunnel was running on cgroup v2 (unified hierarchy)
+      printf "    * /opt/ivpn/etc/splittun.sh -cgroup2 reset: "
+      /opt/ivpn/etc/splittun.sh -cgroup2 reset >/dev/null 2>&1 && echo "OK" || echo "NOK"
+      printf "    * /opt/ivpn/etc/splittun.sh -cgroup2 stop : "
+      /opt/ivpn/etc/splittun.sh -cgroup2 stop >/dev/null 2>&1  && echo "OK" || echo "NOK"
+    fi
   fi
 fi
 
@@ -38,9 +38,9 @@ cd $SCRIPT_DIR/../../
 if [[ "$@" == *"-debug"* ]]
 then
     echo "Compiling in DEBUG mode"
-    go build -tags debug -o "$OUT_FILE" -trimpath -ldflags "-X github.com/ivpn/desktop-app/daemon/version._version=$VERSION -X github.com/ivpn/desktop-app/daemon/version._commit=$COMMIT -X github.com/ivpn/desktop-app/daemon/version._time=$DATE"
+    go build -tags debug -o "$OUT_FILE" -trimpath -ldflags "-X github.com/tahirmahm123/vpn-desktop-app/daemon/version._version=$VERSION -X github.com/tahirmahm123/vpn-desktop-app/daemon/version._commit=$COMMIT -X github.com/tahirmahm123/vpn-desktop-app/daemon/version._time=$DATE"
 else
-    go build -o "$OUT_FILE" -trimpath -ldflags "-s -w -X github.com/ivpn/desktop-app/daemon/version._version=$VERSION -X github.com/ivpn/desktop-app/daemon/version._commit=$COMMIT -X github.com/ivpn/desktop-app/daemon/version._time=$DATE"
+    go build -o "$OUT_FILE" -trimpath -ldflags "-s -w -X github.com/tahirmahm123/vpn-desktop-app/daemon/version._version=$VERSION -X github.com/tahirmahm123/vpn-desktop-app/daemon/version._commit=$COMMIT -X github.com/tahirmahm123/vpn-desktop-app/daemon/version._time=$DATE"
 fi
 
 echo "Compiled CLI binary: '$OUT_FILE'"
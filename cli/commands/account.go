@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -31,10 +31,10 @@ import (
 	"text/tabwriter"
 	"time"
 
-	"github.com/ivpn/desktop-app/cli/flags"
-	"github.com/ivpn/desktop-app/daemon/api/types"
-	"github.com/ivpn/desktop-app/daemon/service/srverrors"
-	"github.com/ivpn/desktop-app/daemon/vpn"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/srverrors"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 	"golang.org/x/term"
 )
 
@@ -57,21 +57,19 @@ func (c *CmdLogout) Run() error {
 // ----------------------------------------------------------------------------------------
 type CmdLogin struct {
 	flags.CmdInfo
-	accountID string
-	force     bool
+	pin string
 }
 
 func (c *CmdLogin) Init() {
-	c.Initialize("login", "Login operation (register ACCOUNT_ID on this device)")
-	c.DefaultStringVar(&c.accountID, "ACCOUNT_ID")
-	c.BoolVar(&c.force, "force", false, "Log out from all other devices (applicable only with 'login' option)")
+	c.Initialize("login", "Login operation (register this device using the login PIN)")
+	c.DefaultStringVar(&c.pin, "PIN")
 }
 
 func (c *CmdLogin) Run() error {
-	return doLogin(c.accountID, c.force)
+	return doLogin(c.pin)
 }
 
-func doLogin(accountID string, force bool) error {
+func doLogin(pin string) error {
 	// checking if we are logged-in
 	_proto.SessionStatus() // do not check error response (could be received 'not logged in' errors)
 	helloResp := _proto.GetHelloResponse()
@@ -82,40 +80,30 @@ func doLogin(accountID string, force bool) error {
 	}
 
 	// login
-	if len(accountID) == 0 {
-		fmt.Print("Enter your Account ID: ")
+	if len(pin) == 0 {
+		fmt.Print("Enter your login PIN: ")
 		data, err := term.ReadPassword(int(syscall.Stdin))
 		fmt.Println("")
 		if err != nil {
-			return fmt.Errorf("failed to read accountID: %w", err)
+			return fmt.Errorf("failed to read PIN: %w", err)
 		}
-		accountID = string(data)
+		pin = string(data)
 	}
 
-	apiStatus, err := _proto.SessionNew(accountID, force, "")
-	if err != nil {
-		if apiStatus == types.The2FARequired {
-			fmt.Println("Account has two-factor authentication enabled.")
-			fmt.Print("Please enter TOTP token to login: ")
-			reader := bufio.NewReader(os.Stdin)
-			topt, _ := reader.ReadString('\n')
-
-			topt = strings.TrimSuffix(topt, "\n")
-			topt = strings.TrimSuffix(topt, "\r")
-
-			apiStatus, err = _proto.SessionNew(accountID, force, topt)
-		}
-
-		if apiStatus == types.CodeSessionsLimitReached {
-			PrintTips([]TipType{TipForceLogin})
-		}
+	pin = strings.TrimSpace(pin)
+	if len(pin) == 0 {
+		return fmt.Errorf("PIN is not defined")
+	}
 
-		if err != nil {
-			return err
-		}
+	resp, err := _proto.VerifyPin(pin)
+	if err != nil {
+		return err
 	}
 
 	fmt.Println("Logged in")
+	if !resp.Account.Active {
+		fmt.Println("Warning: the account is not active")
+	}
 	PrintTips([]TipType{TipServers, TipConnectHelp})
 
 	return nil
@@ -235,26 +223,22 @@ func checkStatus() error {
 		return err
 	}
 
-	if stat.APIStatus != types.CodeSuccess {
-		return fmt.Errorf("API error: %v %v", stat.APIStatus, stat.APIErrorMessage)
+	// use the last known account info when the daemon was not able to get the actual status from the backend
+	acc := helloResp.Account
+	if stat.APIStatus == types.CodeSuccess {
+		acc = stat.Account
 	}
 
-	acc := stat.Account
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 
-	fmt.Fprintln(w, fmt.Sprintf("Account ID:\t%v", helloResp.Session.AccountID))
-
-	if acc.IsFreeTrial {
-		fmt.Fprintln(w, fmt.Sprintf("Plan:\tFree Trial"))
+	fmt.Fprintln(w, fmt.Sprintf("Login PIN:\t%v", helloResp.Session.AccountID))
+	if acc.Active {
+		fmt.Fprintln(w, "Status:\tActive")
 	} else {
-		fmt.Fprintln(w, fmt.Sprintf("Plan:\t%v", acc.CurrentPlan))
-	}
-	fmt.Fprintln(w, fmt.Sprintf("Active until:\t%v", time.Unix(acc.ActiveUntil, 0)))
-	if stat.Account.Limit > 0 {
-		fmt.Fprintln(w, fmt.Sprintf("Devices limit:\t%v", acc.Limit))
+		fmt.Fprintln(w, "Status:\tNot active")
 	}
-	if acc.Upgradable == true && len(acc.UpgradeToPlan) > 0 && len(acc.UpgradeToURL) > 0 {
-		fmt.Fprintln(w, fmt.Sprintf("Upgrade to:\t%v (%v)", acc.UpgradeToPlan, acc.UpgradeToURL))
+	if acc.ActiveUntil > 0 {
+		fmt.Fprintln(w, fmt.Sprintf("Active until:\t%v", time.Unix(acc.ActiveUntil, 0)))
 	}
 	w.Flush()
 
@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -27,9 +27,9 @@ import (
 	"os"
 	"text/tabwriter"
 
-	"github.com/ivpn/desktop-app/cli/flags"
-	"github.com/ivpn/desktop-app/cli/helpers"
-	service_types "github.com/ivpn/desktop-app/daemon/protocol/types"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/helpers"
+	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
 )
 
 type CmdAutoConnect struct {
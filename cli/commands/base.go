@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -27,16 +27,17 @@ import (
 	"os"
 	"regexp"
 	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
-	"github.com/ivpn/desktop-app/cli/cliplatform"
-	"github.com/ivpn/desktop-app/cli/protocol"
-	apitypes "github.com/ivpn/desktop-app/daemon/api/types"
-	"github.com/ivpn/desktop-app/daemon/protocol/types"
-	"github.com/ivpn/desktop-app/daemon/splittun"
-	"github.com/ivpn/desktop-app/daemon/v2r"
-	"github.com/ivpn/desktop-app/daemon/vpn"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/cliplatform"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/protocol"
+	apitypes "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/splittun"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/v2r"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 )
 
 var _proto *protocol.Client
@@ -101,6 +102,16 @@ func printState(w *tabwriter.Writer, state vpn.State, connected types.ConnectedR
 	}
 
 	fmt.Fprintf(w, "    Protocol\t:\t%v\n", protocol)
+	if connected.VpnType == vpn.WireGuard {
+		quantumResistance := "Disabled"
+		if connected.IsQuantumResistant {
+			quantumResistance = "Enabled"
+		}
+		fmt.Fprintf(w, "    Quantum Resistance\t:\t%v\n", quantumResistance)
+	}
+	if len(connected.FallbackAttempt) > 0 {
+		fmt.Fprintf(w, "    Fallback attempt\t:\t%v\n", connected.FallbackAttempt)
+	}
 	fmt.Fprintf(w, "    Local IP\t:\t%v\n", connected.ClientIP)
 	if len(connected.ClientIPv6) > 0 {
 		fmt.Fprintf(w, "    Local IPv6\t:\t%v\n", connected.ClientIPv6)
@@ -122,7 +133,53 @@ func printState(w *tabwriter.Writer, state vpn.State, connected types.ConnectedR
 	return w
 }
 
-func printDNSState(w *tabwriter.Writer, dnsStatus types.DnsStatus, servers *apitypes.ServersInfoResponse) *tabwriter.Writer {
+func printGeoLookup(w *tabwriter.Writer, geo types.GeoLookupResp) *tabwriter.Writer {
+	if w == nil {
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	}
+
+	locationStr := func(l *apitypes.GeoLookupResponse) string {
+		ret := l.IP
+		place := make([]string, 0, 2)
+		for _, p := range []string{l.City, l.Country} {
+			if len(p) > 0 {
+				place = append(place, p)
+			}
+		}
+		if len(place) > 0 {
+			ret += " in " + strings.Join(place, ", ")
+		}
+		viaVpn := "no"
+		if l.IsIvpnServer {
+			viaVpn = "yes"
+		}
+		return ret + fmt.Sprintf(" (via VPN: %s)", viaVpn)
+	}
+
+	if geo.Location != nil {
+		fmt.Fprintf(w, "Public IP\t:\t%v\n", locationStr(geo.Location))
+	}
+	if geo.LocationIPv6 != nil {
+		fmt.Fprintf(w, "Public IPv6\t:\t%v\n", locationStr(geo.LocationIPv6))
+	}
+	return w
+}
+
+func printTunnelStats(stats types.TunnelStatsResp) {
+	handshake := ""
+	if stats.LastHandshakeSecFrom1970 > 0 {
+		handshake = fmt.Sprintf("  Handshake: %v ago", time.Since(time.Unix(stats.LastHandshakeSecFrom1970, 0)).Round(time.Second))
+	}
+	fmt.Printf("[%s] Received: %s  Sent: %s%s  Endpoint: %s  MTU: %d\n",
+		time.Now().Format("15:04:05"),
+		formatBytes(stats.RxBytes),
+		formatBytes(stats.TxBytes),
+		handshake,
+		stats.Endpoint,
+		stats.Mtu)
+}
+
+func printDNSState(w *tabwriter.Writer, dnsStatus types.DnsStatus, servers *apitypes.ServerListResponse) *tabwriter.Writer {
 	if w == nil {
 		w = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 	}
@@ -304,3 +361,16 @@ func printParanoidModeState(w *tabwriter.Writer, helloResp types.HelloResp) *tab
 
 	return w
 }
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -30,15 +30,16 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/ivpn/desktop-app/cli/flags"
-	apitypes "github.com/ivpn/desktop-app/daemon/api/types"
-	"github.com/ivpn/desktop-app/daemon/obfsproxy"
-	"github.com/ivpn/desktop-app/daemon/protocol/types"
-	"github.com/ivpn/desktop-app/daemon/service/dns"
-	"github.com/ivpn/desktop-app/daemon/service/srverrors"
-	service_types "github.com/ivpn/desktop-app/daemon/service/types"
-	"github.com/ivpn/desktop-app/daemon/v2r"
-	"github.com/ivpn/desktop-app/daemon/vpn"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	apitypes "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/obfsproxy"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/serverscore"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/srverrors"
+	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/v2r"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 )
 
 type port struct {
@@ -137,14 +138,14 @@ type CmdConnect struct {
 
 	filter_proto       string
 	filter_location    bool
-	filter_city        bool
 	filter_country     bool
 	filter_countryCode bool
 	filter_invert      bool
 
 	multihopExitSvr string
 
-	fastest bool
+	fastest     bool
+	recommended bool
 }
 
 func (c *CmdConnect) Init() {
@@ -155,22 +156,22 @@ func (c *CmdConnect) Init() {
 	c.DefaultStringVar(&c.gateway, "LOCATION")
 
 	// Filtering flags
-	c.BoolVar(&c.filter_city, "city", false, "Apply LOCATION as a filter to city name")
 	c.BoolVar(&c.filter_countryCode, "cc", false, "Apply LOCATION as a filter to country code")
 	c.BoolVar(&c.filter_countryCode, "country_code", false, "Apply LOCATION as a filter to country code")
 	c.BoolVar(&c.filter_country, "c", false, "Apply LOCATION as a filter to country name")
 	c.BoolVar(&c.filter_country, "country", false, "Apply LOCATION as a filter to country name")
-	c.BoolVar(&c.filter_location, "l", false, "Apply LOCATION as a filter to server location (Hostname)")
-	c.BoolVar(&c.filter_location, "location", false, "Apply LOCATION as a filter to server location (Hostname)")
+	c.BoolVar(&c.filter_location, "l", false, "Apply LOCATION as a filter to server location (server name)")
+	c.BoolVar(&c.filter_location, "location", false, "Apply LOCATION as a filter to server location (server name)")
 	c.BoolVar(&c.filter_invert, "filter_invert", false, "Invert filtering")
 
 	// Automatic server selection flags
 	c.BoolVar(&c.fastest, "fastest", false, "Connect to fastest server")
+	c.BoolVar(&c.recommended, "recommended", false, "Connect to recommended server\n  (servers are ranked by latency, distance, success rate of the previous connections and server load; see 'recommend' command)")
 	c.BoolVar(&c.last, "last", false, "Connect with the last used connection parameters")
 	c.BoolVar(&c.any, "any", false, "Use a random server from the found results to connect")
 
 	// Multi-Hop
-	c.StringVar(&c.multihopExitSvr, "exit_svr", "", "LOCATION", "Exit-server for Multi-Hop connection\n  (use full serverID as a parameter, servers filtering not applicable for it)")
+	c.StringVar(&c.multihopExitSvr, "exit_svr", "", "LOCATION", "Exit-server for Multi-Hop connection\n  (use full server name as a parameter, servers filtering not applicable for it)\n  Note: entry- and exit- servers must be located in different countries")
 
 	// Protocol flags
 	c.StringVar(&c.filter_proto, "protocol", "", "PROTOCOL", "Protocol type (OpenVPN|ovpn|WireGuard|wg)")
@@ -180,7 +181,7 @@ func (c *CmdConnect) Init() {
 
 	// Port flags
 	c.BoolVar(&c.portsShow, "show_ports", false, "Ports which are applicable for '-port' argument. Show all supported connection ports")
-	c.StringVar(&c.port, "port", "", "PROTOCOL:PORT", fmt.Sprintf("Port to connect to (default: '%s')\n  Note: port number ignored for Multi-Hop connections; port type only applicable (UDP/TCP)\n  Tip: use `ivpn connect -show_ports` command to show all supported ports", defaultPort()))
+	c.StringVar(&c.port, "port", "", "PROTOCOL:PORT", "Port to connect to (default: the first supported port of the protocol)\n  Note: port number ignored for Multi-Hop connections; port type only applicable (UDP/TCP)\n  Tip: use `ivpn connect -show_ports` command to show all supported ports")
 
 	// Firewall flags
 	c.BoolVar(&c.firewallOff, "fw_off", false, "Do not enable firewall for this connection\n  (has effect only if Firewall not enabled before)")
@@ -240,9 +241,12 @@ func (c *CmdConnect) preParse(arguments []string) ([]string, error) {
 // Run executes command
 func (c *CmdConnect) Run() (retError error) {
 
-	if len(c.gateway) == 0 && !c.fastest && !c.any && !c.last && !c.portsShow {
+	if len(c.gateway) == 0 && !c.fastest && !c.recommended && !c.any && !c.last && !c.portsShow {
 		return flags.BadParameter{}
 	}
+	if c.fastest && c.recommended {
+		return flags.BadParameter{Message: "cannot use both '-fastest' and '-recommended' options"}
+	}
 	if c.v2rayProxy != "" && c.obfsproxy != "" {
 		return flags.BadParameter{Message: "cannot use both '-v2ray' and '-obfsproxy' options"}
 	}
@@ -257,9 +261,6 @@ func (c *CmdConnect) Run() (retError error) {
 		return err
 	}
 
-	customHostEntryServer := c.gateway
-	customHostExitServer := c.multihopExitSvr
-
 	obfsproxyCfg, err := parseObfsproxyParam(c.obfsproxy)
 	if err != nil {
 		return flags.BadParameter{Message: err.Error()}
@@ -283,8 +284,8 @@ func (c *CmdConnect) Run() (retError error) {
 		return srverrors.ErrorNotLoggedIn{}
 	}
 
-	allowedPortsWg := servers.Config.Ports.WireGuard
-	allowedPortsOvpn := servers.Config.Ports.OpenVPN
+	allowedPortsWg := wireGuardPorts(servers)
+	allowedPortsOvpn := openVPNPorts(servers)
 
 	// Modify allowed ports according to V2Ray configuration
 	if v2rayCfg == v2r.TCP {
@@ -293,28 +294,16 @@ func (c *CmdConnect) Run() (retError error) {
 			c.port = "TCP:80"
 		}
 		// "V2Ray (VMESS/TCP)" connections are always TCP. So we modify port type for WireGuard allowed ports (v2ray listens on the same ports as WireGuard but on both UDP and TCP)
-		allowedPortsWg = []apitypes.PortInfo{}
-		for _, p := range servers.Config.Ports.WireGuard {
-			p.Type = "TCP"
-			allowedPortsWg = append(allowedPortsWg, p)
-		}
-		allowedPortsOvpn = []apitypes.PortInfo{}
-		for _, p := range servers.Config.Ports.OpenVPN {
-			if p.IsTCP() {
-				allowedPortsOvpn = append(allowedPortsWg, p)
-			}
+		for i := range allowedPortsWg {
+			allowedPortsWg[i].tcp = true
 		}
+		allowedPortsOvpn = filterPorts(allowedPortsOvpn, true)
 	} else if v2rayCfg == v2r.QUIC {
 		if len(c.port) == 0 {
 			// If no port specified - use default V2Ray port for QUIC
 			c.port = "UDP:443"
 		}
-		allowedPortsOvpn = []apitypes.PortInfo{}
-		for _, p := range servers.Config.Ports.OpenVPN {
-			if p.IsUDP() {
-				allowedPortsOvpn = append(allowedPortsWg, p)
-			}
-		}
+		allowedPortsOvpn = filterPorts(allowedPortsOvpn, false)
 	}
 
 	if c.portsShow {
@@ -360,109 +349,123 @@ func (c *CmdConnect) Run() (retError error) {
 		req.Params = defaultConnSettings.Params
 		req.Params.FirewallOnDuringConnection = true
 	} else {
-		// MULTI\SINGLE -HOP
-		// Check if the parameters are correct and define correct values for c.gateway and c.multihopExitSvr
 		if len(c.multihopExitSvr) > 0 {
 			// MULTI-HOP
-
 			if err := helloResp.Account.IsCanConnectMultiHop(); err != nil {
 				return err
 			}
-
 			if c.fastest {
 				return flags.BadParameter{Message: "'fastest' flag is not applicable for Multi-Hop connection [exit_svr]"}
 			}
-
-			if c.filter_location || c.filter_city || c.filter_countryCode || c.filter_country || c.filter_invert {
+			if c.recommended {
+				return flags.BadParameter{Message: "'recommended' flag is not applicable for Multi-Hop connection [exit_svr]"}
+			}
+			if c.filter_location || c.filter_countryCode || c.filter_country || c.filter_invert {
 				fmt.Println("WARNING: filtering flags are ignored for Multi-Hop connection [exit_svr]")
 			}
+			// entry server must be defined by full server name
+			svrs = serversFilter(isWgDisabled, isOpenVPNDisabled, svrs, c.gateway, c.filter_proto, true, false, false, false)
+		} else {
+			svrs = serversFilter(isWgDisabled, isOpenVPNDisabled, svrs, c.gateway, c.filter_proto, c.filter_location, c.filter_countryCode, c.filter_country, c.filter_invert)
+		}
 
-			entrySvrs := serversFilter(isWgDisabled, isOpenVPNDisabled, svrs, c.gateway, c.filter_proto, false, false, false, false, false)
-			if len(entrySvrs) == 0 || len(entrySvrs) > 1 {
-				return flags.BadParameter{Message: "specify correct entry server ID for multi-hop connection"}
-			}
+		srvID := ""
+		srvProto := ""
 
-			exitSvrs := serversFilter(isWgDisabled, isOpenVPNDisabled, svrs, c.multihopExitSvr, c.filter_proto, false, false, false, false, false)
-			if len(exitSvrs) == 0 || len(exitSvrs) > 1 {
-				return flags.BadParameter{Message: "specify correct exit server ID for multi-hop connection"}
+		// Fastest server
+		if c.fastest && len(svrs) > 1 {
+			var vpnType *vpn.Type = nil
+			if len(c.filter_proto) > 0 {
+				if p, err := getVpnTypeByFlag(c.filter_proto); err == nil {
+					vpnType = &p
+				}
 			}
-			entrySvr := entrySvrs[0]
-			exitSvr := exitSvrs[0]
-
-			if entrySvr.gateway == exitSvr.gateway {
-				return flags.BadParameter{Message: "unable to use same entry- and exit- servers"}
+			if err := serversPing(svrs, true, vpnType); err != nil {
+				if c.any {
+					fmt.Printf("Error: Failed to ping servers to determine fastest: %s\n", err)
+				} else {
+					return err
+				}
 			}
-
-			if entrySvr.countryCode == exitSvr.countryCode {
-				fmt.Println("Warning! Entry- and exit- servers located in the same country.")
+			fastestSrv := svrs[len(svrs)-1]
+			if fastestSrv.pingMs == 0 {
+				fmt.Println("WARNING! Servers pinging problem.")
 			}
+			srvID = fastestSrv.gateway
+			srvProto = fastestSrv.protocol
+		}
 
-			c.gateway = entrySvr.gateway
-			c.multihopExitSvr = exitSvr.gateway
-		} else { //SINGLE-HOP
-			svrs = serversFilter(isWgDisabled, isOpenVPNDisabled, svrs, c.gateway, c.filter_proto, c.filter_location, c.filter_city, c.filter_countryCode, c.filter_country, c.filter_invert)
-
-			srvID := ""
+		// Recommended server
+		if c.recommended && len(svrs) > 1 {
+			vpnType := vpn.WireGuard
+			if p, err := getVpnTypeByFlag(c.filter_proto); err == nil {
+				vpnType = p
+			} else if isWgDisabled {
+				vpnType = vpn.OpenVPN
+			}
 
-			// Fastest server
-			if c.fastest && len(svrs) > 1 {
-				var vpnType *vpn.Type = nil
-				if len(c.filter_proto) > 0 {
-					if p, err := getVpnTypeByFlag(c.filter_proto); err == nil {
-						vpnType = &p
-					}
+			candidates, err := _proto.RecommendServers(vpnType, serverscore.Constraints{}, 0)
+			if err != nil {
+				if !c.any {
+					return err
 				}
-				if err := serversPing(svrs, true, vpnType); err != nil {
-					if c.any {
-						fmt.Printf("Error: Failed to ping servers to determine fastest: %s\n", err)
-					} else {
-						return err
+				fmt.Printf("Error: Failed to determine recommended server: %s\n", err)
+			}
+			// the best candidate which satisfies the filter
+			protoName := ProtoName_WireGuard
+			if vpnType == vpn.OpenVPN {
+				protoName = ProtoName_OpenVPN
+			}
+		candidatesLoop:
+			for _, cnd := range candidates {
+				for _, s := range svrs {
+					if s.protocol == protoName && strings.EqualFold(s.gateway, strings.TrimSpace(cnd.Host.Name)) {
+						srvID, srvProto = s.gateway, s.protocol
+						break candidatesLoop
 					}
 				}
-				fastestSrv := svrs[len(svrs)-1]
-				if fastestSrv.pingMs == 0 {
-					fmt.Println("WARNING! Servers pinging problem.")
-				}
-				srvID = fastestSrv.gateway
 			}
+		}
 
-			// if we not found required server before (by 'fastest' option)
-			if len(srvID) == 0 {
-				showTipsServerFilterError := func() {
-					fmt.Println()
-					PrintTips([]TipType{TipServers, TipConnectHelp})
-				}
+		// if we not found required server before (by 'fastest' or 'recommended' option)
+		if len(srvID) == 0 {
+			showTipsServerFilterError := func() {
+				fmt.Println()
+				PrintTips([]TipType{TipServers, TipConnectHelp})
+			}
 
-				// no servers found
-				if len(svrs) == 0 {
-					fmt.Println("No servers found by your filter")
-					fmt.Println("Please specify server more correctly")
+			// no servers found
+			if len(svrs) == 0 {
+				fmt.Println("No servers found by your filter")
+				fmt.Println("Please specify server more correctly")
 
-					funcWarnDisabledProtocols() // print info about disabled functionality
-					showTipsServerFilterError()
-					return fmt.Errorf("no servers found by your filter")
-				}
+				funcWarnDisabledProtocols() // print info about disabled functionality
+				showTipsServerFilterError()
+				return fmt.Errorf("no servers found by your filter")
+			}
 
-				// 'any' option
-				if len(svrs) > 1 {
-					fmt.Print("More than one server was found. ")
+			// 'any' option
+			if len(svrs) > 1 && !isSameServer(svrs) {
+				fmt.Print("More than one server was found. ")
 
-					if !c.any {
-						fmt.Println("Please specify server more correctly or use flag '-any'")
-						showTipsServerFilterError()
-						return fmt.Errorf("more than one server found")
-					}
-					fmt.Printf("Taking one random from found servers ...\n")
+				if !c.any {
+					fmt.Println("Please specify server more correctly or use flag '-any'")
+					showTipsServerFilterError()
+					return fmt.Errorf("more than one server found")
 				}
+				fmt.Printf("Taking one random from found servers ...\n")
+			}
 
+			selected := svrs[0] // WireGuard servers are first in the list (preferred when same location found for both protocols)
+			if !isSameServer(svrs) {
 				if rnd, err := rand.Int(rand.Reader, big.NewInt(int64(len(svrs)))); err == nil {
-					srvID = svrs[rnd.Int64()].gateway
-				} else {
-					srvID = svrs[0].gateway
+					selected = svrs[rnd.Int64()]
 				}
 			}
-			c.gateway = srvID
+			srvID = selected.gateway
+			srvProto = selected.protocol
 		}
+		c.gateway = srvID
 
 		// Firewall for current connection
 		req.Params.FirewallOnDuringConnection = true
@@ -482,198 +485,112 @@ func (c *CmdConnect) Run() (retError error) {
 		// Looking for connection server
 
 		// -------- WireGuard section begin ------
-		{
-			funcApplyCustomHost := func(hosts []apitypes.WireGuardServerHostInfo, hostname string) []apitypes.WireGuardServerHostInfo {
-				for _, h := range hosts {
-					if h.Hostname == hostname {
-						return []apitypes.WireGuardServerHostInfo{h}
-					}
+		if srvProto == ProtoName_WireGuard {
+			if s := findServer(servers.ServerList.WireGuardServers, c.gateway); s != nil {
+				serverFound = true
+
+				// Set V2Ray obfuscation parameters
+				if v2rayCfg != v2r.None {
+					fmt.Println("V2Ray configuration: " + v2rayCfg.ToString())
+					req.Params.WireGuardParameters.V2RayProxy = v2rayCfg
 				}
-				return hosts
-			}
 
-			var entrySvrWg *apitypes.WireGuardServerInfo = nil
-			var exitSvrWg *apitypes.WireGuardServerInfo = nil
-			// exit server
-			if len(c.multihopExitSvr) > 0 {
-				for i, s := range servers.WireguardServers {
-					if s.Gateway == c.multihopExitSvr {
-						exitSvrWg = &servers.WireguardServers[i]
-						break
-					}
-				}
-			}
-			// entry server
-			for i, s := range servers.WireguardServers {
-				if s.Gateway == c.gateway {
-					entrySvrWg = &servers.WireguardServers[i]
+				req.Params.VpnType = vpn.WireGuard
+				req.Params.WireGuardParameters.EntryVpnServer.Hosts = []apitypes.ServerListItem{*s}
+				req.Params.IPv6 = c.isIPv6Tunnel
 
-					serverFound = true
+				if c.mtu > 0 {
+					fmt.Printf("[!] Using custom MTU: %d\n", c.mtu)
+					req.Params.WireGuardParameters.Mtu = c.mtu
+				}
 
-					// Set V2Ray obfuscation parameters
-					if v2rayCfg != v2r.None {
-						fmt.Println("V2Ray configuration: " + v2rayCfg.ToString())
-						req.Params.WireGuardParameters.V2RayProxy = v2rayCfg
+				var destPort port
+				if len(c.multihopExitSvr) == 0 {
+					destPort, err = getPort(c.port, allowedPortsWg)
+					if err != nil {
+						printAllowedPorts(allowedPortsWg, allowedPortsOvpn, v2rayCfg)
+						return err
 					}
-
-					req.Params.VpnType = vpn.WireGuard
-					req.Params.WireGuardParameters.EntryVpnServer.Hosts = funcApplyCustomHost(s.Hosts, customHostEntryServer)
-					req.Params.IPv6 = c.isIPv6Tunnel
-
-					if c.mtu > 0 {
-						fmt.Printf("[!] Using custom MTU: %d\n", c.mtu)
-						req.Params.WireGuardParameters.Mtu = c.mtu
+					fmt.Printf("[WireGuard] Connecting to: %s (%s) %s %s...\n", s.Name, s.CountryCode, s.Ip, destPort.String())
+				} else {
+					exitSvr, err := getMultihopExitServer(servers.ServerList.WireGuardServers, *s, c.multihopExitSvr)
+					if err != nil {
+						return err
 					}
-
-					var destPort port
-					if len(c.multihopExitSvr) == 0 {
-						// port
-						destPort, err = getPort(c.port, allowedPortsWg)
-						if err != nil {
-							printAllowedPorts(allowedPortsWg, allowedPortsOvpn, v2rayCfg)
-							return err
-						}
-						fmt.Printf("[WireGuard] Connecting to: %s, %s (%s) %s %s...\n", s.City, s.CountryCode, s.Country, s.Gateway, destPort.String())
-					} else {
-						if exitSvrWg == nil {
-							return fmt.Errorf("serverID not found in servers list (%s)", c.multihopExitSvr)
-						}
-
-						// port definition is not required for WireGuard multi-hop (in use: UDP + port-based-multihop)
-						if len(c.port) > 0 {
-							if v2rayCfg != v2r.None {
-								// port
-								destPort, err = getPort(c.port, allowedPortsWg)
-								if err != nil {
-									printAllowedPorts(allowedPortsWg, allowedPortsOvpn, v2rayCfg)
-									return err
-								}
-							} else {
-								// if user manually defined port for  WireGuard Multi-Hop connection - inform that it is ignored
-								fmt.Printf("Note: port definition is ignored for WireGuard Multi-Hop connections\n")
-							}
-						}
-
-						req.Params.WireGuardParameters.MultihopExitServer.ExitSrvID = strings.Split(exitSvrWg.Gateway, ".")[0]
-						req.Params.WireGuardParameters.MultihopExitServer.Hosts = funcApplyCustomHost(exitSvrWg.Hosts, customHostExitServer)
-
-						fmt.Printf("[WireGuard] Connecting Multi-Hop...\n")
-						fmt.Printf("\tentry server: %s, %s (%s) %s\n", entrySvrWg.City, entrySvrWg.CountryCode, entrySvrWg.Country, entrySvrWg.Gateway)
-						fmt.Printf("\texit server : %s, %s (%s) %s\n", exitSvrWg.City, exitSvrWg.CountryCode, exitSvrWg.Country, exitSvrWg.Gateway)
+					// port definition is not required for WireGuard multi-hop (in use: UDP + port-based-multihop)
+					if len(c.port) > 0 {
+						fmt.Printf("Note: port definition is ignored for WireGuard Multi-Hop connections\n")
 					}
-					req.Params.WireGuardParameters.Port.Port = destPort.port
-					req.Params.WireGuardParameters.Port.Protocol = destPort.IsTCP()
 
-					break
+					req.Params.WireGuardParameters.MultihopExitServer.ExitSrvID = exitSvr.Name
+					req.Params.WireGuardParameters.MultihopExitServer.Hosts = []apitypes.ServerListItem{*exitSvr}
+
+					fmt.Printf("[WireGuard] Connecting Multi-Hop...\n")
+					fmt.Printf("\tentry server: %s (%s) %s\n", s.Name, s.CountryCode, s.Ip)
+					fmt.Printf("\texit server : %s (%s) %s\n", exitSvr.Name, exitSvr.CountryCode, exitSvr.Ip)
 				}
+
+				req.Params.WireGuardParameters.Port.Port = destPort.port
+				req.Params.WireGuardParameters.Port.Protocol = destPort.IsTCP()
 			}
 		}
 		// -------- WireGuard section end --------
 
 		// -------- OpenVPN section begin --------
 		if !serverFound {
-			funcApplyCustomHost := func(hosts []apitypes.OpenVPNServerHostInfo, hostname string) []apitypes.OpenVPNServerHostInfo {
-				for _, h := range hosts {
-					if h.Hostname == hostname {
-						return []apitypes.OpenVPNServerHostInfo{h}
-					}
+			if s := findServer(servers.ServerList.OpenVPNServers, c.gateway); s != nil {
+				serverFound = true
+
+				// Set V2Ray obfuscation parameters
+				if v2rayCfg != v2r.None {
+					fmt.Println("V2Ray configuration: " + v2rayCfg.ToString())
+					req.Params.OpenVpnParameters.V2RayProxy = v2rayCfg
+				} else if obfsproxyCfg.IsObfsproxy() { // Set obfsproxy config
+					fmt.Println("obfsproxy configuration: " + obfsproxyCfg.ToString())
+					req.Params.OpenVpnParameters.Obfs4proxy = obfsproxyCfg
 				}
-				return hosts
-			}
 
-			var entrySvrOvpn *apitypes.OpenvpnServerInfo = nil
-			var exitSvrOvpn *apitypes.OpenvpnServerInfo = nil
+				req.Params.VpnType = vpn.OpenVPN
+				req.Params.OpenVpnParameters.EntryVpnServer.Hosts = []apitypes.ServerListItem{*s}
 
-			// exit server
-			if len(c.multihopExitSvr) > 0 {
-				for i, s := range servers.OpenvpnServers {
-					if s.Gateway == c.multihopExitSvr {
-						exitSvrOvpn = &servers.OpenvpnServers[i]
-						break
-					}
+				destPort, err := getPort(c.port, allowedPortsOvpn)
+				if err != nil {
+					printAllowedPorts(allowedPortsWg, allowedPortsOvpn, v2rayCfg)
+					return err
 				}
-			}
 
-			var destPort port
-			// entry server
-			for i, s := range servers.OpenvpnServers {
-				if s.Gateway == c.gateway {
-					entrySvrOvpn = &servers.OpenvpnServers[i]
-
-					serverFound = true
-
-					// Set V2Ray obfuscation parameters
-					if v2rayCfg != v2r.None {
-						fmt.Println("V2Ray configuration: " + v2rayCfg.ToString())
-						req.Params.OpenVpnParameters.V2RayProxy = v2rayCfg
-					} else if obfsproxyCfg.IsObfsproxy() { // Set obfsproxy config
-						fmt.Println("obfsproxy configuration: " + obfsproxyCfg.ToString())
-						req.Params.OpenVpnParameters.Obfs4proxy = obfsproxyCfg
+				portStrInfo := destPort.String()
+				if obfsproxyCfg.IsObfsproxy() {
+					if len(c.port) > 0 {
+						// if user manually defined port for obfsproxy connection - inform that it is ignored
+						fmt.Printf("Note: port definition is ignored for the connections when the obfsproxy enabled\n")
 					}
-
-					req.Params.VpnType = vpn.OpenVPN
-					req.Params.OpenVpnParameters.EntryVpnServer.Hosts = funcApplyCustomHost(s.Hosts, customHostEntryServer)
-
-					isMultihop := exitSvrOvpn != nil && len(c.multihopExitSvr) > 0
-					if !isMultihop {
-						// port
-						destPort, err = getPort(c.port, allowedPortsOvpn)
-						if err != nil {
-							printAllowedPorts(allowedPortsWg, allowedPortsOvpn, v2rayCfg)
-							return err
-						}
-					} else {
-						// port
-						destPort, err = getPort(c.port, nil)
-						if err != nil {
-							printAllowedPorts(allowedPortsWg, allowedPortsOvpn, v2rayCfg)
-							return err
-						}
-
-						// get Multi-Hop ID
-						req.Params.OpenVpnParameters.MultihopExitServer.ExitSrvID = strings.Split(c.multihopExitSvr, ".")[0]
-						req.Params.OpenVpnParameters.MultihopExitServer.Hosts = funcApplyCustomHost(exitSvrOvpn.Hosts, customHostExitServer)
-						if v2rayCfg == v2r.None { // V2Ray connection uses port info
-							destPort.port = 0 // do not use port number (port-based multihop): set 0 to do not print port number into console
-							fmt.Printf("Note: port number is ignored for OpenVPN Multi-Hop connections\n")
-						}
-					}
-
-					req.Params.OpenVpnParameters.Port.Port = destPort.port
-					req.Params.OpenVpnParameters.Port.Protocol = destPort.IsTCP()
-
-					break
+					portStrInfo = "TCP"
+					destPort.tcp = true
 				}
-			}
 
-			if entrySvrOvpn == nil {
-				return fmt.Errorf("serverID not found in servers list (%s)", c.gateway)
-			}
-			if len(c.multihopExitSvr) > 0 && exitSvrOvpn == nil {
-				return fmt.Errorf("serverID not found in servers list (%s)", c.multihopExitSvr)
-			}
+				if len(c.multihopExitSvr) == 0 {
+					fmt.Printf("[OpenVPN] Connecting to: %s (%s) %s %s...\n", s.Name, s.CountryCode, s.Ip, portStrInfo)
+				} else {
+					exitSvr, err := getMultihopExitServer(servers.ServerList.OpenVPNServers, *s, c.multihopExitSvr)
+					if err != nil {
+						return err
+					}
+					destPort.port = 0 // do not use port number (port-based multihop): set 0 to do not print port number into console
+					if len(c.port) > 0 {
+						fmt.Printf("Note: port number is ignored for OpenVPN Multi-Hop connections\n")
+					}
 
-			portStrInfo := destPort.String()
-			if obfsproxyCfg.IsObfsproxy() {
-				if len(c.port) > 0 {
-					// if user manually defined port for obfsproxy connection - inform that it is ignored
-					fmt.Printf("Note: port definition is ignored for the connections when the obfsproxy enabled\n")
-				}
-				portStrInfo = "TCP"
-				destPort.tcp = true
-			}
+					req.Params.OpenVpnParameters.MultihopExitServer.ExitSrvID = exitSvr.Name
+					req.Params.OpenVpnParameters.MultihopExitServer.Hosts = []apitypes.ServerListItem{*exitSvr}
 
-			if len(c.multihopExitSvr) == 0 {
-				fmt.Printf("[OpenVPN] Connecting to: %s, %s (%s) %s %s...\n", entrySvrOvpn.City, entrySvrOvpn.CountryCode, entrySvrOvpn.Country, entrySvrOvpn.Gateway, portStrInfo)
-			} else {
-				portStrInfo = "UDP"
-				if destPort.tcp {
-					portStrInfo = "TCP"
+					fmt.Printf("[OpenVPN] Connecting Multi-Hop...\n")
+					fmt.Printf("\tentry server: %s (%s) %s %s\n", s.Name, s.CountryCode, s.Ip, destPort.String())
+					fmt.Printf("\texit server : %s (%s) %s\n", exitSvr.Name, exitSvr.CountryCode, exitSvr.Ip)
 				}
 
-				fmt.Printf("[OpenVPN] Connecting Multi-Hop...\n")
-				fmt.Printf("\tentry server: %s, %s (%s) %s %s\n", entrySvrOvpn.City, entrySvrOvpn.CountryCode, entrySvrOvpn.Country, entrySvrOvpn.Gateway, portStrInfo)
-				fmt.Printf("\texit server : %s, %s (%s) %s\n", exitSvrOvpn.City, exitSvrOvpn.CountryCode, exitSvrOvpn.Country, exitSvrOvpn.Gateway)
+				req.Params.OpenVpnParameters.Port.Port = destPort.port
+				req.Params.OpenVpnParameters.Port.Protocol = destPort.IsTCP()
 			}
 		}
 		// -------- OpenVPN section end ----------
@@ -716,6 +633,8 @@ func (c *CmdConnect) Run() (retError error) {
 		// metadata
 		if c.fastest {
 			req.Params.Metadata.ServerSelectionEntry = service_types.Fastest
+		} else if c.recommended {
+			req.Params.Metadata.ServerSelectionEntry = service_types.Recommended
 		} else if c.any {
 			req.Params.Metadata.ServerSelectionEntry = service_types.Random
 		}
@@ -735,7 +654,61 @@ func (c *CmdConnect) Run() (retError error) {
 	return nil
 }
 
-func getPort(portInfo string, allowedPorts []apitypes.PortInfo) (port, error) {
+// getMultihopExitServer returns the exit server for Multi-Hop connection.
+// Entry and exit servers must be located in different jurisdictions (countries).
+func getMultihopExitServer(countries []apitypes.ServerListCountryItem, entrySvr apitypes.ServerListItem, exitSvrName string) (*apitypes.ServerListItem, error) {
+	exitSvr := findServer(countries, exitSvrName)
+	if exitSvr == nil {
+		return nil, fmt.Errorf("serverID not found in servers list (%s)", exitSvrName)
+	}
+	if strings.EqualFold(exitSvr.Name, entrySvr.Name) {
+		return nil, flags.BadParameter{Message: "unable to use same entry- and exit- servers"}
+	}
+	if service_types.IsSameJurisdiction(entrySvr, *exitSvr) {
+		return nil, flags.BadParameter{Message: "entry- and exit- servers must be located in different countries"}
+	}
+	return exitSvr, nil
+}
+
+// wireGuardPorts returns the WireGuard ports supported by the servers list (WireGuard uses UDP only)
+func wireGuardPorts(servers apitypes.ServerListResponse) []port {
+	ret := make([]port, 0, len(servers.WireGuard))
+	for _, p := range servers.WireGuard {
+		ret = append(ret, port{port: p})
+	}
+	return ret
+}
+
+// openVPNPorts returns the OpenVPN ports supported by the servers list
+func openVPNPorts(servers apitypes.ServerListResponse) []port {
+	ret := make([]port, 0, len(servers.OpenVPN.Ports))
+	for _, p := range servers.OpenVPN.Ports {
+		ret = append(ret, port{port: p.Port, tcp: strings.EqualFold(strings.TrimSpace(p.Protocol), "tcp")})
+	}
+	return ret
+}
+
+func filterPorts(ports []port, isTCP bool) []port {
+	ret := make([]port, 0, len(ports))
+	for _, p := range ports {
+		if p.tcp == isTCP {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// isSameServer returns true when all the servers in the list have the same location (e.g. found for both protocols)
+func isSameServer(servers []serverDesc) bool {
+	for _, s := range servers {
+		if s.gateway != servers[0].gateway {
+			return false
+		}
+	}
+	return true
+}
+
+func getPort(portInfo string, allowedPorts []port) (port, error) {
 	var err error
 	var portPtr *int
 	var isTCPPtr *bool
@@ -747,8 +720,17 @@ func getPort(portInfo string, allowedPorts []apitypes.PortInfo) (port, error) {
 	}
 
 	retPort := *defaultPort() // default port
+	if len(allowedPorts) > 0 {
+		retPort = allowedPorts[0]
+		// only port type defined: use first allowed port of required type
+		if isTCPPtr != nil && (portPtr == nil || *portPtr == 0) {
+			if ports := filterPorts(allowedPorts, *isTCPPtr); len(ports) > 0 {
+				retPort = ports[0]
+			}
+		}
+	}
 
-	if portPtr != nil {
+	if portPtr != nil && *portPtr != 0 {
 		retPort.port = *portPtr
 	}
 	if isTCPPtr != nil {
@@ -756,15 +738,15 @@ func getPort(portInfo string, allowedPorts []apitypes.PortInfo) (port, error) {
 	}
 
 	if len(allowedPorts) > 0 {
-		if !isPortAllowed(allowedPorts[:], retPort) {
-			return port{}, fmt.Errorf(fmt.Sprintf("not allowed port '%s'", retPort.String()))
+		if !isPortAllowed(allowedPorts, retPort) {
+			return port{}, fmt.Errorf("not allowed port '%s'", retPort.String())
 		}
 	}
 
 	return retPort, nil
 }
 
-func printAllowedPorts(allowedPortsWg, allowedOvpnPorts []apitypes.PortInfo, v2rayType v2r.V2RayTransportType) {
+func printAllowedPorts(allowedPortsWg, allowedOvpnPorts []port, v2rayType v2r.V2RayTransportType) {
 	fmt.Printf("Allowed ports:\n")
 	v2RayPrefix := ""
 	if v2rayType == v2r.QUIC {
@@ -774,27 +756,24 @@ func printAllowedPorts(allowedPortsWg, allowedOvpnPorts []apitypes.PortInfo, v2r
 	}
 
 	if allowedPortsWg != nil {
-		fmt.Printf("  WireGuard%s: %s\n", v2RayPrefix, allPortsString(allowedPortsWg[:]))
+		fmt.Printf("  WireGuard%s: %s\n", v2RayPrefix, allPortsString(allowedPortsWg))
 
 	}
 	if allowedOvpnPorts != nil {
-		fmt.Printf("  OpenVPN%s  : %s\n", v2RayPrefix, allPortsString(allowedOvpnPorts[:]))
+		fmt.Printf("  OpenVPN%s  : %s\n", v2RayPrefix, allPortsString(allowedOvpnPorts))
 	}
 }
 
-func isPortAllowed(ports []apitypes.PortInfo, thePort port) bool {
+func isPortAllowed(ports []port, thePort port) bool {
 	for _, p := range ports {
-		if p.Port != 0 && p.Port == thePort.port && p.IsTCP() == thePort.tcp {
-			return true
-		}
-		if p.Range.Min > 0 && thePort.port >= p.Range.Min && thePort.port <= p.Range.Max {
+		if p.port == thePort.port && p.tcp == thePort.tcp {
 			return true
 		}
 	}
 	return false
 }
 
-func allPortsString(ports []apitypes.PortInfo) string {
+func allPortsString(ports []port) string {
 	s := make([]string, 0, len(ports))
 	for _, p := range ports {
 		s = append(s, p.String())
@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -29,12 +29,12 @@ import (
 	"strings"
 	"text/tabwriter"
 
-	"github.com/ivpn/desktop-app/cli/cliplatform"
-	"github.com/ivpn/desktop-app/cli/flags"
-	apitypes "github.com/ivpn/desktop-app/daemon/api/types"
-	"github.com/ivpn/desktop-app/daemon/service/dns"
-	service_types "github.com/ivpn/desktop-app/daemon/service/types"
-	"github.com/ivpn/desktop-app/daemon/vpn"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/cliplatform"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	apitypes "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
+	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 )
 
 type CmdDns struct {
@@ -152,7 +152,7 @@ func (c *CmdDns) Run() error {
 		}
 	}
 
-	var servers *apitypes.ServersInfoResponse
+	var servers *apitypes.ServerListResponse
 	// do we have to change custom DNS configuration ?
 	if c.reset || len(c.dns) > 0 {
 		// get default connection parameters (dns, anti-tracker, ... etc.)
@@ -254,12 +254,9 @@ func (c *CmdAntitracker) Run() error {
 	}
 
 	if c.blocklists {
-		svrs, err := _proto.GetServers()
-		if err != nil {
-			return err
-		}
-
-		return printBlockLists(svrs.Config.AntiTrackerPlus.DnsServers)
+		// the servers list does not provide AntiTracker block lists
+		fmt.Println("No DNS block lists available")
+		return nil
 	}
 
 	// do we have to change anti-tracker configuration ?
@@ -368,51 +365,3 @@ func GetAntiTrackerStatusText(antitracker service_types.AntiTrackerMetadata) str
 
 	return fmt.Sprintf("Enabled%s", infoText)
 }
-
-// GetAntitrackerIP - returns IP of antitracker DNS
-func GetAntitrackerIP(vpntype vpn.Type, isHardcore, isMultihop bool, servers *apitypes.ServersInfoResponse) (string, error) {
-	if isHardcore {
-		return servers.Config.Antitracker.Hardcore.IP, nil
-	}
-
-	return servers.Config.Antitracker.Default.IP, nil
-}
-
-/*
-func isAcceptableDnsBlockListName(atDnsServers []apitypes.AntiTrackerPlusServer) error {
-	if len(atDnsServers) == 0 {
-		fmt.Println("No DNS block lists available")
-		return nil
-	}
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
-
-	fmt.Fprintf(w, "|\tDNS BLOCK LIST NAME\t|\tDETAILS\t|\n")
-	fmt.Fprintf(w, "|\t\t|\t\t|\n")
-	for _, bl := range atDnsServers {
-		fmt.Fprintf(w, "|\t%s\t|\t%s\t|\n", bl.Name, bl.Description)
-	}
-
-	w.Flush()
-
-	return nil
-}*/
-
-func printBlockLists(atDnsServers []apitypes.AntiTrackerPlusServer) error {
-	if len(atDnsServers) == 0 {
-		fmt.Println("No DNS block lists available")
-		return nil
-	}
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
-
-	fmt.Fprintf(w, "|\tDNS BLOCK LIST NAME\t|\tDETAILS\t|\n")
-	fmt.Fprintf(w, "|\t\t|\t\t|\n")
-	for _, bl := range atDnsServers {
-		fmt.Fprintf(w, "|\t%s\t|\t%s\t|\n", bl.Name, bl.Description)
-	}
-
-	w.Flush()
-
-	return nil
-}
@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -28,10 +28,10 @@ import (
 	"syscall"
 	"text/tabwriter"
 
-	"github.com/ivpn/desktop-app/cli/flags"
-	"github.com/ivpn/desktop-app/cli/helpers"
-	"github.com/ivpn/desktop-app/daemon/protocol/eaa"
-	"github.com/ivpn/desktop-app/daemon/service/platform"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/helpers"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/eaa"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
 	"golang.org/x/term"
 )
 
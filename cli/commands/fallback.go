@@ -0,0 +1,114 @@
+//
+//  IVPN command line interface (CLI)
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the IVPN command line interface.
+//
+//  The IVPN command line interface is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The IVPN command line interface is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the IVPN command line interface. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/helpers"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+)
+
+type CmdFallback struct {
+	flags.CmdInfo
+	status      bool
+	enable_val  string // on/off
+	timeout_val string
+}
+
+func (c *CmdFallback) Init() {
+	c.KeepArgsOrderInHelp = true
+
+	c.Initialize("fallback", "Manage automatic port/protocol fallback (when the connection is blocked by the network)")
+	c.BoolVar(&c.status, "status", false, "(default) Show settings")
+	c.StringVar(&c.enable_val, "enable", "", "[on/off]", "Enable/disable the connection fallback (disabled by default)\nWhen the connection is not established, the daemon tries the next port/protocol from the list:\nWireGuard UDP, OpenVPN TCP 443 (V2Ray and obfs4 attempts are not available in this version).\nThe succeeded attempt is remembered for the current network (Wi-Fi SSID or default gateway)")
+	c.StringVar(&c.timeout_val, "timeout", "", "SECONDS", "Time to establish the connection for each attempt (0 - default value)")
+}
+
+func (c *CmdFallback) Run() error {
+	uPrefs := _proto.GetHelloResponse().DaemonSettings.UserPrefs
+	isChanged := false
+
+	if len(c.enable_val) > 0 {
+		val, err := helpers.BoolParameterParse(c.enable_val)
+		if err != nil {
+			return err
+		}
+		uPrefs.ConnectionFallback.IsEnabled = val
+		isChanged = true
+	}
+
+	if len(c.timeout_val) > 0 {
+		val, err := strconv.Atoi(c.timeout_val)
+		if err != nil || val < 0 {
+			return flags.BadParameter{Message: "bad value for '-timeout' option (expected number of seconds)"}
+		}
+		uPrefs.ConnectionFallback.AttemptTimeoutSec = val
+		isChanged = true
+	}
+
+	if isChanged {
+		if err := _proto.SetUserPreferences(uPrefs); err != nil {
+			return err
+		}
+	}
+
+	// request updated daemon settings
+	if _, err := _proto.SendHello(); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	printFallbackSettings(w)
+	w.Flush()
+
+	return nil
+}
+
+func printFallbackSettings(w *tabwriter.Writer) {
+	params := _proto.GetHelloResponse().DaemonSettings.UserPrefs.ConnectionFallback
+
+	status := "Disabled"
+	if params.IsEnabled {
+		status = "Enabled"
+	}
+	fmt.Fprintf(w, "Connection fallback\t:\t%v\n", status)
+
+	timeout := "Default"
+	if params.AttemptTimeoutSec > 0 {
+		timeout = fmt.Sprintf("%d sec", params.AttemptTimeoutSec)
+	}
+	fmt.Fprintf(w, "Attempt timeout\t:\t%v\n", timeout)
+
+	attempts := params.Attempts
+	if len(attempts) == 0 {
+		attempts = types.DefaultConnectionAttempts()
+	}
+	for i, a := range attempts {
+		fmt.Fprintf(w, "Attempt %d\t:\t%v\n", i+1, a)
+	}
+}
@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -24,8 +24,10 @@ package commands
 
 import (
 	"fmt"
+	"runtime"
+	"strings"
 
-	"github.com/ivpn/desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
 )
 
 type CmdFirewall struct {
@@ -40,12 +42,33 @@ type CmdFirewall struct {
 	persistentOn       bool
 	persistentOff      bool
 	exceptions         string
+	linuxBackend       string // LinuxFwBackend
 	//allowLanMulticast bool
 	//blockLanMulticast bool
 }
 
 const StringValueNoData = "<!NO DATA!>"
 
+const (
+	LinuxFwBackend_Script   = "script"
+	LinuxFwBackend_Nftables = "nftables"
+)
+
+func IsParamApplicable_LinuxFwBackend() (bool, error) {
+	if runtime.GOOS != "linux" {
+		return false, fmt.Errorf(fmt.Sprintf("functionality not applicable for %s", runtime.GOOS))
+	}
+
+	if _proto != nil {
+		hr := _proto.GetHelloResponse()
+		if len(hr.DisabledFunctions.Platform.Linux.FwNftablesError) > 0 {
+			return false, fmt.Errorf(hr.DisabledFunctions.Platform.Linux.FwNftablesError)
+		}
+	}
+
+	return true, nil
+}
+
 func (c *CmdFirewall) Init() {
 	c.Initialize("firewall", "Firewall management")
 	c.BoolVar(&c.status, "status", false, "(default) Show info about current firewall status")
@@ -58,6 +81,19 @@ func (c *CmdFirewall) Init() {
 	c.BoolVar(&c.persistentOff, "persistent_off", false, "Persistent firewall (Always-on firewall): disable")
 	c.BoolVar(&c.persistentOn, "persistent_on", false, "Persistent firewall (Always-on firewall): enable. When the option is enabled the IVPN Firewall is started during system boot")
 	c.StringVar(&c.exceptions, "exceptions", StringValueNoData, "EXCEPTIONS", "Set configuration: comma-separated list of IP addresses or subnets (using CIDR notation)\nthat will be allowed through the firewall when enabled\nExamples:\n\tivpn firewall -exceptions '192.0.2.0/24, 198.51.100.1'\n\tivpn firewall -exceptions ''")
+	if runtime.GOOS == "linux" {
+		c.StringVarEx(&c.linuxBackend, "backend", "", "BACKEND",
+			fmt.Sprintf(`By default IVPN applies firewall rules using the 'firewall.sh' script (iptables).
+		This option allows to apply the rules natively using nftables (over netlink).
+		Possible values: %s (default); %s
+			Example: 
+				'ivpn firewall -backend=%s'`,
+				LinuxFwBackend_Script, LinuxFwBackend_Nftables, LinuxFwBackend_Nftables),
+			func() bool {
+				ret, _ := IsParamApplicable_LinuxFwBackend()
+				return ret
+			})
+	}
 	//c.BoolVar(&c.allowLanMulticast, "lan_multicast_allow", false, "Same as 'lan_allow' + allow multicast communication ")
 	//c.BoolVar(&c.blockLanMulticast, "lan_multicast_block", false, "Same as 'lan_block' + block multicast communication")
 }
@@ -82,6 +118,36 @@ func (c *CmdFirewall) Run() error {
 	//	return flags.BadParameter{}
 	//}
 
+	if len(c.linuxBackend) > 0 {
+		if ret, err := IsParamApplicable_LinuxFwBackend(); !ret {
+			return flags.BadParameter{Message: fmt.Sprintf("Option '-backend' is not applicable for current environment: %v", err)}
+		}
+
+		val := strings.TrimSpace(strings.ToLower(c.linuxBackend))
+		if val != LinuxFwBackend_Script && val != LinuxFwBackend_Nftables {
+			return flags.BadParameter{}
+		}
+
+		uPrefs := _proto.GetHelloResponse().DaemonSettings.UserPrefs
+		isNftables := val == LinuxFwBackend_Nftables
+		if uPrefs.Linux.IsFwNftablesBackend != isNftables {
+			if isNftables {
+				fmt.Print("Applying configuration: use native nftables firewall backend...\n\n")
+			} else {
+				fmt.Print("Applying configuration: use default firewall backend ('firewall.sh' script)...\n\n")
+			}
+			uPrefs.Linux.IsFwNftablesBackend = isNftables
+			if err := _proto.SetUserPreferences(uPrefs); err != nil {
+				return err
+			}
+
+			// trigger daemon to send HelloResponse with updated user preferences
+			if _, err := _proto.SendHello(); err != nil {
+				return err
+			}
+		}
+	}
+
 	if c.ivpnSvrAccessAllow {
 		if err := _proto.FirewallAllowApiServers(true); err != nil {
 			return err
@@ -154,6 +220,9 @@ func (c *CmdFirewall) Run() error {
 	}
 
 	w := printFirewallState(nil, state.IsEnabled, state.IsPersistent, state.IsAllowLAN, state.IsAllowMulticast, state.IsAllowApiServers, state.UserExceptions, nil)
+	if runtime.GOOS == "linux" && _proto.GetHelloResponse().DaemonSettings.UserPrefs.Linux.IsFwNftablesBackend {
+		fmt.Fprintf(w, "    Backend\t:\t%s\n", LinuxFwBackend_Nftables)
+	}
 	w.Flush()
 
 	// TIPS
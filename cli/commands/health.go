@@ -0,0 +1,115 @@
+//
+//  IVPN command line interface (CLI)
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the IVPN command line interface.
+//
+//  The IVPN command line interface is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The IVPN command line interface is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the IVPN command line interface. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/helpers"
+)
+
+type CmdHealthMonitor struct {
+	flags.CmdInfo
+	status       bool
+	monitor_val  string // on/off
+	failover_val string // on/off
+	probe        string
+}
+
+func (c *CmdHealthMonitor) Init() {
+	c.KeepArgsOrderInHelp = true
+
+	c.Initialize("health", "Manage the tunnel health monitor (reconnect when the connected tunnel stops passing traffic)")
+	c.BoolVar(&c.status, "status", false, "(default) Show settings")
+	c.StringVar(&c.monitor_val, "monitor", "", "[on/off]", "Enable/disable the tunnel health monitor")
+	c.StringVar(&c.failover_val, "failover", "", "[on/off]", "When the tunnel is unhealthy: connect to the next-best host of the same location\n(and then to the next location) instead of reconnecting to the same host\nNot applicable for Multi-Hop connections; disabled by default")
+	c.StringVar(&c.probe, "probe", StringValueNoData, "HOST:PORT", "TCP service which must be reachable through the tunnel (empty value - no probe)\nExamples:\n\tivpn health -probe 1.1.1.1:443\n\tivpn health -probe ''")
+}
+
+func (c *CmdHealthMonitor) Run() error {
+	uPrefs := _proto.GetHelloResponse().DaemonSettings.UserPrefs
+	params := uPrefs.HealthMonitor
+
+	if len(c.monitor_val) > 0 {
+		val, err := helpers.BoolParameterParse(c.monitor_val)
+		if err != nil {
+			return err
+		}
+		params.IsEnabled = val
+	}
+
+	if len(c.failover_val) > 0 {
+		val, err := helpers.BoolParameterParse(c.failover_val)
+		if err != nil {
+			return err
+		}
+		params.IsFailover = val
+	}
+
+	if c.probe != StringValueNoData {
+		params.ProbeTarget = c.probe
+	}
+
+	if params != uPrefs.HealthMonitor {
+		uPrefs.HealthMonitor = params
+		if err := _proto.SetUserPreferences(uPrefs); err != nil {
+			return err
+		}
+	}
+
+	// request updated daemon settings
+	if _, err := _proto.SendHello(); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	printHealthMonitorSettings(w)
+	w.Flush()
+
+	return nil
+}
+
+func printHealthMonitorSettings(w *tabwriter.Writer) {
+	params := _proto.GetHelloResponse().DaemonSettings.UserPrefs.HealthMonitor
+
+	status := "Disabled"
+	if params.IsEnabled {
+		status = "Enabled"
+	}
+	fmt.Fprintf(w, "Tunnel health monitor\t:\t%v\n", status)
+
+	failover := "Disabled (reconnect to the same host)"
+	if params.IsFailover {
+		failover = "Enabled"
+	}
+	fmt.Fprintf(w, "Failover\t:\t%v\n", failover)
+
+	probe := "None"
+	if len(params.ProbeTarget) > 0 {
+		probe = params.ProbeTarget
+	}
+	fmt.Fprintf(w, "Probe target\t:\t%v\n", probe)
+}
@@ -0,0 +1,151 @@
+//
+//  IVPN command line interface (CLI)
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the IVPN command line interface.
+//
+//  The IVPN command line interface is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The IVPN command line interface is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the IVPN command line interface. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/history"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/v2r"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+type CmdHistory struct {
+	flags.CmdInfo
+	count int
+	all   bool
+	clear bool
+}
+
+func (c *CmdHistory) Init() {
+	c.Initialize("history", "Show connection history")
+	c.IntVar(&c.count, "n", 20, "COUNT", "Number of the latest records to show (0 - show all records)")
+	c.BoolVar(&c.all, "all", false, "Show all VPN state transitions (by default, only the main connection events are shown)")
+	c.BoolVar(&c.clear, "clear", false, "Erase connection history")
+}
+
+func (c *CmdHistory) Run() error {
+	if c.count < 0 {
+		return flags.BadParameter{}
+	}
+
+	if c.clear {
+		if err := _proto.ClearConnectionHistory(); err != nil {
+			return err
+		}
+		fmt.Println("Connection history erased")
+		return nil
+	}
+
+	count := c.count
+	if !c.all {
+		// entries are filtered locally: request all of them
+		count = 0
+	}
+
+	entries, err := _proto.GetConnectionHistory(count)
+	if err != nil {
+		return err
+	}
+
+	if !c.all {
+		filtered := make([]history.Entry, 0, len(entries))
+		for _, e := range entries {
+			switch e.State {
+			case vpn.CONNECTED.String(), vpn.RECONNECTING.String(), vpn.DISCONNECTED.String(), vpn.EXITING.String():
+				filtered = append(filtered, e)
+			}
+		}
+		entries = filtered
+		if c.count > 0 && len(entries) > c.count {
+			entries = entries[:c.count]
+		}
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("Connection history is empty")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.Debug)
+	fmt.Fprintln(w, "TIME\tSTATE\tPROTOCOL\tSERVER\tPORT\tDURATION\tRECEIVED\tSENT\tDETAILS\t")
+
+	// print from the oldest to the newest
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+
+		server := e.Server
+		if len(e.ExitServer) > 0 {
+			server += " -> " + e.ExitServer
+		}
+
+		port := ""
+		if e.Port > 0 {
+			if e.IsTCP {
+				port = fmt.Sprintf("TCP:%d", e.Port)
+			} else {
+				port = fmt.Sprintf("UDP:%d", e.Port)
+			}
+		}
+
+		duration, received, sent := "", "", ""
+		if e.Duration > 0 || e.BytesReceived > 0 || e.BytesSent > 0 {
+			duration = (time.Duration(e.Duration) * time.Second).String()
+			received = formatBytes(e.BytesReceived)
+			sent = formatBytes(e.BytesSent)
+		}
+
+		details := e.Reason
+		if len(details) == 0 && e.State != vpn.CONNECTED.String() {
+			details = e.Description
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			time.Unix(e.Time, 0).Format("2006-01-02 15:04:05"),
+			e.State,
+			historyProtocolName(e),
+			server,
+			port,
+			duration,
+			received,
+			sent,
+			details)
+	}
+	w.Flush()
+
+	return nil
+}
+
+func historyProtocolName(e history.Entry) string {
+	ret := e.VpnType.String()
+	if e.V2RayProxy != v2r.None {
+		ret += fmt.Sprintf(" (V2Ray/%s)", e.V2RayProxy.ToString())
+	} else if e.Obfsproxy.IsObfsproxy() {
+		ret += fmt.Sprintf(" (%s)", e.Obfsproxy.ToString())
+	}
+	return ret
+}
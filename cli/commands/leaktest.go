@@ -0,0 +1,150 @@
+//
+//  IVPN command line interface (CLI)
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the IVPN command line interface.
+//
+//  The IVPN command line interface is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The IVPN command line interface is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the IVPN command line interface. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/leaktest"
+)
+
+type CmdLeakTest struct {
+	flags.CmdInfo
+	details bool
+}
+
+func (c *CmdLeakTest) Init() {
+	c.Initialize("leaktest", "Check for DNS and IP leaks\nDNS queries are sent to the system resolver, the resolvers configured in the OS and well-known public resolvers (through all active interfaces).\nThe public IP addresses are compared with the addresses of the VPN server.\nThe command exits with an error when any leak is detected")
+	c.BoolVar(&c.details, "details", false, "Show the results of all DNS probes")
+}
+
+func (c *CmdLeakTest) Run() error {
+	fmt.Println("Running leak test ...")
+	result, err := _proto.LeakTest()
+	if err != nil {
+		return err
+	}
+
+	c.printResult(result)
+
+	if result.IsLeakDetected() {
+		return fmt.Errorf("leaks detected: %d", len(result.Leaks))
+	}
+	return nil
+}
+
+func (c *CmdLeakTest) printResult(r leaktest.Result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+
+	tunnel := "not active"
+	if r.IsTunnelActive {
+		tunnel = "active"
+		if r.IsIPv6InTunnel {
+			tunnel += " (IPv4, IPv6)"
+		} else {
+			tunnel += " (IPv4)"
+		}
+	}
+	fmt.Fprintf(w, "VPN tunnel\t:\t%s\n", tunnel)
+	if len(r.ExpectedDNS) > 0 {
+		fmt.Fprintf(w, "Expected DNS\t:\t%s\n", strings.Join(r.ExpectedDNS, ", "))
+	}
+
+	sysResolver := strings.Join(r.SystemResolver.Answers, ", ")
+	if !r.SystemResolver.IsAnswered {
+		sysResolver = "no answer"
+		if len(r.SystemResolver.Error) > 0 {
+			sysResolver += " (" + r.SystemResolver.Error + ")"
+		}
+	}
+	fmt.Fprintf(w, "System resolver\t:\t%s\n", sysResolver)
+
+	fmt.Fprintf(w, "Public IPv4\t:\t%s\n", valueOrUnknown(r.PublicIPv4))
+	if len(r.PublicIPv6) > 0 {
+		fmt.Fprintf(w, "Public IPv6\t:\t%s\n", r.PublicIPv6)
+	}
+	if r.IPv6.IsChecked {
+		ipv6 := "blocked"
+		if r.IPv6.IsReachable {
+			ipv6 = "reachable"
+		}
+		fmt.Fprintf(w, "IPv6 outside tunnel\t:\t%s\n", ipv6)
+	}
+	w.Flush()
+
+	probes := r.DnsProbes
+	if !c.details {
+		// by default, only the resolvers which answered are shown
+		probes = nil
+		for _, p := range r.DnsProbes {
+			if p.IsAnswered {
+				probes = append(probes, p)
+			}
+		}
+	}
+	if len(probes) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight|tabwriter.Debug)
+		fmt.Fprintln(w, "RESOLVER\tINTERFACE\tANSWERED\tEXPECTED\tRTT\t")
+		for _, p := range probes {
+			iface := p.Interface
+			if len(iface) == 0 {
+				iface = "default"
+			}
+			rtt := " - "
+			if p.IsAnswered {
+				rtt = fmt.Sprintf("%dms", p.RttMs)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", p.Resolver, iface, yesNo(p.IsAnswered), yesNo(p.IsExpected), rtt)
+		}
+		w.Flush()
+	}
+
+	fmt.Println()
+	if !r.IsLeakDetected() {
+		fmt.Println("No leaks detected")
+		return
+	}
+	fmt.Println("Leaks detected:")
+	for _, l := range r.Leaks {
+		fmt.Printf("    %s\n", l)
+	}
+}
+
+func valueOrUnknown(v string) string {
+	if len(v) == 0 {
+		return "unknown"
+	}
+	return v
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,301 @@
+//
+//  IVPN command line interface (CLI)
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the IVPN command line interface.
+//
+//  The IVPN command line interface is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The IVPN command line interface is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the IVPN command line interface. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/helpers"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/preferences"
+)
+
+const (
+	profileAction_connect_vpn      = "connect_vpn"
+	profileAction_disconnect_vpn   = "disconnect_vpn"
+	profileAction_enable_firewall  = "enable_firewall"
+	profileAction_disable_firewall = "disable_firewall"
+	profileAction_block_lan        = "block_lan"
+)
+
+type CmdNetProfiles struct {
+	flags.CmdInfo
+	status     bool
+	enable     string // [on/off]
+	add        string // profile name
+	ssid       string
+	gwMac      string
+	dhcpDomain string
+	iface      string
+	current    bool
+	actions    string // comma-separated list of actions
+	delete     string // profile name
+	reset      bool
+}
+
+func (c *CmdNetProfiles) Init() {
+	c.KeepArgsOrderInHelp = true
+
+	c.Initialize("netprofiles", "Network profiles: actions for wired or wireless networks")
+	c.BoolVar(&c.status, "status", false, "(default) Show settings and the current network identity")
+	c.StringVar(&c.enable, "enable", "", "[on/off]", "Enable or disable network profiles")
+	c.StringVar(&c.add, "add", "", "NAME",
+		`Add (or replace) network profile
+		The network is identified by any combination of the parameters below
+		(all defined parameters must match):
+			-ssid, -gw_mac, -dhcp_domain, -iface
+		Use '-current' to identify the network by the parameters of the current network.
+		Example:
+			ivpn netprofiles -add office -dhcp_domain corp.example.com -actions connect_vpn,enable_firewall
+			ivpn netprofiles -add home -current -actions disconnect_vpn,disable_firewall
+			ivpn netprofiles -add any_wired -iface 'en*' -actions connect_vpn`)
+	c.StringVar(&c.ssid, "ssid", "", "SSID", "(for '-add') WiFi network name")
+	c.StringVar(&c.gwMac, "gw_mac", "", "MAC", "(for '-add') Hardware address of the default gateway")
+	c.StringVar(&c.dhcpDomain, "dhcp_domain", "", "DOMAIN", "(for '-add') Domain name provided by DHCP")
+	c.StringVar(&c.iface, "iface", "", "PATTERN", "(for '-add') Network interface name (shell pattern, e.g. 'enp*')")
+	c.BoolVar(&c.current, "current", false, "(for '-add') Use the gateway MAC and DHCP domain (or SSID) of the current network")
+	c.StringVar(&c.actions, "actions", "", "LIST",
+		`(for '-add') Comma-separated list of actions to apply when joining the network
+		Acceptable actions:
+			* connect_vpn      - Connect to VPN
+			* disconnect_vpn   - Disconnect from VPN
+			* enable_firewall  - Enable firewall
+			* disable_firewall - Disable firewall
+			* block_lan        - Block LAN traffic (enables firewall)`)
+	c.StringVar(&c.delete, "delete", "", "NAME", "Delete network profile")
+	c.BoolVar(&c.reset, "reset", false, "Reset network profiles settings to defaults")
+}
+
+func (c *CmdNetProfiles) Run() error {
+	helloResp := _proto.GetHelloResponse()
+	params := helloResp.DaemonSettings.NetworkProfiles
+
+	isSettingsChanged := false
+
+	if len(c.enable) > 0 {
+		val, err := helpers.BoolParameterParse(c.enable) // [on/off]
+		if err != nil {
+			return err
+		}
+		if val && helloResp.ParanoidMode.IsEnabled {
+			return EaaEnabledOptionNotApplicable{}
+		}
+		params.IsEnabled = val
+		isSettingsChanged = true
+	}
+
+	if len(c.add) > 0 {
+		profile, err := c.createProfile(strings.TrimSpace(c.add))
+		if err != nil {
+			return err
+		}
+
+		isReplaced := false
+		for i, p := range params.Profiles {
+			if strings.EqualFold(p.Name, profile.Name) {
+				params.Profiles[i] = profile
+				isReplaced = true
+				break
+			}
+		}
+		if !isReplaced {
+			params.Profiles = append(params.Profiles, profile)
+		}
+		isSettingsChanged = true
+	}
+
+	if len(c.delete) > 0 {
+		name := strings.TrimSpace(c.delete)
+		isFound := false
+		for i, p := range params.Profiles {
+			if strings.EqualFold(p.Name, name) {
+				params.Profiles = append(params.Profiles[:i], params.Profiles[i+1:]...)
+				isFound = true
+				break
+			}
+		}
+		if !isFound {
+			return flags.BadParameter{Message: fmt.Sprintf("network profile '%s' not found", name)}
+		}
+		isSettingsChanged = true
+	}
+
+	// reset all settings
+	if c.reset {
+		fmt.Println("Resetting settings...")
+		params = preferences.NetworkProfilesParamsCreate()
+		isSettingsChanged = true
+	}
+
+	// send updated settings
+	if isSettingsChanged {
+		fmt.Print("Applying changes... ")
+		if err := _proto.SetNetworkProfiles(params); err != nil {
+			fmt.Println()
+			return err
+		}
+		fmt.Println("Done")
+	}
+
+	if c.status || !isSettingsChanged {
+		w := c.printStatus(nil)
+		w.Flush()
+	}
+	return nil
+}
+
+func (c *CmdNetProfiles) createProfile(name string) (preferences.NetworkProfile, error) {
+	profile := preferences.NetworkProfile{
+		Name: name,
+		Match: preferences.NetworkIdentity{
+			SSID:          c.ssid,
+			GatewayMAC:    c.gwMac,
+			DHCPDomain:    c.dhcpDomain,
+			InterfaceName: c.iface,
+		},
+	}
+
+	if c.current {
+		cur, err := _proto.GetNetworkIdentity()
+		if err != nil {
+			return profile, fmt.Errorf("failed to obtain info about the current network: %w", err)
+		}
+		if len(profile.Match.SSID) == 0 {
+			profile.Match.SSID = cur.Identity.SSID
+		}
+		if len(profile.Match.GatewayMAC) == 0 {
+			profile.Match.GatewayMAC = cur.Identity.GatewayMAC
+		}
+		if len(profile.Match.DHCPDomain) == 0 {
+			profile.Match.DHCPDomain = cur.Identity.DHCPDomain
+		}
+		if profile.Match.IsEmpty() {
+			return profile, fmt.Errorf("unable to identify the current network. Please, specify the network parameters")
+		}
+	}
+
+	if profile.Match.IsEmpty() {
+		return profile, flags.BadParameter{Message: "network is not defined (use -ssid, -gw_mac, -dhcp_domain, -iface or -current)"}
+	}
+
+	for _, a := range strings.Split(c.actions, ",") {
+		switch strings.ToLower(strings.TrimSpace(a)) {
+		case "":
+		case profileAction_connect_vpn:
+			profile.Actions.ConnectVpn = true
+		case profileAction_disconnect_vpn:
+			profile.Actions.DisconnectVpn = true
+		case profileAction_enable_firewall:
+			profile.Actions.EnableFirewall = true
+		case profileAction_disable_firewall:
+			profile.Actions.DisableFirewall = true
+		case profileAction_block_lan:
+			profile.Actions.BlockLan = true
+			profile.Actions.EnableFirewall = true
+		default:
+			return profile, flags.BadParameter{
+				Message: fmt.Sprintf("not supported action name '%s' (acceptable actions: %s)", a,
+					strings.Join([]string{profileAction_connect_vpn, profileAction_disconnect_vpn, profileAction_enable_firewall, profileAction_disable_firewall, profileAction_block_lan}, ", "))}
+		}
+	}
+
+	return profile, nil
+}
+
+func (c *CmdNetProfiles) printStatus(w *tabwriter.Writer) *tabwriter.Writer {
+	if w == nil {
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	}
+
+	valueOrNone := func(v string) string {
+		if len(v) == 0 {
+			return "-"
+		}
+		return v
+	}
+
+	params := _proto.GetHelloResponse().DaemonSettings.NetworkProfiles
+	if params.IsEnabled {
+		fmt.Fprintf(w, "Network profiles\t:\tEnabled\n")
+	} else {
+		fmt.Fprintf(w, "Network profiles\t:\tDisabled\n")
+	}
+
+	cur, err := _proto.GetNetworkIdentity()
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Fprintf(w, "Current network:\t\n")
+		fmt.Fprintf(w, "    WiFi network (SSID)\t:\t%s\n", valueOrNone(cur.Identity.SSID))
+		fmt.Fprintf(w, "    Gateway MAC\t:\t%s\n", valueOrNone(cur.Identity.GatewayMAC))
+		fmt.Fprintf(w, "    DHCP domain\t:\t%s\n", valueOrNone(cur.Identity.DHCPDomain))
+		fmt.Fprintf(w, "    Interface\t:\t%s\n", valueOrNone(cur.Identity.InterfaceName))
+		fmt.Fprintf(w, "    Matching profile\t:\t%s\n", valueOrNone(cur.Profile))
+	}
+
+	if len(params.Profiles) == 0 {
+		fmt.Fprintf(w, "Profiles\t:\tnot defined\n")
+		return w
+	}
+
+	fmt.Fprintf(w, "Profiles:\t\n")
+	for _, p := range params.Profiles {
+		match := make([]string, 0, 4)
+		if len(p.Match.SSID) > 0 {
+			match = append(match, "ssid="+p.Match.SSID)
+		}
+		if len(p.Match.GatewayMAC) > 0 {
+			match = append(match, "gw_mac="+p.Match.GatewayMAC)
+		}
+		if len(p.Match.DHCPDomain) > 0 {
+			match = append(match, "dhcp_domain="+p.Match.DHCPDomain)
+		}
+		if len(p.Match.InterfaceName) > 0 {
+			match = append(match, "iface="+p.Match.InterfaceName)
+		}
+
+		actions := make([]string, 0, 4)
+		if p.Actions.ConnectVpn {
+			actions = append(actions, profileAction_connect_vpn)
+		}
+		if p.Actions.DisconnectVpn {
+			actions = append(actions, profileAction_disconnect_vpn)
+		}
+		if p.Actions.EnableFirewall {
+			actions = append(actions, profileAction_enable_firewall)
+		}
+		if p.Actions.DisableFirewall {
+			actions = append(actions, profileAction_disable_firewall)
+		}
+		if p.Actions.BlockLan {
+			actions = append(actions, profileAction_block_lan)
+		}
+
+		fmt.Fprintf(w, "    %s\t:\t%s\n", p.Name, strings.Join(match, " "))
+		fmt.Fprintf(w, "    \t\tactions: %s\n", valueOrNone(strings.Join(actions, ",")))
+	}
+	return w
+}
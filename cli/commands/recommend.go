@@ -0,0 +1,122 @@
+//
+//  IVPN command line interface (CLI)
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the IVPN command line interface.
+//
+//  The IVPN command line interface is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The IVPN command line interface is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the IVPN command line interface. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/serverscore"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+type CmdRecommend struct {
+	flags.CmdInfo
+	proto   string
+	count   int
+	allow   string
+	deny    string
+	premium bool
+	ipv6    bool
+}
+
+func (c *CmdRecommend) Init() {
+	c.KeepArgsOrderInHelp = true
+
+	c.Initialize("recommend", "Show recommended servers\nServers are ranked by latency, distance, success rate of the previous connections and server load")
+	c.StringVar(&c.proto, "p", "", "PROTOCOL", "Protocol type OpenVPN|ovpn|WireGuard|wg (default: WireGuard)")
+	c.StringVar(&c.proto, "protocol", "", "PROTOCOL", "Protocol type OpenVPN|ovpn|WireGuard|wg (default: WireGuard)")
+	c.IntVar(&c.count, "count", 5, "NUMBER", "Number of servers to show (0 - all applicable servers)")
+	c.StringVar(&c.allow, "allow", "", "COUNTRIES", "Comma separated list of allowed country codes (e.g. 'DE,NL')")
+	c.StringVar(&c.deny, "deny", "", "COUNTRIES", "Comma separated list of country codes to exclude")
+	c.BoolVar(&c.premium, "premium", false, "Only premium servers")
+	c.BoolVar(&c.ipv6, "ipv6", false, "Only servers which support IPv6")
+}
+
+func (c *CmdRecommend) Run() error {
+	if c.count < 0 {
+		return flags.BadParameter{Message: "bad value for '-count' option"}
+	}
+
+	vpnType := vpn.WireGuard
+	if len(c.proto) > 0 {
+		t, err := getVpnTypeByFlag(c.proto)
+		if err != nil {
+			return err
+		}
+		vpnType = t
+	}
+
+	fmt.Println("Ranking servers ...")
+	candidates, err := _proto.RecommendServers(vpnType, c.constraints(), c.count)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight|tabwriter.Debug)
+	fmt.Fprintln(w, "#\tSCORE\tLOCATION\tCOUNTRY\tHOST\tPING\tDISTANCE\t")
+	for i, cnd := range candidates {
+		pingStr := " ? "
+		if cnd.PingMs > 0 {
+			pingStr = fmt.Sprintf("%dms", cnd.PingMs)
+		}
+		distanceStr := " ? "
+		if cnd.DistanceKm >= 0 {
+			distanceStr = fmt.Sprintf("%.0fkm", cnd.DistanceKm)
+		}
+		fmt.Fprintf(w, "%d\t%.2f\t%s\t%s (%s)\t%s\t%s\t%s\t\n", i+1, cnd.Score, cnd.Host.Name, cnd.Host.Country, cnd.Host.CountryCode, cnd.Host.Ip, pingStr, distanceStr)
+	}
+	w.Flush()
+
+	fmt.Println()
+	for i, cnd := range candidates {
+		fmt.Printf("%d. %s:\n", i+1, cnd.Host.Name)
+		for _, e := range cnd.Explanation {
+			fmt.Printf("    %s\n", e)
+		}
+	}
+
+	return nil
+}
+
+func (c *CmdRecommend) constraints() serverscore.Constraints {
+	return serverscore.Constraints{
+		CountriesAllow: splitCountries(c.allow),
+		CountriesDeny:  splitCountries(c.deny),
+		PremiumOnly:    c.premium,
+		IPv6Capable:    c.ipv6,
+	}
+}
+
+func splitCountries(list string) []string {
+	var ret []string
+	for _, c := range strings.Split(list, ",") {
+		if c = strings.TrimSpace(c); len(c) > 0 {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,284 @@
+//
+//  IVPN command line interface (CLI)
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the IVPN command line interface.
+//
+//  The IVPN command line interface is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The IVPN command line interface is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the IVPN command line interface. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/helpers"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/preferences"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/scheduler"
+)
+
+type CmdSchedule struct {
+	flags.CmdInfo
+	status       bool
+	enable       string // [on/off]
+	add          string // rule name
+	cron         string
+	action       string
+	target       string
+	pauseMin     int
+	setRule      string // [name:on/off]
+	delete       string // rule name
+	addTarget    string // target name
+	deleteTarget string // target name
+	reset        bool
+}
+
+func (c *CmdSchedule) Init() {
+	c.KeepArgsOrderInHelp = true
+
+	c.Initialize("schedule", "Time-based connection schedules")
+	c.BoolVar(&c.status, "status", false, "(default) Show schedules")
+	c.StringVar(&c.enable, "enable", "", "[on/off]", "Enable or disable schedules")
+	c.StringVar(&c.add, "add", "", "NAME",
+		`Add (or replace) schedule rule
+		Use with '-cron' and '-action' (and optionally '-target' or '-pause_min').
+		Example (keep VPN connected 08:00-19:00 on weekdays):
+			ivpn schedule -add work_start -cron '0 8 * * mon-fri' -action connect
+			ivpn schedule -add work_end -cron '0 19 * * mon-fri' -action disconnect
+		Example (pause VPN overnight for backups):
+			ivpn schedule -add backup -cron '0 2 * * *' -action pause -pause_min 90`)
+	c.StringVar(&c.cron, "cron", "", "SPEC",
+		`(for '-add') Time specification in the cron format (local time):
+			'<minute> <hour> <day-of-month> <month> <day-of-week>'
+		Also acceptable: @hourly, @daily, @weekly, @monthly, @yearly`)
+	c.StringVar(&c.action, "action", "", "ACTION",
+		`(for '-add') Action of the rule
+		Acceptable actions:
+			* connect      - Connect to VPN (the last connection settings or '-target')
+			* disconnect   - Disconnect from VPN
+			* pause        - Pause VPN connection for '-pause_min' minutes
+			* firewall_on  - Enable firewall
+			* firewall_off - Disable firewall`)
+	c.StringVar(&c.target, "target", "", "NAME", "(for '-add' with 'connect' action) Name of the connection target")
+	c.IntVar(&c.pauseMin, "pause_min", 0, "MINUTES", "(for '-add' with 'pause' action) Duration of the pause")
+	c.StringVar(&c.setRule, "set_rule", "", "CONFIG",
+		`Enable or disable the schedule rule
+		CONFIG parameter format: <NAME>:[on/off]
+		Example:
+			ivpn schedule -set_rule backup:off`)
+	c.StringVar(&c.delete, "delete", "", "NAME", "Delete schedule rule")
+	c.StringVar(&c.addTarget, "add_target", "", "NAME",
+		`Save the current default connection settings as a named connection target
+		(use 'ivpn connect' to define the connection settings)`)
+	c.StringVar(&c.deleteTarget, "delete_target", "", "NAME", "Delete connection target")
+	c.BoolVar(&c.reset, "reset", false, "Reset schedules settings to defaults")
+}
+
+func (c *CmdSchedule) Run() error {
+	helloResp := _proto.GetHelloResponse()
+	params := helloResp.DaemonSettings.Scheduler
+
+	isSettingsChanged := false
+
+	if len(c.enable) > 0 {
+		val, err := helpers.BoolParameterParse(c.enable) // [on/off]
+		if err != nil {
+			return err
+		}
+		if val && helloResp.ParanoidMode.IsEnabled {
+			return EaaEnabledOptionNotApplicable{}
+		}
+		params.IsEnabled = val
+		isSettingsChanged = true
+	}
+
+	if len(c.addTarget) > 0 {
+		defParams, err := _proto.GetDefConnectionParams()
+		if err != nil {
+			return fmt.Errorf("failed to obtain default connection settings: %w", err)
+		}
+		target := preferences.ConnectionTarget{Name: strings.TrimSpace(c.addTarget), Params: defParams.Params}
+		if t := params.FindTarget(target.Name); t != nil {
+			*t = target
+		} else {
+			params.Targets = append(params.Targets, target)
+		}
+		isSettingsChanged = true
+	}
+
+	if len(c.add) > 0 {
+		rule := preferences.ScheduleRule{
+			Name:         strings.TrimSpace(c.add),
+			IsEnabled:    true,
+			Cron:         c.cron,
+			Action:       preferences.ScheduleAction(strings.ToLower(c.action)),
+			Target:       c.target,
+			PauseMinutes: c.pauseMin,
+		}
+		if len(rule.Cron) == 0 || len(rule.Action) == 0 {
+			return flags.BadParameter{Message: "'-cron' and '-action' parameters are required"}
+		}
+
+		isReplaced := false
+		for i, r := range params.Rules {
+			if strings.EqualFold(r.Name, rule.Name) {
+				params.Rules[i] = rule
+				isReplaced = true
+				break
+			}
+		}
+		if !isReplaced {
+			params.Rules = append(params.Rules, rule)
+		}
+		isSettingsChanged = true
+	}
+
+	if len(c.setRule) > 0 {
+		dividerIdx := strings.LastIndex(c.setRule, ":")
+		if dividerIdx < 0 {
+			return flags.BadParameter{Message: "set_rule"}
+		}
+		name := helpers.TrimSpacesAndRemoveQuotes(c.setRule[:dividerIdx])
+		val, err := helpers.BoolParameterParse(c.setRule[dividerIdx+1:]) // [on/off]
+		if err != nil {
+			return err
+		}
+		idx := c.findRule(params, name)
+		if idx < 0 {
+			return flags.BadParameter{Message: fmt.Sprintf("schedule rule '%s' not found", name)}
+		}
+		params.Rules[idx].IsEnabled = val
+		isSettingsChanged = true
+	}
+
+	if len(c.delete) > 0 {
+		name := strings.TrimSpace(c.delete)
+		idx := c.findRule(params, name)
+		if idx < 0 {
+			return flags.BadParameter{Message: fmt.Sprintf("schedule rule '%s' not found", name)}
+		}
+		params.Rules = append(params.Rules[:idx], params.Rules[idx+1:]...)
+		isSettingsChanged = true
+	}
+
+	if len(c.deleteTarget) > 0 {
+		name := strings.TrimSpace(c.deleteTarget)
+		isFound := false
+		for i, t := range params.Targets {
+			if strings.EqualFold(t.Name, name) {
+				params.Targets = append(params.Targets[:i], params.Targets[i+1:]...)
+				isFound = true
+				break
+			}
+		}
+		if !isFound {
+			return flags.BadParameter{Message: fmt.Sprintf("connection target '%s' not found", name)}
+		}
+		isSettingsChanged = true
+	}
+
+	// reset all settings
+	if c.reset {
+		fmt.Println("Resetting settings...")
+		params = preferences.SchedulerParamsCreate()
+		isSettingsChanged = true
+	}
+
+	// send updated settings
+	if isSettingsChanged {
+		fmt.Print("Applying changes... ")
+		if err := _proto.SetSchedulerSettings(params); err != nil {
+			fmt.Println()
+			return err
+		}
+		fmt.Println("Done")
+	}
+
+	if c.status || !isSettingsChanged {
+		w := c.printStatus(nil)
+		w.Flush()
+	}
+	return nil
+}
+
+func (c *CmdSchedule) findRule(params preferences.SchedulerParams, name string) int {
+	for i, r := range params.Rules {
+		if strings.EqualFold(r.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *CmdSchedule) printStatus(w *tabwriter.Writer) *tabwriter.Writer {
+	if w == nil {
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	}
+
+	params := _proto.GetHelloResponse().DaemonSettings.Scheduler
+	if params.IsEnabled {
+		fmt.Fprintf(w, "Schedules\t:\tEnabled\n")
+	} else {
+		fmt.Fprintf(w, "Schedules\t:\tDisabled\n")
+	}
+
+	if len(params.Rules) == 0 {
+		fmt.Fprintf(w, "Rules\t:\tnot defined\n")
+	} else {
+		fmt.Fprintf(w, "Rules:\t\n")
+		now := time.Now()
+		for _, r := range params.Rules {
+			action := string(r.Action)
+			switch r.Action {
+			case preferences.ScheduleConnect:
+				if len(r.Target) > 0 {
+					action += " (" + r.Target + ")"
+				}
+			case preferences.SchedulePause:
+				action += fmt.Sprintf(" (%d min)", r.PauseMinutes)
+			}
+
+			next := "-"
+			if !r.IsEnabled {
+				next = "disabled"
+			} else if cron, err := scheduler.Parse(r.Cron); err == nil {
+				if t := cron.Next(now); !t.IsZero() {
+					next = "next: " + t.Format("2006-01-02 15:04 Mon")
+				}
+			}
+			fmt.Fprintf(w, "    %s\t:\t%s\t%s\t%s\n", r.Name, r.Cron, action, next)
+		}
+	}
+
+	if len(params.Targets) == 0 {
+		fmt.Fprintf(w, "Connection targets\t:\tnot defined\n")
+	} else {
+		fmt.Fprintf(w, "Connection targets:\t\n")
+		for _, t := range params.Targets {
+			hosts := make([]string, 0, len(t.Params.EntryHosts()))
+			for _, h := range t.Params.EntryHosts() {
+				hosts = append(hosts, h.Name)
+			}
+			fmt.Fprintf(w, "    %s\t:\t%s\t%s\t\n", t.Name, t.Params.VpnType.String(), strings.Join(hosts, ","))
+		}
+	}
+	return w
+}
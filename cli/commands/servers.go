@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -29,10 +29,10 @@ import (
 	"strings"
 	"text/tabwriter"
 
-	apitypes "github.com/ivpn/desktop-app/daemon/api/types"
-	"github.com/ivpn/desktop-app/daemon/vpn"
+	apitypes "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 
-	"github.com/ivpn/desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
 )
 
 const (
@@ -44,13 +44,11 @@ type CmdServers struct {
 	flags.CmdInfo
 	proto        string
 	location     bool
-	city         bool
 	country      bool
 	countryCode  bool
 	filter       string
 	ping         bool
-	hosts        bool
-	load         bool
+	update       bool
 	filterInvert bool
 }
 
@@ -61,8 +59,8 @@ func (c *CmdServers) Init() {
 	c.StringVar(&c.proto, "p", "", "PROTOCOL", "Protocol type OpenVPN|ovpn|WireGuard|wg")
 	c.StringVar(&c.proto, "protocol", "", "PROTOCOL", "Protocol type OpenVPN|ovpn|WireGuard|wg")
 
-	c.BoolVar(&c.location, "l", false, "Apply FILTER to server location (Hostname)")
-	c.BoolVar(&c.location, "location", false, "Apply FILTER to server location (Hostname)")
+	c.BoolVar(&c.location, "l", false, "Apply FILTER to server location (server name)")
+	c.BoolVar(&c.location, "location", false, "Apply FILTER to server location (server name)")
 
 	c.BoolVar(&c.country, "c", false, "Apply FILTER to country name")
 	c.BoolVar(&c.country, "country", false, "Apply FILTER to country name")
@@ -70,26 +68,22 @@ func (c *CmdServers) Init() {
 	c.BoolVar(&c.countryCode, "cc", false, "Apply FILTER to country code")
 	c.BoolVar(&c.countryCode, "country_code", false, "Apply FILTER to country code")
 
-	c.BoolVar(&c.city, "city", false, "Apply FILTER to city name")
-
 	c.BoolVar(&c.ping, "ping", false, "Ping servers and view ping result")
 
-	c.BoolVar(&c.hosts, "hosts", false, "Show location hosts")
-	c.BoolVar(&c.load, "load", false, "Show load info for each host")
+	c.BoolVar(&c.update, "update", false, "Update servers list from backend (skip cached data)")
 
 	c.BoolVar(&c.filterInvert, "filter_invert", false, "Invert filtering result")
 }
 func (c *CmdServers) Run() error {
-	var servers apitypes.ServersInfoResponse
+	var servers apitypes.ServerListResponse
 	var err error
 
 	isServersLoaded := false
-	if c.load {
-		fmt.Println("Updating servers load info...")
-		c.hosts = true                                // show also host info
-		servers, err = _proto.GetServersForceUpdate() // force update servers info (we need latest host load statuses)
+	if c.update {
+		fmt.Println("Updating servers list...")
+		servers, err = _proto.GetServersForceUpdate()
 		if err != nil {
-			fmt.Println("Failed to update servers load info. Using cached data!")
+			fmt.Println("Failed to update servers list. Using cached data!")
 		} else {
 			isServersLoaded = true
 		}
@@ -119,33 +113,19 @@ func (c *CmdServers) Run() error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight|tabwriter.Debug)
 
 	pingHeader := ""
-	hostsHeader := ""
-	hostsLoadHeader := ""
 	if c.ping {
 		pingHeader = "PING\t"
 	}
-	if c.hosts {
-		hostsHeader = "HOSTS\t"
-		if c.load {
-			hostsLoadHeader = "LOAD\t"
-		}
-	}
 
-	fmt.Fprintln(w, "PROTOCOL\tLOCATION\tCITY\tCOUNTRY\tISP\tIPv? tunnel\t"+pingHeader+hostsHeader+hostsLoadHeader)
+	fmt.Fprintln(w, "PROTOCOL\tLOCATION\tCOUNTRY\tHOST\tPREMIUM\t"+pingHeader)
 
 	helloResp := _proto.GetHelloResponse()
 	isWgDisabled := len(helloResp.DisabledFunctions.WireGuardError) > 0
 	isOpenVPNDisabled := len(helloResp.DisabledFunctions.OpenVPNError) > 0
 
 	svrs := serversFilter(isWgDisabled, isOpenVPNDisabled,
-		slist, c.filter, c.proto, c.location, c.city, c.countryCode, c.country, c.filterInvert)
+		slist, c.filter, c.proto, c.location, c.countryCode, c.country, c.filterInvert)
 	for _, s := range svrs {
-		str := ""
-		IPvInfo := "IPv4"
-		if s.isIPv6Tunnel {
-			IPvInfo = "IPv4/IPv6"
-		}
-
 		pingStr := ""
 		if c.ping {
 			pingStr = " ?  \t"
@@ -154,40 +134,18 @@ func (c *CmdServers) Run() error {
 			}
 		}
 
-		firstHostStr := ""
-		firstHostLoadStr := ""
-		if c.hosts {
-			firstHostStr = "\t"
-			if len(s.hosts) > 0 {
-				firstHostStr = s.hosts[0].hostname + "\t"
-				if c.load {
-
-					firstHostLoadStr = fmt.Sprintf("%d", int(s.hosts[0].load+0.5)) + "%\t"
-				}
-			}
+		hostStr := ""
+		if len(s.hosts) > 0 {
+			hostStr = s.hosts[0].host
 		}
 
-		str = fmt.Sprintf("%s\t%s\t%s (%s)\t %s\t%s\t%s\t%s%s%s", s.protocol, s.gateway, s.city, s.countryCode, s.country, s.isp, IPvInfo, pingStr, firstHostStr, firstHostLoadStr)
-		fmt.Fprintln(w, str)
-
-		if c.hosts && len(s.hosts) > 1 {
-			for _, h := range s.hosts[1:] {
-				if c.ping {
-					pingStr = " ?  \t"
-					if h.pingMs > 0 {
-						pingStr = fmt.Sprintf("%dms\t", h.pingMs)
-					}
-				}
-
-				loadStr := ""
-				if c.load {
-					loadStr = fmt.Sprintf("%d", int(h.load+0.5)) + "%\t"
-				}
-				str = fmt.Sprintf("%s\t%s\t%s %s\t %s\t%s\t%s\t%s%s%s", "", "", "", "", "", "", "", pingStr, h.hostname+"\t", loadStr)
-				fmt.Fprintln(w, str)
-			}
+		premiumStr := ""
+		if s.premium {
+			premiumStr = "yes"
 		}
 
+		str := fmt.Sprintf("%s\t%s\t%s (%s)\t%s\t%s\t%s", s.protocol, s.gateway, s.country, s.countryCode, hostStr, premiumStr, pingStr)
+		fmt.Fprintln(w, str)
 	}
 
 	w.Flush()
@@ -222,46 +180,51 @@ func getVpnTypeByFlag(proto string) (t vpn.Type, err error) {
 	return t, flags.BadParameter{Message: "protocol definition not correct"}
 }
 
-func serversList(servers apitypes.ServersInfoResponse) []serverDesc {
+func serversList(servers apitypes.ServerListResponse) []serverDesc {
 	svrs := serversListByVpnType(servers, vpn.WireGuard)
 	svrs = append(svrs, serversListByVpnType(servers, vpn.OpenVPN)...)
 	return svrs
 }
 
-func serversListByVpnType(servers apitypes.ServersInfoResponse, t vpn.Type) []serverDesc {
-
-	var ret []serverDesc
+// serversListByVpnType returns the servers list for the given VPN type.
+// The backend groups servers by country; each server is a separate location here.
+func serversListByVpnType(servers apitypes.ServerListResponse, t vpn.Type) []serverDesc {
+	countries := servers.ServerList.OpenVPNServers
+	protoName := ProtoName_OpenVPN
 	if t == vpn.WireGuard {
-		ret = make([]serverDesc, 0, len(servers.WireguardServers))
-
-		for _, s := range servers.WireguardServers {
-			hosts := make([]hostDesc, 0, len(s.Hosts))
+		countries = servers.ServerList.WireGuardServers
+		protoName = ProtoName_WireGuard
+	}
 
-			isIPv6Tunnel := false
-			for _, h := range s.Hosts {
-				if len(h.IPv6.LocalIP) > 0 {
-					isIPv6Tunnel = true
-				}
-				hosts = append(hosts, hostDesc{host: strings.TrimSpace(h.Host), hostname: strings.TrimSpace(h.Hostname), load: h.Load})
+	var ret []serverDesc
+	for _, c := range countries {
+		for _, h := range c.Hosts {
+			country := h.Country
+			if len(country) == 0 {
+				country = c.Country
 			}
-			ret = append(ret, serverDesc{protocol: ProtoName_WireGuard, gateway: s.Gateway, city: s.City, countryCode: s.CountryCode, country: s.Country, isp: s.ISP, hosts: hosts, isIPv6Tunnel: isIPv6Tunnel})
-		}
-	} else {
-		ret = make([]serverDesc, 0, len(servers.OpenvpnServers))
 
-		for _, s := range servers.OpenvpnServers {
-			hosts := make([]hostDesc, 0, len(s.Hosts))
+			hosts := []hostDesc{{host: strings.TrimSpace(h.Ip), hostname: strings.TrimSpace(h.Name)}}
+			ret = append(ret, serverDesc{protocol: protoName, gateway: strings.TrimSpace(h.Name), countryCode: h.CountryCode, country: country, premium: h.Premium, hosts: hosts})
+		}
+	}
+	return ret
+}
 
-			for _, h := range s.Hosts {
-				hosts = append(hosts, hostDesc{host: strings.TrimSpace(h.Host), hostname: strings.TrimSpace(h.Hostname), load: h.Load})
+// findServer returns the server with the given name (location) from the servers list grouped by country
+func findServer(countries []apitypes.ServerListCountryItem, name string) *apitypes.ServerListItem {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for ci := range countries {
+		for hi, h := range countries[ci].Hosts {
+			if strings.ToLower(strings.TrimSpace(h.Name)) == name {
+				return &countries[ci].Hosts[hi]
 			}
-			ret = append(ret, serverDesc{protocol: ProtoName_OpenVPN, gateway: s.Gateway, city: s.City, countryCode: s.CountryCode, country: s.Country, isp: s.ISP, hosts: hosts})
 		}
 	}
-	return ret
+	return nil
 }
 
-func serversFilter(isWgDisabled bool, isOvpnDisabled bool, servers []serverDesc, mask string, proto string, useGw, useCity, useCCode, useCountry, invertFilter bool) (svrs []serverDesc) {
+func serversFilter(isWgDisabled bool, isOvpnDisabled bool, servers []serverDesc, mask string, proto string, useGw, useCCode, useCountry, invertFilter bool) (svrs []serverDesc) {
 	if isWgDisabled || isOvpnDisabled {
 		oldSvrs := servers
 		servers = make([]serverDesc, 0, len(oldSvrs))
@@ -280,7 +243,7 @@ func serversFilter(isWgDisabled bool, isOvpnDisabled bool, servers []serverDesc,
 		return servers
 	}
 	mask = strings.ToLower(mask)
-	checkAll := !(useGw || useCity || useCCode || useCountry)
+	checkAll := !(useGw || useCCode || useCountry)
 
 	ret := make([]serverDesc, 0, len(servers))
 	for _, s := range servers {
@@ -301,9 +264,6 @@ func serversFilter(isWgDisabled bool, isOvpnDisabled bool, servers []serverDesc,
 		if (checkAll || useGw) && strings.ToLower(s.gateway) == mask {
 			isOK = true
 		}
-		if (checkAll || useCity) && strings.Contains(strings.ToLower(s.city), mask) {
-			isOK = true
-		}
 		if (checkAll || useCCode) && strings.ToLower(s.countryCode) == mask {
 			isOK = true
 		}
@@ -312,7 +272,7 @@ func serversFilter(isWgDisabled bool, isOvpnDisabled bool, servers []serverDesc,
 		}
 
 		for _, h := range s.hosts {
-			if h.hostname == mask {
+			if h.host == mask {
 				isOK = true
 				break
 			}
@@ -362,7 +322,7 @@ func serversPing(servers []serverDesc, needSort bool, vpnTypePrioritized *vpn.Ty
 	if needSort {
 		sort.Slice(servers, func(i, j int) bool {
 			if servers[i].pingMs == 0 && servers[j].pingMs == 0 {
-				return strings.Compare(servers[i].city, servers[j].city) < 0
+				return strings.Compare(servers[i].gateway, servers[j].gateway) < 0
 			} else if servers[i].pingMs <= 0 {
 				return true
 			} else if servers[j].pingMs <= 0 {
@@ -380,21 +340,18 @@ type hostDesc struct {
 	hostname string
 	host     string // ip
 	pingMs   int
-	load     float32
 }
 
 type serverDesc struct {
-	protocol     string
-	gateway      string
-	city         string
-	countryCode  string
-	country      string
-	isp          string
-	hosts        []hostDesc
-	pingMs       int
-	isIPv6Tunnel bool
+	protocol    string
+	gateway     string // server name
+	countryCode string
+	country     string
+	premium     bool
+	hosts       []hostDesc
+	pingMs      int
 }
 
 func (s *serverDesc) String() string {
-	return fmt.Sprintf("%s, %s (%s), %s", s.gateway, s.city, s.countryCode, s.country)
+	return fmt.Sprintf("%s (%s), %s", s.gateway, s.countryCode, s.country)
 }
@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -29,11 +29,13 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 
-	"github.com/ivpn/desktop-app/cli/cliplatform"
-	"github.com/ivpn/desktop-app/cli/flags"
-	"github.com/ivpn/desktop-app/cli/helpers"
-	"github.com/ivpn/desktop-app/daemon/protocol/types"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/cliplatform"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/helpers"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/splittun"
 )
 
 type Exclude struct {
@@ -173,6 +175,14 @@ type SplitTun struct {
 	appremove  string
 	appadd     string // this parameter is not in use. We need it just for help info (using 'appaddArgs' parsed with specific logic)
 	appaddArgs []string
+
+	dstBypass string // comma-separated list of destinations
+	dstVpn    string // comma-separated list of destinations
+	dstRemove string // comma-separated list of destinations
+	dstClean  bool
+
+	appRuleAdd    string // comma-separated list of application rules
+	appRuleRemove string // comma-separated list of application rules
 }
 
 const (
@@ -202,6 +212,34 @@ func (c *SplitTun) Init() {
 		c.BoolVar(&c.reset, "clean", false, "Erase configuration (remove applications from configuration and disable Split Tunnel)")
 		c.StringVar(&c.appadd, "appadd", "", "COMMAND", "Execute command (binary) in Split Tunnel environment\nInfo: short version of this command is 'ivpn exclude <command>'\nExamples:\n    ivpn splittun -appadd firefox\n    ivpn splittun -appadd ping 1.1.1.1\n    ivpn splittun -appadd /usr/bin/google-chrome")
 		c.StringVar(&c.appremove, "appremove", "", "PID", "Remove application from Split Tunnel environment\n(argument: Process ID)")
+
+		c.StringVar(&c.dstBypass, "dst_bypass", "", "LIST",
+			`Comma-separated list of destinations which traffic bypasses the VPN
+		Destination: IP address, subnet, domain name or wildcard domain (domain names are resolved continuously;
+		the addresses of wildcard domain '*.example.com' are learned from DNS responses observed on the host,
+		so DNS over HTTPS/TLS queries of applications are not visible)
+		Note! The destination rules are applied only when Split Tunnel is enabled
+		Example:
+			ivpn splittun -dst_bypass 10.0.0.0/8,internal.example.com,*.corp.example.com`)
+		c.StringVar(&c.dstVpn, "dst_vpn", "", "LIST",
+			`Comma-separated list of destinations which traffic is forced through the VPN
+		(even for applications in Split Tunnel environment or in Inverse mode)
+		Example:
+			ivpn splittun -dst_vpn 10.1.2.3,mail.example.com`)
+		c.StringVar(&c.dstRemove, "dst_remove", "", "LIST", "Comma-separated list of destinations to remove from configuration")
+		c.BoolVar(&c.dstClean, "dst_clean", false, "Remove all destination rules")
+
+		c.StringVar(&c.appRuleAdd, "app_rule_add", "", "LIST",
+			`Comma-separated list of application rules: full paths to binaries or binary names.
+		The rules are matched against the executable file of the process
+		(for scripts it is the interpreter, e.g. /usr/bin/python3).
+		The matching processes are moved into Split Tunnel environment automatically when started
+		(including applications started by the desktop environment or systemd).
+		The rules are kept across restarts of the application and reboots.
+		Note! The rules are applied only when Split Tunnel is enabled
+		Example:
+			ivpn splittun -app_rule_add /usr/bin/firefox,telegram-desktop`)
+		c.StringVar(&c.appRuleRemove, "app_rule_remove", "", "LIST", "Comma-separated list of application rules to remove from configuration")
 	}
 
 	c.BoolVar(&c.onInverse, cmd_name_on_inverse, false,
@@ -273,11 +311,35 @@ func (c *SplitTun) Run() error {
 		return fmt.Errorf("the Split Tunneling functionality not available")
 	}
 
+	if len(c.dstBypass) > 0 || len(c.dstVpn) > 0 || len(c.dstRemove) > 0 || c.dstClean {
+		rules := c.updateDestinations(cfg.Destinations)
+		if err = _proto.SetSplitTunnelConfig(cfg.IsEnabled, cfg.IsInversed, cfg.IsAnyDns, cfg.IsAllowWhenNoVpn, false, rules, nil); err != nil {
+			return err
+		}
+		cfg, err = _proto.GetSplitTunnelStatus()
+		if err != nil {
+			return err
+		}
+		return c.doShowStatus(cfg, c.statusFull)
+	}
+
+	if len(c.appRuleAdd) > 0 || len(c.appRuleRemove) > 0 {
+		apps := c.updateAppRules(cfg.SplitTunnelApps)
+		if err = _proto.SetSplitTunnelConfig(cfg.IsEnabled, cfg.IsInversed, cfg.IsAnyDns, cfg.IsAllowWhenNoVpn, false, nil, apps); err != nil {
+			return err
+		}
+		cfg, err = _proto.GetSplitTunnelStatus()
+		if err != nil {
+			return err
+		}
+		return c.doShowStatus(cfg, c.statusFull)
+	}
+
 	if c.reset {
 		cfg.IsEnabled = false
 		cfg.SplitTunnelApps = make([]string, 0)
 
-		if err = _proto.SetSplitTunnelConfig(false, false, false, false, true); err != nil {
+		if err = _proto.SetSplitTunnelConfig(false, false, false, false, true, nil, nil); err != nil {
 			return err
 		}
 		cfg, err = _proto.GetSplitTunnelStatus()
@@ -299,7 +361,7 @@ func (c *SplitTun) Run() error {
 			isInverse = false
 		}
 
-		if err = _proto.SetSplitTunnelConfig(isEnabled, isInverse, isAnyDns, isAllowWhenNoVpn, false); err != nil {
+		if err = _proto.SetSplitTunnelConfig(isEnabled, isInverse, isAnyDns, isAllowWhenNoVpn, false, nil, nil); err != nil {
 			return err
 		}
 		cfg, err = _proto.GetSplitTunnelStatus()
@@ -331,10 +393,90 @@ func (c *SplitTun) Run() error {
 
 func (c *SplitTun) doShowStatus(cfg types.SplitTunnelStatus, isFull bool) error {
 	w := printSplitTunState(nil, false, isFull, cfg.IsEnabled, cfg.IsInversed, cfg.IsAnyDns, cfg.IsAllowWhenNoVpn, cfg.SplitTunnelApps, cfg.RunningApps)
+	printSplitTunDestinations(w, cfg.Destinations)
 	w.Flush()
 	return nil
 }
 
+// updateDestinations returns the destination rules modified according to the command arguments
+func (c *SplitTun) updateDestinations(current []splittun.DestinationStatus) []splittun.DestinationRule {
+	rules := make([]splittun.DestinationRule, 0, len(current))
+	if !c.dstClean {
+		for _, d := range current {
+			rules = append(rules, d.DestinationRule)
+		}
+	}
+
+	remove := func(dst string) {
+		for i, r := range rules {
+			if strings.EqualFold(r.Destination, dst) {
+				rules = append(rules[:i], rules[i+1:]...)
+				return
+			}
+		}
+	}
+
+	for _, dst := range splitList(c.dstRemove) {
+		remove(dst)
+	}
+	for _, dst := range splitList(c.dstBypass) {
+		remove(dst)
+		rules = append(rules, splittun.DestinationRule{Destination: dst, Action: splittun.DestinationBypassVpn})
+	}
+	for _, dst := range splitList(c.dstVpn) {
+		remove(dst)
+		rules = append(rules, splittun.DestinationRule{Destination: dst, Action: splittun.DestinationViaVpn})
+	}
+	return rules
+}
+
+// updateAppRules returns the application rules modified according to the command arguments
+func (c *SplitTun) updateAppRules(current []string) []string {
+	rules := make([]string, 0, len(current))
+	toRemove := make(map[string]struct{})
+	for _, r := range splitList(c.appRuleRemove) {
+		toRemove[r] = struct{}{}
+	}
+	for _, r := range current {
+		if _, ok := toRemove[r]; !ok {
+			rules = append(rules, r)
+		}
+	}
+	return append(rules, splitList(c.appRuleAdd)...)
+}
+
+func splitList(list string) []string {
+	var ret []string
+	for _, d := range strings.Split(list, ",") {
+		if d = strings.TrimSpace(d); len(d) > 0 {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+func printSplitTunDestinations(w *tabwriter.Writer, destinations []splittun.DestinationStatus) {
+	for i, d := range destinations {
+		action := "bypass VPN"
+		if d.Action == splittun.DestinationViaVpn {
+			action = "through VPN"
+		}
+		info := fmt.Sprintf("%s (%s)", d.Destination, action)
+		if len(d.Addresses) > 0 {
+			info += ": " + strings.Join(d.Addresses, ", ")
+		}
+		if len(d.Error) > 0 {
+			info += " [" + d.Error + "]"
+		}
+
+		if i == 0 {
+			fmt.Fprintf(w, "Destinations\t:\t%s\n", info)
+		} else {
+			fmt.Fprintf(w, "\t\t%s\n", info)
+		}
+	}
+}
+
 func (c *SplitTun) doShowStatusShort(cfg types.SplitTunnelStatus) error {
 	w := printSplitTunState(nil, true, false, cfg.IsEnabled, cfg.IsInversed, cfg.IsAnyDns, cfg.IsAllowWhenNoVpn, cfg.SplitTunnelApps, cfg.RunningApps)
 	w.Flush()
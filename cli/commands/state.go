@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -25,22 +25,61 @@ package commands
 import (
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/ivpn/desktop-app/cli/flags"
-	apitypes "github.com/ivpn/desktop-app/daemon/api/types"
-	"github.com/ivpn/desktop-app/daemon/service/srverrors"
-	"github.com/ivpn/desktop-app/daemon/vpn"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/protocol"
+	apitypes "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/srverrors"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 )
 
 type CmdState struct {
 	flags.CmdInfo
+	watch bool
 }
 
 func (c *CmdState) Init() {
 	c.Initialize("status", "Prints full info about IVPN state")
+	c.BoolVar(&c.watch, "watch", false, "Continuously print live statistics of the VPN tunnel (traffic, handshake, endpoint) until disconnection")
 }
 func (c *CmdState) Run() error {
-	return showState()
+	if err := showState(); err != nil || !c.watch {
+		return err
+	}
+	return watchTunnelStats()
+}
+
+func watchTunnelStats() error {
+	state, _, err := _proto.GetVPNState()
+	if err != nil {
+		return err
+	}
+	if state != vpn.CONNECTED {
+		fmt.Println("VPN is not connected")
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println("Tunnel statistics (press Ctrl+C to stop):")
+	if stats, err := _proto.GetTunnelStats(); err == nil {
+		printTunnelStats(stats)
+	}
+
+	for {
+		stats, isDisconnected, err := _proto.WaitTunnelStats(time.Second * 15)
+		if err != nil {
+			if _, ok := err.(protocol.ResponseTimeout); ok {
+				continue // no statistics received (e.g. connection is paused or re-establishing)
+			}
+			return err
+		}
+		if isDisconnected {
+			fmt.Println("VPN disconnected")
+			return nil
+		}
+		printTunnelStats(stats)
+	}
 }
 
 func showState() error {
@@ -62,7 +101,7 @@ func showState() error {
 	serverInfo := ""
 	exitServerInfo := ""
 
-	var servers apitypes.ServersInfoResponse
+	var servers apitypes.ServerListResponse
 	if state == vpn.CONNECTED {
 		servers, err = _proto.GetServers()
 		if err == nil {
@@ -75,6 +114,9 @@ func showState() error {
 
 	w := printAccountInfo(nil, _proto.GetHelloResponse().Session.AccountID)
 	printState(w, state, connected, serverInfo, exitServerInfo, _proto.GetHelloResponse())
+	if geo, err := _proto.GetGeoLookup(); err == nil {
+		printGeoLookup(w, geo)
+	}
 	if state == vpn.CONNECTED {
 		printDNSState(w, connected.Dns, &servers)
 	}
@@ -137,5 +179,5 @@ func getServerInfoByHostName(servers []serverDesc, hostname string) string {
 }
 
 func ConnectedServerInfo(s serverDesc, host hostDesc) string {
-	return fmt.Sprintf("%s [%s], %s (%s), %s", s.gateway, host.hostname, s.city, s.countryCode, s.country)
+	return fmt.Sprintf("%s [%s], %s (%s)", s.gateway, host.host, s.country, s.countryCode)
 }
@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -38,7 +38,6 @@ const (
 	TipHelpCommand               TipType = iota
 	TipLogout                    TipType = iota
 	TipLogin                     TipType = iota
-	TipForceLogin                TipType = iota
 	TipServers                   TipType = iota
 	TipConnectHelp               TipType = iota
 	TipDisconnect                TipType = iota
@@ -82,9 +81,7 @@ func PrintTip(w *tabwriter.Writer, tip TipType) {
 	case TipLogout:
 		str = newTip("logout", "Logout from this device")
 	case TipLogin:
-		str = newTip("login ACCOUNT_ID", "Log in with your Account ID")
-	case TipForceLogin:
-		str = newTip("login -force ACCOUNT_ID", "Log in with your Account ID and logout from all other devices")
+		str = newTip("login PIN", "Log in with your login PIN")
 	case TipServers:
 		str = newTip("servers", "Show servers list")
 	case TipConnectHelp:
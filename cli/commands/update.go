@@ -0,0 +1,86 @@
+//
+//  IVPN command line interface (CLI)
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the IVPN command line interface.
+//
+//  The IVPN command line interface is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The IVPN command line interface is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the IVPN command line interface. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	protocolTypes "github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+)
+
+type CmdUpdate struct {
+	flags.CmdInfo
+	beta bool
+}
+
+func (c *CmdUpdate) Init() {
+	c.Initialize("update", "Check for application updates")
+	c.BoolVar(&c.beta, "beta", false, "Check for beta releases")
+}
+
+func (c *CmdUpdate) Run() error {
+	updateType := protocolTypes.AppUpdateGeneric
+	if c.beta {
+		updateType = protocolTypes.AppUpdateBeta
+	}
+
+	resp, err := _proto.GetAppUpdateInfo(updateType)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "Current version\t:\t%s\n", resp.CurrentVersion)
+	fmt.Fprintf(w, "Latest version\t:\t%s\n", resp.LatestVersion)
+	w.Flush()
+
+	if !resp.IsUpdateAvailable {
+		fmt.Println("\nThe application is up to date")
+		return nil
+	}
+
+	fmt.Println("\nUpdate available!")
+	if latest := resp.UpdateInfo.DaemonVersion(); latest != nil {
+		printReleaseNotes(latest.ReleaseNotes)
+	}
+	return nil
+}
+
+func printReleaseNotes(notes []types.UpdateReleaseNote) {
+	if len(notes) == 0 {
+		return
+	}
+	fmt.Println("Release notes:")
+	for _, n := range notes {
+		if len(n.Type) > 0 {
+			fmt.Printf("  [%s] %s\n", strings.ToUpper(n.Type), n.Description)
+		} else {
+			fmt.Printf("  %s\n", n.Description)
+		}
+	}
+}
@@ -1,26 +1,35 @@
-module github.com/ivpn/desktop-app/cli
+module github.com/tahirmahm123/vpn-desktop-app/cli
 
 go 1.19
 
 require (
-	github.com/ivpn/desktop-app/daemon v0.0.0
+	github.com/tahirmahm123/vpn-desktop-app/daemon v0.0.0
 	golang.org/x/crypto v0.15.0
-	golang.org/x/sys v0.14.0
+	golang.org/x/sys v0.18.0
 	golang.org/x/term v0.14.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/fsnotify/fsnotify v1.6.0 // indirect
+	github.com/fsnotify/fsnotify v1.7.0 // indirect
+	github.com/google/go-cmp v0.5.9 // indirect
 	github.com/google/uuid v1.3.0 // indirect
+	github.com/josharian/native v1.1.0 // indirect
 	github.com/mattn/go-runewidth v0.0.14 // indirect
+	github.com/mdlayher/genetlink v1.3.2 // indirect
+	github.com/mdlayher/netlink v1.7.2 // indirect
+	github.com/mdlayher/socket v0.4.1 // indirect
 	github.com/olekukonko/tablewriter v0.0.5 // indirect
 	github.com/parsiya/golnk v0.0.0-20221103095132-740a4c27c4ff // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.4.4 // indirect
 	github.com/stretchr/testify v1.8.3 // indirect
+	golang.org/x/net v0.18.0 // indirect
+	golang.org/x/sync v0.2.0 // indirect
+	golang.zx2c4.com/wireguard v0.0.0-20230325221338-052af4a8072b // indirect
+	golang.zx2c4.com/wireguard/wgctrl v0.0.0-20230429144221-925a1e7659e6 // indirect
 	golang.zx2c4.com/wireguard/windows v0.5.3 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
-replace github.com/ivpn/desktop-app/daemon => ../daemon
+replace github.com/tahirmahm123/vpn-desktop-app/daemon => ../daemon
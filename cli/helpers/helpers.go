@@ -1,6 +1,6 @@
 //
 //  Daemon for IVPN Client Desktop
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -26,7 +26,7 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/ivpn/desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
 )
 
 func CheckIsAdmin() bool {
@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -25,7 +25,6 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -34,12 +33,12 @@ import (
 	"syscall"
 	"text/tabwriter"
 
-	"github.com/ivpn/desktop-app/cli/cliplatform"
-	"github.com/ivpn/desktop-app/cli/commands"
-	"github.com/ivpn/desktop-app/cli/flags"
-	"github.com/ivpn/desktop-app/cli/protocol"
-	"github.com/ivpn/desktop-app/daemon/service/platform"
-	"github.com/ivpn/desktop-app/daemon/version"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/cliplatform"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/commands"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/flags"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/protocol"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/version"
 	"golang.org/x/term"
 )
 
@@ -100,6 +99,7 @@ func main() {
 	addCommand(&commands.CmdDisconnect{})
 	addCommand(&commands.CmdConnectionControl{})
 	addCommand(&commands.CmdServers{})
+	addCommand(&commands.CmdRecommend{})
 	addCommand(&commands.CmdFirewall{})
 	if cliplatform.IsSplitTunSupported() {
 		// Split tunnel functionality is currently only available on Windows
@@ -112,12 +112,19 @@ func main() {
 	addCommand(&commands.CmdDns{})
 	addCommand(&commands.CmdAntitracker{})
 	addCommand(&commands.CmdLogs{})
+	addCommand(&commands.CmdHistory{})
 	addCommand(&commands.CmdLogin{})
 	addCommand(&commands.CmdLogout{})
 	addCommand(&commands.CmdAccount{})
 	addCommand(&commands.CmdParanoidMode{})
 	addCommand(&commands.CmdAutoConnect{})
+	addCommand(&commands.CmdHealthMonitor{})
+	addCommand(&commands.CmdFallback{})
 	addCommand(&commands.CmdWiFi{})
+	addCommand(&commands.CmdNetProfiles{})
+	addCommand(&commands.CmdSchedule{})
+	addCommand(&commands.CmdLeakTest{})
+	addCommand(&commands.CmdUpdate{})
 
 	if len(os.Args) >= 2 {
 		arg1 := strings.TrimLeft(strings.ToLower(os.Args[1]), "-")
@@ -142,14 +149,19 @@ func main() {
 	}
 
 	// initialize command handler
+	// The Unix domain socket is preferred (if available); the connection-info file is in use for TCP connection
+	socketFile := platform.ServiceSocketFile()
 	port, secret, err := readDaemonPort()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: Unable to connect to service: %s\n", err)
-		printServStartInstructions()
-		os.Exit(1)
+		if _, sockErr := os.Stat(socketFile); len(socketFile) == 0 || sockErr != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Unable to connect to service: %s\n", err)
+			printServStartInstructions()
+			os.Exit(1)
+		}
 	}
 
 	proto := protocol.CreateClient(port, secret)
+	proto.SetUnixSocketFile(socketFile)
 
 	proto.SetParanoidModeSecretRequestFunc(RequestParanoidModePassword)
 	proto.SetPrintFunc(PrintToConsoleFunc)
@@ -260,7 +272,7 @@ func readDaemonPort() (port int, secret uint64, err error) {
 
 	data, err := ioutil.ReadFile(filepath.Clean(file))
 	if err != nil {
-		log.Fatal(err)
+		return 0, 0, fmt.Errorf("failed to read connection-info: %w", err)
 	}
 
 	vars := strings.Split(string(data), ":")
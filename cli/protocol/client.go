@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -35,14 +35,18 @@ import (
 	"sync"
 	"time"
 
-	apitypes "github.com/ivpn/desktop-app/daemon/api/types"
-	"github.com/ivpn/desktop-app/daemon/logger"
-	"github.com/ivpn/desktop-app/daemon/protocol/types"
-	"github.com/ivpn/desktop-app/daemon/service/dns"
-	"github.com/ivpn/desktop-app/daemon/service/preferences"
-	service_types "github.com/ivpn/desktop-app/daemon/service/types"
-	"github.com/ivpn/desktop-app/daemon/version"
-	"github.com/ivpn/desktop-app/daemon/vpn"
+	apitypes "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/logger"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/history"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/leaktest"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/preferences"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/serverscore"
+	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/splittun"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/version"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 	"golang.org/x/crypto/pbkdf2"
 )
 
@@ -52,6 +56,9 @@ type Client struct {
 	_secret uint64
 	_conn   net.Conn
 
+	// path to the Unix domain socket of the daemon (preferred over TCP connection, if defined)
+	_socketFile string
+
 	_requestIdx int
 
 	_defaultTimeout  time.Duration
@@ -83,25 +90,50 @@ func CreateClient(port int, secret uint64) *Client {
 		_receivers:      make(map[*receiverChannel]struct{})}
 }
 
+// SetUnixSocketFile sets the path to the Unix domain socket of the daemon.
+// When defined, the client connects to the socket (the TCP connection is in use only if the socket connection failed)
+func (c *Client) SetUnixSocketFile(path string) {
+	c._socketFile = path
+}
+
 // Connect is connecting to daemon
 func (c *Client) Connect() (err error) {
 	if c._conn != nil {
 		return fmt.Errorf("already connected")
 	}
 
+	if len(c._socketFile) > 0 {
+		if _, err := os.Stat(c._socketFile); err == nil {
+			logger.Info("Connecting (Unix socket)...")
+			if err = c.connect("unix", c._socketFile); err == nil {
+				return nil
+			}
+			logger.Info("Unix socket connection failed: ", err)
+			if c._port <= 0 {
+				return err
+			}
+		}
+	}
+
 	logger.Info("Connecting...")
+	return c.connect("tcp", fmt.Sprintf(":%d", c._port))
+}
 
-	c._conn, err = net.Dial("tcp", fmt.Sprintf(":%d", c._port))
+func (c *Client) connect(network, address string) (err error) {
+	conn, err := net.Dial(network, address)
 	if err != nil {
 		return fmt.Errorf("failed to connect to IVPN daemon (does IVPN daemon/service running?): %w", err)
 	}
+	c._conn = conn
 
 	logger.Info("Connected")
 
 	// start receiver
-	go c.receiverRoutine()
+	go c.receiverRoutine(conn)
 
 	if _, err := c.SendHello(); err != nil {
+		conn.Close()
+		c._conn = nil
 		return err
 	}
 
@@ -167,24 +199,26 @@ func (c *Client) GetHelloResponse() types.HelloResp {
 	return c._helloResponse
 }
 
-// SessionNew creates new session
-func (c *Client) SessionNew(accountID string, forceLogin bool, the2FA string) (apiStatus int, err error) {
+// VerifyPin creates new session using the login PIN code
+func (c *Client) VerifyPin(code string) (resp types.SessionNewResp, err error) {
 	if err := c.ensureConnected(); err != nil {
-		return 0, err
+		return resp, err
 	}
 
-	req := types.SessionNew{AccountID: accountID, ForceLogin: forceLogin, Confirmation2FA: the2FA}
-	var resp types.SessionNewResp
+	req := types.VerifyPin{Code: code}
 
 	if err := c.sendRecv(&req, &resp); err != nil {
-		return 0, err
+		return resp, err
 	}
 
 	if len(resp.Session.Session) <= 0 {
-		return resp.APIStatus, fmt.Errorf("[%d] %s", resp.APIStatus, resp.APIErrorMessage)
+		if len(resp.APIErrorMessage) > 0 {
+			return resp, fmt.Errorf("[%d] %s", resp.APIStatus, resp.APIErrorMessage)
+		}
+		return resp, fmt.Errorf("login failed (the PIN is invalid or expired)")
 	}
 
-	return resp.APIStatus, nil
+	return resp, nil
 }
 
 // SessionDelete remove session
@@ -394,12 +428,14 @@ func (c *Client) GetSplitTunnelStatus() (cfg types.SplitTunnelStatus, err error)
 //	isAnyDns   bool - (only for Inverse Split Tunnel) When false: Allow only DNS servers specified by the IVPN application
 //	isAllowWhenNoVpn bool - (only for Inverse Split Tunnel) Allow connectivity for Split Tunnel apps when VPN is disabled
 //	reset      bool - reset ST config and disable ST (if enabled - all the rest paremeters are ignored)
-func (c *Client) SetSplitTunnelConfig(isEnable, isInversed, isAnyDns, isAllowWhenNoVpn, reset bool) (err error) {
+//	destinations []splittun.DestinationRule - (Linux) rules for destination addresses (nil - keep the current rules)
+//	apps []string - (Linux) application rules: binary paths or names (nil - keep the current rules)
+func (c *Client) SetSplitTunnelConfig(isEnable, isInversed, isAnyDns, isAllowWhenNoVpn, reset bool, destinations []splittun.DestinationRule, apps []string) (err error) {
 	if err := c.ensureConnected(); err != nil {
 		return err
 	}
 
-	req := types.SplitTunnelSetConfig{IsEnabled: isEnable, IsInversed: isInversed, IsAnyDns: isAnyDns, IsAllowWhenNoVpn: isAllowWhenNoVpn, Reset: reset}
+	req := types.SplitTunnelSetConfig{IsEnabled: isEnable, IsInversed: isInversed, IsAnyDns: isAnyDns, IsAllowWhenNoVpn: isAllowWhenNoVpn, Reset: reset, Destinations: destinations, SplitTunnelApps: apps}
 	resp := types.EmptyResp{}
 	if err := c.sendRecv(&req, &resp); err != nil {
 		return err
@@ -501,9 +537,9 @@ func (c *Client) SplitTunnelRemoveApp(cmdOrPid string) error {
 }
 
 // GetServers gets servers list
-func (c *Client) GetServers() (apitypes.ServersInfoResponse, error) {
+func (c *Client) GetServers() (apitypes.ServerListResponse, error) {
 	if err := c.ensureConnected(); err != nil {
-		return apitypes.ServersInfoResponse{}, err
+		return apitypes.ServerListResponse{}, err
 	}
 
 	req := types.GetServers{}
@@ -517,9 +553,9 @@ func (c *Client) GetServers() (apitypes.ServersInfoResponse, error) {
 }
 
 // GetServersForceUpdate gets servers list (skip cache; load data from backend)
-func (c *Client) GetServersForceUpdate() (apitypes.ServersInfoResponse, error) {
+func (c *Client) GetServersForceUpdate() (apitypes.ServerListResponse, error) {
 	if err := c.ensureConnected(); err != nil {
-		return apitypes.ServersInfoResponse{}, err
+		return apitypes.ServerListResponse{}, err
 	}
 
 	req := types.GetServers{
@@ -566,6 +602,34 @@ func (c *Client) GetVPNState() (vpn.State, types.ConnectedResp, error) {
 	return vpn.DISCONNECTED, respConnected, fmt.Errorf("failed to receive VPN state (not expected return type)")
 }
 
+// GetTunnelStats requests live statistics of the active VPN tunnel
+func (c *Client) GetTunnelStats() (stats types.TunnelStatsResp, err error) {
+	if err := c.ensureConnected(); err != nil {
+		return stats, err
+	}
+
+	req := types.GetTunnelStats{}
+	if err := c.sendRecv(&req, &stats); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// WaitTunnelStats waits for the next tunnel statistics notification from the daemon.
+// Returns 'isDisconnected=true' when the VPN disconnection notification received instead.
+func (c *Client) WaitTunnelStats(timeout time.Duration) (stats types.TunnelStatsResp, isDisconnected bool, err error) {
+	if err := c.ensureConnected(); err != nil {
+		return stats, false, err
+	}
+
+	var disconnected types.DisconnectedResp
+	cmd, err := c.waitNotification(timeout, &stats, &disconnected)
+	if err != nil {
+		return stats, false, err
+	}
+	return stats, cmd.Command == types.GetTypeName(disconnected), nil
+}
+
 // DisconnectVPN disconnect active VPN connection
 func (c *Client) DisconnectVPN() error {
 	if err := c.ensureConnected(); err != nil {
@@ -664,6 +728,35 @@ func (c *Client) Pause(durationSec uint32) error {
 	return nil
 }
 
+// GetConnectionHistory requests the connection history (the newest entry is the first one)
+// If 'count' <= 0 - all available entries are requested
+func (c *Client) GetConnectionHistory(count int) ([]history.Entry, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+
+	req := types.GetConnectionHistory{Count: count}
+	var resp types.ConnectionHistoryResp
+	if err := c.sendRecv(&req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Entries, nil
+}
+
+// ClearConnectionHistory erases the connection history
+func (c *Client) ClearConnectionHistory() error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+
+	req := types.ClearConnectionHistory{}
+	var resp types.EmptyResp
+	if err := c.sendRecv(&req, &resp); err != nil {
+		return err
+	}
+	return nil
+}
+
 // PingServers
 func (c *Client) PingServers(vpnTypePrioritized *vpn.Type) (pingResults []types.PingResultType, err error) {
 	if err := c.ensureConnected(); err != nil {
@@ -692,6 +785,21 @@ func (c *Client) PingServers(vpnTypePrioritized *vpn.Type) (pingResults []types.
 	return resp.PingResults, nil
 }
 
+// RecommendServers returns the servers ranked by the composite score (the best first)
+func (c *Client) RecommendServers(vpnType vpn.Type, constraints serverscore.Constraints, count int) ([]serverscore.Candidate, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+
+	req := types.RecommendServers{VpnType: vpnType, Constraints: constraints, Count: count}
+	var resp types.RecommendServersResp
+	if err := c.sendRecv(&req, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Candidates, nil
+}
+
 // SetManualDNS - sets manual DNS for current VPN connection
 func (c *Client) SetManualDNS(dnsCfg dns.DnsSettings, antiTracker service_types.AntiTrackerMetadata) error {
 	if err := c.ensureConnected(); err != nil {
@@ -761,6 +869,59 @@ func (c *Client) SetWiFiSettings(params preferences.WiFiParams) error {
 	return nil
 }
 
+func (c *Client) SetNetworkProfiles(params preferences.NetworkProfilesParams) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+
+	req := types.NetworkProfilesSettings{Params: params}
+	var resp types.EmptyResp
+	if _, _, err := c.sendRecvAny(&req, &resp); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) SetSchedulerSettings(params preferences.SchedulerParams) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+
+	req := types.SchedulerSettings{Params: params}
+	var resp types.EmptyResp
+	if _, _, err := c.sendRecvAny(&req, &resp); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetNetworkIdentity returns the identity of the current network and the name of the matching network profile
+func (c *Client) GetNetworkIdentity() (resp types.NetworkIdentityResp, err error) {
+	if err := c.ensureConnected(); err != nil {
+		return resp, err
+	}
+
+	req := types.GetNetworkIdentity{}
+	if err := c.sendRecv(&req, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// LeakTest runs the DNS and IP leak test
+func (c *Client) LeakTest() (leaktest.Result, error) {
+	if err := c.ensureConnected(); err != nil {
+		return leaktest.Result{}, err
+	}
+
+	req := types.LeakTest{}
+	var resp types.LeakTestResp
+	if err := c.sendRecv(&req, &resp); err != nil {
+		return leaktest.Result{}, err
+	}
+	return resp.Result, nil
+}
+
 func (c *Client) SetDefConnectionParams(params types.ConnectSettings) error {
 	if err := c.ensureConnected(); err != nil {
 		return err
@@ -782,3 +943,31 @@ func (c *Client) GetDefConnectionParams() (types.ConnectSettings, error) {
 	_, _, err := c.sendRecvAny(&types.ConnectSettingsGet{}, &resp)
 	return resp, err
 }
+
+// GetAppUpdateInfo returns information about the latest application version (the update info signature is verified by the daemon)
+func (c *Client) GetAppUpdateInfo(updateType types.AppUpdateType) (types.AppUpdateInfoResp, error) {
+	var resp types.AppUpdateInfoResp
+	if err := c.ensureConnected(); err != nil {
+		return resp, err
+	}
+
+	req := types.GetAppUpdateInfo{UpdateType: updateType}
+	if err := c.sendRecv(&req, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// GetGeoLookup returns the latest known geolocation (public IP address info)
+func (c *Client) GetGeoLookup() (types.GeoLookupResp, error) {
+	var resp types.GeoLookupResp
+	if err := c.ensureConnected(); err != nil {
+		return resp, err
+	}
+
+	req := types.GetGeoLookup{}
+	if err := c.sendRecv(&req, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
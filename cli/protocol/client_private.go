@@ -1,6 +1,6 @@
 //
 //  IVPN command line interface (CLI)
-//  https://github.com/ivpn/desktop-app
+//  https://github.com/tahirmahm123/vpn-desktop-app
 //
 //  Created by Stelnykovych Alexandr.
 //  Copyright (c) 2023 IVPN Limited.
@@ -26,14 +26,15 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
 	"time"
 
-	"github.com/ivpn/desktop-app/cli/helpers"
-	"github.com/ivpn/desktop-app/daemon/logger"
-	"github.com/ivpn/desktop-app/daemon/protocol/types"
-	"github.com/ivpn/desktop-app/daemon/service/platform"
+	"github.com/tahirmahm123/vpn-desktop-app/cli/helpers"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/logger"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
 )
 
 func (c *Client) ensureConnected() error {
@@ -177,6 +178,33 @@ func (c *Client) sendRecvAnyEx(request interface{}, isIgnoreResponseIndex bool,
 	return data, cmdBase, err
 }
 
+// waitNotification waits for a notification from the daemon (no request is sent).
+// The received data is deserialized into the object of corresponding type from 'waitingObjects'.
+func (c *Client) waitNotification(timeout time.Duration, waitingObjects ...interface{}) (cmdBase types.CommandBase, err error) {
+	const isIgnoreResponseIndex = true
+	receiver := createReceiver(0, isIgnoreResponseIndex, waitingObjects...)
+
+	// thread-safe receiver registration
+	c._receiversLocker.Lock()
+	c._receivers[receiver] = struct{}{}
+	c._receiversLocker.Unlock()
+
+	// do not forget to remove receiver
+	defer func() {
+		c._receiversLocker.Lock()
+		defer c._receiversLocker.Unlock()
+
+		delete(c._receivers, receiver)
+	}()
+
+	if err := receiver.Wait(timeout); err != nil {
+		return types.CommandBase{}, err
+	}
+
+	_, cmdBase = receiver.GetReceivedRawData()
+	return cmdBase, nil
+}
+
 func (c *Client) send(cmd interface{}, requestIdx int) error {
 	cmdName := types.GetTypeName(cmd)
 
@@ -217,16 +245,16 @@ func (c *Client) initRequestFields(obj interface{}) error {
 	return nil
 }
 
-func (c *Client) receiverRoutine() {
+func (c *Client) receiverRoutine(conn net.Conn) {
 
 	defer func() {
 		logger.Info("Receiver stopped")
-		c._conn.Close()
+		conn.Close()
 	}()
 
 	logger.Info("Receiver started")
 
-	reader := bufio.NewReader(c._conn)
+	reader := bufio.NewReader(conn)
 
 	// run loop forever
 	for {
@@ -211,6 +211,9 @@ function enable_firewall {
 
       # Split Tunnel: Allow packets from/to cgroup (bypass IVPN firewall)
       ${IPv6BIN} -w ${LOCKWAITTIME} -I ${OUT_IVPN} -m cgroup --cgroup ${_splittun_cgroup_classid} -m comment --comment  "${_splittun_comment}" -j ACCEPT || echo "Failed to add OUTPUT (cgroup) rule for split-tunnel"
+      # cgroup v2 has no 'net_cls' classid: the packets from cgroup are identified by 'mark' (it is set by the split-tunnel script)
+      # (the split-tunnel script also marks the packets to 'bypass' destinations)
+      ${IPv6BIN} -w ${LOCKWAITTIME} -I ${OUT_IVPN} -m mark --mark ${_splittun_packets_fwmark_value} -m comment --comment  "${_splittun_comment}" -j ACCEPT || echo "Failed to add OUTPUT (mark) rule for split-tunnel"
       ${IPv6BIN} -w ${LOCKWAITTIME} -I ${IN_IVPN} -m cgroup --cgroup ${_splittun_cgroup_classid} -m comment --comment  "${_splittun_comment}" -j ACCEPT || echo "Failed to add INPUT (cgroup) rule for split-tunnel"  # this rule is not effective, so we use 'mark' (see the next rule)
       ${IPv6BIN} -w ${LOCKWAITTIME} -I ${IN_IVPN} -m mark --mark ${_splittun_packets_fwmark_value} -m comment --comment  "${_splittun_comment}" -j ACCEPT  || echo "Failed to add INPUT (mark) rule for split-tunnel"
 
@@ -289,6 +292,9 @@ function enable_firewall {
 
     # Split Tunnel: Allow packets from/to cgroup (bypass IVPN firewall)
     ${IPv4BIN} -w ${LOCKWAITTIME} -I ${OUT_IVPN} -m cgroup --cgroup ${_splittun_cgroup_classid} -m comment --comment  "${_splittun_comment}" -j ACCEPT || echo "Failed to add OUTPUT (cgroup) rule for split-tunnel"
+    # cgroup v2 has no 'net_cls' classid: the packets from cgroup are identified by 'mark' (it is set by the split-tunnel script)
+    # (the split-tunnel script also marks the packets to 'bypass' destinations)
+    ${IPv4BIN} -w ${LOCKWAITTIME} -I ${OUT_IVPN} -m mark --mark ${_splittun_packets_fwmark_value} -m comment --comment  "${_splittun_comment}" -j ACCEPT || echo "Failed to add OUTPUT (mark) rule for split-tunnel"
     ${IPv4BIN} -w ${LOCKWAITTIME} -I ${IN_IVPN} -m cgroup --cgroup ${_splittun_cgroup_classid} -m comment --comment  "${_splittun_comment}" -j ACCEPT || echo "Failed to add INPUT (cgroup) rule for split-tunnel"  # this rule is not effective, so we use 'mark' (see the next rule)
     ${IPv4BIN} -w ${LOCKWAITTIME} -I ${IN_IVPN} -m mark --mark ${_splittun_packets_fwmark_value} -m comment --comment  "${_splittun_comment}" -j ACCEPT || echo "Failed to add INPUT (mark) rule for split-tunnel"
 
@@ -25,8 +25,20 @@
 
 # Split Tunneling cgroup parameters
 _cgroup_name=ivpn-exclude
-_cgroup_classid=0x4956504e      # Anything from 0x00000001 to 0xFFFFFFFF
-_cgroup_folder=/sys/fs/cgroup/net_cls/${_cgroup_name}
+_cgroup_classid=0x4956504e      # Anything from 0x00000001 to 0xFFFFFFFF (applicable for cgroup v1 only)
+# cgroup hierarchy: 
+#   1 - legacy 'net_cls' controller (packets are matched by classid)
+#   2 - unified hierarchy (there is no 'net_cls' controller; packets are matched by the cgroup path)
+# Use option '-cgroup2' to switch to the unified hierarchy
+_cgroup_version=1
+# Variables will be initialized by initCgroupVars()
+_cgroup_root=""
+_cgroup_folder=""
+_cgroup_match=""                # iptables 'cgroup' match parameters
+# The original cgroups of the processes moved into the Split Tunneling cgroup (one file per PID: '<start time> <cgroup path>').
+# The processes are moved back to their original cgroups on removal (e.g. to keep them in their systemd user slice/scope).
+# Note: the folder is located in 'tmpfs' (PIDs are not valid after reboot)
+_pids_origin_folder=/run/ivpn-exclude-pids
 
 # Routing tabel configuration for packets coming from Split-Tunneling environment
 _routing_table_name=ivpn-exclude-tbl
@@ -39,6 +51,10 @@ PREROUTING_mangle="IVPN_ST_PREROUTING -t mangle"
 POSTROUTING_nat="IVPN_ST_POSTROUTING -t nat"
 OUTPUT="IVPN_ST_OUTPUT"
 INPUT="IVPN_ST_INPUT"
+# iptables chains for destination rules (see 'destinations' command)
+OUTPUT_mangle_dst="IVPN_ST_DST_OUTPUT -t mangle"
+POSTROUTING_nat_dst="IVPN_ST_DST_POSTROUTING -t nat"
+OUTPUT_dst="IVPN_ST_DST_OUTPUT"
 
 # Additional parameters
 _iptables_locktime=2
@@ -85,6 +101,20 @@ _is_inversed=0
 _is_inversed_blocked=0
 _is_inversed_blocked_ipv6=0
 
+function initCgroupVars()
+{
+    if [ ${_cgroup_version} -eq 2 ]; then
+        _cgroup_root=/sys/fs/cgroup
+        # The path is relative to the root of the cgroup2 hierarchy.
+        # Note: the cgroup must exist before adding iptables rule (the path is resolved when the rule is added)
+        _cgroup_match="--path ${_cgroup_name}"
+    else
+        _cgroup_root=/sys/fs/cgroup/net_cls
+        _cgroup_match="--cgroup ${_cgroup_classid}"
+    fi
+    _cgroup_folder=${_cgroup_root}/${_cgroup_name}
+}
+
 vercomp () {
     if [[ $1 == $2 ]]
     then
@@ -117,6 +147,32 @@ vercomp () {
 }
 
 function test()
+{
+    if [ ${_cgroup_version} -eq 2 ]; then
+        test_cgroup2 || return $?
+    else
+        test_cgroup1 || return $?
+    fi
+
+    test_binaries
+}
+
+function test_cgroup2()
+{
+    # The root of cgroup2 hierarchy must be mounted to ${_cgroup_root}
+    # (/proc/mounts format: <devtype> <mount path> <fstype> <options>)
+    if ! ${_bin_grep} -E "^\S+\s+${_cgroup_root}\s+cgroup2\s" /proc/mounts &>/dev/null ; then
+        echo "ERROR: cgroup2 hierarchy is not mounted to '${_cgroup_root}'" 1>&2
+        return 1
+    fi
+    if [ ! -w ${_cgroup_root}/cgroup.procs ]; then
+        echo "ERROR: No write access to '${_cgroup_root}/cgroup.procs'" 1>&2
+        return 1
+    fi
+    return 0
+}
+
+function test_cgroup1()
 {
     # TODO: the real mount path have to be taken from /proc/mounts
     # It has format: <devtype> <mount path> <fstype> <options>
@@ -137,7 +193,11 @@ function test()
             return 2; 
         fi
     fi
+    return 0
+}
 
+function test_binaries()
+{
     if ! command -v ${_bin_iptables} &>/dev/null ;   then echo "ERROR: Binary Not Found (${_bin_iptables})" 1>&2; return 1; fi
     if ! command -v ${_bin_ip} &>/dev/null ;         then echo "ERROR: Binary Not Found (${_bin_ip})" 1>&2; return 1; fi    
     if ! command -v ${_bin_grep} &>/dev/null ;       then echo "ERROR: Binary Not Found (${_bin_grep})" 1>&2; return 1; fi
@@ -205,30 +265,36 @@ function init_iptables()
     ${bin_iptables} -w ${LOCKWAITTIME} -N ${POSTROUTING_nat}
     ${bin_iptables} -w ${LOCKWAITTIME} -N ${OUTPUT}
     ${bin_iptables} -w ${LOCKWAITTIME} -N ${INPUT}
+    ${bin_iptables} -w ${LOCKWAITTIME} -N ${OUTPUT_mangle_dst}
+    ${bin_iptables} -w ${LOCKWAITTIME} -N ${POSTROUTING_nat_dst}
+    ${bin_iptables} -w ${LOCKWAITTIME} -N ${OUTPUT_dst}
 
     # Save packets mark (to be able to restore mark for incoming packets of the same connection)
     ${bin_iptables} -w ${_iptables_locktime} -I ${POSTROUTING_mangle} -j CONNMARK --save-mark    
     # Change the source IP address of packets to the IP address of the interface they're going out on
     # Do this only if default interface is defined (for example: IPv6 interface may be empty when IPv6 not configured on the system)
     if [ ! -z ${def_inf_name} ]; then
-        ${bin_iptables} -w ${_iptables_locktime} -I ${POSTROUTING_nat} -m cgroup ${inverseOption} --cgroup ${_cgroup_classid} -o ${def_inf_name} -j MASQUERADE
+        ${bin_iptables} -w ${_iptables_locktime} -I ${POSTROUTING_nat} -m cgroup ${inverseOption} ${_cgroup_match} -o ${def_inf_name} -j MASQUERADE
     fi
-    # Add mark on packets of classid ${_cgroup_classid}
-    ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT_mangle} -m cgroup ${inverseOption} --cgroup ${_cgroup_classid} -j MARK --set-mark ${_packets_fwmark_value}
+    # Add mark on packets coming from cgroup
+    ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT_mangle} -m cgroup ${inverseOption} ${_cgroup_match} -j MARK --set-mark ${_packets_fwmark_value}
     # Important! Process DNS request before setting mark rule (DNS request should not be marked)
-    ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT_mangle} -m cgroup ${inverseOption} --cgroup ${_cgroup_classid} -p tcp --dport 53 -j RETURN
-    ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT_mangle} -m cgroup ${inverseOption} --cgroup ${_cgroup_classid} -p udp --dport 53 -j RETURN
+    ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT_mangle} -m cgroup ${inverseOption} ${_cgroup_match} -p tcp --dport 53 -j RETURN
+    ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT_mangle} -m cgroup ${inverseOption} ${_cgroup_match} -p udp --dport 53 -j RETURN
+    # Destination rules have priority over the rules for cgroup (the chain is filled by 'destinations' command)
+    ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT_mangle} -j ${OUTPUT_mangle_dst}
+    ${bin_iptables} -w ${_iptables_locktime} -I ${POSTROUTING_nat} -j ${POSTROUTING_nat_dst}
 
     # Allow packets from/to cgroup (bypass IVPN firewall)
     if [ ! -z ${def_inf_name} ]; then
-        ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT} -m cgroup ${inverseOption} --cgroup ${_cgroup_classid} -j ACCEPT
-        ${bin_iptables} -w ${_iptables_locktime} -I ${INPUT}  -m cgroup ${inverseOption} --cgroup ${_cgroup_classid} -j ACCEPT   # this rule is not effective, so we use 'mark' (see the next rule)
+        ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT} -m cgroup ${inverseOption} ${_cgroup_match} -j ACCEPT
+        ${bin_iptables} -w ${_iptables_locktime} -I ${INPUT}  -m cgroup ${inverseOption} ${_cgroup_match} -j ACCEPT   # this rule is not effective, so we use 'mark' (see the next rule)
         ${bin_iptables} -w ${_iptables_locktime} -I ${INPUT}  -m mark --mark ${_packets_fwmark_value} -j ACCEPT
     else
         # If local interface not defined - block all packets from/to cgroup
         # (for example: IPv6 interface may be empty when IPv6 not configured on the system)
-        ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT} -m cgroup ${inverseOption} --cgroup ${_cgroup_classid} -j DROP
-        ${bin_iptables} -w ${_iptables_locktime} -I ${INPUT}  -m cgroup ${inverseOption} --cgroup ${_cgroup_classid} -j DROP   # this rule is not effective, so we use 'mark' (see the next rule)
+        ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT} -m cgroup ${inverseOption} ${_cgroup_match} -j DROP
+        ${bin_iptables} -w ${_iptables_locktime} -I ${INPUT}  -m cgroup ${inverseOption} ${_cgroup_match} -j DROP   # this rule is not effective, so we use 'mark' (see the next rule)
         ${bin_iptables} -w ${_iptables_locktime} -I ${INPUT}  -m mark --mark ${_packets_fwmark_value} -j DROP
     fi
     
@@ -241,11 +307,18 @@ function init_iptables()
         # Allow or block communication for 'splitted' apps in inverse mode
         # E.g.: If we want to block 'splitted' apps when VPN not connected -  'inverse_block' must be '1'
         if [ ${inverse_block} -eq 1 ]; then
-            ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT} -m cgroup --cgroup ${_cgroup_classid} -j DROP
-            ${bin_iptables} -w ${_iptables_locktime} -I ${INPUT}  -m cgroup --cgroup ${_cgroup_classid} -j DROP
+            ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT} -m cgroup ${_cgroup_match} -j DROP
+            ${bin_iptables} -w ${_iptables_locktime} -I ${INPUT}  -m cgroup ${_cgroup_match} -j DROP
         fi 
     fi 
 
+    # Allow packets to 'bypass' destinations (bypass IVPN firewall; the chain is filled by 'destinations' command)
+    # Destination rules have priority over the rules for cgroup
+    # (the incoming packets are allowed by 'mark', see rules for ${INPUT} above)
+    if [ ! -z ${def_inf_name} ]; then
+        ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT} -j ${OUTPUT_dst}
+    fi
+
     # Just ensure that packets from/to localhost will not be blocked            
     ${bin_iptables} -w ${_iptables_locktime} -I ${OUTPUT} -o lo -j ACCEPT
     ${bin_iptables} -w ${_iptables_locktime} -I ${INPUT}  -i lo -j ACCEPT
@@ -282,6 +355,9 @@ function clear_iptables()
     ${bin_iptables} -w ${_iptables_locktime} -F ${POSTROUTING_nat}
     ${bin_iptables} -w ${_iptables_locktime} -F ${OUTPUT}
     ${bin_iptables} -w ${_iptables_locktime} -F ${INPUT}
+    ${bin_iptables} -w ${_iptables_locktime} -F ${OUTPUT_mangle_dst}
+    ${bin_iptables} -w ${_iptables_locktime} -F ${POSTROUTING_nat_dst}
+    ${bin_iptables} -w ${_iptables_locktime} -F ${OUTPUT_dst}
 
     # '-X' Delete a user-defined chains
     ${bin_iptables} -w ${_iptables_locktime} -X ${POSTROUTING_mangle}
@@ -290,6 +366,9 @@ function clear_iptables()
     ${bin_iptables} -w ${_iptables_locktime} -X ${POSTROUTING_nat}
     ${bin_iptables} -w ${_iptables_locktime} -X ${OUTPUT}
     ${bin_iptables} -w ${_iptables_locktime} -X ${INPUT}
+    ${bin_iptables} -w ${_iptables_locktime} -X ${OUTPUT_mangle_dst}
+    ${bin_iptables} -w ${_iptables_locktime} -X ${POSTROUTING_nat_dst}
+    ${bin_iptables} -w ${_iptables_locktime} -X ${OUTPUT_dst}
 }
 
 function init()
@@ -333,7 +412,9 @@ function init()
     ##############################################
     if [ ! -d ${_cgroup_folder} ]; then
         mkdir -p ${_cgroup_folder}
-        echo ${_cgroup_classid} > ${_cgroup_folder}/net_cls.classid
+        if [ ${_cgroup_version} -eq 1 ]; then
+            echo ${_cgroup_classid} > ${_cgroup_folder}/net_cls.classid
+        fi
     fi
     
     ##############################################
@@ -514,12 +595,124 @@ function restore()
     rm -fr ${_tempDir}
 }
 
-# Move all processes from the IVPN cgroup to the main cgroup
+# Apply destination rules (the previous rules are erased).
+# Arguments: list of rules in format '<action>:<address>[/<prefix>]'
+#   bypass:<subnet> - the traffic to the subnet bypasses the VPN (it is routed by the Split Tunnel routing table)
+#   vpn:<subnet>    - the traffic to the subnet is forced through the VPN (even for processes in the Split Tunnel cgroup)
+# The rules are applied in the order they are defined (the more specific rules are expected first).
+# Example: destinations vpn:10.1.2.3/32 bypass:10.0.0.0/8 bypass:fd00::/8
+function destinations()
+{
+    # simple check if ST enabled
+    if [ ! -d ${_cgroup_folder} ]; then
+        echo "[!] ERROR: split tunneling DISABLED. Please call 'start' command first" 1>&2
+        return 1
+    fi
+
+    ${_bin_iptables} -w ${_iptables_locktime} -F ${OUTPUT_mangle_dst}
+    ${_bin_iptables} -w ${_iptables_locktime} -F ${POSTROUTING_nat_dst}
+    ${_bin_iptables} -w ${_iptables_locktime} -F ${OUTPUT_dst}
+    if [ -f /proc/net/if_inet6 ]; then
+        ${_bin_ip6tables} -w ${_iptables_locktime} -F ${OUTPUT_mangle_dst}
+        ${_bin_ip6tables} -w ${_iptables_locktime} -F ${POSTROUTING_nat_dst}
+        ${_bin_ip6tables} -w ${_iptables_locktime} -F ${OUTPUT_dst}
+    fi
+
+    local _entry _action _dst _bin
+    for _entry in "$@"; do
+        _action=${_entry%%:*}
+        _dst=${_entry#*:}
+
+        _bin=${_bin_iptables}
+        if [[ ${_dst} == *:* ]]; then
+            if [ ! -f /proc/net/if_inet6 ]; then
+                continue
+            fi
+            _bin=${_bin_ip6tables}
+        fi
+
+        case "${_action}" in
+            bypass)
+                # Marked packets are routed by the Split Tunnel routing table
+                ${_bin} -w ${_iptables_locktime} -A ${OUTPUT_mangle_dst} -d ${_dst} -j MARK --set-mark ${_packets_fwmark_value}
+                ${_bin} -w ${_iptables_locktime} -A ${OUTPUT_mangle_dst} -d ${_dst} -j ACCEPT
+                ${_bin} -w ${_iptables_locktime} -A ${POSTROUTING_nat_dst} -d ${_dst} -m mark --mark ${_packets_fwmark_value} -j MASQUERADE
+                # Allow marked packets (bypass IVPN firewall)
+                ${_bin} -w ${_iptables_locktime} -A ${OUTPUT_dst} -d ${_dst} -m mark --mark ${_packets_fwmark_value} -j ACCEPT
+                ;;
+            vpn)
+                # Skip the rest of rules: the packets stay unmarked (routed through the VPN)
+                ${_bin} -w ${_iptables_locktime} -A ${OUTPUT_mangle_dst} -d ${_dst} -j ACCEPT
+                ;;
+            *)
+                echo "[!] ERROR: unknown destination rule '${_entry}'" 1>&2
+                return 1
+                ;;
+        esac
+    done
+}
+
+# Print the path of the cgroup of the process (relative to ${_cgroup_root})
+function getPidCgroup()
+{
+    local _pid="$1"
+    if [ ${_cgroup_version} -eq 2 ]; then
+        # line format: '0::<path>'
+        ${_bin_sed} -n 's/^0:://p' /proc/${_pid}/cgroup 2>/dev/null
+    else
+        # line format: '<hierarchy ID>:<controllers>:<path>' (e.g. '7:net_cls,net_prio:/')
+        ${_bin_awk} -F: '$2 ~ /(^|,)net_cls(,|$)/ { print $3 }' /proc/${_pid}/cgroup 2>/dev/null
+    fi
+}
+
+# Print the start time of the process (to detect that the PID was reused by another process)
+function getPidStartTime()
+{
+    # field 22 of '/proc/<PID>/stat'; the fields are counted after the command name (it can contain spaces)
+    ${_bin_sed} -n 's/^.*) //p' /proc/$1/stat 2>/dev/null | ${_bin_awk} '{ print $20 }'
+}
+
+# Save the original cgroup of the process (before moving it into the Split Tunneling cgroup)
+function savePidCgroup()
+{
+    local _pid="$1"
+    local _cgroup=$(getPidCgroup ${_pid})
+    if [ -z "${_cgroup}" ] || [ "${_cgroup_root}${_cgroup}" == "${_cgroup_folder}" ]; then
+        return 0 # unknown or already in the Split Tunneling cgroup
+    fi
+    mkdir -p ${_pids_origin_folder}
+    echo "$(getPidStartTime ${_pid}) ${_cgroup}" > ${_pids_origin_folder}/${_pid}
+}
+
+# Move the process back to its original cgroup (saved by 'savePidCgroup').
+# The root cgroup is in use when the original cgroup is unknown or it does not exist anymore
+function restorePidCgroup()
+{
+    local _pid="$1"
+    local _target=${_cgroup_root}
+    local _file=${_pids_origin_folder}/${_pid}
+    if [ -f ${_file} ]; then
+        local _starttime _cgroup
+        read -r _starttime _cgroup < ${_file}
+        if [ "${_starttime}" == "$(getPidStartTime ${_pid})" ] && [ -n "${_cgroup}" ] && [ -f "${_cgroup_root}${_cgroup}/cgroup.procs" ]; then
+            _target=${_cgroup_root}${_cgroup}
+        fi
+        rm -f ${_file}
+    fi
+
+    if [ "${_target}" != "${_cgroup_root}" ]; then
+        echo ${_pid} 2>/dev/null >> ${_target}/cgroup.procs && return 0
+        echo "[!] Unable to move PID ${_pid} back to '${_target}'; moving it to the root cgroup" 1>&2
+    fi
+    echo ${_pid} >> ${_cgroup_root}/cgroup.procs
+}
+
+# Move all processes from the IVPN cgroup to their original cgroups
 function removeAllPids() 
 {    
     while IFS= read -r line
     do
-        echo $line >> /sys/fs/cgroup/net_cls/cgroup.procs
+        restorePidCgroup $line
     done < "${_cgroup_folder}/cgroup.procs"
 }
 
@@ -531,7 +724,7 @@ function removepid()
         exit 1
     fi   
     echo "[+] Removing PID ${_pid} from Split Tunneling group..."
-    echo ${_pid} >> /sys/fs/cgroup/net_cls/cgroup.procs
+    restorePidCgroup ${_pid}
 }
 
 function addpid()
@@ -542,6 +735,7 @@ function addpid()
         exit 1
     fi   
     echo "[+] Adding PID ${_pid} to Split Tunneling group..."
+    savePidCgroup ${_pid}
     echo ${_pid} >> ${_cgroup_folder}/cgroup.procs
 }
 
@@ -618,8 +812,10 @@ function info()
         echo "[*] cgroup folder NOT exists: '${_cgroup_folder}'"
     else
         echo "[*] cgroup folder exists: '${_cgroup_folder}'"
-        echo "[*] File '${_cgroup_folder}/net_cls.classid':"
-        cat ${_cgroup_folder}/net_cls.classid
+        if [ ${_cgroup_version} -eq 1 ]; then
+            echo "[*] File '${_cgroup_folder}/net_cls.classid':"
+            cat ${_cgroup_folder}/net_cls.classid
+        fi
     fi
     
     echo ---------------------------------
@@ -716,6 +912,13 @@ function parseInputArgs()
     done
 }
 
+# Global options (must be defined before the command)
+if [[ $1 = "-cgroup2" ]] ; then
+    _cgroup_version=2
+    shift
+fi
+initCgroupVars
+
 if [[ $1 = "start" ]] ; then    
     shift    
     parseInputArgs "$@"    
@@ -752,6 +955,10 @@ elif [[ $1 = "run" ]] ; then
     _command=$@
     execute "${_user}" "${_command}"     
 
+elif [[ $1 = "destinations" ]] ; then
+    shift
+    destinations "$@"
+
 elif [[ $1 = "update-routes" ]] ; then
     # Linux is erasing ST routing rules when disable/enable default network interface, so we need to restore them back
     shift 
@@ -787,7 +994,10 @@ else
     echo ""
     echo "Usage:"
     echo "Note! The script have to be started under privilaged user (sudo $0 ...)"
-    echo "    $0 <command> [parameters]"
+    echo "    $0 [-cgroup2] <command> [parameters]"
+    echo "Options:"
+    echo "    -cgroup2"
+    echo "        Use cgroup v2 (unified hierarchy) instead of 'net_cls' controller of cgroup v1"
     echo "Parameters:"
     echo "    start [-interface <inf_name>] [-gateway <gateway>] [-interface6 <inf_name_IPv6>] [-gateway6 <gateway_IPv6>] [[-inverse] [-inverse_block]]"
     echo "        Initialize split-tunneling functionality"
@@ -815,6 +1025,10 @@ else
     echo "    removepid <PID>"
     echo "        Remove process from Split Tunneling environment"
     echo "        - PID             - process ID"
+    echo "    destinations [<action>:<subnet> ...]"
+    echo "        Apply destination rules (the previous rules are erased)"
+    echo "        - action          - 'bypass' (the traffic bypasses the VPN) or 'vpn' (the traffic is forced through the VPN)"
+    echo "        - subnet          - IP address or subnet (e.g. 10.0.0.0/8)"
     echo "    update-routes"
     echo "        Update the routing table for packets within the split tunnel."
     echo "        Linux erases split-tunnel routing rules when the default network interface is disabled/enabled. This command restores those rules."
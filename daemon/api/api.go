@@ -27,6 +27,8 @@ import (
 	"fmt"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/config"
 	"net"
+	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -41,6 +43,7 @@ const (
 	_defaultDialTimeout        = time.Second * 5  // time for t
 	_apiPathPrefix             = "v3"
 	_updateHost                = "repo.ivpn.net"
+	_apiIvpnHost               = "api.ivpn.net"
 	_sessionNewPath            = _apiPathPrefix + "/auth"
 	_serversPath               = "v2/servers-list?group=country,protocol"
 	_sessionStatusPath         = _apiPathPrefix + "/details"
@@ -109,6 +112,26 @@ func init() {
 type IConnectivityInfo interface {
 	// IsConnectivityBlocked - returns nil if connectivity NOT blocked
 	IsConnectivityBlocked() (err error)
+	// AllowAPIServerAccess - temporary allows communication with the API server IP address (if it is blocked by the firewall
+	// and the access to API servers is allowed by the configuration).
+	// Returns function which must be called to remove the exception (nil - if the exception was not added)
+	AllowAPIServerAccess(ip net.IP) (remove func())
+}
+
+// ErrorConnectivityBlocked - API request can not be performed: the connectivity is blocked (e.g. by the firewall)
+type ErrorConnectivityBlocked struct {
+	Reason error
+}
+
+func (e ErrorConnectivityBlocked) Error() string {
+	if e.Reason == nil {
+		return "connectivity is blocked"
+	}
+	return e.Reason.Error()
+}
+
+func (e ErrorConnectivityBlocked) Unwrap() error {
+	return e.Reason
 }
 
 type geolookup struct {
@@ -130,8 +153,9 @@ type API struct {
 	lastGoodAlternateIPv6 net.IP
 	connectivityChecker   IConnectivityInfo
 
-	// last geolookups result
-	geolookup geolookup
+	// last geolookups result (each IP protocol has separate request)
+	geolookupV4 geolookup
+	geolookupV6 geolookup
 }
 
 // CreateAPI creates new API object
@@ -146,14 +170,30 @@ func (a *API) SetConnectivityChecker(connectivityChecker IConnectivityInfo) {
 	a.connectivityChecker = connectivityChecker
 }
 
-// DownloadServersList - download servers list form API IVPN server
-func (a *API) DownloadServersList() (*types.ServerListResponse, error) {
-	//servers := new(types.ServerListResponse)
-	servers, _, _, err := a.ServersList()
+// DownloadServersList - download servers list form API IVPN server.
+// The detached signature of the list is downloaded and verified as well (if enabled: see IsServersListSignatureEnabled()).
+// Returns the parsed servers list together with its raw data and signature (to be able to store them on disk and verify again later)
+func (a *API) DownloadServersList() (servers *types.ServerListResponse, rawData []byte, signature []byte, err error) {
+	servers, _, rawResponse, err := a.ServersList()
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
+	}
+	rawData = []byte(rawResponse)
+
+	if !IsServersListSignatureEnabled() {
+		return servers, rawData, nil, nil
 	}
-	return servers, nil
+
+	signature, err = a.ServersListSignature()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := VerifyServersListSignature(rawData, signature); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return servers, rawData, signature, nil
 }
 
 // DoRequestByAlias do API request (by API endpoint alias). Returns raw data of response
@@ -163,31 +203,37 @@ func (a *API) DoRequestByAlias(apiAlias string, ipTypeRequired protocolTypes.Req
 		if ipTypeRequired != protocolTypes.IPv4 && ipTypeRequired != protocolTypes.IPv6 {
 			return nil, fmt.Errorf("geolookup request failed: IP version not defined")
 		}
-		_, responseData, err = a.GeoLookup()
+		_, responseData, err = a.GeoLookup(ipTypeRequired)
 		return responseData, err
 	}
 
-	//// get connection info by API alias
-	//alias, ok := APIAliases[apiAlias]
-	//if !ok {
-	//	return nil, fmt.Errorf("unexpected request alias")
-	//}
-	//
-	//if !alias.isArcIndependent {
-	//	// If isArcIndependent!=true, the path will be updated: the "_<architecture>" will be added to filename
-	//	// Example:
-	//	//		The "updateInfo_macOS" on arm64 platform will use file "/macos/update_arm64.json" (NOT A "/macos/update.json"!)
-	//	if runtime.GOARCH != "amd64" {
-	//		extIdx := strings.Index(alias.path, ".")
-	//		if extIdx > 0 {
-	//			newPath := alias.path[:extIdx] + "_" + runtime.GOARCH + alias.path[extIdx:]
-	//			alias.path = newPath
-	//		}
-	//	}
-	//}
-	//
-	//return a.requestRaw(ipTypeRequired, alias.host, alias.path, "", "", nil, 0, 0)
-	return nil, err
+	// get connection info by API alias
+	alias, ok := APIAliases[apiAlias]
+	if !ok {
+		return nil, fmt.Errorf("unexpected request alias")
+	}
+
+	if !alias.isArcIndependent {
+		// If isArcIndependent!=true, the path will be updated: the "_<architecture>" will be added to filename
+		// Example:
+		//		The "updateInfo_macOS" on arm64 platform will use file "/macos/update_arm64.json" (NOT A "/macos/update.json"!)
+		if runtime.GOARCH != "amd64" {
+			extIdx := strings.Index(alias.path, ".")
+			if extIdx > 0 {
+				newPath := alias.path[:extIdx] + "_" + runtime.GOARCH + alias.path[extIdx:]
+				alias.path = newPath
+			}
+		}
+	}
+
+	data, statusCode, err := a.request(ipTypeRequired, alias.host, alias.path, "GET", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != 200 {
+		return nil, fmt.Errorf("API request '%s' failed (status code %d)", apiAlias, statusCode)
+	}
+	return data, nil
 }
 
 func (a *API) VerifyPin(code string) (
@@ -220,13 +266,15 @@ func (a *API) VerifyPin(code string) (
 }
 
 // WireGuardKeySet - update WG key
-func (a *API) WireGuardKeySet(session string, publicKey string) (
+// kemKeys - public keys for KEM (the server responds with ciphers which in use to calculate the WG PresharedKey)
+func (a *API) WireGuardKeySet(session string, publicKey string, kemKeys types.KemPublicKeys) (
 	successResp *types.WGKeysUpdateResponse,
 	rawResponse string, // RAW response
 	err error) {
 
 	data, statusCode, err := a.requestRaw(_wgKeySetPath, "POST", types.WGKeyUpdateRequest{
-		PublicKey: publicKey,
+		PublicKey:     publicKey,
+		KemPublicKeys: kemKeys,
 	}, map[string]string{
 		"Content-Type":  "application/json",
 		"Authorization": "Bearer " + session,
@@ -249,14 +297,22 @@ func (a *API) WireGuardKeySet(session string, publicKey string) (
 }
 
 // GeoLookup gets geolocation
-func (a *API) GeoLookup() (location *types.GeoLookupResponse, rawData []byte, retErr error) {
+// ipTypeRequired - IP protocol to use for the request (IPvAny - the same as IPv4)
+func (a *API) GeoLookup(ipTypeRequired protocolTypes.RequiredIPProtocol) (location *types.GeoLookupResponse, rawData []byte, retErr error) {
 	// There could be multiple Geolookup requests at the same time.
 	// It doesn't make sense to make multiple requests to the API.
 	// The internal function below reduces the number of similar API calls.
 
+	if ipTypeRequired != protocolTypes.IPv6 {
+		ipTypeRequired = protocolTypes.IPv4
+	}
+
 	singletonFunc := func() (*types.GeoLookupResponse, []byte, error) {
 		// Each IP protocol has separate request
-		var gl = &a.geolookup
+		var gl = &a.geolookupV4
+		if ipTypeRequired == protocolTypes.IPv6 {
+			gl = &a.geolookupV6
+		}
 		// Try to make API request (if not started yet). Only one API request allowed in the same time.
 		func() {
 			gl.mutex.Lock()
@@ -275,7 +331,11 @@ func (a *API) GeoLookup() (location *types.GeoLookupResponse, rawData []byte, re
 					gl.isRunning = false
 					close(gl.done)
 				}()
-				gl.response, _, gl.err = a.requestRaw(_geoLookupPath, "GET", nil, nil)
+				gl.location = types.GeoLookupResponse{}
+				gl.response, _, gl.err = a.request(ipTypeRequired, config.GetAPIHost(), _geoLookupPath, "GET", nil, nil)
+				if gl.err != nil {
+					return
+				}
 				if err := json.Unmarshal(gl.response, &gl.location); err != nil {
 					gl.err = fmt.Errorf("failed to deserialize API response: %w", err)
 				}
@@ -283,7 +343,8 @@ func (a *API) GeoLookup() (location *types.GeoLookupResponse, rawData []byte, re
 		}()
 		// wait for API call result (for routine stop)
 		<-gl.done
-		return &gl.location, gl.response, gl.err
+		location := gl.location
+		return &location, gl.response, gl.err
 	}
 
 	// request Geolocation info
@@ -321,3 +382,20 @@ func (a *API) ServersList() (
 	}
 	return nil, statusCode, rawResponse, fmt.Errorf("request Failed with Status coode %d and Response: %s", statusCode, rawResponse)
 }
+
+// ServersListSignature - download the detached signature of the servers list
+func (a *API) ServersListSignature() (signature []byte, err error) {
+	if len(ServersListSignaturePath) == 0 {
+		return nil, fmt.Errorf("servers list signature endpoint not defined")
+	}
+	data, statusCode, err := a.requestRaw(ServersListSignaturePath, "GET", nil, map[string]string{
+		"Authorization": config.GetAuthCredentials(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != 200 {
+		return nil, fmt.Errorf("failed to download servers list signature (status code %d)", statusCode)
+	}
+	return data, nil
+}
@@ -1,5 +1,10 @@
 package api
 
+// APIHostHashes - base64-encoded SHA256 hashes of the public keys of the API server of this build (config.GetAPIHost()),
+// in use for certificate key pinning. Include the backup key(s) to allow the certificate rotation.
+// Note: the hashes are not defined yet: the connections to the API host are not pinned (a warning is logged).
+var APIHostHashes = []string{}
+
 // APIIvpnHashes - base64-encoded SHA256 hashes for 'api.ivpn.net' server public keys (in use for certificate key pinning)
 var APIIvpnHashes = []string{
 	"g6WEFnt9DyTi70nW/fufsZNw83vFpcmIhMuDPQ1MFcI=",
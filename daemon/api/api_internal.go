@@ -2,16 +2,28 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/tahirmahm123/vpn-desktop-app/daemon/config"
 	"io"
+	"net"
 	"net/http"
 	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/config"
+	protocolTypes "github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
 )
 
-func getURL(urlPath string) string {
-	return "https://" + path.Join(config.GetAPIHost(), urlPath)
+func getURL(host string, urlPath string) string {
+	return "https://" + path.Join(host, urlPath)
 }
 
 func newRequest(urlPath string, method string, headers map[string]string, body io.Reader) (*http.Request, error) {
@@ -30,48 +42,308 @@ func newRequest(urlPath string, method string, headers map[string]string, body i
 	return req, nil
 }
 
-func (a *API) doRequest(urlPath string, requestType string, request interface{}, headers map[string]string) (resp *http.Response, err error) {
+// SetAlternateIPs save info about alternate IP addresses of the API server.
+// The alternate IPs are in use when the API host is not accessible by the DNS name (e.g. DNS is blocked)
+func (a *API) SetAlternateIPs(IPv4List []string, IPv6List []string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.alternateIPsV4, a.lastGoodAlternateIPv4 = parseAlternateIPs(IPv4List, a.lastGoodAlternateIPv4)
+	a.alternateIPsV6, a.lastGoodAlternateIPv6 = parseAlternateIPs(IPv6List, a.lastGoodAlternateIPv6)
+	return nil
+}
+
+// IsAlternateIPsInitialized - checks if the alternate IP addresses are initialized
+func (a *API) IsAlternateIPsInitialized(IPv6 bool) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if IPv6 {
+		return len(a.alternateIPsV6) > 0
+	}
+	return len(a.alternateIPsV4) > 0
+}
+
+func parseAlternateIPs(ips []string, lastGood net.IP) (ret []net.IP, retLastGood net.IP) {
+	ret = make([]net.IP, 0, len(ips))
+	for _, ipStr := range ips {
+		ip := net.ParseIP(strings.TrimSpace(ipStr))
+		if ip == nil {
+			continue
+		}
+		ret = append(ret, ip)
+		// keep the last good IP only if it is still in the list
+		if lastGood != nil && ip.Equal(lastGood) {
+			retLastGood = ip
+		}
+	}
+	return ret, retLastGood
+}
+
+// getAlternateIPs returns the list of alternate IPs of required IP protocol (IPvAny - IPv4 and IPv6).
+// The last good IP is the first element in the list of each protocol (if defined)
+func (a *API) getAlternateIPs(ipTypeRequired protocolTypes.RequiredIPProtocol) []net.IP {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	ret := make([]net.IP, 0, len(a.alternateIPsV4)+len(a.alternateIPsV6))
+	add := func(ips []net.IP, lastGood net.IP) {
+		if lastGood != nil {
+			ret = append(ret, lastGood)
+		}
+		for _, ip := range ips {
+			if !ip.Equal(lastGood) {
+				ret = append(ret, ip)
+			}
+		}
+	}
+
+	if ipTypeRequired != protocolTypes.IPv6 {
+		add(a.alternateIPsV4, a.lastGoodAlternateIPv4)
+	}
+	if ipTypeRequired != protocolTypes.IPv4 {
+		add(a.alternateIPsV6, a.lastGoodAlternateIPv6)
+	}
+	return ret
+}
+
+func (a *API) isLastGoodAlternateIPExists(ipTypeRequired protocolTypes.RequiredIPProtocol) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	switch ipTypeRequired {
+	case protocolTypes.IPv4:
+		return a.lastGoodAlternateIPv4 != nil
+	case protocolTypes.IPv6:
+		return a.lastGoodAlternateIPv6 != nil
+	}
+	return a.lastGoodAlternateIPv4 != nil || a.lastGoodAlternateIPv6 != nil
+}
+
+func (a *API) saveLastGoodAlternateIP(ip net.IP) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if ip.To4() != nil {
+		a.lastGoodAlternateIPv4 = ip
+	} else {
+		a.lastGoodAlternateIPv6 = ip
+	}
+}
+
+// hosts which were already reported as not pinned (to avoid logging the warning on each request)
+var notPinnedHostsReported sync.Map
+
+// findPinForHost returns the list of public key hashes (certificate key pinning) for the host.
+// A warning is logged when there are no hashes for the host (only the standard verification of the certificate chain is in use).
+func findPinForHost(host string) []string {
+	var pins []string
+	switch host {
+	case config.GetAPIHost():
+		pins = APIHostHashes
+	case _apiIvpnHost:
+		pins = APIIvpnHashes
+	case _updateHost:
+		pins = UpdateIvpnHashes
+	}
+
+	if len(pins) == 0 {
+		if _, reported := notPinnedHostsReported.LoadOrStore(host, struct{}{}); !reported {
+			log.Warning(fmt.Sprintf("No certificate key pins defined for '%s': the server public key is not pinned", host))
+		}
+	}
+	return pins
+}
+
+// makeVerifyPeerCertificateFunc returns a function which checks that any certificate in the chain
+// has the public key with the hash from the 'pins' list.
+// Note: it is an additional check; the standard verification of the certificate chain is performed anyway.
+func makeVerifyPeerCertificateFunc(pins []string) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(pins) == 0 {
+		return nil
+	}
+
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		for _, rawCert := range rawCerts {
+			cert, err := x509.ParseCertificate(rawCert)
+			if err != nil {
+				continue
+			}
+			hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			certHash := base64.StdEncoding.EncodeToString(hash[:])
+			for _, pin := range pins {
+				if pin == certHash {
+					return nil
+				}
+			}
+		}
+		return errors.New("certificate key pinning failed: the server public key is not trusted")
+	}
+}
+
+// lookupHost resolves the host name into IP addresses of required IP protocol
+func lookupHost(host string, ipTypeRequired protocolTypes.RequiredIPProtocol) ([]net.IP, error) {
+	network := "ip"
+	switch ipTypeRequired {
+	case protocolTypes.IPv4:
+		network = "ip4"
+	case protocolTypes.IPv6:
+		network = "ip6"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), _defaultDialTimeout)
+	defer cancel()
+
+	ips, err := net.DefaultResolver.LookupIP(ctx, network, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s': %w", host, err)
+	}
+	return ips, nil
+}
+
+// newClient creates HTTP client with timeouts and certificate key pinning.
+// The connection is established to the 'ip' (the host name is not resolved by the client),
+// but the host name is still in use for TLS (SNI and certificate verification).
+func newClient(host string, ip net.IP, timeout time.Duration, dialTimeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			ServerName:            host,
+			VerifyPeerCertificate: makeVerifyPeerCertificateFunc(findPinForHost(host)),
+		},
+		TLSHandshakeTimeout: dialTimeout,
+		// the client is created for each request: do not keep idle connections
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			return dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		},
+	}
+
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// doRequest makes HTTP request to the host and returns the response body.
+// ipTypeRequired - IP protocol to use for the request (IPvAny - any available)
+//
+// The request fails immediately (ErrorConnectivityBlocked) if the connectivity is blocked.
+// The host name is resolved and the addresses are in use one by one.
+// For API host: if the request by host name fails - the alternate IP addresses are in use (one by one).
+// The last good alternate IP is remembered (separately for IPv4 and IPv6) and it is the first one to try next time.
+func (a *API) doRequest(ipTypeRequired protocolTypes.RequiredIPProtocol, host string, urlPath string, method string, request interface{}, headers map[string]string) (responseData []byte, statusCode int, err error) {
+	a.mutex.Lock()
+	connectivityChecker := a.connectivityChecker
+	a.mutex.Unlock()
+
+	if connectivityChecker != nil {
+		if err := connectivityChecker.IsConnectivityBlocked(); err != nil {
+			return nil, 0, ErrorConnectivityBlocked{Reason: err}
+		}
+	}
 
-	client := http.Client{}
 	var data []byte
 	if request != nil {
 		data, err = json.Marshal(request)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 	}
-	bodyBuffer := bytes.NewBuffer(data)
-	req, err := newRequest(urlPath, requestType, headers, bodyBuffer)
-	if err != nil {
-		return nil, err
+
+	makeRequest := func(ip net.IP) ([]byte, int, error) {
+		if connectivityChecker != nil {
+			// the exception must exist until the response is fully received
+			if remove := connectivityChecker.AllowAPIServerAccess(ip); remove != nil {
+				defer remove()
+			}
+		}
+
+		req, err := newRequest(getURL(host, urlPath), method, headers, bytes.NewReader(data))
+		if err != nil {
+			return nil, 0, err
+		}
+		resp, err := newClient(host, ip, _defaultRequestTimeout, _defaultDialTimeout).Do(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get API HTTP response body: %w", err)
+		}
+		return body, resp.StatusCode, nil
 	}
 
-	resp, err = client.Do(req)
-	if err != nil {
-		return nil, err
+	type ipsGroup struct {
+		isAlternate bool
+		getIPs      func() []net.IP
+	}
+
+	resolved := ipsGroup{getIPs: func() []net.IP {
+		ips, e := lookupHost(host, ipTypeRequired)
+		if e != nil {
+			log.Warning(e)
+			err = e
+		}
+		return ips
+	}}
+	alternate := ipsGroup{isAlternate: true, getIPs: func() []net.IP { return a.getAlternateIPs(ipTypeRequired) }}
+
+	// Order of attempts: addresses resolved by the DNS, alternate IPs.
+	// If there is a last good alternate IP - alternate IPs are in use first.
+	groups := []ipsGroup{resolved}
+	if host == config.GetAPIHost() {
+		if a.isLastGoodAlternateIPExists(ipTypeRequired) {
+			groups = []ipsGroup{alternate, resolved}
+		} else {
+			groups = []ipsGroup{resolved, alternate}
+		}
+	}
+
+	for _, g := range groups {
+		for _, ip := range g.getIPs() {
+			if responseData, statusCode, err = makeRequest(ip); err == nil {
+				if g.isAlternate {
+					a.saveLastGoodAlternateIP(ip)
+				}
+				return responseData, statusCode, nil
+			}
+			log.Warning(fmt.Sprintf("API request to '%s' (%s) failed: %s", host, ip, err))
+		}
+	}
+
+	if err == nil {
+		err = fmt.Errorf("no IP addresses available for '%s'", host)
 	}
-	return resp, err
+	return nil, 0, err
 }
 
-func (a *API) requestRaw(urlPath string, method string, requestObject interface{}, headers map[string]string) (responseData []byte, statusCode int, err error) {
+// request makes HTTP request to the host and returns the response body
+func (a *API) request(ipTypeRequired protocolTypes.RequiredIPProtocol, host string, urlPath string, method string, requestObject interface{}, headers map[string]string) (responseData []byte, statusCode int, err error) {
 	if headers == nil {
 		headers = map[string]string{
 			"Content-Type": "application/json",
 		}
 	}
-	resp, err := a.doRequest(getURL(urlPath), method, requestObject, headers)
+
+	responseData, statusCode, err = a.doRequest(ipTypeRequired, host, urlPath, method, requestObject, headers)
 	if err != nil {
+		var errBlocked ErrorConnectivityBlocked
+		if errors.As(err, &errBlocked) {
+			return nil, 0, err
+		}
 		return nil, 0, fmt.Errorf("API request failed: %w", err)
 	}
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(resp.Body)
-	if err != nil {
-		return nil, 0, err
-	}
-	newStr := buf.String()
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get API HTTP response body: %w", err)
-	}
-	log.Info(fmt.Sprintf("API HTTP Path: %s Status Code: %d Body: %s", urlPath, resp.StatusCode, newStr))
-	return buf.Bytes(), resp.StatusCode, nil
+
+	log.Info(fmt.Sprintf("API HTTP Path: %s Status Code: %d Body: %s", urlPath, statusCode, string(responseData)))
+	return responseData, statusCode, nil
+}
+
+// requestRaw makes HTTP request to the API host (any IP protocol) and returns the response body
+func (a *API) requestRaw(urlPath string, method string, requestObject interface{}, headers map[string]string) (responseData []byte, statusCode int, err error) {
+	return a.request(protocolTypes.IPvAny, config.GetAPIHost(), urlPath, method, requestObject, headers)
 }
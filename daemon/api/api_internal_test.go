@@ -0,0 +1,82 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/config"
+)
+
+func TestFindPinForHost(t *testing.T) {
+	hashes := APIHostHashes
+	defer func() { APIHostHashes = hashes }()
+
+	APIHostHashes = []string{"pin1", "pin2"}
+	if pins := findPinForHost(config.GetAPIHost()); !reflect.DeepEqual(pins, APIHostHashes) {
+		t.Errorf("unexpected pins for the API host: %v", pins)
+	}
+	if pins := findPinForHost(_updateHost); !reflect.DeepEqual(pins, UpdateIvpnHashes) {
+		t.Errorf("unexpected pins for the update host: %v", pins)
+	}
+	if pins := findPinForHost("unknown.example.com"); len(pins) != 0 {
+		t.Errorf("unexpected pins for unknown host: %v", pins)
+	}
+}
+
+func TestVerifyPeerCertificatePins(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "test"}, NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(hash[:])
+
+	if makeVerifyPeerCertificateFunc(nil) != nil {
+		t.Error("no verification function expected without pins")
+	}
+	if err := makeVerifyPeerCertificateFunc([]string{"other", pin})([][]byte{raw}, nil); err != nil {
+		t.Errorf("pinned key rejected: %v", err)
+	}
+	if err := makeVerifyPeerCertificateFunc([]string{"other"})([][]byte{raw}, nil); err == nil {
+		t.Error("not pinned key accepted")
+	}
+}
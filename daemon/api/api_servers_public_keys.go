@@ -0,0 +1,31 @@
+package api
+
+import "errors"
+
+// ServersListPublicKeys - base64-encoded Ed25519 public keys in use to verify the detached signature of the servers list.
+// The list is accepted when the signature is valid for any of the keys (keys rotation: the new key is added before the backend starts using it)
+//
+// The keys and the signature endpoint (ServersListSignaturePath) must be the ones published by the backend operator.
+// They are not defined for this build: see ServersListSignatureConfigError().
+var ServersListPublicKeys = []string{}
+
+// ServersListSignaturePath - API path of the detached signature of the servers list (base64-encoded Ed25519 signature of the raw list)
+var ServersListSignaturePath = ""
+
+// ServersListSignatureConfigError returns error when the servers list signature verification is not configured for this build.
+// In this case the signature is not requested, the downloaded list is protected only by TLS
+// and the servers cache file is not in use (its integrity can not be verified).
+func ServersListSignatureConfigError() error {
+	if len(ServersListPublicKeys) == 0 {
+		return errors.New("servers list signature verification is not configured: no public keys defined")
+	}
+	if len(ServersListSignaturePath) == 0 {
+		return errors.New("servers list signature verification is not configured: no signature endpoint defined")
+	}
+	return nil
+}
+
+// IsServersListSignatureEnabled returns 'true' when the signature of the servers list must be verified
+func IsServersListSignatureEnabled() bool {
+	return ServersListSignatureConfigError() == nil
+}
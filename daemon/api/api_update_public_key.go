@@ -0,0 +1,18 @@
+package api
+
+// UpdateInfoPublicKey - RSA public key (PEM) in use to verify the signature of the application update info (update.json).
+// It is the same key as in use by UI to verify the update binaries ('ui/extraResources/public.pem')
+const UpdateInfoPublicKey = `-----BEGIN PUBLIC KEY-----
+MIICIjANBgkqhkiG9w0BAQEFAAOCAg8AMIICCgKCAgEA1m7vr8rY10V1ZDIxsP6g
+Bhq+QYRGNt+33NA0+/MUpxioi2t6sfua0ql6Pxs+Q5x10C/Sx8vNlcagOHwXOS6W
+YNnLsqEOHCxgd0M5thEdT5KXjJEbpzjjrTmk2HuD2cnqmI5b9wCYx5GzREMguCAU
+or+PCUEV/TWittG1DYAW3evPUy3VIMer+Oq6L0jLFSDpfGlXBBKmqZwX3nRuzSaI
+iS0qfs39FipVEyuX/ZKNHXx7mFG73RqhU1V6m3dFEdwrMGEqq9rHc/XUXZKMgiwO
+Wvr7qfCXFoYYcYdseQg1g/8MP6ur0WctMfK5PC36MJlSq/gy/W/gRiIrQMCYMHnB
+0yRrGXvm1n8483y0YVorz2WcGt4cal4bCEnOuYam+SOjD+XM81FIXJnlUFpehXbA
+ZNxgu/5woENBPavCkgK0z+d+CdPdF6WAO6mzytAakLyDffOBblVpGouyYr78LhF3
+DfEQSV06n6dAYFyIyxR/jET24MrWwM3KCXTQAyPV1v2eKaMJoh8JMf+4dEVde5om
+LopbFeMGb9xFxQmedNqtBb/DYBcgEh/Fa3s9r+V/8Fq6ULzjeyejC4VMnc8KCST9
+mX57qSlQ3sj9GG7wlW5TvGUnpJ6vuTj50S6ZXfYe7VuvBM9gxtOhJVPwA5Uy/RzX
+C6HXQqBJNLEOqq2b/+q9fHECAwEAAQ==
+-----END PUBLIC KEY-----`
@@ -0,0 +1,65 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package api
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// VerifyServersListSignature checks the detached signature of the servers list data.
+// 'signature' is base64-encoded Ed25519 signature of the raw servers list data
+// (the same format as it is received from the backend and stored in the '.sig' file).
+// Returns nil only if the signature is valid for one of ServersListPublicKeys.
+func VerifyServersListSignature(data []byte, signature []byte) error {
+	if len(data) == 0 {
+		return errors.New("servers list is empty")
+	}
+	if len(ServersListPublicKeys) == 0 {
+		return errors.New("no public keys defined to verify servers list signature")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("failed to decode servers list signature: %w", err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("bad servers list signature size (%d)", len(sig))
+	}
+
+	for _, k := range ServersListPublicKeys {
+		key, err := base64.StdEncoding.DecodeString(k)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			log.Error(fmt.Sprintf("bad servers list public key '%s'", k))
+			continue
+		}
+		if ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+			return nil
+		}
+	}
+
+	return errors.New("servers list signature verification failed")
+}
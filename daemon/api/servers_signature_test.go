@@ -0,0 +1,93 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package api_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/api"
+)
+
+func TestVerifyServersListSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := api.ServersListPublicKeys
+	defer func() { api.ServersListPublicKeys = keys }()
+	api.ServersListPublicKeys = append([]string{base64.StdEncoding.EncodeToString(pub)}, keys...)
+
+	data := []byte(`{"wireguard":[2049]}`)
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data)) + "\n")
+
+	if err := api.VerifyServersListSignature(data, sig); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	if err := api.VerifyServersListSignature([]byte(`{"wireguard":[2050]}`), sig); err == nil {
+		t.Error("signature accepted for modified data")
+	}
+	if err := api.VerifyServersListSignature(data, []byte("bad signature")); err == nil {
+		t.Error("malformed signature accepted")
+	}
+
+	api.ServersListPublicKeys = keys
+	if err := api.VerifyServersListSignature(data, sig); err == nil {
+		t.Error("signature accepted for unknown key")
+	}
+}
+
+func TestServersListSignatureNotConfigured(t *testing.T) {
+	keys, path := api.ServersListPublicKeys, api.ServersListSignaturePath
+	defer func() { api.ServersListPublicKeys, api.ServersListSignaturePath = keys, path }()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"wireguard":[2049]}`)
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data)))
+
+	// no keys: nothing is accepted
+	api.ServersListPublicKeys, api.ServersListSignaturePath = nil, "servers-list/signature"
+	if api.ServersListSignatureConfigError() == nil || api.IsServersListSignatureEnabled() {
+		t.Error("expected configuration error when no keys defined")
+	}
+	if err := api.VerifyServersListSignature(data, sig); err == nil {
+		t.Error("signature accepted without public keys")
+	}
+
+	// no signature endpoint
+	api.ServersListPublicKeys, api.ServersListSignaturePath = []string{base64.StdEncoding.EncodeToString(pub)}, ""
+	if api.ServersListSignatureConfigError() == nil || api.IsServersListSignatureEnabled() {
+		t.Error("expected configuration error when no signature endpoint defined")
+	}
+
+	api.ServersListSignaturePath = "servers-list/signature"
+	if err := api.ServersListSignatureConfigError(); err != nil {
+		t.Errorf("unexpected configuration error: %v", err)
+	}
+}
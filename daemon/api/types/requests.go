@@ -56,5 +56,6 @@ type ServerListRequest struct {
 // WGKeyUpdateRequest request for rotation of WG keys
 type WGKeyUpdateRequest struct {
 	PublicKey  string `json:"publicKey"`
-	PrivateKey string `json:"privateKey"`
+	PrivateKey string `json:"privateKey,omitempty"`
+	KemPublicKeys
 }
@@ -43,17 +43,17 @@ type KemCiphers struct {
 
 // GeoLookupResponse geolocation info
 type GeoLookupResponse struct {
-	//ip_address   string
-	//isp          string
-	//organization string
-	//country      string
-	//country_code string
-	//city         string
+	IP           string `json:"ip_address"`
+	Isp          string `json:"isp"`
+	Organization string `json:"organization"`
+	Country      string `json:"country"`
+	CountryCode  string `json:"country_code"`
+	City         string `json:"city"`
 
 	SLatitude  string `json:"latitude"`
 	SLongitude string `json:"longitude"`
 
-	//isIvpnServer bool
+	IsIvpnServer bool `json:"isIvpnServer"` // true - when the IP belongs to the VPN server (the traffic goes through the VPN)
 }
 
 func (s *GeoLookupResponse) Latitude() float32 {
@@ -74,6 +74,7 @@ func (s *GeoLookupResponse) Longitude() float32 {
 type WGKeysUpdateResponse struct {
 	Message string `json:"message"`
 	LocalIP string `json:"localIP"`
+	KemCiphers
 }
 type PinValidationResponse struct {
 	Status     string `json:"status"`
@@ -88,3 +89,34 @@ const (
 	InvalidPin = "Invalid"
 	ExpiredPin = "Expired"
 )
+
+// UpdateReleaseNote - release note of the application update
+type UpdateReleaseNote struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// UpdateVersionInfo - information about the latest version of the application (or its component)
+type UpdateVersionInfo struct {
+	Version      string              `json:"version"`
+	ReleaseNotes []UpdateReleaseNote `json:"releaseNotes,omitempty"`
+	DownloadLink string              `json:"downloadLink,omitempty"`
+	Signature    string              `json:"signature,omitempty"` // link to the signature of the binary
+}
+
+// UpdateInfo - application update info (update.json)
+// Windows and macOS: the 'Generic' field is in use;
+// Linux: 'Daemon' and 'UIClient' fields are in use
+type UpdateInfo struct {
+	Generic  *UpdateVersionInfo `json:"generic,omitempty"`
+	Daemon   *UpdateVersionInfo `json:"daemon,omitempty"`
+	UIClient *UpdateVersionInfo `json:"uiClient,omitempty"`
+}
+
+// DaemonVersion returns the latest version of the daemon
+func (u UpdateInfo) DaemonVersion() *UpdateVersionInfo {
+	if u.Daemon != nil {
+		return u.Daemon
+	}
+	return u.Generic
+}
@@ -60,7 +60,14 @@ type ServerListItem struct {
 	CountryCode string              `json:"country_code"`
 	OpenVPN     []OpenVPNInstance   `json:"openvpn"`
 	WireGuard   []WireGuardInstance `json:"wg"`
-	Location    struct {
+	// MultihopPort is the port on an entry server which forwards the traffic to this server
+	// (in use when this server is an exit server of a Multi-Hop connection)
+	MultihopPort int `json:"multihop_port,omitempty"`
+	// IPv6 address of the host (empty if the host does not support IPv6)
+	IPv6 string `json:"ipv6,omitempty"`
+	// Load - current server load in percent (0 - not provided by the backend)
+	Load     float32 `json:"load,omitempty"`
+	Location struct {
 		Latitude  string `json:"latitude"`
 		Longitude string `json:"longitude"`
 	} `json:"location"`
@@ -90,9 +97,19 @@ type ServerListProtoItem struct {
 	OpenVPNServers   []ServerListCountryItem `json:"openvpn"`
 	WireGuardServers []ServerListCountryItem `json:"wireguard"`
 }
+
+// APIInfo - alternate IP addresses of the API server (in use when the API host can not be reached by the DNS name)
+type APIInfo struct {
+	IPAddresses   []string `json:"ips"`
+	IPv6Addresses []string `json:"ipv6s"`
+}
+type ConfigInfo struct {
+	API APIInfo `json:"api"`
+}
 type ServerListResponse struct {
 	ServerList ServerListProtoItem `json:"servers,omitempty"`
 	DnsServers DNSServers          `json:"dnsServers"`
 	OpenVPN    OpenVPNProtocol     `json:"openvpn"`
 	WireGuard  []int               `json:"wireguard"`
+	Config     ConfigInfo          `json:"config,omitempty"`
 }
@@ -0,0 +1,132 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package api
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	protocolTypes "github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+)
+
+// AppUpdateInfo downloads the application update info (update.json) for current platform and verifies its signature.
+// Returns the parsed update info and the raw (verified) data.
+func (a *API) AppUpdateInfo(updateType protocolTypes.AppUpdateType) (info *types.UpdateInfo, rawData []byte, err error) {
+	infoAlias, signAlias, err := appUpdateAliases(updateType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawData, err = a.DoRequestByAlias(infoAlias, protocolTypes.IPvAny)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get update info: %w", err)
+	}
+	signature, err := a.DoRequestByAlias(signAlias, protocolTypes.IPvAny)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get update info signature: %w", err)
+	}
+
+	if err := VerifyUpdateInfoSignature(rawData, signature); err != nil {
+		return nil, nil, err
+	}
+
+	info = &types.UpdateInfo{}
+	if err := json.Unmarshal(rawData, info); err != nil {
+		return nil, nil, fmt.Errorf("failed to deserialize update info: %w", err)
+	}
+	return info, rawData, nil
+}
+
+// VerifyUpdateInfoSignature checks the signature of the application update info.
+// 'signature' is base64-encoded RSA (PKCS #1 v1.5) signature of SHA256 hash of the data
+// (openssl dgst -sha256 -sign private.pem ... | openssl base64)
+func VerifyUpdateInfoSignature(data []byte, signature []byte) error {
+	block, _ := pem.Decode([]byte(UpdateInfoPublicKey))
+	if block == nil {
+		return errors.New("failed to decode update info public key")
+	}
+	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse update info public key: %w", err)
+	}
+	rsaKey, ok := pubKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("update info public key is not an RSA key")
+	}
+
+	// openssl splits base64 data by lines
+	sigBase64 := strings.Join(strings.Fields(string(signature)), "")
+	sig, err := base64.StdEncoding.DecodeString(sigBase64)
+	if err != nil {
+		return fmt.Errorf("failed to decode update info signature: %w", err)
+	}
+
+	hash := sha256.Sum256(data)
+	if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, hash[:], sig); err != nil {
+		return fmt.Errorf("update info signature verification failed: %w", err)
+	}
+	return nil
+}
+
+// appUpdateAliases returns API aliases of the update info and its signature for current platform
+func appUpdateAliases(updateType protocolTypes.AppUpdateType) (infoAlias, signAlias string, err error) {
+	platform := ""
+	switch runtime.GOOS {
+	case "linux":
+		platform = "Linux"
+	case "darwin":
+		platform = "macOS"
+	case "windows":
+		platform = "Windows"
+	default:
+		return "", "", fmt.Errorf("update info is not available for platform '%s'", runtime.GOOS)
+	}
+
+	suffix := platform
+	switch updateType {
+	case protocolTypes.AppUpdateGeneric:
+	case protocolTypes.AppUpdateManual, protocolTypes.AppUpdateBeta:
+		suffix = string(updateType) + "_" + platform
+	default:
+		return "", "", fmt.Errorf("unknown update type '%s'", updateType)
+	}
+
+	infoAlias, signAlias = "updateInfo_"+suffix, "updateSign_"+suffix
+	if _, ok := APIAliases[infoAlias]; !ok {
+		return "", "", fmt.Errorf("update info alias '%s' not defined", infoAlias)
+	}
+	if _, ok := APIAliases[signAlias]; !ok {
+		return "", "", fmt.Errorf("update info signature alias '%s' not defined", signAlias)
+	}
+	return infoAlias, signAlias, nil
+}
@@ -0,0 +1,68 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package helpers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// IsNewerVersion returns 'true' when 'latest' version is newer than 'current'.
+// Versions are compared by numeric components (e.g. "v3.10.2" < "3.11.0");
+// pre-release suffixes (e.g. "-beta") are ignored.
+func IsNewerVersion(current, latest string) bool {
+	cur, lst := parseVersion(current), parseVersion(latest)
+	if len(lst) == 0 {
+		return false
+	}
+	for i := 0; i < len(cur) || i < len(lst); i++ {
+		var c, l int
+		if i < len(cur) {
+			c = cur[i]
+		}
+		if i < len(lst) {
+			l = lst[i]
+		}
+		if c != l {
+			return l > c
+		}
+	}
+	return false
+}
+
+func parseVersion(ver string) []int {
+	ver = strings.TrimPrefix(strings.TrimSpace(ver), "v")
+	if idx := strings.IndexAny(ver, "-+ "); idx >= 0 {
+		ver = ver[:idx]
+	}
+
+	ret := make([]int, 0, 4)
+	for _, p := range strings.Split(ver, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		ret = append(ret, n)
+	}
+	return ret
+}
@@ -49,6 +49,14 @@ import (
 var log *logger.Logger
 var activeProtocol IProtocol
 
+// Access configuration of the Unix domain socket interface (applicable for Linux).
+// Can be changed by command line arguments: '--socket_group=<name>', '--socket_readonly_group=<name>'
+var unixSocketConfig = protocol.UnixSocketConfig{ControlGroup: protocol.DefaultUnixSocketControlGroup}
+
+// Configuration of the JSON-RPC gateway (HTTP interface on the localhost; disabled by default).
+// Can be enabled by command line argument: '--jsonrpc_port=<port>'
+var jsonRPCConfig protocol.JSONRPCConfig
+
 // systemLog - if channel initialized, service will write there messages for system log.
 //
 //	Channel have to be initialized in platform-specific implementation of 'main' package (e.g. doPrepareToRun()).
@@ -78,6 +86,20 @@ func Launch() {
 
 	// Checking command line arguments
 	for _, arg := range os.Args {
+		if v, ok := argValue(arg, "socket_group"); ok {
+			unixSocketConfig.ControlGroup = v
+		}
+		if v, ok := argValue(arg, "socket_readonly_group"); ok {
+			unixSocketConfig.ReadOnlyGroup = v
+		}
+		if v, ok := argValue(arg, "jsonrpc_port"); ok {
+			if port, err := strconv.Atoi(v); err != nil || port < 0 || port > 65535 {
+				logger.Error(fmt.Sprintf("Bad value of the 'jsonrpc_port' argument: '%s'", v))
+			} else {
+				jsonRPCConfig.Port = port
+			}
+		}
+
 		arg = strings.ToLower(arg)
 		if arg == "-logging" || arg == "--logging" {
 			isLoggingEnabledArgument = true
@@ -272,6 +294,8 @@ func launchService(secret uint64, startedOnPort chan<- int) {
 
 	// network change detector
 	netDetector := netchange.Create()
+	// network change detector for network profiles (works independently of the VPN connection)
+	netProfilesDetector := netchange.Create()
 
 	// WireGuard keys manager
 	wgKeysMgr := wgkeys.CreateKeysManager(apiObj, platform.WgToolBinaryPath())
@@ -284,9 +308,11 @@ func launchService(secret uint64, startedOnPort chan<- int) {
 
 	// save protocol (to be able to stop it)
 	activeProtocol = protocol
+	protocol.SetUnixSocketConfig(unixSocketConfig)
+	protocol.SetJSONRPCConfig(jsonRPCConfig)
 
 	// initialize service
-	serv, err := service.CreateService(protocol, apiObj, updater, netDetector, wgKeysMgr, serviceEventsChan, systemLog)
+	serv, err := service.CreateService(protocol, apiObj, updater, netDetector, netProfilesDetector, wgKeysMgr, serviceEventsChan, systemLog)
 	if err != nil {
 		log.Panic("Failed to initialize service:", err)
 	}
@@ -308,3 +334,12 @@ func launchService(secret uint64, startedOnPort chan<- int) {
 		log.Error("Protocol stopped with error:", err)
 	}
 }
+
+// argValue returns the value of the command line argument in format '-<name>=<value>' (or '--<name>=<value>')
+func argValue(arg, name string) (value string, ok bool) {
+	arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	if !strings.HasPrefix(strings.ToLower(arg), name+"=") {
+		return "", false
+	}
+	return strings.TrimSpace(arg[len(name)+1:]), true
+}
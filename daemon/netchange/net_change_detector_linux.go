@@ -22,18 +22,99 @@
 
 package netchange
 
-// structure contains properties required for for macOS implementation
+import (
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/netinfo"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/oshelpers/linux/netlink"
+)
+
+// The netlink socket read timeout: the period to check if the detector is stopped
+const readTimeout = time.Second
+
+// structure contains properties required for for Linux implementation
 type osSpecificProperties struct {
+	stopChan chan struct{}
 }
 
 func (d *Detector) isRoutingChanged() (bool, error) {
-	// TODO: not implemented
-	return false, nil
+	if d.interfaceToProtect == nil {
+		log.Error("failed to check route change. Initial interface not defined")
+		return false, nil
+	}
+
+	isDefaultRoute, err := netinfo.IsDefaultRoutingInterface(d.interfaceToProtect.Name)
+	if err != nil {
+		log.Error("Failed to check route change:", err)
+		return false, err
+	}
+
+	return !isDefaultRoute, nil
 }
 
 func (d *Detector) doStart() {
-	// TODO: not implemented
+	d.locker.Lock()
+	if !d.isStarted || d.props.stopChan != nil {
+		// stopped before the routine started (or already running)
+		d.locker.Unlock()
+		return
+	}
+
+	// Listening for changes of routes, routing policy rules (e.g. WireGuard uses policy routing) and interfaces
+	listener, err := netlink.CreateListenerForGroups(netlink.GroupIPv4Route | netlink.GroupIPv6Route |
+		netlink.GroupIPv4Rule | netlink.GroupIPv6Rule | netlink.GroupLink)
+	if err != nil {
+		d.locker.Unlock()
+		log.Error("Failed to start route change detector:", err)
+		return
+	}
+	if err := listener.SetReadTimeout(readTimeout); err != nil {
+		d.locker.Unlock()
+		listener.Close()
+		log.Error("Failed to start route change detector:", err)
+		return
+	}
+
+	stopChan := make(chan struct{})
+	d.props.stopChan = stopChan
+	d.locker.Unlock()
+
+	log.Info("Route change detector started")
+	defer func() {
+		listener.Close()
+		log.Info("Route change detector stopped")
+	}()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		default:
+		}
+
+		msgs, err := listener.ReadMsgs()
+		if err != nil {
+			if netlink.IsTimeout(err) {
+				continue
+			}
+			log.Error("Route change detector (error on socket read):", err)
+			return
+		}
+
+		for i := range msgs {
+			m := msgs[i]
+			if netlink.IsNewRoute(&m) || netlink.IsDelRoute(&m) || netlink.IsRuleChange(&m) || netlink.IsLinkChange(&m) {
+				d.routingChangeDetected()
+				break
+			}
+		}
+	}
 }
 
+// doStop must be called under 'd.locker'
 func (d *Detector) doStop() {
+	if d.props.stopChan != nil {
+		close(d.props.stopChan)
+		d.props.stopChan = nil
+	}
 }
@@ -26,6 +26,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/logger"
 )
@@ -79,6 +80,53 @@ func DefaultGatewayIP() (defGatewayIP net.IP, err error) {
 	return doDefaultGatewayIP()
 }
 
+// DefaultNetworkInfo - information about the network in use by the default route
+type DefaultNetworkInfo struct {
+	GatewayIP     net.IP
+	GatewayMAC    net.HardwareAddr // nil - unknown
+	InterfaceName string
+	DHCPDomain    string // domain name provided by DHCP (empty - unknown)
+}
+
+// GetDefaultNetworkInfo returns the information about the network in use by the default route.
+// Only the default route (gateway and interface) is mandatory; the rest of fields are filled when the information is available.
+func GetDefaultNetworkInfo() (DefaultNetworkInfo, error) {
+	// method should be implemented in platform-specific file
+	return doGetDefaultNetworkInfo()
+}
+
+// GetDNSServers returns the DNS servers configured in the OS (including the original servers replaced by the daemon, when known)
+func GetDNSServers() []net.IP {
+	// method should be implemented in platform-specific file
+	return doGetDNSServers()
+}
+
+// parseResolvConfNameservers returns the 'nameserver' addresses from resolv.conf data
+func parseResolvConfNameservers(data []byte) []net.IP {
+	ret := make([]net.IP, 0, 2)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		// IPv6 address can contain zone ("fe80::1%eth0")
+		addr, _, _ := strings.Cut(fields[1], "%")
+		if ip := net.ParseIP(addr); ip != nil {
+			ret = append(ret, ip)
+		}
+	}
+	return ret
+}
+
+// GetInterfaceStatistics returns the total number of bytes received and sent over the network interface
+func GetInterfaceStatistics(iface *net.Interface) (rxBytes, txBytes uint64, err error) {
+	if iface == nil {
+		return 0, 0, fmt.Errorf("interface not defined")
+	}
+	// method should be implemented in platform-specific file
+	return doGetInterfaceStatistics(iface)
+}
+
 func GetOutboundIP(isIPv6 bool) (net.IP, error) {
 	if isIPv6 {
 		return GetOutboundIPEx(net.ParseIP("2a00:1450:400d:80a::200e"))
@@ -25,9 +25,11 @@ package netinfo
 import (
 	"fmt"
 	"net"
+	"os"
 	"os/exec"
 	"regexp"
 	"strings"
+	"syscall"
 )
 
 // IsDefaultRoutingInterface - Get active routing interface
@@ -135,3 +137,71 @@ func doGetDefaultRoutes(getAllDefRoutes bool) (routes []Route, err error) {
 
 	return routes, nil
 }
+
+// doGetInterfaceStatistics - returns interface byte counters (from the routing socket interface info)
+// Note: the kernel keeps 32-bit counters in 'if_data' structure
+func doGetInterfaceStatistics(iface *net.Interface) (rxBytes, txBytes uint64, err error) {
+	rib, err := syscall.RouteRIB(syscall.NET_RT_IFLIST, iface.Index)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read interface statistics: %w", err)
+	}
+	msgs, err := syscall.ParseRoutingMessage(rib)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse interface statistics: %w", err)
+	}
+	for _, m := range msgs {
+		if ifm, ok := m.(*syscall.InterfaceMessage); ok && int(ifm.Header.Index) == iface.Index {
+			return uint64(ifm.Header.Data.Ibytes), uint64(ifm.Header.Data.Obytes), nil
+		}
+	}
+	return 0, 0, fmt.Errorf("statistics not found for interface '%s'", iface.Name)
+}
+
+// doGetDefaultNetworkInfo - returns info about the network in use by the default route
+func doGetDefaultNetworkInfo() (ret DefaultNetworkInfo, err error) {
+	routes, err := doGetDefaultRoutes(false)
+	if err != nil {
+		return ret, err
+	}
+	ret.GatewayIP = routes[0].GatewayIP
+	ret.InterfaceName = routes[0].InterfaceName
+
+	// Expected output of "arp -n 192.168.1.1" command:
+	//	? (192.168.1.1) at a4:91:b1:0:0:1 on en0 ifscope [ethernet]
+	if out, err := exec.Command("/usr/sbin/arp", "-n", ret.GatewayIP.String()).Output(); err == nil {
+		fields := strings.Fields(string(out))
+		for i, f := range fields {
+			if f == "at" && i+1 < len(fields) {
+				if mac, err := net.ParseMAC(normalizeMAC(fields[i+1])); err == nil {
+					ret.GatewayMAC = mac
+				}
+				break
+			}
+		}
+	}
+
+	if out, err := exec.Command("/usr/sbin/ipconfig", "getoption", ret.InterfaceName, "domain_name").Output(); err == nil {
+		ret.DHCPDomain = strings.TrimSpace(string(out))
+	}
+	return ret, nil
+}
+
+// normalizeMAC - 'arp' prints the octets without leading zeros (e.g. "a4:91:b1:0:0:1")
+func normalizeMAC(mac string) string {
+	octets := strings.Split(mac, ":")
+	for i, o := range octets {
+		if len(o) == 1 {
+			octets[i] = "0" + o
+		}
+	}
+	return strings.Join(octets, ":")
+}
+
+// doGetDNSServers - returns DNS servers from '/etc/resolv.conf' (the file is generated by the OS from the current configuration)
+func doGetDNSServers() []net.IP {
+	data, err := os.ReadFile("/etc/resolv.conf")
+	if err != nil {
+		return nil
+	}
+	return parseResolvConfNameservers(data)
+}
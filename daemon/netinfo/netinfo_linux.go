@@ -25,56 +25,162 @@ package netinfo
 import (
 	"fmt"
 	"net"
-	"regexp"
+	"os"
+	"path/filepath"
 	"strconv"
-
-	"github.com/tahirmahm123/vpn-desktop-app/daemon/shell"
+	"strings"
 )
 
 // doDefaultGatewayIP - returns: default gateway
+// (if more then one default gateways - use one with smaller metric value)
 func doDefaultGatewayIP() (defGatewayIP net.IP, err error) {
-	defGatewayIP = nil
-	// Expected output of "/sbin/ip route" command:
-	// (if more then one default gateways - use  one with smaller metric value)
-	//
-	// default via 192.168.1.1 dev enp0s3 proto dhcp metric 100
-	// 192.168.1.0/24 dev enp0s3 proto kernel scope link src 192.168.1.57 metric 100
-	// 192.168.122.0/24 dev virbr0 proto kernel scope link src 192.168.122.1 linkdown
-
-	metric := -1
-	outRegexp := regexp.MustCompile("default[ a-z]*([0-9.]*).*metric *([0-9]*)")
-
-	outParse := func(text string, isError bool) {
-		if !isError {
-			columns := outRegexp.FindStringSubmatch(text)
-			if len(columns) <= 2 {
-				return
-			}
+	r, err := DefaultRoute(false)
+	if err != nil {
+		log.Error("Failed to obtain local gateway: ", err.Error())
+		return nil, err
+	}
+	if r.Gateway == nil {
+		return nil, fmt.Errorf("Unable to obtain default gateway IP")
+	}
+	return r.Gateway, nil
+}
 
-			gw := net.ParseIP(columns[1])
-			if gw == nil {
-				return
-			}
-			m, err := strconv.Atoi(columns[2])
-			if err != nil {
-				return
-			}
-			if metric == -1 || metric > m {
-				defGatewayIP = gw
-				metric = m
+// doGetInterfaceStatistics - returns interface byte counters (from '/sys/class/net/<iface>/statistics')
+func doGetInterfaceStatistics(iface *net.Interface) (rxBytes, txBytes uint64, err error) {
+	readCounter := func(name string) (uint64, error) {
+		data, err := os.ReadFile(filepath.Join("/sys/class/net", iface.Name, "statistics", name))
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	}
+
+	if rxBytes, err = readCounter("rx_bytes"); err != nil {
+		return 0, 0, fmt.Errorf("failed to read interface statistics: %w", err)
+	}
+	if txBytes, err = readCounter("tx_bytes"); err != nil {
+		return 0, 0, fmt.Errorf("failed to read interface statistics: %w", err)
+	}
+	return rxBytes, txBytes, nil
+}
+
+// doGetDefaultNetworkInfo - returns info about the network in use by the default route
+func doGetDefaultNetworkInfo() (ret DefaultNetworkInfo, err error) {
+	r, err := DefaultRoute(false)
+	if err != nil {
+		return ret, err
+	}
+	iface, err := net.InterfaceByIndex(r.OutIfIndex)
+	if err != nil {
+		return ret, fmt.Errorf("failed to get default route interface: %w", err)
+	}
+
+	ret.GatewayIP = r.Gateway
+	ret.InterfaceName = iface.Name
+
+	if ret.GatewayIP != nil {
+		if data, err := os.ReadFile("/proc/net/arp"); err == nil {
+			ret.GatewayMAC = parseArpTable(data, ret.GatewayIP, iface.Name)
+		}
+	}
+
+	ret.DHCPDomain = dhcpDomain(iface)
+	return ret, nil
+}
+
+// parseArpTable returns the hardware address of the IP from the '/proc/net/arp' data
+//
+//	IP address       HW type     Flags       HW address            Mask     Device
+//	192.168.1.1      0x1         0x2         a4:91:b1:00:00:01     *        enp3s0
+func parseArpTable(data []byte, ip net.IP, ifaceName string) net.HardwareAddr {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !ip.Equal(net.ParseIP(fields[0])) || fields[5] != ifaceName {
+			continue
+		}
+		if mac, err := net.ParseMAC(fields[3]); err == nil && !isZeroMAC(mac) {
+			return mac
+		}
+	}
+	return nil
+}
+
+func isZeroMAC(mac net.HardwareAddr) bool {
+	for _, b := range mac {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// dhcpDomain returns the domain name received by DHCP for the interface.
+// Sources (in order): systemd-networkd lease, NetworkManager device state, resolv.conf ('domain' or the first 'search' entry)
+func dhcpDomain(iface *net.Interface) string {
+	idx := strconv.Itoa(iface.Index)
+	sources := []struct {
+		file string
+		key  string
+	}{
+		{"/run/systemd/netif/leases/" + idx, "DOMAINNAME"},
+		{"/run/NetworkManager/devices/" + idx, "domain_name"},
+	}
+	for _, src := range sources {
+		if data, err := os.ReadFile(src.file); err == nil {
+			if domain := parseKeyValue(data, src.key); len(domain) > 0 {
+				return domain
 			}
 		}
 	}
 
-	retErr := shell.ExecAndProcessOutput(log, outParse, "", "/sbin/ip", "route")
+	// the backup of the original file exists when '/etc/resolv.conf' is modified by the daemon
+	for _, f := range []string{"/etc/resolv.conf.ivpnsave", "/etc/resolv.conf"} {
+		if data, err := os.ReadFile(f); err == nil {
+			return parseResolvConfDomain(data)
+		}
+	}
+	return ""
+}
 
-	if retErr == nil {
-		if defGatewayIP == nil {
-			retErr = fmt.Errorf("Unable to obtain default gateway IP")
+// parseKeyValue returns the value of the first 'key=value' line with the key
+func parseKeyValue(data []byte, key string) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
 		}
-	} else {
-		log.Error("Failed to obtain local gateway: ", retErr.Error())
 	}
+	return ""
+}
 
-	return defGatewayIP, retErr
+// parseResolvConfDomain returns the local domain name from resolv.conf data ('domain' or the first 'search' entry)
+func parseResolvConfDomain(data []byte) string {
+	search := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "domain":
+			return fields[1]
+		case "search":
+			if len(search) == 0 && fields[1] != "." {
+				search = fields[1]
+			}
+		}
+	}
+	return search
+}
+
+// doGetDNSServers - returns DNS servers from resolv.conf files
+// ('/etc/resolv.conf', the backup of the original file and the upstream servers of systemd-resolved)
+func doGetDNSServers() []net.IP {
+	ret := make([]net.IP, 0, 4)
+	for _, f := range []string{"/etc/resolv.conf", "/etc/resolv.conf.ivpnsave", "/run/systemd/resolve/resolv.conf"} {
+		if data, err := os.ReadFile(f); err == nil {
+			ret = append(ret, parseResolvConfNameservers(data)...)
+		}
+	}
+	return ret
 }
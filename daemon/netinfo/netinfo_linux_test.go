@@ -0,0 +1,79 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package netinfo
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseArpTable(t *testing.T) {
+	data := []byte(`IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.1      0x1         0x2         a4:91:b1:00:00:01     *        enp3s0
+192.168.1.1      0x1         0x2         a4:91:b1:00:00:02     *        wlp2s0
+192.168.1.7      0x1         0x0         00:00:00:00:00:00     *        enp3s0
+`)
+
+	if mac := parseArpTable(data, net.ParseIP("192.168.1.1"), "wlp2s0"); mac.String() != "a4:91:b1:00:00:02" {
+		t.Errorf("unexpected MAC: %v", mac)
+	}
+	if mac := parseArpTable(data, net.ParseIP("192.168.1.7"), "enp3s0"); mac != nil {
+		t.Errorf("incomplete entry must be ignored: %v", mac)
+	}
+	if mac := parseArpTable(data, net.ParseIP("10.0.0.1"), "enp3s0"); mac != nil {
+		t.Errorf("unexpected MAC for unknown IP: %v", mac)
+	}
+}
+
+func TestParseDomain(t *testing.T) {
+	lease := []byte("# This is private data. Do not parse.\nADDRESS=192.168.1.10\nDOMAINNAME=corp.example.com\n")
+	if d := parseKeyValue(lease, "DOMAINNAME"); d != "corp.example.com" {
+		t.Errorf("unexpected lease domain: '%s'", d)
+	}
+	if d := parseKeyValue(lease, "HOSTNAME"); d != "" {
+		t.Errorf("unexpected value for missing key: '%s'", d)
+	}
+
+	tests := []struct {
+		conf string
+		want string
+	}{
+		{"nameserver 127.0.0.53\nsearch home.lan office.lan\n", "home.lan"},
+		{"search home.lan\ndomain corp.example.com\n", "corp.example.com"},
+		{"nameserver 127.0.0.53\nsearch .\n", ""},
+		{"nameserver 1.1.1.1\n", ""},
+	}
+	for _, tt := range tests {
+		if d := parseResolvConfDomain([]byte(tt.conf)); d != tt.want {
+			t.Errorf("resolv.conf %q: got '%s'; expected '%s'", tt.conf, d, tt.want)
+		}
+	}
+}
+
+func TestParseResolvConfNameservers(t *testing.T) {
+	data := []byte("# generated\nnameserver 127.0.0.53\nnameserver fe80::1%enp3s0\nnameserver bad\noptions edns0\n")
+	ips := parseResolvConfNameservers(data)
+	if len(ips) != 2 || !ips[0].Equal(net.ParseIP("127.0.0.53")) || !ips[1].Equal(net.ParseIP("fe80::1")) {
+		t.Errorf("unexpected nameservers: %v", ips)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"net"
 
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/oshelpers/windows/iphlpapi"
 	"golang.org/x/sys/windows"
 	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
 )
@@ -100,3 +101,68 @@ func DefaultGatewayEx(isIpv6 bool) (defGatewayIP net.IP, inf *net.Interface, err
 	}
 	return bestNextHop, &bestIf, nil
 }
+
+// doGetInterfaceStatistics - returns interface byte counters
+func doGetInterfaceStatistics(iface *net.Interface) (rxBytes, txBytes uint64, err error) {
+	luid, err := winipcfg.LUIDFromIndex(uint32(iface.Index))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get interface LUID: %w", err)
+	}
+	row, err := luid.Interface()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read interface statistics: %w", err)
+	}
+	return row.InOctets, row.OutOctets, nil
+}
+
+// doGetDefaultNetworkInfo - returns info about the network in use by the default route
+func doGetDefaultNetworkInfo() (ret DefaultNetworkInfo, err error) {
+	gw, iface, err := DefaultGatewayEx(false)
+	if err != nil {
+		return ret, err
+	}
+	ret.GatewayIP = gw
+	ret.InterfaceName = iface.Name
+
+	if gw != nil && !gw.IsUnspecified() {
+		if mac, err := iphlpapi.APISendARP(gw); err == nil {
+			ret.GatewayMAC = mac
+		} else {
+			log.Warning("Failed to obtain gateway hardware address: ", err)
+		}
+	}
+
+	adapters, err := winipcfg.GetAdaptersAddresses(windows.AF_UNSPEC, winipcfg.GAAFlagDefault)
+	if err != nil {
+		log.Warning("Failed to obtain network adapters info: ", err)
+		return ret, nil
+	}
+	for _, a := range adapters {
+		if int(a.IfIndex) == iface.Index {
+			ret.DHCPDomain = a.DNSSuffix()
+			break
+		}
+	}
+	return ret, nil
+}
+
+// doGetDNSServers - returns DNS servers configured for the network adapters
+func doGetDNSServers() []net.IP {
+	adapters, err := winipcfg.GetAdaptersAddresses(windows.AF_UNSPEC, winipcfg.GAAFlagDefault)
+	if err != nil {
+		log.Warning("Failed to obtain network adapters info: ", err)
+		return nil
+	}
+	ret := make([]net.IP, 0, 4)
+	for _, a := range adapters {
+		if a.OperStatus != winipcfg.IfOperStatusUp {
+			continue
+		}
+		for dns := a.FirstDNSServerAddress; dns != nil; dns = dns.Next {
+			if ip := dns.Address.IP(); ip != nil {
+				ret = append(ret, ip)
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,305 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package netinfo
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	"golang.org/x/sys/unix"
+)
+
+// Routing helper for Linux: the routing tables are accessed directly over rtnetlink
+// (no dependency on 'ip' binary and its output format)
+
+// Routing tables
+const (
+	RouteTableMain uint32 = unix.RT_TABLE_MAIN
+	RouteTableAll  uint32 = 0 // (for ListRoutes) routes of all tables
+)
+
+// Any public IP address: in use only to ask the kernel which route is in use for the internet traffic (no packets are sent)
+var routeProbeIPv4 = net.IPv4(1, 1, 1, 1)
+
+// Route - routing table entry
+type Route struct {
+	Table      uint32
+	Dst        net.IPNet // 0.0.0.0/0 (or ::/0) for default route
+	Gateway    net.IP    // nil when the destination is directly reachable
+	OutIfIndex int
+	Metric     uint32
+}
+
+// IsDefault returns 'true' for the default route
+func (r Route) IsDefault() bool {
+	ones, _ := r.Dst.Mask.Size()
+	return ones == 0
+}
+
+func (r Route) String() string {
+	gw := ""
+	if r.Gateway != nil {
+		gw = " via " + r.Gateway.String()
+	}
+	return fmt.Sprintf("%s%s dev_idx %d metric %d table %d", r.Dst.String(), gw, r.OutIfIndex, r.Metric, r.Table)
+}
+
+// ListRoutes returns the routes of the routing table (RouteTableAll - all tables).
+// isIPv6 - IPv6 routes are requested (otherwise - IPv4)
+func ListRoutes(table uint32, isIPv6 bool) ([]Route, error) {
+	family := byte(unix.AF_INET)
+	if isIPv6 {
+		family = unix.AF_INET6
+	}
+
+	msgs, err := rtnlExecute(unix.RTM_GETROUTE, netlink.Dump, newRtMsg(family, 0), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes: %w", err)
+	}
+
+	ret := make([]Route, 0, len(msgs))
+	for _, m := range msgs {
+		if m.Header.Type != unix.RTM_NEWROUTE {
+			continue
+		}
+		r, rtype, err := parseRoute(m.Data)
+		if err != nil {
+			return nil, err
+		}
+		if rtype != unix.RTN_UNICAST {
+			continue
+		}
+		if table != RouteTableAll && r.Table != table {
+			continue
+		}
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+// DefaultRoute returns the default route with the lowest metric from the main routing table
+func DefaultRoute(isIPv6 bool) (Route, error) {
+	routes, err := ListRoutes(RouteTableMain, isIPv6)
+	if err != nil {
+		return Route{}, err
+	}
+
+	defRoutes := make([]Route, 0, 2)
+	for _, r := range routes {
+		if r.IsDefault() {
+			defRoutes = append(defRoutes, r)
+		}
+	}
+	if len(defRoutes) == 0 {
+		return Route{}, fmt.Errorf("default route not found")
+	}
+
+	sort.SliceStable(defRoutes, func(i, j int) bool { return defRoutes[i].Metric < defRoutes[j].Metric })
+	return defRoutes[0], nil
+}
+
+// AddHostRoute adds (or replaces) the route to the host over the gateway (main routing table)
+func AddHostRoute(host net.IP, gateway net.IP) error {
+	family, ip, bits := ipFamily(host)
+	if ip == nil {
+		return fmt.Errorf("bad host address '%s'", host)
+	}
+	_, gw, _ := ipFamily(gateway)
+	if gw == nil || len(gw) != len(ip) {
+		return fmt.Errorf("bad gateway address '%s' for host '%s'", gateway, host)
+	}
+
+	hdr := newRtMsg(family, bits)
+	hdr[4] = byte(unix.RT_TABLE_MAIN)
+	hdr[5] = unix.RTPROT_BOOT
+	hdr[6] = unix.RT_SCOPE_UNIVERSE
+	hdr[7] = unix.RTN_UNICAST
+
+	if _, err := rtnlExecute(unix.RTM_NEWROUTE, netlink.Acknowledge|netlink.Create|netlink.Replace, hdr, func(ae *netlink.AttributeEncoder) {
+		ae.Bytes(unix.RTA_DST, ip)
+		ae.Bytes(unix.RTA_GATEWAY, gw)
+	}); err != nil {
+		return fmt.Errorf("failed to add route to %s via %s: %w", host, gateway, err)
+	}
+	return nil
+}
+
+// DeleteHostRoute removes the route to the host (main routing table)
+func DeleteHostRoute(host net.IP) error {
+	family, ip, bits := ipFamily(host)
+	if ip == nil {
+		return fmt.Errorf("bad host address '%s'", host)
+	}
+
+	hdr := newRtMsg(family, bits)
+	hdr[4] = byte(unix.RT_TABLE_MAIN)
+	hdr[6] = unix.RT_SCOPE_NOWHERE
+
+	if _, err := rtnlExecute(unix.RTM_DELROUTE, netlink.Acknowledge, hdr, func(ae *netlink.AttributeEncoder) {
+		ae.Bytes(unix.RTA_DST, ip)
+	}); err != nil {
+		return fmt.Errorf("failed to delete route to %s: %w", host, err)
+	}
+	return nil
+}
+
+// IsDefaultRoutingInterface - checks if the interface is in use to route the internet traffic (IPv4).
+// The policy routing rules are taken into account.
+func IsDefaultRoutingInterface(interfaceName string) (bool, error) {
+	iface, err := net.InterfaceByName(interfaceName)
+	if err != nil {
+		return false, fmt.Errorf("failed to get interface '%s': %w", interfaceName, err)
+	}
+
+	r, err := routeGet(routeProbeIPv4)
+	if err != nil {
+		return false, err
+	}
+	return r.OutIfIndex == iface.Index, nil
+}
+
+// DeleteInterface removes the network interface (the same as 'ip link delete <name>')
+func DeleteInterface(name string) error {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return fmt.Errorf("failed to get interface '%s': %w", name, err)
+	}
+
+	// struct ifinfomsg
+	hdr := make([]byte, unix.SizeofIfInfomsg)
+	hdr[0] = unix.AF_UNSPEC
+	nlenc.PutInt32(hdr[4:8], int32(iface.Index))
+
+	if _, err := rtnlExecute(unix.RTM_DELLINK, netlink.Acknowledge, hdr, nil); err != nil {
+		return fmt.Errorf("failed to delete interface '%s': %w", name, err)
+	}
+	return nil
+}
+
+//---------------------------------------------------------------------
+
+// routeGet asks the kernel which route is in use to reach the destination (the same as 'ip route get <dst>').
+// The policy routing rules are taken into account.
+func routeGet(dst net.IP) (Route, error) {
+	family, ip, bits := ipFamily(dst)
+	if ip == nil {
+		return Route{}, fmt.Errorf("bad destination address '%s'", dst)
+	}
+
+	msgs, err := rtnlExecute(unix.RTM_GETROUTE, 0, newRtMsg(family, bits), func(ae *netlink.AttributeEncoder) {
+		ae.Bytes(unix.RTA_DST, ip)
+	})
+	if err != nil {
+		return Route{}, fmt.Errorf("failed to get route to %s: %w", dst, err)
+	}
+
+	for _, m := range msgs {
+		if m.Header.Type != unix.RTM_NEWROUTE {
+			continue
+		}
+		r, _, err := parseRoute(m.Data)
+		return r, err
+	}
+	return Route{}, fmt.Errorf("no route to %s", dst)
+}
+
+func ipFamily(ip net.IP) (family byte, ipBytes net.IP, bits int) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return unix.AF_INET, ip4, 32
+	}
+	if ip16 := ip.To16(); ip16 != nil {
+		return unix.AF_INET6, ip16, 128
+	}
+	return 0, nil, 0
+}
+
+// newRtMsg returns 'struct rtmsg'
+func newRtMsg(family byte, dstLen int) []byte {
+	hdr := make([]byte, unix.SizeofRtMsg)
+	hdr[0] = family
+	hdr[1] = byte(dstLen)
+	return hdr
+}
+
+func rtnlExecute(typ uint16, flags netlink.HeaderFlags, hdr []byte, encodeAttrs func(ae *netlink.AttributeEncoder)) ([]netlink.Message, error) {
+	data := hdr
+	if encodeAttrs != nil {
+		ae := netlink.NewAttributeEncoder()
+		encodeAttrs(ae)
+		attrs, err := ae.Encode()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, attrs...)
+	}
+
+	conn, err := netlink.Dial(unix.NETLINK_ROUTE, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open netlink connection: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Execute(netlink.Message{
+		Header: netlink.Header{Type: netlink.HeaderType(typ), Flags: netlink.Request | flags},
+		Data:   data,
+	})
+}
+
+// parseRoute parses 'struct rtmsg' with attributes
+func parseRoute(data []byte) (r Route, routeType byte, err error) {
+	if len(data) < unix.SizeofRtMsg {
+		return r, 0, fmt.Errorf("bad route message")
+	}
+	family, dstLen := data[0], int(data[1])
+	r.Table = uint32(data[4])
+	routeType = data[7]
+
+	bits := 32
+	if family == unix.AF_INET6 {
+		bits = 128
+	}
+	r.Dst = net.IPNet{IP: make(net.IP, bits/8), Mask: net.CIDRMask(dstLen, bits)}
+
+	ad, err := netlink.NewAttributeDecoder(data[unix.SizeofRtMsg:])
+	if err != nil {
+		return r, 0, err
+	}
+	for ad.Next() {
+		switch ad.Type() {
+		case unix.RTA_TABLE:
+			r.Table = ad.Uint32()
+		case unix.RTA_DST:
+			r.Dst.IP = net.IP(ad.Bytes())
+		case unix.RTA_GATEWAY:
+			r.Gateway = net.IP(ad.Bytes())
+		case unix.RTA_OIF:
+			r.OutIfIndex = int(ad.Uint32())
+		case unix.RTA_PRIORITY:
+			r.Metric = ad.Uint32()
+		}
+	}
+	return r, routeType, ad.Err()
+}
@@ -0,0 +1,61 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package netinfo
+
+import (
+	"net"
+	"testing"
+
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestParseRoute(t *testing.T) {
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(unix.RTA_TABLE, RouteTableMain)
+	ae.Bytes(unix.RTA_GATEWAY, net.IPv4(192, 168, 1, 1).To4())
+	ae.Uint32(unix.RTA_OIF, 2)
+	ae.Uint32(unix.RTA_PRIORITY, 100)
+	attrs, err := ae.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hdr := newRtMsg(unix.AF_INET, 0)
+	hdr[7] = unix.RTN_UNICAST
+
+	r, rtype, err := parseRoute(append(hdr, attrs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rtype != unix.RTN_UNICAST || !r.IsDefault() || r.Table != RouteTableMain || r.OutIfIndex != 2 || r.Metric != 100 {
+		t.Errorf("unexpected route: %s", r)
+	}
+	if !r.Gateway.Equal(net.IPv4(192, 168, 1, 1)) {
+		t.Errorf("unexpected gateway: %s", r.Gateway)
+	}
+
+	if _, _, err := parseRoute([]byte{unix.AF_INET}); err == nil {
+		t.Error("expected error for short message")
+	}
+}
@@ -26,8 +26,10 @@
 package netlink
 
 import (
+	"errors"
 	"fmt"
 	"syscall"
+	"time"
 )
 
 // Listener provides possibility to listen for a netlink messages
@@ -55,12 +57,26 @@ type Listener struct {
 	sa *syscall.SockaddrNetlink
 }
 
-// CreateListener creates new NetlinkListener object
+// Multicast groups to listen
+const (
+	GroupLink       = 1 << (syscall.RTNLGRP_LINK - 1)
+	GroupIPv4Addr   = 1 << (syscall.RTNLGRP_IPV4_IFADDR - 1)
+	GroupIPv6Addr   = 1 << (syscall.RTNLGRP_IPV6_IFADDR - 1)
+	GroupIPv4Route  = 1 << (syscall.RTNLGRP_IPV4_ROUTE - 1)
+	GroupIPv6Route  = 1 << (syscall.RTNLGRP_IPV6_ROUTE - 1)
+	GroupIPv4Rule   = 1 << (syscall.RTNLGRP_IPV4_RULE - 1)
+	GroupIPv6Rule   = 1 << (syscall.RTNLGRP_IPV6_RULE - 1)
+	groupsLinkAddrs = GroupLink | GroupIPv4Addr | GroupIPv6Addr
+)
+
+// CreateListener creates new NetlinkListener object (listening link and address changes)
 func CreateListener() (*Listener, error) {
-	groups := (1 << (syscall.RTNLGRP_LINK - 1)) |
-		(1 << (syscall.RTNLGRP_IPV4_IFADDR - 1)) |
-		(1 << (syscall.RTNLGRP_IPV6_IFADDR - 1))
+	return CreateListenerForGroups(groupsLinkAddrs)
+}
 
+// CreateListenerForGroups creates new NetlinkListener object listening the specified multicast groups
+// (e.g. GroupIPv4Route|GroupIPv6Route)
+func CreateListenerForGroups(groups uint32) (*Listener, error) {
 	s, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM,
 		syscall.NETLINK_ROUTE)
 	if err != nil {
@@ -70,17 +86,35 @@ func CreateListener() (*Listener, error) {
 	addr := &syscall.SockaddrNetlink{
 		Family: syscall.AF_NETLINK,
 		Pid:    uint32(0),
-		Groups: uint32(groups),
+		Groups: groups,
 	}
 
 	err = syscall.Bind(s, addr)
 	if err != nil {
+		syscall.Close(s)
 		return nil, fmt.Errorf("socket binding error: %s", err)
 	}
 
 	return &Listener{fd: s, sa: addr}, nil
 }
 
+// SetReadTimeout sets the timeout for ReadMsgs() (0 - no timeout).
+// When the timeout elapsed - ReadMsgs() returns error which can be checked by IsTimeout()
+func (l *Listener) SetReadTimeout(timeout time.Duration) error {
+	tv := syscall.NsecToTimeval(timeout.Nanoseconds())
+	return syscall.SetsockoptTimeval(l.fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+}
+
+// Close closes the listener
+func (l *Listener) Close() error {
+	return syscall.Close(l.fd)
+}
+
+// IsTimeout returns 'true' if the error returned by ReadMsgs() is the read timeout
+func IsTimeout(err error) bool {
+	return errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EWOULDBLOCK)
+}
+
 // ReadMsgs return received messages
 func (l *Listener) ReadMsgs() ([]syscall.NetlinkMessage, error) {
 	defer func() {
@@ -91,7 +125,7 @@ func (l *Listener) ReadMsgs() ([]syscall.NetlinkMessage, error) {
 
 	n, err := syscall.Read(l.fd, pkt)
 	if err != nil {
-		return nil, fmt.Errorf("NetlinkListener read error: %s", err)
+		return nil, fmt.Errorf("NetlinkListener read error: %w", err)
 	}
 
 	msgs, err := syscall.ParseNetlinkMessage(pkt[:n])
@@ -117,3 +151,23 @@ func IsDelAddr(msg *syscall.NetlinkMessage) bool {
 	}
 	return false
 }
+
+// IsNewRoute checking message type for syscall.RTM_NEWROUTE
+func IsNewRoute(msg *syscall.NetlinkMessage) bool {
+	return msg.Header.Type == syscall.RTM_NEWROUTE
+}
+
+// IsDelRoute checking message type for syscall.RTM_DELROUTE
+func IsDelRoute(msg *syscall.NetlinkMessage) bool {
+	return msg.Header.Type == syscall.RTM_DELROUTE
+}
+
+// IsRuleChange checking message type for syscall.RTM_NEWRULE or syscall.RTM_DELRULE
+func IsRuleChange(msg *syscall.NetlinkMessage) bool {
+	return msg.Header.Type == syscall.RTM_NEWRULE || msg.Header.Type == syscall.RTM_DELRULE
+}
+
+// IsLinkChange checking message type for syscall.RTM_NEWLINK or syscall.RTM_DELLINK
+func IsLinkChange(msg *syscall.NetlinkMessage) bool {
+	return msg.Header.Type == syscall.RTM_NEWLINK || msg.Header.Type == syscall.RTM_DELLINK
+}
@@ -0,0 +1,107 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build linux
+// +build linux
+
+// Package peercred provides credentials of the process connected to the Unix domain socket
+package peercred
+
+import (
+	"fmt"
+	"net"
+	"os/user"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// Credentials of the peer process
+type Credentials struct {
+	Pid    int
+	Uid    int
+	Gid    int   // primary group
+	Groups []int // supplementary groups
+}
+
+// Get returns credentials of the process connected to the socket (SO_PEERCRED).
+// Supplementary groups are the groups of the user (uid) from the user database.
+// Note: the groups are not read from the peer process by PID: the process can exit (and PID be reused)
+// or change its groups after the connection was established.
+func Get(conn *net.UnixConn) (Credentials, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	var ucred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return Credentials{}, err
+	}
+	if credErr != nil {
+		return Credentials{}, fmt.Errorf("SO_PEERCRED: %w", credErr)
+	}
+
+	ret := Credentials{Pid: int(ucred.Pid), Uid: int(ucred.Uid), Gid: int(ucred.Gid)}
+	groups, err := userGroups(ret.Uid)
+	if err != nil {
+		return ret, err
+	}
+	ret.Groups = groups
+	return ret, nil
+}
+
+// IsMemberOf returns 'true' if the process belongs to the group (primary or supplementary)
+func (c Credentials) IsMemberOf(gid int) bool {
+	if c.Gid == gid {
+		return true
+	}
+	for _, g := range c.Groups {
+		if g == gid {
+			return true
+		}
+	}
+	return false
+}
+
+// userGroups returns the list of groups the user is a member of
+func userGroups(uid int) ([]int, error) {
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info (uid %d): %w", uid, err)
+	}
+	ids, err := u.GroupIds()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups of the user '%s': %w", u.Username, err)
+	}
+
+	ret := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if gid, err := strconv.Atoi(id); err == nil {
+			ret = append(ret, gid)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,94 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build linux
+// +build linux
+
+package peercred
+
+import (
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsMemberOf(t *testing.T) {
+	c := Credentials{Uid: 1000, Gid: 1000, Groups: []int{4, 1001}}
+	if !c.IsMemberOf(1000) || !c.IsMemberOf(1001) || c.IsMemberOf(27) {
+		t.Error("unexpected group membership")
+	}
+}
+
+func TestUserGroups(t *testing.T) {
+	u, err := user.Current()
+	if err != nil {
+		t.Skip(err)
+	}
+	ids, err := u.GroupIds()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	groups, err := userGroups(os.Getuid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != len(ids) {
+		t.Errorf("unexpected groups: %v (expected %v)", groups, ids)
+	}
+
+	if _, err := userGroups(-1); err == nil {
+		t.Error("expected error for unknown user")
+	}
+}
+
+func TestGet(t *testing.T) {
+	sockFile := filepath.Join(t.TempDir(), "test.sock")
+	l, err := net.Listen("unix", sockFile)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+
+	go func() {
+		if c, err := net.Dial("unix", sockFile); err == nil {
+			defer c.Close()
+			c.Read(make([]byte, 1))
+		}
+	}()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	cred, err := Get(conn.(*net.UnixConn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.Pid != os.Getpid() || cred.Uid != os.Getuid() || cred.Gid != os.Getgid() {
+		t.Errorf("unexpected credentials: %+v", cred)
+	}
+}
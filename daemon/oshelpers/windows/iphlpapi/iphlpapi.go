@@ -27,6 +27,7 @@ package iphlpapi
 
 import (
 	"encoding/binary"
+	"fmt"
 	"net"
 	"syscall"
 	"unsafe"
@@ -47,6 +48,7 @@ var (
 	_fGetBestRoute         = _dll.NewProc("GetBestRoute")
 	_fGetIPForwardTable    = _dll.NewProc("GetIpForwardTable")
 	_fGetExtendedTcpTable  = _dll.NewProc("GetExtendedTcpTable")
+	_fSendARP              = _dll.NewProc("SendARP")
 )
 
 // APINotifyRouteChange - The NotifyRouteChange function causes a notification to be sent to the caller whenever a change occurs in the IPv4 routing table.
@@ -84,6 +86,30 @@ func APIGetBestRoute(dwDestAddr net.IP, dwSourceAddr net.IP, bestRoute *APIMibIP
 	return checkDefaultAPIResp(retval, err)
 }
 
+// APISendARP - The SendARP function sends an Address Resolution Protocol (ARP) request to obtain the physical address that corresponds to the specified destination IPv4 address.
+// https://docs.microsoft.com/en-us/windows/win32/api/iphlpapi/nf-iphlpapi-sendarp
+func APISendARP(destIP net.IP) (mac net.HardwareAddr, err error) {
+	defer catchPanic(&err)
+
+	ip4 := destIP.To4()
+	if ip4 == nil {
+		return nil, fmt.Errorf("not an IPv4 address: %v", destIP)
+	}
+	// IPAddr is in network byte order: keep the bytes as is in memory
+	dest := binary.LittleEndian.Uint32(ip4)
+
+	var buf [8]byte
+	bufLen := uint32(len(buf))
+	retval, _, err := _fSendARP.Call(uintptr(dest), 0, uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&bufLen)))
+	if err := checkDefaultAPIResp(retval, err); err != nil {
+		return nil, err
+	}
+	if bufLen == 0 || bufLen > uint32(len(buf)) {
+		return nil, fmt.Errorf("SendARP: unexpected address length %d", bufLen)
+	}
+	return net.HardwareAddr(buf[:bufLen]), nil
+}
+
 // GetIPForwardTable - The GetIpForwardTable function retrieves the IPv4 routing table.
 // https://docs.microsoft.com/en-us/windows/win32/api/iphlpapi/nf-iphlpapi-getipforwardtable
 func GetIPForwardTable(pIPForwardTable []byte, pdwSize *uint32, bOrder bool) (r syscall.Errno, err error) {
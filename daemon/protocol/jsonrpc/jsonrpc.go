@@ -0,0 +1,171 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+// Package jsonrpc contains the message types of the JSON-RPC 2.0 protocol (https://www.jsonrpc.org/specification)
+// and the helpers to describe Go types by JSON Schema (in use for the API description document).
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Version - the protocol version (value of the 'jsonrpc' member)
+const Version = "2.0"
+
+// Error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	// the error reported by the method (implementation-defined server error)
+	CodeServerError = -32000
+)
+
+// Request - JSON-RPC request object
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// nil for notifications (the client does not expect the response)
+	ID json.RawMessage `json:"id,omitempty"`
+}
+
+// IsNotification returns 'true' when the request has no 'id' member
+func (r Request) IsNotification() bool {
+	return r.ID == nil
+}
+
+// Response - JSON-RPC response object
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// Error - JSON-RPC error object
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// NewError creates the error object
+func NewError(code int, format string, a ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, a...)}
+}
+
+// NewResponse creates the 'success' response. The result is 'null' when not defined.
+func NewResponse(id json.RawMessage, result json.RawMessage) Response {
+	if len(result) == 0 {
+		result = json.RawMessage("null")
+	}
+	return Response{JSONRPC: Version, Result: result, ID: responseID(id)}
+}
+
+// NewErrorResponse creates the error response
+func NewErrorResponse(id json.RawMessage, err *Error) Response {
+	return Response{JSONRPC: Version, Error: err, ID: responseID(id)}
+}
+
+// the 'id' member is required in the response ('null' if it was not possible to detect the request id)
+func responseID(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// ParseRequests parses the request body: a single request object or a batch (array of requests).
+// The returned error means that the body cannot be processed at all (the error response must be sent back).
+// Each parsed request must be checked by Validate() before processing.
+func ParseRequests(data []byte) (requests []Request, isBatch bool, err *Error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, false, NewError(CodeInvalidRequest, "Invalid Request: empty body")
+	}
+
+	if data[0] != '[' {
+		var r Request
+		if e := json.Unmarshal(data, &r); e != nil {
+			return nil, false, parseError(data, e)
+		}
+		return []Request{r}, false, nil
+	}
+
+	var raw []json.RawMessage
+	if e := json.Unmarshal(data, &raw); e != nil {
+		return nil, true, NewError(CodeParseError, "Parse error: %v", e)
+	}
+	if len(raw) == 0 {
+		return nil, true, NewError(CodeInvalidRequest, "Invalid Request: empty batch")
+	}
+
+	requests = make([]Request, 0, len(raw))
+	for _, r := range raw {
+		var req Request
+		if e := json.Unmarshal(r, &req); e != nil {
+			// keep the request invalid: Validate() will report the error for it
+			req = Request{}
+		}
+		requests = append(requests, req)
+	}
+	return requests, true, nil
+}
+
+// Validate checks the request object.
+// Only named parameters (JSON object) are supported.
+func (r Request) Validate() *Error {
+	if r.JSONRPC != Version {
+		return NewError(CodeInvalidRequest, "Invalid Request: unsupported protocol version '%s'", r.JSONRPC)
+	}
+	if len(r.Method) == 0 {
+		return NewError(CodeInvalidRequest, "Invalid Request: method not defined")
+	}
+	if len(r.ID) > 0 {
+		switch r.ID[0] {
+		case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		default:
+			return NewError(CodeInvalidRequest, "Invalid Request: 'id' must be a string, number or null")
+		}
+	}
+	if params := bytes.TrimSpace(r.Params); len(params) > 0 && params[0] != '{' && !bytes.Equal(params, []byte("null")) {
+		return NewError(CodeInvalidParams, "Invalid params: only named parameters (JSON object) are supported")
+	}
+	return nil
+}
+
+func parseError(data []byte, err error) *Error {
+	// the body is a valid JSON but not an object
+	if json.Valid(data) {
+		return NewError(CodeInvalidRequest, "Invalid Request: %v", err)
+	}
+	return NewError(CodeParseError, "Parse error: %v", err)
+}
@@ -0,0 +1,201 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		isBatch   bool
+		count     int
+		errorCode int
+	}{
+		{"single", `{"jsonrpc":"2.0","method":"GetVPNState","id":1}`, false, 1, 0},
+		{"batch", `[{"jsonrpc":"2.0","method":"GetVPNState","id":1},{"jsonrpc":"2.0","method":"Disconnect"}]`, true, 2, 0},
+		{"batch with invalid element", `[1,{"jsonrpc":"2.0","method":"Disconnect"}]`, true, 2, 0},
+		{"empty", ` `, false, 0, CodeInvalidRequest},
+		{"empty batch", `[]`, true, 0, CodeInvalidRequest},
+		{"not an object", `"GetVPNState"`, false, 0, CodeInvalidRequest},
+		{"bad json", `{"jsonrpc":"2.0","method"`, false, 0, CodeParseError},
+		{"bad json batch", `[{"jsonrpc":"2.0"},`, true, 0, CodeParseError},
+	}
+
+	for _, tt := range tests {
+		reqs, isBatch, err := ParseRequests([]byte(tt.data))
+		if tt.errorCode != 0 {
+			if err == nil || err.Code != tt.errorCode {
+				t.Errorf("%s: expected error %d; got %v", tt.name, tt.errorCode, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if isBatch != tt.isBatch || len(reqs) != tt.count {
+			t.Errorf("%s: got batch=%v count=%d; expected batch=%v count=%d", tt.name, isBatch, len(reqs), tt.isBatch, tt.count)
+		}
+	}
+}
+
+func TestRequestValidate(t *testing.T) {
+	tests := []struct {
+		data           string
+		errorCode      int
+		isNotification bool
+	}{
+		{`{"jsonrpc":"2.0","method":"GetVPNState","id":1}`, 0, false},
+		{`{"jsonrpc":"2.0","method":"GetVPNState","id":"abc"}`, 0, false},
+		{`{"jsonrpc":"2.0","method":"GetVPNState","id":null}`, 0, false},
+		{`{"jsonrpc":"2.0","method":"KillSwitchSetEnabled","params":{"IsEnabled":true}}`, 0, true},
+		{`{"jsonrpc":"2.0","method":"GetVPNState","params":null,"id":1}`, 0, false},
+		{`{"method":"GetVPNState","id":1}`, CodeInvalidRequest, false},
+		{`{"jsonrpc":"1.0","method":"GetVPNState","id":1}`, CodeInvalidRequest, false},
+		{`{"jsonrpc":"2.0","id":1}`, CodeInvalidRequest, false},
+		{`{"jsonrpc":"2.0","method":"GetVPNState","id":{}}`, CodeInvalidRequest, false},
+		{`{"jsonrpc":"2.0","method":"KillSwitchSetEnabled","params":[true],"id":1}`, CodeInvalidParams, false},
+	}
+
+	for _, tt := range tests {
+		var r Request
+		if err := json.Unmarshal([]byte(tt.data), &r); err != nil {
+			t.Fatal(err)
+		}
+		err := r.Validate()
+		if tt.errorCode == 0 && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.data, err)
+		} else if tt.errorCode != 0 && (err == nil || err.Code != tt.errorCode) {
+			t.Errorf("%s: expected error %d; got %v", tt.data, tt.errorCode, err)
+		}
+		if r.IsNotification() != tt.isNotification {
+			t.Errorf("%s: IsNotification() = %v", tt.data, r.IsNotification())
+		}
+	}
+}
+
+func TestResponseMarshal(t *testing.T) {
+	tests := []struct {
+		resp Response
+		want string
+	}{
+		{NewResponse(json.RawMessage("1"), nil), `{"jsonrpc":"2.0","result":null,"id":1}`},
+		{NewResponse(json.RawMessage(`"a"`), json.RawMessage(`{"Command":"EmptyResp"}`)), `{"jsonrpc":"2.0","result":{"Command":"EmptyResp"},"id":"a"}`},
+		{NewErrorResponse(nil, NewError(CodeParseError, "Parse error")), `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`},
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("got %s; expected %s", data, tt.want)
+		}
+	}
+}
+
+type testBase struct {
+	Command string
+	Idx     int
+}
+
+type testItem struct {
+	Name  string
+	Items []testItem
+}
+
+type testRequest struct {
+	testBase
+	Idx      string // overrides the member of embedded structure
+	Renamed  bool   `json:"renamed,omitempty"`
+	Skipped  bool   `json:"-"`
+	private  int
+	Time     time.Time
+	IP       net.IP
+	Data     []byte
+	Ports    []uint16
+	Map      map[string]float64
+	Item     testItem
+	ItemPtr  *testItem
+	Any      interface{}
+	Inline   struct{ Value int }
+	RawValue json.RawMessage
+}
+
+func TestFields(t *testing.T) {
+	var names []string
+	for _, f := range Fields(reflect.TypeOf(&testRequest{})) {
+		names = append(names, f.Name)
+	}
+	want := []string{"Idx", "renamed", "Time", "IP", "Data", "Ports", "Map", "Item", "ItemPtr", "Any", "Inline", "RawValue", "Command"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("fields %v; expected %v", names, want)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	g := NewSchemaGenerator("#/defs/")
+	name, ref := g.Ref(reflect.TypeOf(testRequest{}))
+	if name != "testRequest" || ref["$ref"] != "#/defs/testRequest" {
+		t.Fatalf("unexpected reference: %s %v", name, ref)
+	}
+
+	props := g.Definitions["testRequest"]["properties"].(map[string]Schema)
+	tests := map[string]Schema{
+		"Idx":      {"type": "string"},
+		"Command":  {"type": "string"},
+		"renamed":  {"type": "boolean"},
+		"Time":     {"type": "string", "format": "date-time"},
+		"IP":       {"type": "string"},
+		"Data":     {"type": "string", "contentEncoding": "base64"},
+		"Ports":    {"type": "array", "items": Schema{"type": "integer"}},
+		"Map":      {"type": "object", "additionalProperties": Schema{"type": "number"}},
+		"Item":     {"$ref": "#/defs/testItem"},
+		"ItemPtr":  {"$ref": "#/defs/testItem"},
+		"Any":      {},
+		"Inline":   {"type": "object", "properties": map[string]Schema{"Value": {"type": "integer"}}},
+		"RawValue": {},
+	}
+	if len(props) != len(tests) {
+		t.Errorf("got %d properties; expected %d", len(props), len(tests))
+	}
+	for name, want := range tests {
+		if !reflect.DeepEqual(props[name], want) {
+			t.Errorf("%s: got %v; expected %v", name, props[name], want)
+		}
+	}
+
+	// recursive type
+	item := g.Definitions["testItem"]["properties"].(map[string]Schema)
+	if !reflect.DeepEqual(item["Items"], Schema{"type": "array", "items": Schema{"$ref": "#/defs/testItem"}}) {
+		t.Errorf("unexpected schema of recursive type: %v", item["Items"])
+	}
+}
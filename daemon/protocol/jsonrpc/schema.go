@@ -0,0 +1,200 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package jsonrpc
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema - JSON Schema object
+type Schema map[string]interface{}
+
+// Field - JSON member of the structure
+type Field struct {
+	Name string
+	Type reflect.Type
+}
+
+var (
+	typeTime          = reflect.TypeOf(time.Time{})
+	typeJSONMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	typeTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaGenerator describes Go types by JSON Schema (according to the rules of 'encoding/json' package).
+// The named structures are described once: they are saved to Definitions and referenced by '$ref'.
+type SchemaGenerator struct {
+	// Definitions of the named structures (the key is the name of the definition)
+	Definitions map[string]Schema
+
+	refPrefix string
+	names     map[reflect.Type]string
+}
+
+// NewSchemaGenerator creates the generator.
+// refPrefix - the path to the definitions in the document (e.g. "#/components/schemas/")
+func NewSchemaGenerator(refPrefix string) *SchemaGenerator {
+	return &SchemaGenerator{
+		Definitions: make(map[string]Schema),
+		refPrefix:   refPrefix,
+		names:       make(map[reflect.Type]string),
+	}
+}
+
+// Ref returns the reference to the definition of the named structure (the definition is created if not exists)
+func (g *SchemaGenerator) Ref(t reflect.Type) (name string, ref Schema) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if name, ok := g.names[t]; ok {
+		return name, Schema{"$ref": g.refPrefix + name}
+	}
+
+	name = t.Name()
+	if _, exists := g.Definitions[name]; exists {
+		// the name is already in use by the type from another package
+		name = path.Base(t.PkgPath()) + "." + t.Name()
+	}
+	g.names[t] = name
+	// register the definition before describing the fields (the structure can refer to itself)
+	g.Definitions[name] = Schema{}
+	g.Definitions[name] = g.structSchema(t)
+	return name, Schema{"$ref": g.refPrefix + name}
+}
+
+// Schema returns the schema of the type
+func (g *SchemaGenerator) Schema(t reflect.Type) Schema {
+	if t == typeTime {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+	if t.Implements(typeJSONMarshaler) || reflect.PointerTo(t).Implements(typeJSONMarshaler) {
+		if t.Implements(typeTextMarshaler) || reflect.PointerTo(t).Implements(typeTextMarshaler) {
+			return Schema{"type": "string"}
+		}
+		// custom serialization: the format is unknown
+		return Schema{}
+	}
+	if t.Implements(typeTextMarshaler) || reflect.PointerTo(t).Implements(typeTextMarshaler) {
+		return Schema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Pointer:
+		return g.Schema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": g.Schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.Schema(t.Elem())}
+	case reflect.Struct:
+		if len(t.Name()) == 0 {
+			return g.structSchema(t)
+		}
+		_, ref := g.Ref(t)
+		return ref
+	}
+	// interface{} (or unsupported type): any value
+	return Schema{}
+}
+
+func (g *SchemaGenerator) structSchema(t reflect.Type) Schema {
+	props := make(map[string]Schema)
+	for _, f := range Fields(t) {
+		props[f.Name] = g.Schema(f.Type)
+	}
+	return Schema{"type": "object", "properties": props}
+}
+
+// Fields returns the list of JSON members of the structure.
+// The fields of embedded structures are included (if not overridden by the fields of outer structure).
+func Fields(t reflect.Type) []Field {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var ret []Field
+	known := make(map[string]struct{})
+	add := func(fields []Field) {
+		for _, f := range fields {
+			if _, ok := known[f.Name]; ok {
+				continue
+			}
+			known[f.Name] = struct{}{}
+			ret = append(ret, f)
+		}
+	}
+
+	var embedded [][]Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && len(name) == 0 {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// members of embedded structure have lower priority than the members of this structure
+				embedded = append(embedded, Fields(ft))
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = sf.Name
+		}
+		add([]Field{{Name: name, Type: sf.Type}})
+	}
+
+	for _, fields := range embedded {
+		add(fields)
+	}
+	return ret
+}
@@ -29,20 +29,28 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/helpers"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/logger"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/oshelpers"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/eaa"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/history"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/leaktest"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/preferences"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/serverscore"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/srverrors"
 	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/splittun"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/version"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/wifiNotifier"
 )
@@ -74,8 +82,11 @@ type Service interface {
 	ServersListForceUpdate() (*api_types.ServerListResponse, error)
 
 	PingServers(timeoutMs int, vpnTypePrioritized vpn.Type, skipSecondPhase bool) (map[string]int, error)
+	RecommendServers(vpnType vpn.Type, constraints serverscore.Constraints, count int) ([]serverscore.Candidate, error)
 
 	APIRequest(apiAlias string, ipTypeRequired types.RequiredIPProtocol) (responseData []byte, err error)
+	GetAppUpdateInfo(updateType types.AppUpdateType) (*api_types.UpdateInfo, error)
+	GetGeoLookup() (location, locationIPv6 *api_types.GeoLookupResponse)
 
 	KillSwitchState() (status service_types.KillSwitchStatus, err error)
 	SetKillSwitchState(bool) error
@@ -88,8 +99,12 @@ type Service interface {
 	GetConnectionParams() service_types.ConnectionParams
 	SetConnectionParams(params service_types.ConnectionParams) error
 	SetWiFiSettings(params preferences.WiFiParams) error
+	SetNetworkProfiles(params preferences.NetworkProfilesParams) error
+	SetSchedulerSettings(params preferences.SchedulerParams) error
+	GetNetworkIdentity() (identity preferences.NetworkIdentity, profileName string)
+	LeakTest() (leaktest.Result, error)
 
-	SplitTunnelling_SetConfig(isEnabled, isInversed, isAnyDns, isAllowWhenNoVpn, reset bool) error
+	SplitTunnelling_SetConfig(isEnabled, isInversed, isAnyDns, isAllowWhenNoVpn, reset bool, destinations []splittun.DestinationRule, apps []string) error
 	SplitTunnelling_GetStatus() (types.SplitTunnelStatus, error)
 	SplitTunnelling_AddApp(exec string) (cmdToExecute string, isAlreadyRunning bool, err error)
 	SplitTunnelling_RemoveApp(pid int, exec string) (err error)
@@ -113,6 +128,7 @@ type Service interface {
 	Connect(params service_types.ConnectionParams) error
 	Disconnect() error
 	Connected() bool
+	GetTunnelStatistics() (vpn.Statistics, error)
 
 	Pause(durationSeconds uint32) error
 	Resume() error
@@ -140,6 +156,9 @@ type Service interface {
 	GetWiFiAvailableNetworks() []string
 
 	GetDiagnosticLogs() (logActive string, logPrevSession string, extraInfo string, err error)
+
+	GetConnectionHistory(count int) ([]history.Entry, error)
+	ClearConnectionHistory() error
 }
 
 // CreateProtocol - Create new protocol object
@@ -163,6 +182,16 @@ type Protocol struct {
 	// connections listener
 	_connListener *net.TCPListener
 
+	// Unix domain socket listener (nil - not applicable for the platform)
+	_unixListener     net.Listener
+	_unixSocketConfig UnixSocketConfig
+	// connections with read-only access (map[net.Conn]struct{})
+	_readOnlyConns sync.Map
+
+	// JSON-RPC gateway (nil - disabled)
+	_jsonRPCServer *http.Server
+	_jsonRPCConfig JSONRPCConfig
+
 	_connectionsMutex sync.RWMutex
 	_connections      map[net.Conn]connectionInfo
 
@@ -202,12 +231,18 @@ func (p *Protocol) Stop() {
 		p._isRunning = false
 		// do not accept new incoming connections
 		listener.Close()
+		if p._unixListener != nil {
+			p._unixListener.Close()
+		}
+		p.stopJSONRPC()
 
 		// Do not use any send\receive communications with connected clients after listener stopped
 	}
 }
 
 // Start - starts TCP interface to communicate with IVPN application (server to listen incoming connections)
+// The Unix domain socket interface is started as well (if applicable for the platform)
+// and the JSON-RPC gateway (if enabled by SetJSONRPCConfig())
 func (p *Protocol) Start(secret uint64, startedOnPort chan<- int, service Service) error {
 	if p._service != nil {
 		return errors.New("unable to start protocol communication. It is already initialized")
@@ -251,9 +286,18 @@ func (p *Protocol) Start(secret uint64, startedOnPort chan<- int, service Servic
 	log.Info(fmt.Sprintf("IVPN service started: %d [...%s]", openedPort, fmt.Sprintf("%016x", secret)[12:]))
 	defer func() {
 		listener.Close()
+		if p._unixListener != nil {
+			p._unixListener.Close()
+		}
+		p.stopJSONRPC()
 		log.Info("Listener closed")
 	}()
 
+	// Unix domain socket interface (in addition to TCP)
+	p.startUnixSocket()
+	// JSON-RPC gateway (if enabled)
+	p.startJSONRPC()
+
 	// Start processing of new connection requests
 	// (connection requests collecting in to chain and processing in order they were received.
 	// See also "RegisterConnectionRequest()" for details)
@@ -269,13 +313,16 @@ func (p *Protocol) Start(secret uint64, startedOnPort chan<- int, service Servic
 			log.Error("Server: failed to accept incoming connection:", err)
 			return fmt.Errorf("(server) failed to accept incoming connection: %w", err)
 		}
-		go p.processClient(conn)
+		go p.processClient(conn, nil)
 	}
 }
 
-func (p *Protocol) processClient(conn net.Conn) {
+// processClient processes requests from the connected client
+// peer - access rights of the client connected over the Unix domain socket (nil - TCP connection, authenticated by the secret)
+func (p *Protocol) processClient(conn net.Conn, peer *peerAccess) {
 	// The first request from a client should be 'Hello' request with correct secret
 	// In case of wrong secret - the daemon drops connection
+	// (the secret is not required for the clients connected over the Unix domain socket: they are authorized by credentials)
 	isAuthenticated := false
 
 	clientRemoteAddr := conn.RemoteAddr()
@@ -342,7 +389,17 @@ func (p *Protocol) processClient(conn net.Conn) {
 				p.sendErrorResponse(conn, cmd, fmt.Errorf("connection authentication error: %w", err))
 				return
 			}
-			if hello.Secret != p._secret {
+			if peer != nil {
+				if peer.err != nil {
+					log.Warning(fmt.Errorf("refusing Unix socket connection (%s): %w", peer.description, peer.err))
+					p.sendErrorResponse(conn, cmd, peer.err)
+					return
+				}
+				log.Info(fmt.Sprintf("%sUnix socket client authorized (%s; read-only: %v)", p.connLogID(conn), peer.description, peer.isReadOnly))
+				if peer.isReadOnly {
+					p._readOnlyConns.Store(conn, struct{}{})
+				}
+			} else if hello.Secret != p._secret {
 				log.Warning(fmt.Errorf("refusing connection: secret verification error"))
 				p.sendErrorResponse(conn, cmd, fmt.Errorf("secret verification error"))
 				return
@@ -400,8 +457,13 @@ func (p *Protocol) processRequest(conn net.Conn, message string) {
 			"GetVPNState",
 			"GetServers",
 			"PingServers",
+			"RecommendServers",
 			"APIRequest",
+			"GetAppUpdateInfo",
+			"GetGeoLookup",
 			"WiFiAvailableNetworks",
+			"GetNetworkIdentity",
+			"LeakTest",
 			"KillSwitchGetStatus",
 			"SplitTunnelGetStatus",
 			"GetDnsPredefinedConfigs",
@@ -425,6 +487,11 @@ func (p *Protocol) processRequest(conn net.Conn, message string) {
 		}
 	}
 
+	if p.isReadOnlyClient(conn) && !isReadOnlyRequest(reqCmd.Command) {
+		p.sendErrorResponse(conn, reqCmd, fmt.Errorf("access denied: the client has read-only access to the daemon"))
+		return
+	}
+
 	if !p._eaa.IsEnabled() {
 		// EAA is disabled. So, mark connection as authenticated
 		p.clientSetAuthenticated(conn)
@@ -530,6 +597,14 @@ func (p *Protocol) processRequest(conn net.Conn, message string) {
 		// send VPN connection  state
 		sendState(reqCmd.Idx, false)
 
+	case "GetTunnelStats":
+		stats, err := p._service.GetTunnelStatistics()
+		if err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			break
+		}
+		p.sendResponse(conn, types.CreateTunnelStatsResp(stats), reqCmd.Idx)
+
 	case "GetServers":
 		var req types.GetServers
 		if err := json.Unmarshal(messageData, &req); err != nil {
@@ -583,6 +658,20 @@ func (p *Protocol) processRequest(conn net.Conn, message string) {
 
 		p.sendResponse(conn, &types.PingServersResp{PingResults: results}, req.Idx)
 
+	case "RecommendServers":
+		var req types.RecommendServers
+		if err := json.Unmarshal(messageData, &req); err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			break
+		}
+
+		candidates, err := p._service.RecommendServers(req.VpnType, req.Constraints, req.Count)
+		if err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			break
+		}
+		p.sendResponse(conn, &types.RecommendServersResp{Candidates: candidates}, req.Idx)
+
 	case "APIRequest":
 		var req types.APIRequest
 		if err := json.Unmarshal(messageData, &req); err != nil {
@@ -597,6 +686,30 @@ func (p *Protocol) processRequest(conn net.Conn, message string) {
 		}
 		p.sendResponse(conn, &types.APIResponse{APIPath: req.APIPath, ResponseData: string(data)}, req.Idx)
 
+	case "GetGeoLookup":
+		location, locationIPv6 := p._service.GetGeoLookup()
+		p.sendResponse(conn, &types.GeoLookupResp{Location: location, LocationIPv6: locationIPv6}, reqCmd.Idx)
+
+	case "GetAppUpdateInfo":
+		var req types.GetAppUpdateInfo
+		if err := json.Unmarshal(messageData, &req); err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			break
+		}
+
+		info, err := p._service.GetAppUpdateInfo(req.UpdateType)
+		if err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			break
+		}
+
+		resp := types.AppUpdateInfoResp{UpdateType: req.UpdateType, UpdateInfo: *info, CurrentVersion: version.Version()}
+		if latest := info.DaemonVersion(); latest != nil {
+			resp.LatestVersion = latest.Version
+			resp.IsUpdateAvailable = helpers.IsNewerVersion(resp.CurrentVersion, resp.LatestVersion)
+		}
+		p.sendResponse(conn, &resp, req.Idx)
+
 	case "WiFiAvailableNetworks":
 		networks := p._service.GetWiFiAvailableNetworks()
 		nets := make([]types.WiFiNetworkInfo, 0, len(networks))
@@ -756,7 +869,7 @@ func (p *Protocol) processRequest(conn net.Conn, message string) {
 			p.sendErrorResponse(conn, reqCmd, err)
 			break
 		}
-		if err := p._service.SplitTunnelling_SetConfig(req.IsEnabled, req.IsInversed, req.IsAnyDns, req.IsAllowWhenNoVpn, req.Reset); err != nil {
+		if err := p._service.SplitTunnelling_SetConfig(req.IsEnabled, req.IsInversed, req.IsAnyDns, req.IsAllowWhenNoVpn, req.Reset, req.Destinations, req.SplitTunnelApps); err != nil {
 			p.sendErrorResponse(conn, reqCmd, err)
 			break
 		}
@@ -897,6 +1010,27 @@ func (p *Protocol) processRequest(conn net.Conn, message string) {
 		p.sendResponse(conn, &types.EmptyResp{}, reqCmd.Idx)
 		// all clients will be notified by service in OnVpnPauseChanged() handler
 
+	case "GetConnectionHistory":
+		var req types.GetConnectionHistory
+		if err := json.Unmarshal(messageData, &req); err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			break
+		}
+
+		entries, err := p._service.GetConnectionHistory(req.Count)
+		if err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			break
+		}
+		p.sendResponse(conn, &types.ConnectionHistoryResp{Entries: entries}, reqCmd.Idx)
+
+	case "ClearConnectionHistory":
+		if err := p._service.ClearConnectionHistory(); err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			break
+		}
+		p.sendResponse(conn, &types.EmptyResp{}, reqCmd.Idx)
+
 	case "VerifyPin":
 		var req types.VerifyPin
 		if err := json.Unmarshal(messageData, &req); err != nil {
@@ -1071,6 +1205,56 @@ func (p *Protocol) processRequest(conn net.Conn, message string) {
 		// notify all clients about changed wifi settings
 		p.notifyClients(p.createHelloResponse())
 
+	case "NetworkProfilesSettings":
+		var r types.NetworkProfilesSettings
+		if err := json.Unmarshal(messageData, &r); err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			return
+		}
+		if err := p._service.SetNetworkProfiles(r.Params); err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			return
+		}
+		p.sendResponse(conn, &types.EmptyResp{}, reqCmd.Idx)
+
+		// notify all clients about changed settings
+		p.notifyClients(p.createHelloResponse())
+
+	case "SchedulerSettings":
+		var r types.SchedulerSettings
+		if err := json.Unmarshal(messageData, &r); err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			return
+		}
+		if err := p._service.SetSchedulerSettings(r.Params); err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			return
+		}
+		p.sendResponse(conn, &types.EmptyResp{}, reqCmd.Idx)
+
+		// notify all clients about changed settings
+		p.notifyClients(p.createHelloResponse())
+
+	case "LeakTest":
+		var req types.LeakTest
+		if err := json.Unmarshal(messageData, &req); err != nil {
+			p.sendErrorResponse(conn, reqCmd, err)
+			return
+		}
+		// the test takes some time: do not block processing of other requests
+		go func() {
+			result, err := p._service.LeakTest()
+			if err != nil {
+				p.sendErrorResponse(conn, reqCmd, err)
+				return
+			}
+			p.sendResponse(conn, &types.LeakTestResp{Result: result}, req.Idx)
+		}()
+
+	case "GetNetworkIdentity":
+		identity, profile := p._service.GetNetworkIdentity()
+		p.sendResponse(conn, &types.NetworkIdentityResp{Identity: identity, Profile: profile}, reqCmd.Idx)
+
 	case "Disconnect":
 		p._disconnectRequested = true
 		p._lastConnectionErrorToNotifyClient = ""
@@ -1217,6 +1401,10 @@ func (p *Protocol) processConnectionRequests() {
 							connectionError = fmt.Errorf("authentication failure")
 						}
 					}
+					var unhealthyErr srverrors.ErrorTunnelUnhealthy
+					if errors.As(connectionError, &unhealthyErr) {
+						disconnectionReason = types.TunnelUnhealthy
+					}
 					if p._disconnectRequested {
 						// notify clients that disconnection was manually requested by one of connected clients
 						// (prevent UI clients trying to reconnect)
@@ -1292,3 +1480,11 @@ func (p *Protocol) OnVpnStateChanged(state vpn.StateInfo) {
 func (p *Protocol) OnVpnPauseChanged() {
 	p.notifyVpnStateChanged(nil)
 }
+
+func (p *Protocol) OnTunnelStatistics(stats vpn.Statistics) {
+	p.notifyClients(types.CreateTunnelStatsResp(stats))
+}
+
+func (p *Protocol) OnGeoLookupChanged(location, locationIPv6 *api_types.GeoLookupResponse) {
+	p.notifyClients(&types.GeoLookupResp{Location: location, LocationIPv6: locationIPv6})
+}
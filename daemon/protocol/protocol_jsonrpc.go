@@ -0,0 +1,451 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package protocol
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/jsonrpc"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+)
+
+// The JSON-RPC gateway: HTTP interface on the localhost for third-party integrations (scripts etc.).
+// The JSON-RPC 2.0 requests are converted to the daemon requests and processed the same way as requests from the regular clients:
+//
+//	POST /v1/jsonrpc		- JSON-RPC 2.0 requests (method - name of the daemon request; params - fields of the request object)
+//	GET  /v1/events			- Server-Sent Events: the notifications which are sent to all connected clients
+//	GET  /v1/openrpc.json	- the API description (also available by 'rpc.discover' method)
+//
+// Authentication: the secret from the ServicePortFile() must be sent in the header 'Authorization: Bearer <secret>'.
+// When Enhanced App Authentication is enabled, the EAA password must be sent in 'ProtocolSecret' parameter (same as for the regular clients).
+const (
+	jsonRPCAPIVersion     = "1"
+	jsonRPCPath           = "/v" + jsonRPCAPIVersion + "/jsonrpc"
+	jsonRPCEventsPath     = "/v" + jsonRPCAPIVersion + "/events"
+	jsonRPCSchemaPath     = "/v" + jsonRPCAPIVersion + "/openrpc.json"
+	jsonRPCDiscoverMethod = "rpc.discover"
+
+	// each request is processed over a separate connection: the index of the request is always the same
+	jsonRPCRequestIdx = 1
+
+	jsonRPCMaxBodySize       = 4 * 1024 * 1024
+	jsonRPCCallTimeout       = 5 * time.Minute
+	jsonRPCEventsQueueSize   = 64
+	jsonRPCKeepAliveInterval = 30 * time.Second
+)
+
+// JSONRPCConfig - configuration of the JSON-RPC gateway
+type JSONRPCConfig struct {
+	Port int // TCP port on the localhost (0 - the gateway is disabled)
+}
+
+// SetJSONRPCConfig sets configuration of the JSON-RPC gateway.
+// Must be called before Start()
+func (p *Protocol) SetJSONRPCConfig(cfg JSONRPCConfig) {
+	p._jsonRPCConfig = cfg
+}
+
+// jsonRPCConn - the connection used to pass the requests (and to receive the responses) of the gateway clients.
+// It looks like a regular client connection for the request processing routines.
+type jsonRPCConn struct {
+	net.Conn
+	remoteAddr jsonRPCAddr
+}
+
+func (c *jsonRPCConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+// jsonRPCAddr - address of the gateway client (for logging)
+type jsonRPCAddr string
+
+func (a jsonRPCAddr) Network() string { return "jsonrpc" }
+func (a jsonRPCAddr) String() string  { return "rpc:" + string(a) }
+
+func newJSONRPCConnPair(r *http.Request) (conn *jsonRPCConn, clientSide net.Conn) {
+	remote := r.RemoteAddr
+	if _, port, err := net.SplitHostPort(remote); err == nil {
+		remote = port
+	}
+	serverSide, clientSide := net.Pipe()
+	return &jsonRPCConn{Conn: serverSide, remoteAddr: jsonRPCAddr(remote)}, clientSide
+}
+
+// startJSONRPC starts the JSON-RPC gateway (if enabled)
+func (p *Protocol) startJSONRPC() {
+	if p._jsonRPCConfig.Port <= 0 {
+		return
+	}
+
+	listener, err := net.Listen("tcp4", fmt.Sprintf("127.0.0.1:%d", p._jsonRPCConfig.Port))
+	if err != nil {
+		log.Error(fmt.Errorf("failed to start JSON-RPC gateway: %w", err))
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(jsonRPCPath, p.jsonRPCAuthorized(p.jsonRPCHandleCall))
+	mux.HandleFunc(jsonRPCEventsPath, p.jsonRPCAuthorized(p.jsonRPCHandleEvents))
+	mux.HandleFunc(jsonRPCSchemaPath, p.jsonRPCAuthorized(p.jsonRPCHandleSchema))
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	p._jsonRPCServer = server
+	log.Info(fmt.Sprintf("JSON-RPC gateway started: %s", p.jsonRPCURL(jsonRPCPath)))
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(fmt.Errorf("JSON-RPC gateway stopped: %w", err))
+		}
+	}()
+}
+
+func (p *Protocol) stopJSONRPC() {
+	if p._jsonRPCServer != nil {
+		p._jsonRPCServer.Close()
+	}
+}
+
+func (p *Protocol) jsonRPCURL(path string) string {
+	return fmt.Sprintf("http://127.0.0.1:%d%s", p._jsonRPCConfig.Port, path)
+}
+
+// jsonRPCAuthorized checks the secret of the client before calling the handler
+func (p *Protocol) jsonRPCAuthorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// protection from DNS rebinding attacks: only requests addressed to the localhost are accepted
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if ip := net.ParseIP(host); (ip == nil || !ip.IsLoopback()) && !strings.EqualFold(host, "localhost") {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		secret, err := strconv.ParseUint(strings.TrimSpace(token), 16, 64)
+		if !strings.EqualFold(scheme, "Bearer") || err != nil || secret != p._secret {
+			log.Warning(fmt.Errorf("refusing JSON-RPC gateway request (%s): secret verification error", r.RemoteAddr))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ivpn"`)
+			http.Error(w, "Secret verification error", http.StatusUnauthorized)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func (p *Protocol) jsonRPCHandleSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, p.jsonRPCSchema())
+}
+
+func (p *Protocol) jsonRPCHandleCall(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, jsonRPCMaxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	requests, isBatch, rpcErr := jsonrpc.ParseRequests(body)
+	if rpcErr != nil {
+		writeJSON(w, jsonrpc.NewErrorResponse(nil, rpcErr))
+		return
+	}
+
+	// the requests of the batch are processed one by one (in order they were received)
+	responses := make([]jsonrpc.Response, 0, len(requests))
+	for _, req := range requests {
+		if err := req.Validate(); err != nil {
+			responses = append(responses, jsonrpc.NewErrorResponse(req.ID, err))
+			continue
+		}
+
+		result, err := p.jsonRPCCall(r, req)
+		if req.IsNotification() {
+			continue
+		}
+		if err != nil {
+			responses = append(responses, jsonrpc.NewErrorResponse(req.ID, err))
+		} else {
+			responses = append(responses, jsonrpc.NewResponse(req.ID, result))
+		}
+	}
+
+	switch {
+	case len(responses) == 0:
+		// only notifications were received
+		w.WriteHeader(http.StatusNoContent)
+	case isBatch:
+		writeJSON(w, responses)
+	default:
+		writeJSON(w, responses[0])
+	}
+}
+
+// jsonRPCCall converts the JSON-RPC request to the daemon request, processes it and returns the result
+func (p *Protocol) jsonRPCCall(r *http.Request, req jsonrpc.Request) (json.RawMessage, *jsonrpc.Error) {
+	if req.Method == jsonRPCDiscoverMethod {
+		data, err := json.Marshal(p.jsonRPCSchema())
+		if err != nil {
+			return nil, jsonrpc.NewError(jsonrpc.CodeInternalError, "Internal error: %v", err)
+		}
+		return data, nil
+	}
+
+	method, ok := jsonRPCMethods[req.Method]
+	if !ok {
+		return nil, jsonrpc.NewError(jsonrpc.CodeMethodNotFound, "Method not found: '%s'", req.Method)
+	}
+
+	var fields map[string]json.RawMessage
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &fields); err != nil {
+			return nil, jsonrpc.NewError(jsonrpc.CodeInvalidParams, "Invalid params: %v", err)
+		}
+		// ensure the parameters are compatible with the request object
+		if err := json.Unmarshal(req.Params, reflectNew(method.params)); err != nil {
+			return nil, jsonrpc.NewError(jsonrpc.CodeInvalidParams, "Invalid params: %v", err)
+		}
+	}
+	if fields == nil {
+		fields = make(map[string]json.RawMessage)
+	}
+
+	fields["Command"], _ = json.Marshal(req.Method)
+	fields["Idx"], _ = json.Marshal(jsonRPCRequestIdx)
+	message, err := json.Marshal(fields)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.CodeInternalError, "Internal error: %v", err)
+	}
+
+	response, err := p.jsonRPCProcessRequest(r, string(message), method.isAsync)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.CodeInternalError, "Internal error: %v", err)
+	}
+	if response == nil {
+		return nil, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(response, &obj); err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.CodeInternalError, "Internal error: %v", err)
+	}
+	// the index is internal info of the gateway
+	delete(obj, "Idx")
+	result, err := json.Marshal(obj)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.CodeInternalError, "Internal error: %v", err)
+	}
+
+	var errResp types.ErrorResp
+	if err := json.Unmarshal(response, &errResp); err == nil && errResp.Command == types.GetTypeName(errResp) {
+		// the original error object is in 'data' (e.g. ErrorType is required to detect the EAA password error)
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeServerError, Message: errResp.ErrorMessage, Data: json.RawMessage(result)}
+	}
+	return result, nil
+}
+
+// jsonRPCProcessRequest processes the daemon request and returns the response to it (nil - no response)
+func (p *Protocol) jsonRPCProcessRequest(r *http.Request, message string, isAsync bool) ([]byte, error) {
+	conn, clientSide := newJSONRPCConnPair(r)
+	defer conn.Close()
+	defer clientSide.Close()
+
+	responseChan := make(chan []byte, 1)
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		reader := bufio.NewReader(clientSide)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			// only the first response to the request is in use (e.g. 'Hello' request has additional responses)
+			if cmd, err := types.GetCommandBase(line); err == nil && cmd.Idx == jsonRPCRequestIdx {
+				select {
+				case responseChan <- line:
+				default:
+				}
+			}
+		}
+	}()
+
+	processed := make(chan struct{})
+	go func() {
+		defer close(processed)
+		p.processRequest(conn, message)
+	}()
+
+	timeout := time.NewTimer(jsonRPCCallTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case response := <-responseChan:
+			return response, nil
+
+		case <-processed:
+			processed = nil
+			if isAsync {
+				// the response will be sent later
+				continue
+			}
+			// no more responses expected: waiting until all the data sent is received
+			conn.Close()
+			<-readerDone
+			select {
+			case response := <-responseChan:
+				return response, nil
+			default:
+				return nil, nil
+			}
+
+		case <-r.Context().Done():
+			return nil, r.Context().Err()
+
+		case <-timeout.C:
+			return nil, fmt.Errorf("request timeout")
+		}
+	}
+}
+
+// jsonRPCHandleEvents sends the notifications to the client (Server-Sent Events).
+// The client is registered as a regular client connection; the connection is dropped when the client is not able to receive the events in time.
+func (p *Protocol) jsonRPCHandleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	conn, clientSide := newJSONRPCConnPair(r)
+	p.clientConnected(conn, types.ClientCli)
+	log.Info("Client connected: ", conn.RemoteAddr())
+	defer func() {
+		// the client side must be closed first: the notifications must not be blocked by this connection
+		clientSide.Close()
+		p.clientDisconnected(conn)
+		log.Info("Client disconnected: ", conn.RemoteAddr())
+	}()
+
+	events := make(chan []byte, jsonRPCEventsQueueSize)
+	go func() {
+		defer close(events)
+		defer clientSide.Close()
+		reader := bufio.NewReader(clientSide)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			select {
+			case events <- line:
+			default:
+				log.Warning(fmt.Sprintf("%sthe client does not receive events in time. Closing.", p.connLogID(conn)))
+				return
+			}
+		}
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(jsonRPCKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal(evt, &obj); err != nil {
+				continue
+			}
+			var name string
+			json.Unmarshal(obj["Command"], &name)
+			delete(obj, "Idx")
+			data, err := json.Marshal(obj)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+				return
+			}
+
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// reflectNew returns a pointer to the new zero object of the same type as 'obj'
+func reflectNew(obj interface{}) interface{} {
+	return reflect.New(reflect.TypeOf(obj)).Interface()
+}
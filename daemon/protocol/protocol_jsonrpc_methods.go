@@ -0,0 +1,166 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package protocol
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/jsonrpc"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+)
+
+// jsonRPCMethod - description of the daemon request available over the JSON-RPC gateway
+type jsonRPCMethod struct {
+	params  interface{}   // request object
+	results []interface{} // possible response objects (nil - the method can return no result)
+	// the response is sent after the request processing is finished (e.g. long-running operation)
+	isAsync bool
+}
+
+// jsonRPCMethods - the daemon requests available over the JSON-RPC gateway (the method name is the name of the request)
+// The list must be in sync with Protocol.processRequest()
+var jsonRPCMethods = map[string]jsonRPCMethod{
+	"EmptyReq":                         {params: types.EmptyReq{}, results: []interface{}{types.EmptyResp{}}},
+	"Hello":                            {params: types.Hello{}, results: []interface{}{types.HelloResp{}}},
+	"ParanoidModeSetPasswordReq":       {params: types.ParanoidModeSetPasswordReq{}, results: []interface{}{types.EmptyResp{}}},
+	"GetVPNState":                      {params: types.GetVPNState{}, results: []interface{}{types.ConnectedResp{}, types.DisconnectedResp{}, types.VpnStateResp{}}},
+	"GetTunnelStats":                   {params: types.GetTunnelStats{}, results: []interface{}{types.TunnelStatsResp{}}},
+	"GetServers":                       {params: types.GetServers{}, results: []interface{}{types.ServerListResp{}}},
+	"PingServers":                      {params: types.PingServers{}, results: []interface{}{types.PingServersResp{}}},
+	"RecommendServers":                 {params: types.RecommendServers{}, results: []interface{}{types.RecommendServersResp{}}},
+	"APIRequest":                       {params: types.APIRequest{}, results: []interface{}{types.APIResponse{}}},
+	"GetGeoLookup":                     {params: types.GetGeoLookup{}, results: []interface{}{types.GeoLookupResp{}}},
+	"GetAppUpdateInfo":                 {params: types.GetAppUpdateInfo{}, results: []interface{}{types.AppUpdateInfoResp{}}},
+	"WiFiAvailableNetworks":            {params: types.WiFiAvailableNetworks{}, results: []interface{}{types.EmptyResp{}}},
+	"WiFiCurrentNetwork":               {params: types.WiFiCurrentNetwork{}, results: []interface{}{types.WiFiCurrentNetworkResp{}}},
+	"KillSwitchGetStatus":              {params: types.KillSwitchGetStatus{}, results: []interface{}{types.KillSwitchStatusResp{}}},
+	"KillSwitchSetEnabled":             {params: types.KillSwitchSetEnabled{}, results: []interface{}{types.EmptyResp{}}},
+	"KillSwitchSetAllowLANMulticast":   {params: types.KillSwitchSetAllowLANMulticast{}, results: []interface{}{types.EmptyResp{}}},
+	"KillSwitchSetAllowLAN":            {params: types.KillSwitchSetAllowLAN{}, results: []interface{}{types.EmptyResp{}}},
+	"KillSwitchSetUserExceptions":      {params: types.KillSwitchSetUserExceptions{}, results: []interface{}{types.EmptyResp{}}},
+	"KillSwitchSetIsPersistent":        {params: types.KillSwitchSetIsPersistent{}, results: []interface{}{types.EmptyResp{}}},
+	"KillSwitchSetAllowApiServers":     {params: types.KillSwitchSetAllowApiServers{}, results: []interface{}{types.EmptyResp{}}},
+	"SetPreference":                    {params: types.SetPreference{}, results: []interface{}{types.EmptyResp{}}},
+	"SetUserPreferences":               {params: types.SetUserPreferences{}, results: []interface{}{types.EmptyResp{}}},
+	"SplitTunnelGetStatus":             {params: types.SplitTunnelGetStatus{}, results: []interface{}{types.SplitTunnelStatus{}}},
+	"SplitTunnelSetConfig":             {params: types.SplitTunnelSetConfig{}, results: []interface{}{types.EmptyResp{}}},
+	"SplitTunnelAddApp":                {params: types.SplitTunnelAddApp{}, results: []interface{}{types.EmptyResp{}, types.SplitTunnelAddAppCmdResp{}}},
+	"SplitTunnelRemoveApp":             {params: types.SplitTunnelRemoveApp{}, results: []interface{}{types.EmptyResp{}}},
+	"SplitTunnelAddedPidInfo":          {params: types.SplitTunnelAddedPidInfo{}, results: []interface{}{types.EmptyResp{}}},
+	"GetInstalledApps":                 {params: types.GetInstalledApps{}, results: []interface{}{types.InstalledAppsResp{}}},
+	"GetAppIcon":                       {params: types.GetAppIcon{}, results: []interface{}{types.AppIconResp{}}},
+	"GenerateDiagnostics":              {params: types.EmptyReq{}, results: []interface{}{types.DiagnosticsGeneratedResp{}}},
+	"SetAlternateDns":                  {params: types.SetAlternateDns{}, results: []interface{}{types.EmptyResp{}}},
+	"GetDnsPredefinedConfigs":          {params: types.GetDnsPredefinedConfigs{}, results: []interface{}{types.DnsPredefinedConfigsResp{}}},
+	"PauseConnection":                  {params: types.PauseConnection{}, results: []interface{}{types.ConnectedResp{}}},
+	"ResumeConnection":                 {params: types.ResumeConnection{}, results: []interface{}{types.EmptyResp{}}},
+	"GetConnectionHistory":             {params: types.GetConnectionHistory{}, results: []interface{}{types.ConnectionHistoryResp{}}},
+	"ClearConnectionHistory":           {params: types.ClearConnectionHistory{}, results: []interface{}{types.EmptyResp{}}},
+	"VerifyPin":                        {params: types.VerifyPin{}, results: []interface{}{types.SessionNewResp{}}},
+	"SessionDelete":                    {params: types.SessionDelete{}, results: []interface{}{types.EmptyResp{}}},
+	"AccountStatus":                    {params: types.AccountStatus{}, results: []interface{}{types.AccountStatusResp{}}},
+	"WireGuardGenerateNewKeys":         {params: types.WireGuardGenerateNewKeys{}, results: []interface{}{types.EmptyResp{}}},
+	"WireGuardSetKeysRotationInterval": {params: types.WireGuardSetKeysRotationInterval{}, results: []interface{}{types.EmptyResp{}}},
+	"WiFiSettings":                     {params: types.WiFiSettings{}, results: []interface{}{types.EmptyResp{}}},
+	"NetworkProfilesSettings":          {params: types.NetworkProfilesSettings{}, results: []interface{}{types.EmptyResp{}}},
+	"SchedulerSettings":                {params: types.SchedulerSettings{}, results: []interface{}{types.EmptyResp{}}},
+	"LeakTest":                         {params: types.LeakTest{}, results: []interface{}{types.LeakTestResp{}}, isAsync: true},
+	"GetNetworkIdentity":               {params: types.GetNetworkIdentity{}, results: []interface{}{types.NetworkIdentityResp{}}},
+	"ConnectSettingsGet":               {params: types.ConnectSettingsGet{}, results: []interface{}{types.ConnectSettings{}}},
+	"ConnectSettings":                  {params: types.ConnectSettings{}, results: []interface{}{types.EmptyResp{}}},
+	"Connect":                          {params: types.Connect{}, results: []interface{}{types.EmptyResp{}}},
+	// no result when disconnection was performed (the clients are notified by DisconnectedResp event)
+	"Disconnect": {params: types.Disconnect{}, results: []interface{}{types.DisconnectedResp{}, nil}},
+}
+
+// jsonRPCSchema returns the description of the JSON-RPC API (OpenRPC document: https://spec.open-rpc.org)
+// The schemas of parameters and results are generated from the request/response types.
+func (p *Protocol) jsonRPCSchema() map[string]interface{} {
+	g := jsonrpc.NewSchemaGenerator("#/components/schemas/")
+
+	names := make([]string, 0, len(jsonRPCMethods))
+	for name := range jsonRPCMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	methods := make([]interface{}, 0, len(names)+1)
+	for _, name := range names {
+		m := jsonRPCMethods[name]
+
+		params := make([]interface{}, 0)
+		for _, f := range jsonrpc.Fields(reflect.TypeOf(m.params)) {
+			// the fields are initialized by the gateway
+			if f.Name == "Command" || f.Name == "Idx" {
+				continue
+			}
+			params = append(params, map[string]interface{}{"name": f.Name, "schema": g.Schema(f.Type)})
+		}
+
+		results := make([]interface{}, 0, len(m.results))
+		for _, r := range m.results {
+			if r == nil {
+				results = append(results, jsonrpc.Schema{"type": "null"})
+			} else {
+				results = append(results, g.Schema(reflect.TypeOf(r)))
+			}
+		}
+		result := map[string]interface{}{"name": "result", "schema": results[0]}
+		if len(results) > 1 {
+			result["schema"] = jsonrpc.Schema{"oneOf": results}
+		}
+
+		methods = append(methods, map[string]interface{}{
+			"name":           name,
+			"paramStructure": "by-name",
+			"params":         params,
+			"result":         result,
+		})
+	}
+
+	methods = append(methods, map[string]interface{}{
+		"name":    jsonRPCDiscoverMethod,
+		"summary": "Returns the description of the API (OpenRPC document)",
+		"params":  []interface{}{},
+		"result":  map[string]interface{}{"name": "result", "schema": jsonrpc.Schema{"type": "object"}},
+	})
+
+	// the types of the objects sent only by the events stream
+	for _, evt := range []interface{}{types.SettingsResp{}, types.SetAlternateDNSResp{}, types.WiFiAvailableNetworksResp{}, types.ErrorRespDelayed{}, types.ServiceExitingResp{}} {
+		g.Ref(reflect.TypeOf(evt))
+	}
+
+	return map[string]interface{}{
+		"openrpc": "1.2.6",
+		"info": map[string]interface{}{
+			"title":   "IVPN Daemon",
+			"version": jsonRPCAPIVersion + ".0.0",
+			"description": "The method names and parameters are the same as for the daemon requests. " +
+				"Server-pushed events (the response objects sent to all clients) are available as Server-Sent Events: GET " + jsonRPCEventsPath,
+		},
+		"servers":    []interface{}{map[string]interface{}{"name": "localhost", "url": p.jsonRPCURL(jsonRPCPath)}},
+		"methods":    methods,
+		"components": map[string]interface{}{"schemas": g.Definitions},
+	}
+}
@@ -23,12 +23,14 @@
 package protocol
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/api"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/helpers"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
@@ -39,6 +41,9 @@ import (
 
 // ----------------------------------------------------------------------
 func getConnectionName(c net.Conn) string {
+	if addr := c.RemoteAddr(); addr == nil || addr.Network() == "unix" {
+		return "unix"
+	}
 	return strings.TrimSpace(strings.Replace(c.RemoteAddr().String(), "127.0.0.1:", "", 1))
 }
 
@@ -102,6 +107,7 @@ func (p *Protocol) clientDisconnected(c net.Conn) (disconnectedClientInfo *conne
 	}
 
 	delete(p._connections, c)
+	p._readOnlyConns.Delete(c)
 	c.Close()
 
 	return disconnectedClientInfo
@@ -141,6 +147,8 @@ func (p *Protocol) notifyClientsDaemonExiting() {
 func (p *Protocol) clientSetAuthenticated(c net.Conn) {
 	// contains information about just connected client (first authentication) or nil
 	var justConnectedClientInfo *connectionInfo
+	// 'false' for the connections which are not registered as clients (e.g. requests of the JSON-RPC gateway)
+	isRegisteredClient := false
 
 	// separate anonymous function for correct mutex unlock
 	func() {
@@ -148,6 +156,7 @@ func (p *Protocol) clientSetAuthenticated(c net.Conn) {
 		defer p._connectionsMutex.Unlock()
 
 		if cInfo, ok := p._connections[c]; ok {
+			isRegisteredClient = true
 			if !cInfo.IsAuthenticated {
 				cInfo.IsAuthenticated = true
 				p._connections[c] = cInfo
@@ -163,6 +172,10 @@ func (p *Protocol) clientSetAuthenticated(c net.Conn) {
 		}()
 	}
 
+	if !isRegisteredClient {
+		return
+	}
+
 	if len(p._lastConnectionErrorToNotifyClient) > 0 {
 		log.Info("Sending delayed error to client: ", p._lastConnectionErrorToNotifyClient)
 		delayedErr := types.ErrorRespDelayed{}
@@ -180,7 +193,12 @@ func (p *Protocol) sendError(conn net.Conn, errorText string, cmdIdx int) {
 
 func (p *Protocol) sendErrorResponse(conn net.Conn, request types.RequestBase, err error) {
 	log.Error(fmt.Sprintf("%sError processing request '%s': %s", p.connLogID(conn), request.Command, err))
-	p.sendResponse(conn, &types.ErrorResp{ErrorMessage: helpers.CapitalizeFirstLetter(err.Error())}, request.Idx)
+
+	errType := types.ErrorUnknown
+	if errors.As(err, &api.ErrorConnectivityBlocked{}) {
+		errType = types.ErrorConnectivityBlocked
+	}
+	p.sendResponse(conn, &types.ErrorResp{ErrorMessage: helpers.CapitalizeFirstLetter(err.Error()), ErrorType: errType}, request.Idx)
 }
 
 func (p *Protocol) sendResponse(conn net.Conn, cmd types.ICommandBase, idx int) (retErr error) {
@@ -188,10 +206,19 @@ func (p *Protocol) sendResponse(conn net.Conn, cmd types.ICommandBase, idx int)
 		return fmt.Errorf("%sresponse not sent (no connection to client)", p.connLogID(conn))
 	}
 
+	if p.isReadOnlyClient(conn) {
+		cmd = redactForReadOnlyClient(cmd)
+	}
+
 	if err := types.Send(conn, cmd, idx); err != nil {
 		return fmt.Errorf("%sfailed to send command: %w", p.connLogID(conn), err)
 	}
 
+	// periodic notifications are not logged (to avoid flooding the log)
+	if _, ok := cmd.(*types.TunnelStatsResp); ok {
+		return nil
+	}
+
 	// Just for logging
 	if reqType := types.GetTypeName(cmd); len(reqType) > 0 {
 		log.Info(fmt.Sprintf("[-->] %s", p.connLogID(conn)), reqType, fmt.Sprintf(" [%d]", idx), " ", cmd.LogExtraInfo())
@@ -211,6 +238,8 @@ func (p *Protocol) createSettingsResponse() *types.SettingsResp {
 		UserDefinedOvpnFile:         platform.OpenvpnUserParamsFile(),
 		UserPrefs:                   prefs.UserPrefs,
 		WiFi:                        prefs.WiFiControl,
+		NetworkProfiles:             prefs.NetworkProfiles,
+		Scheduler:                   prefs.Scheduler,
 		IsLogging:                   prefs.IsLogging,
 		AntiTracker:                 p._service.GetAntiTrackerStatus(),
 		// TODO: implement the rest of daemon settings
@@ -262,20 +291,22 @@ func (p *Protocol) createConnectedResponse(state vpn.StateInfo) *types.Connected
 	manualDns := dns.GetLastManualDNS()
 
 	ret := &types.ConnectedResp{
-		TimeSecFrom1970: state.Time,
-		ClientIP:        state.ClientIP.String(),
-		ClientIPv6:      ipv6,
-		ServerIP:        state.ServerIP.String(),
-		ServerPort:      state.ServerPort,
-		VpnType:         state.VpnType,
-		ExitHostname:    state.ExitHostname,
-		Dns:             types.DnsStatus{Dns: manualDns, AntiTrackerStatus: p._service.GetAntiTrackerStatus()},
-		IsTCP:           state.IsTCP,
-		Mtu:             state.Mtu,
-		V2RayProxy:      state.V2RayProxy,
-		Obfsproxy:       state.Obfsproxy,
-		IsPaused:        p._service.IsPaused(),
-		PausedTill:      pausedTillStr,
+		TimeSecFrom1970:    state.Time,
+		ClientIP:           state.ClientIP.String(),
+		ClientIPv6:         ipv6,
+		ServerIP:           state.ServerIP.String(),
+		ServerPort:         state.ServerPort,
+		VpnType:            state.VpnType,
+		ExitHostname:       state.ExitHostname,
+		Dns:                types.DnsStatus{Dns: manualDns, AntiTrackerStatus: p._service.GetAntiTrackerStatus()},
+		IsTCP:              state.IsTCP,
+		Mtu:                state.Mtu,
+		IsQuantumResistant: state.IsQuantumResistant,
+		FallbackAttempt:    state.FallbackAttempt,
+		V2RayProxy:         state.V2RayProxy,
+		Obfsproxy:          state.Obfsproxy,
+		IsPaused:           p._service.IsPaused(),
+		PausedTill:         pausedTillStr,
 	}
 
 	return ret
@@ -0,0 +1,144 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package protocol
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
+)
+
+// DefaultUnixSocketControlGroup - members of this group have full access to the daemon over the Unix domain socket
+const DefaultUnixSocketControlGroup = "vpnusers"
+
+// UnixSocketConfig - access configuration for clients connected over the Unix domain socket (applicable for Linux).
+// The clients are authorized by the credentials of the client process (uid/gid); the privileged user always has full access.
+// Note: the TCP interface (authenticated by the secret from the ServicePortFile()) is still available for compatibility.
+type UnixSocketConfig struct {
+	ControlGroup  string // members of the group have full access
+	ReadOnlyGroup string // members of the group are able only to read the daemon state (e.g. status monitors)
+}
+
+// peerAccess - access rights of the client connected over the Unix domain socket
+type peerAccess struct {
+	description string // info about the client process (for logging)
+	isReadOnly  bool
+	err         error // access denied (when not nil)
+}
+
+// SetUnixSocketConfig sets access configuration for the Unix domain socket.
+// Must be called before Start()
+func (p *Protocol) SetUnixSocketConfig(cfg UnixSocketConfig) {
+	p._unixSocketConfig = cfg
+}
+
+// startUnixSocket starts listening the Unix domain socket (if applicable for the platform)
+func (p *Protocol) startUnixSocket() {
+	sockFile := platform.ServiceSocketFile()
+	if len(sockFile) == 0 {
+		return
+	}
+
+	listener, err := listenUnixSocket(sockFile)
+	if err != nil {
+		log.Error(fmt.Errorf("failed to start Unix domain socket listener: %w", err))
+		return
+	}
+	p._unixListener = listener
+	log.Info(fmt.Sprintf("Unix domain socket listener started: '%s' (control group: '%s'; read-only group: '%s')",
+		sockFile, p._unixSocketConfig.ControlGroup, p._unixSocketConfig.ReadOnlyGroup))
+
+	go func() {
+		defer func() {
+			listener.Close()
+			os.Remove(sockFile)
+		}()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if p._isRunning {
+					log.Error("Server: failed to accept incoming Unix socket connection:", err)
+				}
+				return
+			}
+			go p.processClient(conn, getPeerAccess(conn, p._unixSocketConfig))
+		}
+	}()
+}
+
+// isReadOnlyRequest returns 'true' for requests which are allowed for clients with read-only access
+func isReadOnlyRequest(commandName string) bool {
+	switch commandName {
+	case "Hello",
+		"EmptyReq",
+		"GetVPNState",
+		"GetTunnelStats",
+		"GetServers",
+		"PingServers",
+		"RecommendServers",
+		"GetGeoLookup",
+		"GetAppUpdateInfo",
+		"WiFiAvailableNetworks",
+		"WiFiCurrentNetwork",
+		"GetNetworkIdentity",
+		"LeakTest",
+		"KillSwitchGetStatus",
+		"SplitTunnelGetStatus",
+		"GetDnsPredefinedConfigs",
+		"GetConnectionHistory",
+		"ConnectSettingsGet",
+		"AccountStatus":
+		return true
+	}
+	return false
+}
+
+func (p *Protocol) isReadOnlyClient(c net.Conn) bool {
+	_, ok := p._readOnlyConns.Load(c)
+	return ok
+}
+
+// redactForReadOnlyClient returns a copy of the response without the account credentials (session token etc.)
+// The clients with read-only access must not get the account credentials.
+func redactForReadOnlyClient(cmd types.ICommandBase) types.ICommandBase {
+	switch c := cmd.(type) {
+	case *types.HelloResp:
+		h := *c
+		h.Session = types.SessionResp{}
+		return &h
+	case *types.AccountStatusResp:
+		a := *c
+		a.SessionToken = ""
+		return &a
+	case *types.SessionNewResp:
+		s := *c
+		s.Session = types.SessionResp{}
+		s.RawResponse = ""
+		return &s
+	}
+	return cmd
+}
@@ -0,0 +1,93 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build linux
+// +build linux
+
+package protocol
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/oshelpers/linux/peercred"
+)
+
+func listenUnixSocket(sockFile string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(sockFile), 0755); err != nil {
+		return nil, err
+	}
+	// remove the socket file left by the previous daemon instance
+	if err := os.Remove(sockFile); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", sockFile)
+	if err != nil {
+		return nil, err
+	}
+	// any local user is able to connect: the access is checked by the credentials of the client process
+	if err := os.Chmod(sockFile, 0666); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// getPeerAccess returns access rights of the client process (SO_PEERCRED)
+// The group names are resolved on each connection: the groups can be created (or changed) when the daemon is already running
+func getPeerAccess(conn net.Conn, cfg UnixSocketConfig) *peerAccess {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return &peerAccess{err: fmt.Errorf("unexpected connection type")}
+	}
+	cred, err := peercred.Get(uc)
+	if err != nil {
+		return &peerAccess{err: fmt.Errorf("unable to get credentials of the client: %w", err)}
+	}
+
+	ret := &peerAccess{description: fmt.Sprintf("pid:%d uid:%d", cred.Pid, cred.Uid)}
+	isMemberOf := func(groupName string) bool {
+		if len(groupName) == 0 {
+			return false
+		}
+		g, err := user.LookupGroup(groupName)
+		if err != nil {
+			return false
+		}
+		gid, err := strconv.Atoi(g.Gid)
+		return err == nil && cred.IsMemberOf(gid)
+	}
+
+	switch {
+	case cred.Uid == 0 || isMemberOf(cfg.ControlGroup):
+	case isMemberOf(cfg.ReadOnlyGroup):
+		ret.isReadOnly = true
+	default:
+		ret.err = fmt.Errorf("access denied: the user is not a member of '%s' group", cfg.ControlGroup)
+	}
+	return ret
+}
@@ -0,0 +1,39 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build !linux
+// +build !linux
+
+package protocol
+
+import (
+	"fmt"
+	"net"
+)
+
+func listenUnixSocket(sockFile string) (net.Listener, error) {
+	return nil, fmt.Errorf("Unix domain socket interface is not applicable for this platform")
+}
+
+func getPeerAccess(conn net.Conn, cfg UnixSocketConfig) *peerAccess {
+	return &peerAccess{err: fmt.Errorf("Unix domain socket interface is not applicable for this platform")}
+}
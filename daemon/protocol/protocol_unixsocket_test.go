@@ -0,0 +1,80 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package protocol
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+)
+
+const testSessionToken = "test-session-token-0123456789"
+
+// sends the response over the connection and returns the data received by the client
+func sendAndReceive(t *testing.T, p *Protocol, conn, clientSide net.Conn, cmd types.ICommandBase) string {
+	received := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(clientSide).ReadString('\n')
+		received <- line
+	}()
+	if err := p.sendResponse(conn, cmd, 1); err != nil {
+		t.Fatal(err)
+	}
+	return <-received
+}
+
+func TestReadOnlyClientNeverGetsSessionToken(t *testing.T) {
+	responses := []types.ICommandBase{
+		&types.HelloResp{Session: types.SessionResp{Session: testSessionToken}},
+		&types.AccountStatusResp{SessionToken: testSessionToken},
+		&types.SessionNewResp{Session: types.SessionResp{Session: testSessionToken}, RawResponse: `{"token":"` + testSessionToken + `"}`},
+	}
+
+	p := &Protocol{}
+	for _, cmd := range responses {
+		conn, clientSide := net.Pipe()
+
+		// full access: the response is not modified
+		if data := sendAndReceive(t, p, conn, clientSide, cmd); !strings.Contains(data, testSessionToken) {
+			t.Errorf("%s: session token expected for the client with full access", types.GetTypeName(cmd))
+		}
+
+		// read-only access
+		p._readOnlyConns.Store(conn, struct{}{})
+		if data := sendAndReceive(t, p, conn, clientSide, cmd); strings.Contains(data, testSessionToken) {
+			t.Errorf("%s: session token sent to the read-only client: %s", types.GetTypeName(cmd), data)
+		}
+		p._readOnlyConns.Delete(conn)
+
+		conn.Close()
+		clientSide.Close()
+	}
+
+	// the original objects are not modified (they can be sent to other clients)
+	if responses[1].(*types.AccountStatusResp).SessionToken != testSessionToken {
+		t.Error("original response object was modified")
+	}
+}
@@ -25,6 +25,7 @@ package types
 import (
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/preferences"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/serverscore"
 	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 )
@@ -93,6 +94,16 @@ type PingServers struct {
 	SkipSecondPhase       bool
 }
 
+// RecommendServers request the servers ranked by the composite score
+// (latency, distance, success rate of the previous connections and the server load)
+type RecommendServers struct {
+	RequestBase
+	VpnType     vpn.Type
+	Constraints serverscore.Constraints
+	// Max number of servers to return (0 - all applicable servers)
+	Count int
+}
+
 // KillSwitchSetAllowLANMulticast enable\disable LAN multicast acces for kill-switch
 type KillSwitchSetAllowLANMulticast struct {
 	RequestBase
@@ -177,6 +188,28 @@ type WiFiSettings struct {
 	Params preferences.WiFiParams
 }
 
+// NetworkProfilesSettings - set network profiles configuration
+type NetworkProfilesSettings struct {
+	RequestBase
+	Params preferences.NetworkProfilesParams
+}
+
+// SchedulerSettings - set configuration of the time-based connection schedules
+type SchedulerSettings struct {
+	RequestBase
+	Params preferences.SchedulerParams
+}
+
+// LeakTest - check the current configuration for DNS and IP leaks
+type LeakTest struct {
+	RequestBase
+}
+
+// GetNetworkIdentity - request the identity of the current network (and the matching network profile)
+type GetNetworkIdentity struct {
+	RequestBase
+}
+
 // ConnectSettings contains same data as 'Connect' request but this command not start the connection.
 // UI/CLI client have to notify daemon about changes in connection settings.
 // It is required:
@@ -209,6 +242,12 @@ type GetVPNState struct {
 	RequestBase
 }
 
+// GetTunnelStats request live statistics of the active VPN tunnel
+// (while connected, the statistics is also sent to clients periodically by the daemon)
+type GetTunnelStats struct {
+	RequestBase
+}
+
 type PauseConnection struct {
 	RequestBase
 	Duration uint32 // seconds
@@ -218,6 +257,17 @@ type ResumeConnection struct {
 	RequestBase
 }
 
+// GetConnectionHistory request the connection history (journal of VPN state transitions)
+type GetConnectionHistory struct {
+	RequestBase
+	Count int // number of the latest entries to return (0 - all available entries)
+}
+
+// ClearConnectionHistory erase the connection history
+type ClearConnectionHistory struct {
+	RequestBase
+}
+
 // VerifyPin - create new session
 //
 // When force is set to true - all active sessions will be deleted prior to creating a new one if user reached session limit.
@@ -271,6 +321,27 @@ type APIRequest struct {
 	IPProtocolRequired RequiredIPProtocol
 }
 
+// GetGeoLookup request to get the latest known geolocation (public IP address info)
+type GetGeoLookup struct {
+	RequestBase
+}
+
+// AppUpdateType - type of the application update info
+type AppUpdateType string
+
+const (
+	AppUpdateGeneric AppUpdateType = ""       // stable releases
+	AppUpdateManual  AppUpdateType = "manual" // releases which are not offered for automatic update
+	AppUpdateBeta    AppUpdateType = "beta"   // beta releases
+)
+
+// GetAppUpdateInfo request to get information about the latest application version
+// (the signature of the update info is verified by the daemon)
+type GetAppUpdateInfo struct {
+	RequestBase
+	UpdateType AppUpdateType
+}
+
 // paranoid mode
 
 type ParanoidModeSetPasswordReq struct {
@@ -29,7 +29,10 @@ import (
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/logger"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/obfsproxy"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/history"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/leaktest"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/preferences"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/serverscore"
 	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/v2r"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
@@ -46,6 +49,7 @@ type ErrorType int
 const (
 	ErrorUnknown                   ErrorType = iota
 	ErrorParanoidModePasswordError ErrorType = iota
+	ErrorConnectivityBlocked       ErrorType = iota // API request is blocked by the firewall
 )
 
 // ErrorResp response of error
@@ -87,6 +91,10 @@ type DisabledFunctionalityLinux struct {
 	//	- there is no 'resolvectl' binary on target system
 	//	- 'resolvectl' initialisation try was failed
 	DnsMgmtNewResolvectlError string
+
+	// If not empty - it is not possible to use native nftables firewall backend
+	// (e.g. kernel compiled without nftables support)
+	FwNftablesError string
 }
 
 type DisabledFunctionalityForPlatform struct {
@@ -130,6 +138,8 @@ type SettingsResp struct {
 	UserDefinedOvpnFile         string
 	UserPrefs                   preferences.UserPreferences
 	WiFi                        preferences.WiFiParams
+	NetworkProfiles             preferences.NetworkProfilesParams
+	Scheduler                   preferences.SchedulerParams
 	IsLogging                   bool
 	AntiTracker                 service_types.AntiTrackerMetadata
 
@@ -216,6 +226,12 @@ type KillSwitchGetIsPestistentResp struct {
 	IsPersistent bool
 }
 
+// ConnectionHistoryResp contains the connection history entries (the newest entry is the first one)
+type ConnectionHistoryResp struct {
+	CommandBase
+	Entries []history.Entry
+}
+
 // DiagnosticsGeneratedResp returns info from daemon logs
 type DiagnosticsGeneratedResp struct {
 	CommandBase
@@ -244,20 +260,66 @@ type DnsPredefinedConfigsResp struct {
 // ConnectedResp notifying about established connection
 type ConnectedResp struct {
 	CommandBase
-	VpnType         vpn.Type
-	TimeSecFrom1970 int64
-	ClientIP        string
-	ClientIPv6      string
-	ServerIP        string
-	ServerPort      int
-	ExitHostname    string // multi-hop exit hostname (e.g. "us-tx1.wg.ivpn.net")
-	Dns             DnsStatus
-	IsTCP           bool
-	Mtu             int                    // (for WireGuard connections)
-	V2RayProxy      v2r.V2RayTransportType // applicable only for 'CONNECTED' state
-	Obfsproxy       obfsproxy.Config       // applicable only for 'CONNECTED' state (OpenVPN)
-	IsPaused        bool                   // When "true" - the actual connection may be "disconnected" (depending on the platform and VPN protocol), but the daemon responds "connected"
-	PausedTill      string                 // pausedTill.Format(time.RFC3339)
+	VpnType            vpn.Type
+	TimeSecFrom1970    int64
+	ClientIP           string
+	ClientIPv6         string
+	ServerIP           string
+	ServerPort         int
+	ExitHostname       string // multi-hop exit hostname (e.g. "us-tx1.wg.ivpn.net")
+	Dns                DnsStatus
+	IsTCP              bool
+	Mtu                int                    // (for WireGuard connections)
+	IsQuantumResistant bool                   // (for WireGuard connections) PresharedKey exchanged using post-quantum KEM is in use
+	FallbackAttempt    string                 // the connection fallback attempt which succeeded (empty if the fallback is not in use)
+	V2RayProxy         v2r.V2RayTransportType // applicable only for 'CONNECTED' state
+	Obfsproxy          obfsproxy.Config       // applicable only for 'CONNECTED' state (OpenVPN)
+	IsPaused           bool                   // When "true" - the actual connection may be "disconnected" (depending on the platform and VPN protocol), but the daemon responds "connected"
+	PausedTill         string                 // pausedTill.Format(time.RFC3339)
+}
+
+// TunnelStatsResp contains live statistics of the active VPN tunnel
+// (while connected, it is sent to clients periodically)
+type TunnelStatsResp struct {
+	CommandBase
+	RxBytes                  uint64
+	TxBytes                  uint64
+	LastHandshakeSecFrom1970 int64 // time of the latest handshake (WireGuard only; 0 - when not applicable)
+	Endpoint                 string
+	Mtu                      int
+}
+
+// CreateTunnelStatsResp creates TunnelStatsResp object
+func CreateTunnelStatsResp(stats vpn.Statistics) *TunnelStatsResp {
+	ret := &TunnelStatsResp{
+		RxBytes:  stats.RxBytes,
+		TxBytes:  stats.TxBytes,
+		Endpoint: stats.Endpoint,
+		Mtu:      stats.Mtu,
+	}
+	if !stats.LastHandshake.IsZero() {
+		ret.LastHandshakeSecFrom1970 = stats.LastHandshake.Unix()
+	}
+	return ret
+}
+
+// GeoLookupResp contains the latest known geolocation (public IP address info) for each IP protocol.
+// It is sent to clients each time the geolocation was updated (e.g. after connection/disconnection)
+type GeoLookupResp struct {
+	CommandBase
+	Location     *types.GeoLookupResponse `json:",omitempty"` // nil - if not available
+	LocationIPv6 *types.GeoLookupResponse `json:",omitempty"` // nil - if not available
+}
+
+func (r GeoLookupResp) LogExtraInfo() string {
+	ret := ""
+	if r.Location != nil {
+		ret += fmt.Sprintf("IPv4:(VPN:%v) ", r.Location.IsIvpnServer)
+	}
+	if r.LocationIPv6 != nil {
+		ret += fmt.Sprintf("IPv6:(VPN:%v)", r.LocationIPv6.IsIvpnServer)
+	}
+	return ret
 }
 
 // DisconnectionReason - disconnection reason
@@ -268,6 +330,7 @@ const (
 	Unknown             DisconnectionReason = iota
 	AuthenticationError DisconnectionReason = iota
 	DisconnectRequested DisconnectionReason = iota
+	TunnelUnhealthy     DisconnectionReason = iota // the tunnel health monitor failed to find a healthy host
 )
 
 // DisconnectedResp notifying about stopped connetion
@@ -306,6 +369,12 @@ type PingServersResp struct {
 	PingResults []PingResultType
 }
 
+// RecommendServersResp contains the servers ranked by the composite score (the best first)
+type RecommendServersResp struct {
+	CommandBase
+	Candidates []serverscore.Candidate
+}
+
 // WiFiNetworkInfo - information about WIFI network
 type WiFiNetworkInfo struct {
 	SSID string
@@ -324,6 +393,19 @@ type WiFiCurrentNetworkResp struct {
 	IsInsecureNetwork bool
 }
 
+// LeakTestResp contains the result of the DNS and IP leak test
+type LeakTestResp struct {
+	CommandBase
+	Result leaktest.Result
+}
+
+// NetworkIdentityResp contains the identity of the current network
+type NetworkIdentityResp struct {
+	CommandBase
+	Identity preferences.NetworkIdentity
+	Profile  string // name of the matching network profile (empty - no matching profile)
+}
+
 // APIResponse contains the raw data of response to custom API request
 type APIResponse struct {
 	CommandBase
@@ -338,3 +420,18 @@ func (r APIResponse) LogExtraInfo() string {
 	}
 	return fmt.Sprint(r.APIPath)
 }
+
+// AppUpdateInfoResp contains information about the latest application version.
+// The update info is received only if its signature is valid.
+type AppUpdateInfoResp struct {
+	CommandBase
+	UpdateType        AppUpdateType
+	UpdateInfo        types.UpdateInfo
+	CurrentVersion    string // version of the daemon
+	LatestVersion     string
+	IsUpdateAvailable bool
+}
+
+func (r AppUpdateInfoResp) LogExtraInfo() string {
+	return fmt.Sprintf("current:%s latest:%s", r.CurrentVersion, r.LatestVersion)
+}
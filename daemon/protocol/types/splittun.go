@@ -71,6 +71,14 @@ type SplitTunnelSetConfig struct {
 	IsAnyDns         bool // (only for Inverse Split Tunnel) When false: Allow only DNS servers specified by the IVPN application
 	IsAllowWhenNoVpn bool // (only for Inverse Split Tunnel) Allow connectivity for Split Tunnel apps when VPN is disabled
 	Reset            bool // disable ST and erase all ST config (if enabled - all the rest paremeters are ignored)
+	// (applicable for Linux) Rules for destination addresses (IP, subnet, domain name or wildcard domain '*.example.com'):
+	// bypass the VPN or forced through the VPN.
+	// nil - keep the current rules (e.g. the client is not aware of destination rules); empty list - remove all rules
+	Destinations []splittun.DestinationRule
+	// (applicable for Linux) Application rules: full paths to binaries or binary names (matched against the executable file of the process).
+	// Matching processes are moved into the Split Tunnel environment automatically when started.
+	// nil - keep the current rules; empty list - remove all rules
+	SplitTunnelApps []string
 }
 
 // GetSplitTunnelStatus (request) requests the Split-Tunnelling configuration
@@ -92,11 +100,14 @@ type SplitTunnelStatus struct {
 	// (true - if commands GetAppIcon/AppIconResp  applicable for this platform)
 	IsCanGetAppIconForBinary bool
 	// Information about applications added to ST configuration
-	// (applicable for Windows)
+	// (Windows: binaries excluded from the VPN; Linux: application rules)
 	SplitTunnelApps []string
 	// Information about active applications running in Split-Tunnel environment
 	// (applicable for Linux)
 	RunningApps []splittun.RunningApp
+	// Rules for destination addresses and their state (resolved addresses of domain names)
+	// (applicable for Linux)
+	Destinations []splittun.DestinationStatus
 }
 
 // SplitTunnelAddApp (request) add application to SplitTunneling
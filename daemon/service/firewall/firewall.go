@@ -57,12 +57,44 @@ var (
 	stateAllowLanMulticast bool
 )
 
+type FuncGetExtraSettings func() ExtraSettings
+
+// ExtraSettings - platform-specific firewall settings defined by the user
+type ExtraSettings struct {
+	// If true - use native nftables firewall backend
+	// instead of the 'firewall.sh' script (iptables)
+	Linux_IsNftablesBackend bool
+}
+
+var funcGetExtraSettings FuncGetExtraSettings
+
+func getExtraSettings() ExtraSettings {
+	if funcGetExtraSettings != nil {
+		return funcGetExtraSettings()
+	}
+	return ExtraSettings{}
+}
+
 // Initialize is doing initialization stuff
 // Must be called on application start
-func Initialize() error {
+func Initialize(getExtraSettingsFunc FuncGetExtraSettings) error {
+	funcGetExtraSettings = getExtraSettingsFunc
 	return implInitialize()
 }
 
+// ApplyUserSettings - reinitialize firewall according to user settings
+// (e.g. Linux: when the user changed the firewall backend)
+func ApplyUserSettings() error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	err := implApplyUserSettings()
+	if err != nil {
+		log.Error(err)
+	}
+	return err
+}
+
 // SetEnabled - change firewall state
 func SetEnabled(enable bool) error {
 	mutex.Lock()
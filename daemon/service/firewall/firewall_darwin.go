@@ -50,6 +50,10 @@ func init() {
 
 func implInitialize() error { return nil }
 
+func implApplyUserSettings() error {
+	return nil // nothing to do here for current platform
+}
+
 func implGetEnabled() (bool, error) {
 	err := shell.Exec(nil, platform.FirewallScript(), "-status")
 
@@ -25,15 +25,37 @@ package firewall
 import (
 	"fmt"
 	"net"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/netinfo"
-	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
-	"github.com/tahirmahm123/vpn-desktop-app/daemon/shell"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/firewall/nftables"
 )
 
+// linuxBackend - the low-level implementation which applies firewall rules to the system.
+// There are two implementations:
+//   - scriptBackend: rules are managed by the 'firewall.sh' script (iptables);
+//   - nftBackend: rules are managed natively over netlink (nftables).
+//
+// The backend selection is defined by the user preferences (see ExtraSettings.Linux_IsNftablesBackend).
+// The script backend is in use as a fallback when nftables is not available.
+type linuxBackend interface {
+	name() string
+	isNftables() bool
+	initialize() error
+	getEnabled() (bool, error)
+	enable() error
+	disable() error
+	connected(ifaceName string, clientLocalIP net.IP, clientPort int, serverIP net.IP, serverPort int, isTCP bool) error
+	disconnected() error
+	addExceptions(hostsIPs []string, isPersistant bool, onlyForICMP bool) error
+	removeExceptions(hostsIPs []string, isPersistant bool, onlyForICMP bool) error
+	setDNS(addr net.IP) error
+	setUserExceptions(masks []string, isIPv6 bool) error
+	singleDnsRuleOn(dnsAddr net.IP, exceptions []string) error
+	singleDnsRuleOff() error
+}
+
 var (
 	// key: is a string representation of allowed IP
 	// value: true - if exception rule is persistant (persistant, means will stay available even client is disconnected)
@@ -45,40 +67,111 @@ var (
 	curStateAllowLanMulticast bool     // Allow Multicast is enabled
 	curStateEnabled           bool     // Firewall is enabled
 	isPersistant              bool     // Firewall is persistant
+	curSingleDnsRule          net.IP   // DNS address allowed by 'single DNS rule' (nil - rule is not active)
 	mutexInternal             sync.Mutex
+
+	backend linuxBackend = &scriptBackend{}
 )
 
 func init() {
 	allowedHosts = make(map[string]bool)
 }
 
+// NftablesCheck returns error if the native nftables backend can not be used on the current system
+func NftablesCheck() error {
+	return nftables.Check()
+}
+
 func implInitialize() error {
+	newBackend := createBackend(getExtraSettings().Linux_IsNftablesBackend)
+
+	// Remove rules which could stay active from the previous daemon run with another backend
+	// (e.g. persistant firewall was enabled before user changed the backend)
+	if newBackend.isNftables() {
+		cleanupBackend(&scriptBackend{})
+	} else if err := nftables.Check(); err == nil {
+		cleanupBackend(newNftBackend())
+	}
+
+	backend = newBackend
 	return nil
 }
 
-func implGetEnabled() (bool, error) {
-	err := shell.Exec(nil, platform.FirewallScript(), "-status")
+func implApplyUserSettings() error {
+	isNftRequired := getExtraSettings().Linux_IsNftablesBackend
+	if isNftRequired == backend.isNftables() {
+		return nil // expected configuration already applied
+	}
 
-	if err != nil {
-		exitCode, err := shell.GetCmdExitCode(err)
-		if err != nil {
-			return false, fmt.Errorf("failed to get Cmd exit code: %w", err)
+	newBackend := createBackend(isNftRequired)
+	if newBackend.isNftables() == backend.isNftables() {
+		return nil // nothing changed (the required backend is not available)
+	}
+
+	// remove rules applied by the current backend
+	if curStateEnabled {
+		if err := backend.disable(); err != nil {
+			return fmt.Errorf("failed to disable firewall rules (%s): %w", backend.name(), err)
+		}
+	} else if curSingleDnsRule != nil {
+		if err := backend.singleDnsRuleOff(); err != nil {
+			return fmt.Errorf("failed to disable DNS rule (%s): %w", backend.name(), err)
 		}
-		if exitCode == 0 {
-			return true, nil
+	}
+
+	backend = newBackend
+
+	// apply current configuration using new backend
+	if curStateEnabled {
+		if err := implSetEnabled(true); err != nil {
+			return err
+		}
+		if connectedClientInterfaceIP != nil && !isClientPaused {
+			return implClientConnected(connectedClientInterfaceIP, connectedClientInterfaceIPv6, connectedClientPort, connectedHostIP, connectedHostPort, connectedIsTCP)
+		}
+	} else if curSingleDnsRule != nil {
+		return implSingleDnsRuleOn(curSingleDnsRule)
+	}
+	return nil
+}
+
+func createBackend(isNftRequired bool) linuxBackend {
+	if isNftRequired {
+		b := newNftBackend()
+		err := b.initialize()
+		if err == nil {
+			log.Info("Using firewall backend: ", b.name())
+			return b
 		}
-		return false, nil
+		log.Warning(fmt.Sprintf("Native nftables backend is not available (using 'firewall.sh' as fallback): %s", err))
+	}
+
+	b := &scriptBackend{}
+	log.Info("Using firewall backend: ", b.name())
+	return b
+}
+
+func cleanupBackend(b linuxBackend) {
+	if enabled, err := b.getEnabled(); err != nil || !enabled {
+		return
+	}
+	log.Info(fmt.Sprintf("Removing firewall rules applied by '%s' backend", b.name()))
+	if err := b.disable(); err != nil {
+		log.Error(err)
 	}
-	return true, nil
+}
+
+func implGetEnabled() (bool, error) {
+	return backend.getEnabled()
 }
 
 func implSetEnabled(isEnabled bool) error {
 	curStateEnabled = isEnabled
+	curSingleDnsRule = nil // enabling/disabling firewall also removes 'single DNS rule'
 
 	if isEnabled {
-		err := shell.Exec(nil, platform.FirewallScript(), "-enable")
-		if err != nil {
-			return fmt.Errorf("failed to execute shell command: %w", err)
+		if err := backend.enable(); err != nil {
+			return err
 		}
 
 		// To fulfill such flow (example): Connected -> FWDisable -> FWEnable
@@ -90,7 +183,7 @@ func implSetEnabled(isEnabled bool) error {
 	curAllowedLanIPs = nil // forget allowed LAN IP addresses
 	isPersistant = false
 	allowedForICMP = nil
-	return shell.Exec(nil, platform.FirewallScript(), "-disable")
+	return backend.disable()
 }
 
 func implSetPersistant(persistant bool) error {
@@ -145,18 +238,7 @@ func implClientConnected(clientLocalIPAddress net.IP, clientLocalIPv6Address net
 		return fmt.Errorf("failed to get local interface by IP: %w", err)
 	}
 
-	protocol := "udp"
-	if isTCP {
-		protocol = "tcp"
-	}
-	scriptArgs := fmt.Sprintf("-connected %s %s %d %s %d %s",
-		inf.Name,
-		clientLocalIPAddress,
-		clientPort,
-		serverIP,
-		serverPort,
-		protocol)
-	err = shell.Exec(nil, platform.FirewallScript(), scriptArgs)
+	err = backend.connected(inf.Name, clientLocalIPAddress, clientPort, serverIP, serverPort, isTCP)
 	if err != nil {
 		return fmt.Errorf("failed to add rule for current connection directions: %w", err)
 	}
@@ -174,7 +256,7 @@ func implClientDisconnected() error {
 		log.Error(err)
 	}
 
-	return shell.Exec(nil, platform.FirewallScript(), "-disconnected")
+	return backend.disconnected()
 }
 
 func implAllowLAN(isAllowLAN bool, isAllowLanMulticast bool) error {
@@ -266,7 +348,7 @@ func implOnChangeDNS(addr net.IP) error {
 	}
 
 	log.Info("-set_dns", " ", addrStr)
-	return shell.Exec(nil, platform.FirewallScript(), "-set_dns", addrStr)
+	return backend.setDNS(addr)
 }
 
 // implOnUserExceptionsUpdated() called when 'userExceptions' value were updated. Necessary to update firewall rules.
@@ -280,20 +362,7 @@ func implOnUserExceptionsUpdated() error {
 			expMasks = append(expMasks, mask.String())
 		}
 
-		scriptCommand := "-set_user_exceptions_static"
-		if !isIpv4 {
-			scriptCommand = "-set_user_exceptions_static_ipv6"
-		}
-
-		ipList := strings.Join(expMasks, ",")
-
-		if len(ipList) > 250 {
-			log.Info(scriptCommand, " <...multiple addresses...>")
-		} else {
-			log.Info(scriptCommand, " ", ipList)
-		}
-
-		return shell.Exec(nil, platform.FirewallScript(), scriptCommand, ipList)
+		return backend.setUserExceptions(expMasks, !isIpv4)
 	}
 
 	err := applyFunc(false)
@@ -305,64 +374,34 @@ func implOnUserExceptionsUpdated() error {
 }
 
 func implSingleDnsRuleOff() (retErr error) {
-	return shell.Exec(log, platform.FirewallScript(), "-only_dns_off")
+	curSingleDnsRule = nil
+	return backend.singleDnsRuleOff()
 }
 
 func implSingleDnsRuleOn(dnsAddr net.IP) (retErr error) {
-	exceptions := ""
-	if prioritized, _ := getAllowedIpExceptions(); len(prioritized) > 0 {
-		exceptions = strings.Join(prioritized, ",")
-	}
+	prioritized, _ := getAllowedIpExceptions()
 
-	return shell.Exec(log, platform.FirewallScript(), "-only_dns", dnsAddr.String(), exceptions)
+	if err := backend.singleDnsRuleOn(dnsAddr, prioritized); err != nil {
+		return err
+	}
+	curSingleDnsRule = dnsAddr
+	return nil
 }
 
 //---------------------------------------------------------------------
 
 func applyAddHostsToExceptions(hostsIPs []string, isPersistant bool, onlyForICMP bool) error {
-	ipList := strings.Join(hostsIPs, ",")
-
-	if len(ipList) > 0 {
-		scriptCommand := "-add_exceptions"
-
-		if onlyForICMP {
-			scriptCommand = "-add_exceptions_icmp"
-		} else if isPersistant {
-			scriptCommand = "-add_exceptions_static"
-		}
-
-		if len(ipList) > 250 {
-			log.Info(scriptCommand, " <...multiple addresses...>")
-		} else {
-			log.Info(scriptCommand, " ", ipList)
-		}
-
-		return shell.Exec(nil, platform.FirewallScript(), scriptCommand, ipList)
+	if len(hostsIPs) == 0 {
+		return nil
 	}
-	return nil
+	return backend.addExceptions(hostsIPs, isPersistant, onlyForICMP)
 }
 
 func applyRemoveHostsFromExceptions(hostsIPs []string, isPersistant bool, onlyForICMP bool) error {
-	ipList := strings.Join(hostsIPs, ",")
-
-	if len(ipList) > 0 {
-		scriptCommand := "-remove_exceptions"
-
-		if onlyForICMP {
-			scriptCommand = "-remove_exceptions_icmp"
-		} else if isPersistant {
-			scriptCommand = "-remove_exceptions_static"
-		}
-
-		if len(ipList) > 250 {
-			log.Info(scriptCommand, " <...multiple addresses...>")
-		} else {
-			log.Info(scriptCommand, " ", ipList)
-		}
-
-		return shell.Exec(nil, platform.FirewallScript(), scriptCommand, ipList)
+	if len(hostsIPs) == 0 {
+		return nil
 	}
-	return nil
+	return backend.removeExceptions(hostsIPs, isPersistant, onlyForICMP)
 }
 
 func reApplyExceptions() error {
@@ -0,0 +1,424 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/firewall/nftables"
+)
+
+// Useful commands for testing:
+// sudo nft list table inet ivpn
+
+const (
+	nftTableName        = "ivpn"
+	nftChainInput       = "input"
+	nftChainOutput      = "output"
+	nftChainForward     = "forward"
+	nftChainOnlyDnsName = "output_dnsonly"
+
+	// The 'mark' value for packets coming from the Split-Tunneling environment
+	// (the same as WireGuard marking packets which were processed)
+	nftSplitTunFwmark = 0xca6c
+	// Split Tunnel cgroup classid
+	nftSplitTunCgroupClassid = 0x4956504e
+
+	icmpEchoReply   = 0
+	icmpEchoRequest = 8
+)
+
+type nftConnectionInfo struct {
+	serverIP   net.IP
+	serverPort int
+	isTCP      bool
+}
+
+// nftBackend - firewall rules are managed natively over netlink (nftables).
+// The backend keeps the full firewall configuration in memory.
+// Each change re-generates the IVPN table and replaces it in a single atomic transaction,
+// so there is no moment when the rules are partially applied.
+type nftBackend struct {
+	isEnabled bool
+
+	vpnIface string             // VPN interface name (when connected)
+	vpnConn  *nftConnectionInfo // connection to the VPN server (when connected)
+
+	exceptions       map[string]struct{} // non-persistant exceptions (have highest priority; not blocked by DNS rules)
+	exceptionsStatic map[string]struct{} // persistant exceptions (e.g. 'Allow LAN')
+	exceptionsICMP   map[string]struct{} // allowed only for ICMP (e.g. ping VPN servers)
+	userExceptions   map[bool][]string   // key: isIPv6; value: IP masks defined by the user
+
+	dns net.IP // the only DNS server allowed (if nil - all DNS requests are blocked)
+
+	singleDns           net.IP   // 'single DNS rule' (applicable only when firewall is disabled)
+	singleDnsExceptions []string // hosts allowed to be accessed by port 53 in 'single DNS rule' mode
+}
+
+func newNftBackend() *nftBackend {
+	b := &nftBackend{}
+	b.reset()
+	return b
+}
+
+func (b *nftBackend) reset() {
+	b.vpnIface = ""
+	b.vpnConn = nil
+	b.exceptions = make(map[string]struct{})
+	b.exceptionsStatic = make(map[string]struct{})
+	b.exceptionsICMP = make(map[string]struct{})
+	b.userExceptions = make(map[bool][]string)
+	b.dns = nil
+	b.singleDns = nil
+	b.singleDnsExceptions = nil
+}
+
+func (b *nftBackend) name() string { return "nftables" }
+
+func (b *nftBackend) isNftables() bool { return true }
+
+func (b *nftBackend) initialize() error {
+	return nftables.Check()
+}
+
+func (b *nftBackend) getEnabled() (bool, error) {
+	return nftables.IsChainExists(nftables.FamilyINet, nftTableName, nftChainOutput)
+}
+
+func (b *nftBackend) enable() error {
+	b.isEnabled = true
+	b.singleDns = nil
+	b.singleDnsExceptions = nil
+	return b.apply()
+}
+
+func (b *nftBackend) disable() error {
+	b.isEnabled = false
+	b.reset()
+	return b.apply()
+}
+
+func (b *nftBackend) connected(ifaceName string, clientLocalIP net.IP, clientPort int, serverIP net.IP, serverPort int, isTCP bool) error {
+	if !b.isEnabled {
+		return nil
+	}
+	b.vpnIface = ifaceName
+	b.vpnConn = &nftConnectionInfo{serverIP: serverIP, serverPort: serverPort, isTCP: isTCP}
+	return b.apply()
+}
+
+func (b *nftBackend) disconnected() error {
+	if !b.isEnabled {
+		return nil
+	}
+	b.vpnIface = ""
+	b.vpnConn = nil
+	b.exceptions = make(map[string]struct{})
+	return b.apply()
+}
+
+func (b *nftBackend) addExceptions(hostsIPs []string, isPersistant bool, onlyForICMP bool) error {
+	exceptions := b.exceptionsMap(isPersistant, onlyForICMP)
+	if exceptions == nil {
+		return nil
+	}
+	for _, ip := range hostsIPs {
+		exceptions[ip] = struct{}{}
+	}
+	return b.applyIfEnabled()
+}
+
+func (b *nftBackend) removeExceptions(hostsIPs []string, isPersistant bool, onlyForICMP bool) error {
+	exceptions := b.exceptionsMap(isPersistant, onlyForICMP)
+	if exceptions == nil {
+		return nil
+	}
+	for _, ip := range hostsIPs {
+		delete(exceptions, ip)
+	}
+	return b.applyIfEnabled()
+}
+
+func (b *nftBackend) setDNS(addr net.IP) error {
+	if !b.isEnabled {
+		return nil
+	}
+	b.dns = addr
+	return b.apply()
+}
+
+func (b *nftBackend) setUserExceptions(masks []string, isIPv6 bool) error {
+	b.userExceptions[isIPv6] = masks
+	return b.applyIfEnabled()
+}
+
+func (b *nftBackend) singleDnsRuleOn(dnsAddr net.IP, exceptions []string) error {
+	// We can not apply this rules when firewall enabled
+	if b.isEnabled {
+		return fmt.Errorf("failed to apply specific DNS rule: Firewall already enabled")
+	}
+	b.singleDns = dnsAddr
+	b.singleDnsExceptions = exceptions
+	return b.apply()
+}
+
+func (b *nftBackend) singleDnsRuleOff() error {
+	if b.singleDns == nil {
+		return nil
+	}
+	b.singleDns = nil
+	b.singleDnsExceptions = nil
+	if b.isEnabled {
+		return nil
+	}
+	return b.apply()
+}
+
+//---------------------------------------------------------------------
+
+func (b *nftBackend) exceptionsMap(isPersistant bool, onlyForICMP bool) map[string]struct{} {
+	if onlyForICMP {
+		return b.exceptionsICMP
+	}
+	if isPersistant {
+		return b.exceptionsStatic
+	}
+	if !b.isEnabled {
+		// non-persistant exceptions make sense only when firewall is enabled
+		return nil
+	}
+	return b.exceptions
+}
+
+func (b *nftBackend) applyIfEnabled() error {
+	if !b.isEnabled {
+		return nil
+	}
+	return b.apply()
+}
+
+func (b *nftBackend) apply() error {
+	var err error
+	if b.isEnabled {
+		err = nftables.Apply(b.firewallTable())
+	} else if b.singleDns != nil {
+		err = nftables.Apply(b.singleDnsTable())
+	} else {
+		err = nftables.Delete(nftables.FamilyINet, nftTableName)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to apply nftables rules: %w", err)
+	}
+	return nil
+}
+
+// firewallTable returns complete configuration of the IVPN firewall.
+// The rules order follows the chains order defined in 'firewall.sh':
+// split-tunnel -> local -> connection exceptions (IF0) -> DNS -> VPN interface (IF1) -> static exceptions -> user exceptions -> ICMP exceptions -> DROP
+func (b *nftBackend) firewallTable() nftables.Table {
+	in := make([]*nftables.Rule, 0, 32)
+	out := make([]*nftables.Rule, 0, 32)
+	fwd := make([]*nftables.Rule, 0, 2)
+
+	// Split Tunnel: allow packets from/to cgroup (bypass IVPN firewall)
+	out = append(out, nftables.NewRule().Cgroup(nftSplitTunCgroupClassid).Accept())
+	// cgroup v2 has no 'net_cls' classid: the packets from cgroup are identified by 'mark' (it is set by the split-tunnel script)
+	// (the split-tunnel script also marks the packets to 'bypass' destinations, so they are allowed by the same rule)
+	out = append(out, nftables.NewRule().Mark(nftSplitTunFwmark).Accept())
+	in = append(in, nftables.NewRule().Cgroup(nftSplitTunCgroupClassid).Accept())
+	in = append(in, nftables.NewRule().Mark(nftSplitTunFwmark).Accept())
+
+	// IPv6: block DNS before allowing link-local and unique-local addresses
+	// (it prevents potential DNS leaking in some situations, for example, from VM to a host machine)
+	out = append(out, nftables.NewRule().IPv6().DstPort(nftables.ProtoUDP, 53).Drop())
+	out = append(out, nftables.NewRule().IPv6().DstPort(nftables.ProtoTCP, 53).Drop())
+
+	// allow local (lo) interface
+	out = append(out, nftables.NewRule().OutIface("lo").Accept())
+	in = append(in, nftables.NewRule().InIface("lo").Accept())
+
+	// IPv6: allow link-local and unique-local addresses
+	for _, n := range []string{"fe80::/10", "fd00::/8"} {
+		ipNet, _ := parseIPNet(n)
+		in = append(in, nftables.NewRule().IPv6().SrcAddr(ipNet, false).Accept())
+		out = append(out, nftables.NewRule().IPv6().DstAddr(ipNet, false).Accept())
+	}
+
+	// allow DHCP port (67out 68in)
+	out = append(out, nftables.NewRule().IPv4().DstPort(nftables.ProtoUDP, 67).Accept())
+	in = append(in, nftables.NewRule().IPv4().DstPort(nftables.ProtoUDP, 68).Accept())
+
+	// allow communication with VPN server only srcPort <=> host.dstPort
+	if c := b.vpnConn; c != nil {
+		if srv, err := parseIPNet(c.serverIP.String()); err == nil {
+			proto := byte(nftables.ProtoUDP)
+			if c.isTCP {
+				proto = nftables.ProtoTCP
+			}
+			in = append(in, nftRuleFamily(srv).SrcAddr(srv, false).SrcPort(proto, uint16(c.serverPort)).Accept())
+			out = append(out, nftRuleFamily(srv).DstAddr(srv, false).DstPort(proto, uint16(c.serverPort)).Accept())
+		}
+	}
+
+	// exceptions (must be processed before DNS rules!)
+	in, out = nftAppendExceptions(in, out, sortedKeys(b.exceptions))
+
+	// block DNS (except the allowed DNS server)
+	for _, proto := range []byte{nftables.ProtoUDP, nftables.ProtoTCP} {
+		r := nftables.NewRule().IPv4()
+		if b.dns != nil {
+			dnsNet, _ := parseIPNet(b.dns.String())
+			r = r.DstAddr(dnsNet, true)
+		}
+		out = append(out, r.DstPort(proto, 53).Drop())
+	}
+
+	// allow all packets to VPN interface
+	if len(b.vpnIface) > 0 {
+		out = append(out, nftables.NewRule().OutIface(b.vpnIface).Accept())
+		in = append(in, nftables.NewRule().InIface(b.vpnIface).Accept())
+		fwd = append(fwd, nftables.NewRule().InIface(b.vpnIface).Accept())
+		fwd = append(fwd, nftables.NewRule().OutIface(b.vpnIface).Accept())
+	}
+
+	// static exceptions
+	in, out = nftAppendExceptions(in, out, sortedKeys(b.exceptionsStatic))
+
+	// user exceptions
+	in, out = nftAppendExceptions(in, out, b.userExceptions[false])
+	in, out = nftAppendExceptions(in, out, b.userExceptions[true])
+
+	// ICMP exceptions (only IPv4)
+	for _, ipStr := range sortedKeys(b.exceptionsICMP) {
+		ipNet, err := parseIPNet(ipStr)
+		if err != nil || ipNet.IP.To4() == nil {
+			continue
+		}
+		in = append(in, nftables.NewRule().IPv4().SrcAddr(ipNet, false).IcmpType(icmpEchoReply).
+			CtState(nftables.CtStateEstablished|nftables.CtStateRelated).Accept())
+		out = append(out, nftables.NewRule().IPv4().DstAddr(ipNet, false).IcmpType(icmpEchoRequest).
+			CtState(nftables.CtStateNew|nftables.CtStateEstablished|nftables.CtStateRelated).Accept())
+	}
+
+	// Block everything by default (chain policy).
+	// Note! If the packet does not match any IVPN rule - DROP it.
+	// This will block all user-defined firewall rules!
+	return nftables.Table{
+		Family: nftables.FamilyINet,
+		Name:   nftTableName,
+		Chains: []nftables.Chain{
+			{Name: nftChainInput, Hook: nftables.HookInput, Priority: nftables.PriorityFilter, Policy: nftables.VerdictDrop, Rules: in},
+			{Name: nftChainOutput, Hook: nftables.HookOutput, Priority: nftables.PriorityFilter, Policy: nftables.VerdictDrop, Rules: out},
+			{Name: nftChainForward, Hook: nftables.HookForward, Priority: nftables.PriorityFilter, Policy: nftables.VerdictDrop, Rules: fwd},
+		},
+	}
+}
+
+// singleDnsTable returns configuration to allow only specific DNS address: in use by Inverse Split Tunnel mode
+// (Inverse Split Tunnel mode does not allow to enable "firewall" but have to block unwanted DNS requests anyway)
+func (b *nftBackend) singleDnsTable() nftables.Table {
+	out := make([]*nftables.Rule, 0, 4)
+
+	// Allow communication with IP addresses from exceptions list (if defined)
+	// It avoids situation of blocking communication with VPN server over port 53 (e.g. connection trough V2Ray/QUICK on UDP 53)
+	for _, ipStr := range b.singleDnsExceptions {
+		ipNet, err := parseIPNet(ipStr)
+		if err != nil {
+			log.Warning(err)
+			continue
+		}
+		out = append(out, nftRuleFamily(ipNet).DstAddr(ipNet, false).DstPort(nftables.ProtoUDP, 53).Accept())
+	}
+
+	out = append(out, nftables.NewRule().OutIface("lo").Accept())
+
+	if dnsNet, err := parseIPNet(b.singleDns.String()); err == nil {
+		for _, proto := range []byte{nftables.ProtoTCP, nftables.ProtoUDP} {
+			out = append(out, nftRuleFamily(dnsNet).DstAddr(dnsNet, true).DstPort(proto, 53).Drop())
+		}
+	}
+
+	return nftables.Table{
+		Family: nftables.FamilyINet,
+		Name:   nftTableName,
+		Chains: []nftables.Chain{
+			{Name: nftChainOnlyDnsName, Hook: nftables.HookOutput, Priority: nftables.PriorityFilter, Policy: nftables.VerdictAccept, Rules: out},
+		},
+	}
+}
+
+//---------------------------------------------------------------------
+
+func nftRuleFamily(n net.IPNet) *nftables.Rule {
+	if n.IP.To4() != nil {
+		return nftables.NewRule().IPv4()
+	}
+	return nftables.NewRule().IPv6()
+}
+
+func nftAppendExceptions(in, out []*nftables.Rule, IPs []string) (retIn, retOut []*nftables.Rule) {
+	for _, ipStr := range IPs {
+		ipNet, err := parseIPNet(ipStr)
+		if err != nil {
+			log.Warning(err)
+			continue
+		}
+		in = append(in, nftRuleFamily(ipNet).SrcAddr(ipNet, false).Accept())
+		out = append(out, nftRuleFamily(ipNet).DstAddr(ipNet, false).Accept())
+	}
+	return in, out
+}
+
+// parseIPNet parses IP address or subnet (in CIDR notation)
+func parseIPNet(s string) (net.IPNet, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return net.IPNet{}, fmt.Errorf("failed to parse subnet '%s': %w", s, err)
+		}
+		return *n, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return net.IPNet{}, fmt.Errorf("failed to parse IP address '%s'", s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
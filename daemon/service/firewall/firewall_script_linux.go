@@ -0,0 +1,144 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/shell"
+)
+
+// scriptBackend - firewall rules are managed by the 'firewall.sh' script (iptables)
+type scriptBackend struct{}
+
+func (b *scriptBackend) name() string { return "firewall.sh (iptables)" }
+
+func (b *scriptBackend) isNftables() bool { return false }
+
+func (b *scriptBackend) initialize() error { return nil }
+
+func (b *scriptBackend) getEnabled() (bool, error) {
+	err := shell.Exec(nil, platform.FirewallScript(), "-status")
+
+	if err != nil {
+		exitCode, err := shell.GetCmdExitCode(err)
+		if err != nil {
+			return false, fmt.Errorf("failed to get Cmd exit code: %w", err)
+		}
+		if exitCode == 0 {
+			return true, nil
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+func (b *scriptBackend) enable() error {
+	err := shell.Exec(nil, platform.FirewallScript(), "-enable")
+	if err != nil {
+		return fmt.Errorf("failed to execute shell command: %w", err)
+	}
+	return nil
+}
+
+func (b *scriptBackend) disable() error {
+	return shell.Exec(nil, platform.FirewallScript(), "-disable")
+}
+
+func (b *scriptBackend) connected(ifaceName string, clientLocalIP net.IP, clientPort int, serverIP net.IP, serverPort int, isTCP bool) error {
+	protocol := "udp"
+	if isTCP {
+		protocol = "tcp"
+	}
+	scriptArgs := fmt.Sprintf("-connected %s %s %d %s %d %s",
+		ifaceName,
+		clientLocalIP,
+		clientPort,
+		serverIP,
+		serverPort,
+		protocol)
+	return shell.Exec(nil, platform.FirewallScript(), scriptArgs)
+}
+
+func (b *scriptBackend) disconnected() error {
+	return shell.Exec(nil, platform.FirewallScript(), "-disconnected")
+}
+
+func (b *scriptBackend) addExceptions(hostsIPs []string, isPersistant bool, onlyForICMP bool) error {
+	scriptCommand := "-add_exceptions"
+	if onlyForICMP {
+		scriptCommand = "-add_exceptions_icmp"
+	} else if isPersistant {
+		scriptCommand = "-add_exceptions_static"
+	}
+	return b.execWithIPList(scriptCommand, hostsIPs)
+}
+
+func (b *scriptBackend) removeExceptions(hostsIPs []string, isPersistant bool, onlyForICMP bool) error {
+	scriptCommand := "-remove_exceptions"
+	if onlyForICMP {
+		scriptCommand = "-remove_exceptions_icmp"
+	} else if isPersistant {
+		scriptCommand = "-remove_exceptions_static"
+	}
+	return b.execWithIPList(scriptCommand, hostsIPs)
+}
+
+func (b *scriptBackend) setDNS(addr net.IP) error {
+	addrStr := ""
+	if addr != nil {
+		addrStr = addr.String()
+	}
+	return shell.Exec(nil, platform.FirewallScript(), "-set_dns", addrStr)
+}
+
+func (b *scriptBackend) setUserExceptions(masks []string, isIPv6 bool) error {
+	scriptCommand := "-set_user_exceptions_static"
+	if isIPv6 {
+		scriptCommand = "-set_user_exceptions_static_ipv6"
+	}
+	return b.execWithIPList(scriptCommand, masks)
+}
+
+func (b *scriptBackend) singleDnsRuleOn(dnsAddr net.IP, exceptions []string) error {
+	return shell.Exec(log, platform.FirewallScript(), "-only_dns", dnsAddr.String(), strings.Join(exceptions, ","))
+}
+
+func (b *scriptBackend) singleDnsRuleOff() error {
+	return shell.Exec(log, platform.FirewallScript(), "-only_dns_off")
+}
+
+func (b *scriptBackend) execWithIPList(scriptCommand string, IPs []string) error {
+	ipList := strings.Join(IPs, ",")
+
+	if len(ipList) > 250 {
+		log.Info(scriptCommand, " <...multiple addresses...>")
+	} else {
+		log.Info(scriptCommand, " ", ipList)
+	}
+
+	return shell.Exec(nil, platform.FirewallScript(), scriptCommand, ipList)
+}
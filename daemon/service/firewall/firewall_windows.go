@@ -78,6 +78,10 @@ func implInitialize() error {
 	return nil
 }
 
+func implApplyUserSettings() error {
+	return nil // nothing to do here for current platform
+}
+
 func implGetEnabled() (bool, error) {
 	pInfo, err := manager.GetProviderInfo(providerKey)
 	if err != nil {
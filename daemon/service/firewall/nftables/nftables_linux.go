@@ -0,0 +1,277 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+// Package nftables is a minimal nftables client which talks to the kernel directly over netlink.
+// It supports only the functionality required by the IVPN firewall:
+// a whole table (with base chains and rules) is always applied as a single atomic transaction.
+package nftables
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// Address families
+const (
+	FamilyINet = unix.NFPROTO_INET
+	FamilyIPv4 = unix.NFPROTO_IPV4
+	FamilyIPv6 = unix.NFPROTO_IPV6
+)
+
+// Base chain hooks
+const (
+	HookInput   = unix.NF_INET_LOCAL_IN
+	HookForward = unix.NF_INET_FORWARD
+	HookOutput  = unix.NF_INET_LOCAL_OUT
+)
+
+// PriorityFilter - the standard priority of the 'filter' base chains
+const PriorityFilter int32 = 0
+
+// Verdict - netfilter verdict
+type Verdict uint32
+
+const (
+	VerdictDrop   Verdict = 0 // NF_DROP
+	VerdictAccept Verdict = 1 // NF_ACCEPT
+)
+
+const transactionTimeout = time.Second * 10
+
+// Table - nftables table with all its content
+type Table struct {
+	Family byte
+	Name   string
+	Chains []Chain
+}
+
+// Chain - nftables base chain of type 'filter'
+type Chain struct {
+	Name     string
+	Hook     uint32
+	Priority int32
+	Policy   Verdict
+	Rules    []*Rule
+}
+
+// Check returns error if nftables is not accessible on the current system
+// (e.g. kernel compiled without nftables support or no required privileges)
+func Check() error {
+	_, err := isObjectExists(unix.NFT_MSG_GETTABLE, FamilyINet, func(ae *netlink.AttributeEncoder) {
+		ae.String(unix.NFTA_TABLE_NAME, "ivpn_probe")
+	})
+	return err
+}
+
+// IsChainExists returns 'true' when the chain exists in the table
+func IsChainExists(family byte, table, chain string) (bool, error) {
+	return isObjectExists(unix.NFT_MSG_GETCHAIN, family, func(ae *netlink.AttributeEncoder) {
+		ae.String(unix.NFTA_CHAIN_TABLE, table)
+		ae.String(unix.NFTA_CHAIN_NAME, chain)
+	})
+}
+
+// Apply replaces the table (if exists) by the new one.
+// The operation is atomic: the old table content stays active until the new one is fully accepted by the kernel.
+func Apply(t Table) error {
+	msgs := make([]netlink.Message, 0, 8)
+
+	// "add table" + "delete table" are used to remove the table without failing when it does not exist yet
+	for _, typ := range []uint16{unix.NFT_MSG_NEWTABLE, unix.NFT_MSG_DELTABLE, unix.NFT_MSG_NEWTABLE} {
+		m, err := tableMsg(typ, t.Family, t.Name)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, m)
+	}
+
+	for _, c := range t.Chains {
+		m, err := chainMsg(t.Family, t.Name, c)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, m)
+
+		for _, r := range c.Rules {
+			m, err := ruleMsg(t.Family, t.Name, c.Name, r)
+			if err != nil {
+				return fmt.Errorf("chain '%s': %w", c.Name, err)
+			}
+			msgs = append(msgs, m)
+		}
+	}
+
+	return transaction(msgs)
+}
+
+// Delete removes the table (if exists)
+func Delete(family byte, name string) error {
+	msgs := make([]netlink.Message, 0, 2)
+	for _, typ := range []uint16{unix.NFT_MSG_NEWTABLE, unix.NFT_MSG_DELTABLE} {
+		m, err := tableMsg(typ, family, name)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, m)
+	}
+	return transaction(msgs)
+}
+
+//---------------------------------------------------------------------
+
+func newAttributeEncoder() *netlink.AttributeEncoder {
+	ae := netlink.NewAttributeEncoder()
+	// nftables attributes are in network byte order
+	ae.ByteOrder = binary.BigEndian
+	return ae
+}
+
+// nfgenmsg header
+func genMsgHeader(family byte, resID uint16) []byte {
+	return []byte{family, unix.NFNETLINK_V0, byte(resID >> 8), byte(resID)}
+}
+
+func newMessage(typ uint16, flags netlink.HeaderFlags, family byte, attrs []byte) netlink.Message {
+	return netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.NFNL_SUBSYS_NFTABLES<<8 | typ),
+			Flags: netlink.Request | flags,
+		},
+		Data: append(genMsgHeader(family, 0), attrs...),
+	}
+}
+
+func tableMsg(typ uint16, family byte, name string) (netlink.Message, error) {
+	ae := newAttributeEncoder()
+	ae.String(unix.NFTA_TABLE_NAME, name)
+	attrs, err := ae.Encode()
+	if err != nil {
+		return netlink.Message{}, err
+	}
+
+	flags := netlink.Acknowledge
+	if typ == unix.NFT_MSG_NEWTABLE {
+		flags |= netlink.Create
+	}
+	return newMessage(typ, flags, family, attrs), nil
+}
+
+func chainMsg(family byte, table string, c Chain) (netlink.Message, error) {
+	ae := newAttributeEncoder()
+	ae.String(unix.NFTA_CHAIN_TABLE, table)
+	ae.String(unix.NFTA_CHAIN_NAME, c.Name)
+	ae.Nested(unix.NFTA_CHAIN_HOOK, func(nae *netlink.AttributeEncoder) error {
+		nae.Uint32(unix.NFTA_HOOK_HOOKNUM, c.Hook)
+		nae.Uint32(unix.NFTA_HOOK_PRIORITY, uint32(c.Priority))
+		return nil
+	})
+	ae.Uint32(unix.NFTA_CHAIN_POLICY, uint32(c.Policy))
+	ae.String(unix.NFTA_CHAIN_TYPE, "filter")
+	attrs, err := ae.Encode()
+	if err != nil {
+		return netlink.Message{}, err
+	}
+	return newMessage(unix.NFT_MSG_NEWCHAIN, netlink.Acknowledge|netlink.Create, family, attrs), nil
+}
+
+func ruleMsg(family byte, table, chain string, r *Rule) (netlink.Message, error) {
+	attrs, err := r.marshal(table, chain)
+	if err != nil {
+		return netlink.Message{}, err
+	}
+	return newMessage(unix.NFT_MSG_NEWRULE, netlink.Acknowledge|netlink.Create|netlink.Append, family, attrs), nil
+}
+
+// transaction sends all messages to the kernel as a single batch.
+// The kernel applies the batch atomically: in case of any error, no changes are applied.
+func transaction(msgs []netlink.Message) error {
+	conn, err := netlink.Dial(unix.NETLINK_NETFILTER, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open netlink connection: %w", err)
+	}
+	defer conn.Close()
+
+	batch := make([]netlink.Message, 0, len(msgs)+2)
+	batch = append(batch, netlink.Message{
+		Header: netlink.Header{Type: netlink.HeaderType(unix.NFNL_MSG_BATCH_BEGIN), Flags: netlink.Request},
+		Data:   genMsgHeader(unix.AF_UNSPEC, unix.NFNL_SUBSYS_NFTABLES),
+	})
+	batch = append(batch, msgs...)
+	batch = append(batch, netlink.Message{
+		Header: netlink.Header{Type: netlink.HeaderType(unix.NFNL_MSG_BATCH_END), Flags: netlink.Request},
+		Data:   genMsgHeader(unix.AF_UNSPEC, unix.NFNL_SUBSYS_NFTABLES),
+	})
+
+	if _, err := conn.SendMessages(batch); err != nil {
+		return fmt.Errorf("failed to send nftables transaction: %w", err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(transactionTimeout)); err != nil {
+		return err
+	}
+
+	// every message in the batch has 'Acknowledge' flag: waiting for all acknowledgements
+	for acks := 0; acks < len(msgs); {
+		replies, err := conn.Receive()
+		if err != nil {
+			return fmt.Errorf("nftables transaction failed: %w", err)
+		}
+		for _, r := range replies {
+			if r.Header.Type == netlink.Error {
+				acks++
+			}
+		}
+	}
+	return nil
+}
+
+func isObjectExists(typ uint16, family byte, encodeAttrs func(ae *netlink.AttributeEncoder)) (bool, error) {
+	conn, err := netlink.Dial(unix.NETLINK_NETFILTER, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to open netlink connection: %w", err)
+	}
+	defer conn.Close()
+
+	ae := newAttributeEncoder()
+	encodeAttrs(ae)
+	attrs, err := ae.Encode()
+	if err != nil {
+		return false, err
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(transactionTimeout)); err != nil {
+		return false, err
+	}
+
+	if _, err := conn.Execute(newMessage(typ, 0, family, attrs)); err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,292 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package nftables
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	"golang.org/x/sys/unix"
+)
+
+// L4 protocols
+const (
+	ProtoICMP = unix.IPPROTO_ICMP
+	ProtoTCP  = unix.IPPROTO_TCP
+	ProtoUDP  = unix.IPPROTO_UDP
+)
+
+// Connection tracking states (bit mask)
+const (
+	CtStateEstablished uint32 = 1 << 1
+	CtStateRelated     uint32 = 1 << 2
+	CtStateNew         uint32 = 1 << 3
+)
+
+const (
+	ifNameSize = unix.IFNAMSIZ
+	register   = unix.NFT_REG_1
+)
+
+// expr - nftables expression
+type expr struct {
+	name    string
+	marshal func(ae *netlink.AttributeEncoder)
+}
+
+// Rule is a sequence of nftables expressions.
+// Use NewRule() and the chain of matchers to define the rule; the rule must be finished by a verdict (Accept() or Drop())
+// Example (ipv4 udp packets to 10.0.0.1:53 are allowed):
+//
+//	NewRule().IPv4().DstAddr(net.IPNet{IP: net.IPv4(10, 0, 0, 1), Mask: net.CIDRMask(32, 32)}, false).DstPort(ProtoUDP, 53).Accept()
+type Rule struct {
+	exprs []expr
+	err   error
+}
+
+func NewRule() *Rule {
+	return &Rule{}
+}
+
+// IPv4 - match only IPv4 packets
+func (r *Rule) IPv4() *Rule {
+	return r.metaCmp(unix.NFT_META_NFPROTO, []byte{unix.NFPROTO_IPV4}, false)
+}
+
+// IPv6 - match only IPv6 packets
+func (r *Rule) IPv6() *Rule {
+	return r.metaCmp(unix.NFT_META_NFPROTO, []byte{unix.NFPROTO_IPV6}, false)
+}
+
+// InIface - match incoming interface name
+func (r *Rule) InIface(name string) *Rule {
+	return r.metaCmp(unix.NFT_META_IIFNAME, ifName(name), false)
+}
+
+// OutIface - match outgoing interface name
+func (r *Rule) OutIface(name string) *Rule {
+	return r.metaCmp(unix.NFT_META_OIFNAME, ifName(name), false)
+}
+
+// Mark - match packet mark
+func (r *Rule) Mark(mark uint32) *Rule {
+	return r.metaCmp(unix.NFT_META_MARK, nlenc.Uint32Bytes(mark), false)
+}
+
+// Cgroup - match net_cls cgroup classid of the socket
+func (r *Rule) Cgroup(classid uint32) *Rule {
+	return r.metaCmp(unix.NFT_META_CGROUP, nlenc.Uint32Bytes(classid), false)
+}
+
+// SrcAddr - match source address (or subnet).
+// Note: the rule must contain IPv4() or IPv6() matcher before (according to the address type)
+func (r *Rule) SrcAddr(n net.IPNet, negate bool) *Rule {
+	if n.IP.To4() != nil {
+		return r.addr(12, n, negate) // ipv4 header: saddr
+	}
+	return r.addr(8, n, negate) // ipv6 header: saddr
+}
+
+// DstAddr - match destination address (or subnet).
+// Note: the rule must contain IPv4() or IPv6() matcher before (according to the address type)
+func (r *Rule) DstAddr(n net.IPNet, negate bool) *Rule {
+	if n.IP.To4() != nil {
+		return r.addr(16, n, negate) // ipv4 header: daddr
+	}
+	return r.addr(24, n, negate) // ipv6 header: daddr
+}
+
+// Proto - match L4 protocol
+func (r *Rule) Proto(proto byte) *Rule {
+	return r.metaCmp(unix.NFT_META_L4PROTO, []byte{proto}, false)
+}
+
+// SrcPort - match L4 protocol and source port
+func (r *Rule) SrcPort(proto byte, port uint16) *Rule {
+	return r.Proto(proto).payloadCmp(unix.NFT_PAYLOAD_TRANSPORT_HEADER, 0, binary.BigEndian.AppendUint16(nil, port), false)
+}
+
+// DstPort - match L4 protocol and destination port
+func (r *Rule) DstPort(proto byte, port uint16) *Rule {
+	return r.Proto(proto).payloadCmp(unix.NFT_PAYLOAD_TRANSPORT_HEADER, 2, binary.BigEndian.AppendUint16(nil, port), false)
+}
+
+// IcmpType - match ICMP packets of specified type
+func (r *Rule) IcmpType(icmpType byte) *Rule {
+	return r.Proto(ProtoICMP).payloadCmp(unix.NFT_PAYLOAD_TRANSPORT_HEADER, 0, []byte{icmpType}, false)
+}
+
+// CtState - match connection tracking state (any of states from the mask)
+func (r *Rule) CtState(stateMask uint32) *Rule {
+	r.exprs = append(r.exprs,
+		expr{"ct", func(ae *netlink.AttributeEncoder) {
+			ae.Uint32(unix.NFTA_CT_KEY, unix.NFT_CT_STATE)
+			ae.Uint32(unix.NFTA_CT_DREG, register)
+		}},
+		bitwise(nlenc.Uint32Bytes(stateMask)),
+		cmp(unix.NFT_CMP_NEQ, make([]byte, 4)))
+	return r
+}
+
+// Accept - finish the rule with verdict 'accept'
+func (r *Rule) Accept() *Rule {
+	return r.verdict(VerdictAccept)
+}
+
+// Drop - finish the rule with verdict 'drop'
+func (r *Rule) Drop() *Rule {
+	return r.verdict(VerdictDrop)
+}
+
+func (r *Rule) verdict(v Verdict) *Rule {
+	r.exprs = append(r.exprs, expr{"immediate", func(ae *netlink.AttributeEncoder) {
+		ae.Uint32(unix.NFTA_IMMEDIATE_DREG, unix.NFT_REG_VERDICT)
+		ae.Nested(unix.NFTA_IMMEDIATE_DATA, func(nae *netlink.AttributeEncoder) error {
+			nae.Nested(unix.NFTA_DATA_VERDICT, func(vae *netlink.AttributeEncoder) error {
+				vae.Uint32(unix.NFTA_VERDICT_CODE, uint32(v))
+				return nil
+			})
+			return nil
+		})
+	}})
+	return r
+}
+
+func (r *Rule) metaCmp(key uint32, value []byte, negate bool) *Rule {
+	r.exprs = append(r.exprs,
+		expr{"meta", func(ae *netlink.AttributeEncoder) {
+			ae.Uint32(unix.NFTA_META_KEY, key)
+			ae.Uint32(unix.NFTA_META_DREG, register)
+		}},
+		cmp(cmpOp(negate), value))
+	return r
+}
+
+func (r *Rule) payloadCmp(base, offset uint32, value []byte, negate bool) *Rule {
+	r.exprs = append(r.exprs, payload(base, offset, uint32(len(value))), cmp(cmpOp(negate), value))
+	return r
+}
+
+func (r *Rule) addr(offset uint32, n net.IPNet, negate bool) *Rule {
+	ip := n.IP.To4()
+	if ip == nil {
+		ip = n.IP.To16()
+	}
+	if ip == nil || len(n.Mask) != len(ip) {
+		if r.err == nil {
+			r.err = fmt.Errorf("bad address '%s'", n.String())
+		}
+		return r
+	}
+
+	r.exprs = append(r.exprs, payload(unix.NFT_PAYLOAD_NETWORK_HEADER, offset, uint32(len(ip))))
+	if ones, bits := n.Mask.Size(); ones != bits {
+		r.exprs = append(r.exprs, bitwise(n.Mask))
+	}
+	r.exprs = append(r.exprs, cmp(cmpOp(negate), ip.Mask(n.Mask)))
+	return r
+}
+
+func (r *Rule) marshal(table, chain string) ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.exprs) == 0 {
+		return nil, fmt.Errorf("empty rule")
+	}
+
+	ae := newAttributeEncoder()
+	ae.String(unix.NFTA_RULE_TABLE, table)
+	ae.String(unix.NFTA_RULE_CHAIN, chain)
+	ae.Nested(unix.NFTA_RULE_EXPRESSIONS, func(lae *netlink.AttributeEncoder) error {
+		for _, e := range r.exprs {
+			e := e
+			lae.Nested(unix.NFTA_LIST_ELEM, func(eae *netlink.AttributeEncoder) error {
+				eae.String(unix.NFTA_EXPR_NAME, e.name)
+				eae.Nested(unix.NFTA_EXPR_DATA, func(dae *netlink.AttributeEncoder) error {
+					e.marshal(dae)
+					return nil
+				})
+				return nil
+			})
+		}
+		return nil
+	})
+	return ae.Encode()
+}
+
+//---------------------------------------------------------------------
+
+func cmpOp(negate bool) uint32 {
+	if negate {
+		return unix.NFT_CMP_NEQ
+	}
+	return unix.NFT_CMP_EQ
+}
+
+func cmp(op uint32, value []byte) expr {
+	return expr{"cmp", func(ae *netlink.AttributeEncoder) {
+		ae.Uint32(unix.NFTA_CMP_SREG, register)
+		ae.Uint32(unix.NFTA_CMP_OP, op)
+		ae.Nested(unix.NFTA_CMP_DATA, func(nae *netlink.AttributeEncoder) error {
+			nae.Bytes(unix.NFTA_DATA_VALUE, value)
+			return nil
+		})
+	}}
+}
+
+func payload(base, offset, length uint32) expr {
+	return expr{"payload", func(ae *netlink.AttributeEncoder) {
+		ae.Uint32(unix.NFTA_PAYLOAD_DREG, register)
+		ae.Uint32(unix.NFTA_PAYLOAD_BASE, base)
+		ae.Uint32(unix.NFTA_PAYLOAD_OFFSET, offset)
+		ae.Uint32(unix.NFTA_PAYLOAD_LEN, length)
+	}}
+}
+
+// bitwise: register = (register & mask) ^ 0
+func bitwise(mask []byte) expr {
+	return expr{"bitwise", func(ae *netlink.AttributeEncoder) {
+		ae.Uint32(unix.NFTA_BITWISE_SREG, register)
+		ae.Uint32(unix.NFTA_BITWISE_DREG, register)
+		ae.Uint32(unix.NFTA_BITWISE_LEN, uint32(len(mask)))
+		ae.Nested(unix.NFTA_BITWISE_MASK, func(nae *netlink.AttributeEncoder) error {
+			nae.Bytes(unix.NFTA_DATA_VALUE, mask)
+			return nil
+		})
+		ae.Nested(unix.NFTA_BITWISE_XOR, func(nae *netlink.AttributeEncoder) error {
+			nae.Bytes(unix.NFTA_DATA_VALUE, make([]byte, len(mask)))
+			return nil
+		})
+	}}
+}
+
+// interface name in the format expected by the kernel (zero-padded to IFNAMSIZ)
+func ifName(name string) []byte {
+	b := make([]byte, ifNameSize)
+	copy(b, name)
+	return b
+}
@@ -0,0 +1,108 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package nftables
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// decodes rule attributes and returns the list of expression names
+func exprNames(t *testing.T, data []byte) (table, chain string, names []string) {
+	ad, err := netlink.NewAttributeDecoder(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ad.Next() {
+		switch ad.Type() {
+		case unix.NFTA_RULE_TABLE:
+			table = ad.String()
+		case unix.NFTA_RULE_CHAIN:
+			chain = ad.String()
+		case unix.NFTA_RULE_EXPRESSIONS:
+			ad.Nested(func(lad *netlink.AttributeDecoder) error {
+				for lad.Next() {
+					lad.Nested(func(ead *netlink.AttributeDecoder) error {
+						for ead.Next() {
+							if ead.Type() == unix.NFTA_EXPR_NAME {
+								names = append(names, ead.String())
+							}
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+		}
+	}
+	if err := ad.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return table, chain, names
+}
+
+func TestRuleMarshal(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("192.168.0.0/16")
+	host := net.IPNet{IP: net.IPv4(10, 0, 0, 1).To4(), Mask: net.CIDRMask(32, 32)}
+
+	tests := []struct {
+		name string
+		rule *Rule
+		want []string
+	}{
+		{"iface", NewRule().OutIface("lo").Accept(), []string{"meta", "cmp", "immediate"}},
+		{"host", NewRule().IPv4().DstAddr(host, false).Accept(), []string{"meta", "cmp", "payload", "cmp", "immediate"}},
+		{"subnet", NewRule().IPv4().SrcAddr(*subnet, false).Accept(), []string{"meta", "cmp", "payload", "bitwise", "cmp", "immediate"}},
+		{"port", NewRule().IPv4().DstPort(ProtoUDP, 53).Drop(), []string{"meta", "cmp", "meta", "cmp", "payload", "cmp", "immediate"}},
+		{"ct", NewRule().IcmpType(8).CtState(CtStateNew).Accept(), []string{"meta", "cmp", "payload", "cmp", "ct", "bitwise", "cmp", "immediate"}},
+	}
+
+	for _, tt := range tests {
+		data, err := tt.rule.marshal("ivpn", "output")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		table, chain, names := exprNames(t, data)
+		if table != "ivpn" || chain != "output" {
+			t.Errorf("%s: unexpected table/chain: %s/%s", tt.name, table, chain)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("%s: expressions %v; expected %v", tt.name, names, tt.want)
+		}
+	}
+}
+
+func TestRuleMarshalErrors(t *testing.T) {
+	if _, err := NewRule().marshal("ivpn", "output"); err == nil {
+		t.Error("expected error for empty rule")
+	}
+
+	bad := net.IPNet{IP: net.IPv4(10, 0, 0, 1).To4(), Mask: net.CIDRMask(64, 128)}
+	if _, err := NewRule().IPv4().DstAddr(bad, false).Accept().marshal("ivpn", "output"); err == nil {
+		t.Error("expected error for address with wrong mask")
+	}
+}
@@ -0,0 +1,197 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+// Package history implements the persistent journal of VPN connections.
+// Each VPN state transition is stored as a separate JSON line in the journal file.
+// When the file exceeds the size limit, it is rotated (renamed to '<file>.0') and a new file is started.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/logger"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/obfsproxy"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/v2r"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+var log *logger.Logger
+
+func init() {
+	log = logger.NewLogger("hstry")
+}
+
+// DefaultMaxFileSize - default size limit of the journal file (before rotation)
+const DefaultMaxFileSize int64 = 256 * 1024
+
+const fileMode = os.FileMode(0600) // read\write only for privileged user
+
+// ReasonDisconnectionRequested - disconnection reason when the disconnection was requested by the user
+const ReasonDisconnectionRequested = "disconnection requested"
+
+// Entry - the journal record about VPN state transition
+type Entry struct {
+	Time  int64 // unix time of the transition
+	State string
+	// Additional info about the state (e.g. reason of reconnection)
+	Description string
+
+	VpnType    vpn.Type
+	Server     string // server name (entry server for Multi-Hop connections)
+	ExitServer string // Multi-Hop exit server name (empty for Single-Hop connections)
+	ServerIP   string
+	Port       int
+	IsTCP      bool
+
+	V2RayProxy v2r.V2RayTransportType
+	Obfsproxy  obfsproxy.Config
+
+	// The fields below are defined only for transitions which finish the connected state
+	// (e.g. DISCONNECTED or RECONNECTING after CONNECTED)
+	Duration      int64  // duration of the connected state (seconds)
+	BytesReceived uint64 // bytes received over the VPN interface while connected
+	BytesSent     uint64 // bytes sent over the VPN interface while connected
+
+	// Disconnection reason (defined only for the final DISCONNECTED record)
+	Reason string `json:",omitempty"`
+}
+
+// Journal - the connection history storage
+type Journal struct {
+	mutex       sync.Mutex
+	file        string
+	maxFileSize int64
+}
+
+// NewJournal creates journal object.
+// 'maxFileSize' - size limit of the journal file (if <= 0 - the DefaultMaxFileSize is in use).
+// Note: the history can occupy up to double 'maxFileSize' on disk (the current file + the rotated one)
+func NewJournal(file string, maxFileSize int64) *Journal {
+	if maxFileSize <= 0 {
+		maxFileSize = DefaultMaxFileSize
+	}
+	return &Journal{file: file, maxFileSize: maxFileSize}
+}
+
+// Add saves new entry into the journal
+func (j *Journal) Add(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to serialize history entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if fi, err := os.Stat(j.file); err == nil && fi.Size()+int64(len(data)) > j.maxFileSize {
+		if err := os.Rename(j.file, j.rotatedFile()); err != nil {
+			log.Warning(fmt.Errorf("failed to rotate history file: %w", err))
+		}
+	}
+
+	file, err := os.OpenFile(j.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return nil
+}
+
+// Get returns the latest 'count' entries (the newest entry is the first one).
+// If 'count' <= 0 - all available entries are returned.
+func (j *Journal) Get(count int) ([]Entry, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	var entries []Entry
+	for _, f := range []string{j.rotatedFile(), j.file} {
+		fEntries, err := readEntries(f)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fEntries...)
+	}
+
+	if count > 0 && len(entries) > count {
+		entries = entries[len(entries)-count:]
+	}
+
+	// newest first
+	for l, r := 0, len(entries)-1; l < r; l, r = l+1, r-1 {
+		entries[l], entries[r] = entries[r], entries[l]
+	}
+	return entries, nil
+}
+
+// Clear erases all the history
+func (j *Journal) Clear() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	for _, f := range []string{j.rotatedFile(), j.file} {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove history file: %w", err)
+		}
+	}
+	return nil
+}
+
+func (j *Journal) rotatedFile() string {
+	return j.file + ".0"
+}
+
+func readEntries(file string) ([]Entry, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			// skip corrupted records (e.g. the last record was not fully written)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
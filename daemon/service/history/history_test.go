@@ -0,0 +1,76 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package history_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/history"
+)
+
+func TestJournalRotation(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history.json")
+	j := history.NewJournal(file, 512)
+
+	const total = 20
+	for i := 0; i < total; i++ {
+		if err := j.Add(history.Entry{Time: int64(i), State: "CONNECTED", Server: "server"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fi, err := os.Stat(file); err != nil || fi.Size() > 512 {
+		t.Fatalf("history file was not rotated (err=%v)", err)
+	}
+	if _, err := os.Stat(file + ".0"); err != nil {
+		t.Fatalf("rotated history file not exists: %v", err)
+	}
+
+	entries, err := j.Get(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries; got %d", len(entries))
+	}
+	if entries[0].Time != total-1 || entries[2].Time != total-3 {
+		t.Errorf("unexpected entries order: %v", entries)
+	}
+
+	all, err := j.Get(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) <= 3 || len(all) >= total {
+		t.Errorf("unexpected number of entries after rotation: %d", len(all))
+	}
+
+	if err := j.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if all, err = j.Get(0); err != nil || len(all) != 0 {
+		t.Errorf("history not cleared (entries=%d; err=%v)", len(all), err)
+	}
+}
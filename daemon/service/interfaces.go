@@ -66,6 +66,7 @@ type IWgKeysManager interface {
 	StopKeysRotation()
 	GenerateKeys() error
 	UpdateKeysIfNecessary() (retErr error)
+	RequestNewKeys(session string) (pub, priv string, localIP net.IP, wgPresharedKey string, err error)
 }
 
 // IServiceEventsReceiver is the receiver for service events (normally, it is protocol object)
@@ -79,6 +80,8 @@ type IServiceEventsReceiver interface {
 	OnSplitTunnelStatusChanged()
 	OnVpnStateChanged(state vpn.StateInfo)
 	OnVpnPauseChanged()
+	OnTunnelStatistics(stats vpn.Statistics)
+	OnGeoLookupChanged(location, locationIPv6 *api_types.GeoLookupResponse)
 
 	// called by a service when new connection is required (e.g. requested by 'trusted-wifi' functionality or 'auto-connect' on launch)
 	RegisterConnectionRequest(params service_types.ConnectionParams) error
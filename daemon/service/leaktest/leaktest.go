@@ -0,0 +1,392 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+// Package leaktest checks the current network configuration for DNS and IP leaks.
+//
+// DNS: the query is sent directly to each known resolver (through each local interface) and the
+// resolvers which answer are inspected. While the tunnel is active only the expected resolvers
+// (VPN/manual DNS) must answer and only through the tunnel interface.
+// IP: the public addresses must belong to the VPN server; when IPv6 is not routed through the tunnel,
+// IPv6 connectivity must be blocked.
+package leaktest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// DefaultProbeName - the answer for this name contains the address of the resolver which performed the recursive query
+	DefaultProbeName = "whoami.akamai.net."
+	DefaultTimeout   = time.Second * 3
+	DefaultPort      = 53
+)
+
+// Interface - local network interface to send the probes through
+type Interface struct {
+	Name     string
+	IP       net.IP
+	IsTunnel bool
+}
+
+// PublicIP - the public address as it is seen from the internet
+type PublicIP struct {
+	IP          net.IP
+	IsVpnServer bool   // the address belongs to the VPN server (e.g. the info from the geolocation service)
+	Error       string // the address can not be obtained
+}
+
+// Input - parameters of the leak test
+type Input struct {
+	IsTunnelActive bool
+	IsIPv6InTunnel bool
+
+	// The DNS servers allowed to answer when the tunnel is active (VPN DNS or manual DNS)
+	ExpectedDNS []net.IP
+	// The DNS servers to probe (e.g. the servers configured in the OS, the default gateway, well-known public resolvers)
+	Resolvers []net.IP
+	// Local interfaces to send the DNS probes through (the probe with the default routing is always sent)
+	Interfaces []Interface
+
+	// System resolver (nil - net.DefaultResolver)
+	SystemResolver *net.Resolver
+
+	PublicIPv4 PublicIP
+	PublicIPv6 PublicIP
+	// The addresses of the VPN servers in use (entry/exit)
+	TunnelEndpoints []net.IP
+
+	// "host:port" to check IPv6 connectivity (applicable when the tunnel is active and IPv6 is not in the tunnel)
+	IPv6ProbeAddr string
+
+	ProbeName string        // default: DefaultProbeName
+	Port      int           // DNS port (default: DefaultPort)
+	Timeout   time.Duration // timeout of each probe (default: DefaultTimeout)
+}
+
+// DnsProbe - the result of the DNS query sent directly to the resolver
+type DnsProbe struct {
+	Resolver   string
+	Interface  string // empty - default routing
+	IsAnswered bool
+	IsExpected bool     // the resolver is allowed to answer
+	Answers    []string // addresses from the answer
+	RttMs      int
+	Error      string `json:",omitempty"`
+}
+
+// SystemResolverProbe - the result of the query sent through the system resolver
+type SystemResolverProbe struct {
+	IsAnswered bool
+	// Addresses from the answer (for DefaultProbeName: the addresses of the resolver which performed the recursive query)
+	Answers []string
+	Error   string `json:",omitempty"`
+}
+
+// IPv6Check - the result of the IPv6 connectivity check (the IPv6 must be blocked when it is not routed through the tunnel)
+type IPv6Check struct {
+	IsChecked   bool
+	IsReachable bool
+	Error       string `json:",omitempty"`
+}
+
+// Result - the result of the leak test
+type Result struct {
+	IsTunnelActive bool
+	IsIPv6InTunnel bool
+	ExpectedDNS    []string
+
+	SystemResolver SystemResolverProbe
+	DnsProbes      []DnsProbe
+	PublicIPv4     string
+	PublicIPv6     string
+	IPv6           IPv6Check
+
+	// Leaks - description of detected leaks (empty - no leaks detected)
+	Leaks []string
+}
+
+// IsLeakDetected returns 'true' when any leak detected
+func (r Result) IsLeakDetected() bool {
+	return len(r.Leaks) > 0
+}
+
+// Run performs the leak test
+func Run(ctx context.Context, in Input) Result {
+	if len(in.ProbeName) == 0 {
+		in.ProbeName = DefaultProbeName
+	}
+	if in.Port <= 0 {
+		in.Port = DefaultPort
+	}
+	if in.Timeout <= 0 {
+		in.Timeout = DefaultTimeout
+	}
+
+	ret := Result{IsTunnelActive: in.IsTunnelActive, IsIPv6InTunnel: in.IsIPv6InTunnel}
+	for _, ip := range in.ExpectedDNS {
+		ret.ExpectedDNS = append(ret.ExpectedDNS, ip.String())
+	}
+	if in.PublicIPv4.IP != nil {
+		ret.PublicIPv4 = in.PublicIPv4.IP.String()
+	}
+	if in.PublicIPv6.IP != nil {
+		ret.PublicIPv6 = in.PublicIPv6.IP.String()
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ret.SystemResolver = querySystemResolver(ctx, in)
+	}()
+
+	// the default routing (no interface) + each interface
+	ifaces := append([]Interface{{}}, in.Interfaces...)
+	for _, resolver := range uniqueIPs(append(append([]net.IP{}, in.ExpectedDNS...), in.Resolvers...)) {
+		for _, iface := range ifaces {
+			if iface.IP != nil && (iface.IP.To4() == nil) != (resolver.To4() == nil) {
+				continue // IP protocol mismatch
+			}
+			ret.DnsProbes = append(ret.DnsProbes, DnsProbe{
+				Resolver:   resolver.String(),
+				Interface:  iface.Name,
+				IsExpected: containsIP(in.ExpectedDNS, resolver),
+			})
+		}
+	}
+	for i := range ret.DnsProbes {
+		wg.Add(1)
+		go func(p *DnsProbe) {
+			defer wg.Done()
+			var localIP net.IP
+			for _, iface := range in.Interfaces {
+				if iface.Name == p.Interface && (iface.IP.To4() == nil) == (net.ParseIP(p.Resolver).To4() == nil) {
+					localIP = iface.IP
+					break
+				}
+			}
+			start := time.Now()
+			answers, err := Query(ctx, net.ParseIP(p.Resolver), in.Port, localIP, p.Interface, in.ProbeName, in.Timeout)
+			if err != nil {
+				p.Error = err.Error()
+				return
+			}
+			p.IsAnswered = true
+			p.RttMs = int(time.Since(start) / time.Millisecond)
+			for _, a := range answers {
+				p.Answers = append(p.Answers, a.String())
+			}
+		}(&ret.DnsProbes[i])
+	}
+
+	if in.IsTunnelActive && !in.IsIPv6InTunnel && len(in.IPv6ProbeAddr) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ret.IPv6 = checkIPv6(ctx, in.IPv6ProbeAddr, in.Timeout)
+		}()
+	}
+
+	wg.Wait()
+
+	ret.Leaks = evaluate(in, ret)
+	return ret
+}
+
+// evaluate returns the list of detected leaks
+func evaluate(in Input, r Result) []string {
+	if !in.IsTunnelActive {
+		return nil
+	}
+
+	tunnelIfaces := make(map[string]bool, len(in.Interfaces))
+	for _, iface := range in.Interfaces {
+		tunnelIfaces[iface.Name] = iface.IsTunnel
+	}
+
+	leaks := make([]string, 0)
+	for _, p := range r.DnsProbes {
+		if !p.IsAnswered {
+			continue
+		}
+		via := ""
+		if len(p.Interface) > 0 {
+			via = fmt.Sprintf(" (through interface '%s')", p.Interface)
+		}
+		if !p.IsExpected {
+			leaks = append(leaks, fmt.Sprintf("DNS: unexpected resolver %s answered%s", p.Resolver, via))
+		} else if len(p.Interface) > 0 && !tunnelIfaces[p.Interface] {
+			leaks = append(leaks, fmt.Sprintf("DNS: resolver %s is reachable outside of the tunnel%s", p.Resolver, via))
+		}
+	}
+
+	if in.PublicIPv4.IP != nil && !in.PublicIPv4.IsVpnServer && !containsIP(in.TunnelEndpoints, in.PublicIPv4.IP) {
+		leaks = append(leaks, fmt.Sprintf("IPv4: public address %s does not belong to the VPN server", in.PublicIPv4.IP))
+	}
+	if in.IsIPv6InTunnel {
+		if in.PublicIPv6.IP != nil && !in.PublicIPv6.IsVpnServer && !containsIP(in.TunnelEndpoints, in.PublicIPv6.IP) {
+			leaks = append(leaks, fmt.Sprintf("IPv6: public address %s does not belong to the VPN server", in.PublicIPv6.IP))
+		}
+	} else {
+		if r.IPv6.IsReachable {
+			leaks = append(leaks, "IPv6: connectivity is not blocked while IPv6 is not routed through the tunnel")
+		}
+		if in.PublicIPv6.IP != nil {
+			leaks = append(leaks, fmt.Sprintf("IPv6: public address %s is visible while IPv6 is not routed through the tunnel", in.PublicIPv6.IP))
+		}
+	}
+	return leaks
+}
+
+// Query sends the DNS query (type A or AAAA, according to the resolver address type) directly to the resolver
+// and returns the addresses from the answer.
+// localIP - the local address to send the query from (nil - default routing)
+// ifaceName - the interface to send the query through (empty - default routing).
+// Note: the local address alone does not define the egress interface (e.g. Linux uses the weak host model),
+// so the socket is bound to the interface where it is supported by the platform (see bindToInterface()).
+func Query(ctx context.Context, resolver net.IP, port int, localIP net.IP, ifaceName string, name string, timeout time.Duration) ([]net.IP, error) {
+	qName, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, fmt.Errorf("bad name '%s': %w", name, err)
+	}
+	qType := dnsmessage.TypeA
+	if resolver.To4() == nil {
+		qType = dnsmessage.TypeAAAA
+	}
+
+	id := uint16(rand.Uint32())
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qName, Type: qType, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	if localIP != nil {
+		dialer.LocalAddr = &net.UDPAddr{IP: localIP}
+	}
+	if len(ifaceName) > 0 {
+		dialer.Control = bindToInterface(ifaceName)
+	}
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(resolver.String(), strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(packet); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp dnsmessage.Message
+		if err := resp.Unpack(buf[:n]); err != nil || resp.Header.ID != id || !resp.Header.Response {
+			continue // not a response to our query
+		}
+		if resp.Header.RCode != dnsmessage.RCodeSuccess {
+			// the resolver answered (the query reached it) but the name was not resolved
+			return nil, nil
+		}
+
+		ret := make([]net.IP, 0, len(resp.Answers))
+		for _, a := range resp.Answers {
+			switch b := a.Body.(type) {
+			case *dnsmessage.AResource:
+				ret = append(ret, net.IP(b.A[:]))
+			case *dnsmessage.AAAAResource:
+				ret = append(ret, net.IP(b.AAAA[:]))
+			}
+		}
+		return ret, nil
+	}
+}
+
+func querySystemResolver(ctx context.Context, in Input) (ret SystemResolverProbe) {
+	resolver := in.SystemResolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ctx, cancel := context.WithTimeout(ctx, in.Timeout)
+	defer cancel()
+
+	addrs, err := resolver.LookupHost(ctx, in.ProbeName)
+	if err != nil {
+		ret.Error = err.Error()
+		return ret
+	}
+	ret.IsAnswered = true
+	ret.Answers = addrs
+	return ret
+}
+
+func checkIPv6(ctx context.Context, addr string, timeout time.Duration) (ret IPv6Check) {
+	ret.IsChecked = true
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp6", addr)
+	if err != nil {
+		ret.Error = err.Error()
+		return ret
+	}
+	conn.Close()
+	ret.IsReachable = true
+	return ret
+}
+
+func containsIP(list []net.IP, ip net.IP) bool {
+	for _, i := range list {
+		if i.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueIPs(list []net.IP) []net.IP {
+	ret := make([]net.IP, 0, len(list))
+	for _, ip := range list {
+		if ip != nil && !ip.IsUnspecified() && !containsIP(ret, ip) {
+			ret = append(ret, ip)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,210 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package leaktest
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// standInResolver - local DNS server which answers any A query with the 'answer' address
+type standInResolver struct {
+	conn   net.PacketConn
+	answer net.IP
+}
+
+func startResolver(t *testing.T, addr string, answer net.IP) *standInResolver {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		t.Skipf("unable to start stand-in resolver on %s: %v", addr, err)
+	}
+	r := &standInResolver{conn: conn, answer: answer.To4()}
+	go r.serve()
+	t.Cleanup(func() { conn.Close() })
+	return r
+}
+
+func (r *standInResolver) port() int {
+	return r.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func (r *standInResolver) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := r.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var req dnsmessage.Message
+		if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) == 0 {
+			continue
+		}
+		resp := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: req.Header.ID, Response: true, RecursionAvailable: true},
+			Questions: req.Questions,
+		}
+		if q := req.Questions[0]; q.Type == dnsmessage.TypeA {
+			var a [4]byte
+			copy(a[:], r.answer)
+			resp.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: a},
+			}}
+		}
+		if packet, err := resp.Pack(); err == nil {
+			r.conn.WriteTo(packet, from)
+		}
+	}
+}
+
+func hasLeak(r Result, substr string) bool {
+	for _, l := range r.Leaks {
+		if strings.Contains(l, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestQuery(t *testing.T) {
+	r := startResolver(t, "127.0.0.1:0", net.IPv4(198, 51, 100, 7))
+
+	answers, err := Query(context.Background(), net.IPv4(127, 0, 0, 1), r.port(), nil, "", DefaultProbeName, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 1 || !answers[0].Equal(net.IPv4(198, 51, 100, 7)) {
+		t.Errorf("unexpected answers: %v", answers)
+	}
+}
+
+func TestRunDns(t *testing.T) {
+	expected := startResolver(t, "127.0.0.1:0", net.IPv4(198, 51, 100, 7))
+	port := expected.port()
+
+	systemResolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", expected.conn.LocalAddr().String())
+		},
+	}
+
+	in := Input{
+		IsTunnelActive: true,
+		IsIPv6InTunnel: true,
+		ExpectedDNS:    []net.IP{net.IPv4(127, 0, 0, 1)},
+		Resolvers:      []net.IP{net.IPv4(127, 0, 0, 2)},
+		Interfaces:     []Interface{{Name: "lo", IP: net.IPv4(127, 0, 0, 1), IsTunnel: true}}, // the probes are bound to the interface: it must exist
+		SystemResolver: systemResolver,
+		Port:           port,
+		Timeout:        time.Millisecond * 500,
+	}
+
+	// only the expected resolver answers through the tunnel
+	res := Run(context.Background(), in)
+	if res.IsLeakDetected() {
+		t.Errorf("unexpected leaks: %v", res.Leaks)
+	}
+	if !res.SystemResolver.IsAnswered || len(res.SystemResolver.Answers) != 1 || res.SystemResolver.Answers[0] != "198.51.100.7" {
+		t.Errorf("unexpected system resolver result: %+v", res.SystemResolver)
+	}
+
+	// expected resolver is reachable through the interface which is not the tunnel
+	in.Interfaces[0].IsTunnel = false
+	res = Run(context.Background(), in)
+	if !hasLeak(res, "outside of the tunnel") {
+		t.Errorf("leak outside of the tunnel not detected: %v", res.Leaks)
+	}
+	in.Interfaces[0].IsTunnel = true
+
+	// unexpected resolver answers
+	startResolver(t, net.JoinHostPort("127.0.0.2", strconv.Itoa(port)), net.IPv4(203, 0, 113, 1))
+	res = Run(context.Background(), in)
+	if !hasLeak(res, "unexpected resolver 127.0.0.2") {
+		t.Errorf("unexpected resolver not detected: %v", res.Leaks)
+	}
+
+	// no leaks are reported when the tunnel is not active
+	in.IsTunnelActive = false
+	if res = Run(context.Background(), in); res.IsLeakDetected() {
+		t.Errorf("leaks reported while tunnel is not active: %v", res.Leaks)
+	}
+}
+
+func TestEvaluateIP(t *testing.T) {
+	endpoint := net.IPv4(185, 102, 219, 1)
+	in := Input{
+		IsTunnelActive:  true,
+		IsIPv6InTunnel:  true,
+		TunnelEndpoints: []net.IP{endpoint},
+		PublicIPv4:      PublicIP{IP: endpoint},
+	}
+	if leaks := evaluate(in, Result{}); len(leaks) > 0 {
+		t.Errorf("unexpected leaks: %v", leaks)
+	}
+
+	in.PublicIPv4 = PublicIP{IP: net.IPv4(203, 0, 113, 5)}
+	if leaks := evaluate(in, Result{}); len(leaks) != 1 {
+		t.Errorf("public IPv4 leak not detected: %v", leaks)
+	}
+
+	in.PublicIPv4 = PublicIP{IP: net.IPv4(203, 0, 113, 5), IsVpnServer: true}
+	if leaks := evaluate(in, Result{}); len(leaks) > 0 {
+		t.Errorf("unexpected leaks: %v", leaks)
+	}
+
+	in.IsIPv6InTunnel = false
+	in.PublicIPv6 = PublicIP{IP: net.ParseIP("2001:db8::1")}
+	if leaks := evaluate(in, Result{IPv6: IPv6Check{IsChecked: true, IsReachable: true}}); len(leaks) != 2 {
+		t.Errorf("IPv6 leaks not detected: %v", leaks)
+	}
+}
+
+func TestIPv6Blocked(t *testing.T) {
+	l, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	defer l.Close()
+
+	in := Input{
+		IsTunnelActive: true,
+		IsIPv6InTunnel: false,
+		IPv6ProbeAddr:  l.Addr().String(),
+		Timeout:        time.Millisecond * 500,
+		SystemResolver: &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, &net.OpError{Op: "dial", Err: context.Canceled}
+		}},
+	}
+	res := Run(context.Background(), in)
+	if !res.IPv6.IsReachable || !hasLeak(res, "IPv6: connectivity is not blocked") {
+		t.Errorf("IPv6 connectivity leak not detected: %+v", res)
+	}
+}
@@ -46,6 +46,13 @@ var (
 	serversFile     string
 	logFile         string
 
+	// path to the Unix domain socket of the daemon (empty if not applicable for the platform)
+	serviceSocketFile string
+
+	// path to the readonly servers.json file bundled into the package (last-known-good servers list)
+	// Empty if the package installs the servers list directly into 'serversFile' location
+	serversFileBundled string
+
 	openVpnBinaryPath     string
 	openvpnCaKeyFile      string
 	openvpnTaKeyFile      string
@@ -252,11 +259,29 @@ func SettingsFile() string {
 	return settingsFile
 }
 
+// ConnectionHistoryFile path to the connection history (journal) file (JSON lines).
+// The file is located in the same folder as the settings file
+func ConnectionHistoryFile() string {
+	return filepath.Join(filepath.Dir(settingsFile), "connection_history.jsonl")
+}
+
+// SecretsKeyFile path to the file which contains the key to encrypt secrets (account ID, session token, WG keys) in the settings file.
+// The file is located in the same folder as the settings file and it is accessible only for privileged user
+func SecretsKeyFile() string {
+	return filepath.Join(filepath.Dir(settingsFile), "secrets.key")
+}
+
 // ServicePortFile path to service port file
 func ServicePortFile() string {
 	return servicePortFile
 }
 
+// ServiceSocketFile path to the Unix domain socket of the daemon (empty if not applicable for the platform)
+// The clients connected to the socket are authorized by the credentials of the client process (no secret required)
+func ServiceSocketFile() string {
+	return serviceSocketFile
+}
+
 // ParanoidModeSecretFile path to a file which contains 'secret' (password) for 'Paranoid mode'
 // If 'paranoid mode' enabled - this 'secret' must be used in each request to a daemon.
 // This file should be accessible to read only for 'privilaged' user
@@ -269,6 +294,16 @@ func ServersFile() string {
 	return serversFile
 }
 
+// ServersFileBundled path to servers.json bundled into the package (can be empty)
+func ServersFileBundled() string {
+	return serversFileBundled
+}
+
+// ServersSignatureFile path to the detached signature of the servers list file
+func ServersSignatureFile(serversFile string) string {
+	return serversFile + ".sig"
+}
+
 // LogFile path to log-file
 func LogFile() string {
 	return logFile
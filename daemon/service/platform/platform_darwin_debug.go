@@ -62,6 +62,7 @@ func doOsInitForBuild() (warnings []string, errors []error) {
 	settingsDir := "/Library/Application Support/IVPN"
 	settingsFile = path.Join(settingsDir, "settings.json")
 	serversFile = path.Join(settingsDir, "servers.json")
+	serversFileBundled = path.Join(installDir, "References/common/etc/servers.json")
 	openvpnConfigFile = path.Join(settingsDir, "openvpn.cfg")
 	openvpnProxyAuthFile = path.Join(settingsDir, "proxyauth.txt")
 	wgConfigFilePath = path.Join(settingsDir, "wireguard.conf")
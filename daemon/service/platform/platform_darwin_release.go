@@ -35,10 +35,6 @@ import (
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform/filerights"
 )
 
-const (
-	serversFileBundled = "/Applications/IVPN.app/Contents/Resources/etc/servers.json"
-)
-
 func doOsInitForBuild() (warnings []string, errors []error) {
 	// macOS-specific variable initialization
 	firewallScript = "/Applications/IVPN.app/Contents/Resources/etc/firewall.sh"
@@ -48,6 +44,7 @@ func doOsInitForBuild() (warnings []string, errors []error) {
 	settingsDir := "/Library/Application Support/IVPN"
 	settingsFile = path.Join(settingsDir, "settings.json")
 	serversFile = path.Join(settingsDir, "servers.json")
+	serversFileBundled = "/Applications/IVPN.app/Contents/Resources/etc/servers.json"
 	openvpnConfigFile = path.Join(settingsDir, "openvpn.cfg")
 	openvpnProxyAuthFile = path.Join(settingsDir, "proxyauth.txt")
 	wgConfigFilePath = path.Join(settingsDir, "wireguard.conf")
@@ -42,9 +42,6 @@ var (
 
 	// path to 'resolvectl' binary
 	resolvectlBinPath string
-
-	// path to the readonly servers.json file bundled into the package
-	serversFileBundled string
 )
 
 const (
@@ -130,12 +127,14 @@ func isSnapPlugConnected(plugName string) (bool, error) {
 func doInitConstants() {
 	openVpnBinaryPath = "/usr/sbin/openvpn"
 	routeCommand = "/sbin/ip route"
+	serviceSocketFile = "/run/ivpn/ivpn.sock"
 
 	// check if we are running in snap environment
 	if envs := GetSnapEnvs(); envs != nil {
 		// Note! Changing 'tmpDir' value may break upgrade compatibility with old versions (e.g. lose account login information)
 		logDir = path.Join(envs.SNAP_COMMON, "/opt/ivpn/log")
 		tmpDir = path.Join(envs.SNAP_COMMON, "/opt/ivpn/mutable")
+		serviceSocketFile = path.Join(tmpDir, "ivpn.sock")
 		openVpnBinaryPath = path.Join(envs.SNAP, openVpnBinaryPath)
 	}
 
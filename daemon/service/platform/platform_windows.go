@@ -83,6 +83,7 @@ func doOsInit() (warnings []string, errors []error, logInfo []string) {
 
 	settingsFile = path.Join(settingsDir, "settings.json")
 
+	// the installer places the bundled servers list (and its signature) directly into this location
 	serversFile = path.Join(settingsDirCommon, "servers.json")
 	openvpnConfigFile = path.Join(settingsDir, "openvpn.cfg")
 	openvpnProxyAuthFile = path.Join(settingsDir, "proxyauth.txt")
@@ -39,5 +39,8 @@ type AccountStatus struct {
 }
 
 func (a AccountStatus) IsCanConnectMultiHop() error {
-	return fmt.Errorf("MultiHop connections are not allowed for the current subscription plan. Please upgrade your subscription to Pro")
+	if !a.Active {
+		return fmt.Errorf("MultiHop connections are not allowed: the subscription is not active")
+	}
+	return nil
 }
@@ -0,0 +1,65 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package preferences
+
+import (
+	"fmt"
+
+	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+)
+
+// ConnectionFallbackParams - parameters of the connection fallback.
+// When the connection can not be established (e.g. the port is blocked by the network),
+// the daemon tries the next port/protocol/obfuscation from the ordered list of attempts.
+type ConnectionFallbackParams struct {
+	IsEnabled bool
+	// AttemptTimeoutSec - the time to establish the connection for each attempt (0 - default value)
+	AttemptTimeoutSec int
+	// Attempts - ordered list of connection attempts (empty - default list)
+	Attempts []service_types.ConnectionAttempt
+}
+
+// ConnectionFallbackParamsCreate returns the default parameters.
+// The fallback is disabled by default: the VPN protocol/port is not changed without the user's consent.
+func ConnectionFallbackParamsCreate() ConnectionFallbackParams {
+	return ConnectionFallbackParams{IsEnabled: false}
+}
+
+// Validate returns error when the parameters contain values which the daemon can not use
+func (p ConnectionFallbackParams) Validate() error {
+	if p.AttemptTimeoutSec < 0 {
+		return fmt.Errorf("bad connection fallback attempt timeout: %d", p.AttemptTimeoutSec)
+	}
+	for _, a := range p.Attempts {
+		if err := a.IsSupported(); err != nil {
+			return fmt.Errorf("connection fallback attempt '%s' is not supported: %w", a, err)
+		}
+	}
+	return nil
+}
+
+// FallbackNetworkInfo - the connection attempt which succeeded last time on the network
+type FallbackNetworkInfo struct {
+	Attempt  service_types.ConnectionAttempt
+	LastUsed int64 // unix time (seconds)
+}
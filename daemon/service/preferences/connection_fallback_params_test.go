@@ -0,0 +1,59 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package preferences
+
+import (
+	"testing"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/obfsproxy"
+	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/v2r"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+func TestConnectionFallbackParamsValidate(t *testing.T) {
+	if err := ConnectionFallbackParamsCreate().Validate(); err != nil {
+		t.Errorf("default parameters are not valid: %v", err)
+	}
+	if ConnectionFallbackParamsCreate().IsEnabled {
+		t.Error("the connection fallback must be disabled by default")
+	}
+
+	valid := ConnectionFallbackParams{IsEnabled: true, AttemptTimeoutSec: 20, Attempts: service_types.DefaultConnectionAttempts()}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("default attempts are not valid: %v", err)
+	}
+
+	bad := []ConnectionFallbackParams{
+		{AttemptTimeoutSec: -1},
+		{Attempts: []service_types.ConnectionAttempt{{VpnType: vpn.WireGuard, IsTCP: true, V2RayProxy: v2r.TCP}}},
+		{Attempts: []service_types.ConnectionAttempt{{VpnType: vpn.OpenVPN, IsTCP: true, Obfsproxy: obfsproxy.Config{Version: obfsproxy.OBFS4}}}},
+		{Attempts: []service_types.ConnectionAttempt{{VpnType: vpn.WireGuard, IsTCP: true}}},
+		{Attempts: []service_types.ConnectionAttempt{{VpnType: vpn.OpenVPN, Port: 70000}}},
+	}
+	for i, p := range bad {
+		if err := p.Validate(); err == nil {
+			t.Errorf("%d: expected error for %v", i, p)
+		}
+	}
+}
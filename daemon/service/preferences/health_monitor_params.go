@@ -0,0 +1,44 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package preferences
+
+// HealthMonitorParams - parameters of the tunnel health monitor.
+// The monitor watches the connected tunnel (WireGuard handshake age, traffic counters, optional probe)
+// and reconnects when the tunnel stops passing traffic.
+type HealthMonitorParams struct {
+	IsEnabled bool
+	// IsFailover: when the tunnel is unhealthy - reconnect to the next-best host of the same location
+	// (and then to the hosts of the next location) instead of reconnecting to the same host.
+	// Not applicable for Multi-Hop connections.
+	// Disabled by default: the connection is not moved to a server the user did not choose, unless the user opted in.
+	IsFailover bool
+	// ProbeTarget (optional): "host:port" of a TCP service which must be reachable through the tunnel
+	ProbeTarget string
+}
+
+func HealthMonitorParamsCreate() HealthMonitorParams {
+	return HealthMonitorParams{
+		IsEnabled:  true,
+		IsFailover: false,
+	}
+}
@@ -0,0 +1,172 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package preferences
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+)
+
+// NetworkIdentity - the fingerprint of a network (Wi-Fi or wired).
+// Empty fields are not in use for matching.
+type NetworkIdentity struct {
+	SSID          string `json:"ssid,omitempty"`
+	GatewayMAC    string `json:"gatewayMac,omitempty"`    // hardware address of the default gateway
+	DHCPDomain    string `json:"dhcpDomain,omitempty"`    // domain name provided by DHCP
+	InterfaceName string `json:"interfaceName,omitempty"` // name of the default route interface (for profiles: shell pattern, e.g. "enp*")
+}
+
+func (id NetworkIdentity) IsEmpty() bool {
+	return len(id.SSID) == 0 && len(id.GatewayMAC) == 0 && len(id.DHCPDomain) == 0 && len(id.InterfaceName) == 0
+}
+
+// NetworkProfileActions - actions to apply when the profile matches the current network
+type NetworkProfileActions struct {
+	ConnectVpn      bool `json:"connectVpn"`
+	DisconnectVpn   bool `json:"disconnectVpn"`
+	EnableFirewall  bool `json:"enableFirewall"`
+	DisableFirewall bool `json:"disableFirewall"`
+	BlockLan        bool `json:"blockLan"` // block LAN traffic when the firewall is enabled
+}
+
+// NetworkProfile - the network rule: actions to apply when the current network matches the identity
+type NetworkProfile struct {
+	Name    string                `json:"name"`
+	Match   NetworkIdentity       `json:"match"`
+	Actions NetworkProfileActions `json:"actions"`
+}
+
+// IsMatching returns 'true' when all defined fields of the profile identity match the current network
+func (p NetworkProfile) IsMatching(current NetworkIdentity) bool {
+	m := p.Match
+	if m.IsEmpty() {
+		return false
+	}
+	if len(m.SSID) > 0 && m.SSID != current.SSID {
+		return false
+	}
+	if len(m.GatewayMAC) > 0 && !isSameMAC(m.GatewayMAC, current.GatewayMAC) {
+		return false
+	}
+	if len(m.DHCPDomain) > 0 && normalizeDomain(m.DHCPDomain) != normalizeDomain(current.DHCPDomain) {
+		return false
+	}
+	if len(m.InterfaceName) > 0 {
+		if ok, err := path.Match(m.InterfaceName, current.InterfaceName); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// NetworkProfilesParams - network profiles configuration.
+// Profiles are applicable to any network type (not only Wi-Fi); the first matching profile wins.
+// The action of the matching profile has priority over the trusted Wi-Fi action.
+type NetworkProfilesParams struct {
+	IsEnabled bool             `json:"isEnabled"`
+	Profiles  []NetworkProfile `json:"profiles"`
+}
+
+func NetworkProfilesParamsCreate() NetworkProfilesParams {
+	return NetworkProfilesParams{}
+}
+
+// MatchingProfile returns the first profile which matches the network (nil - no matching profile or profiles disabled)
+func (p NetworkProfilesParams) MatchingProfile(current NetworkIdentity) *NetworkProfile {
+	if !p.IsEnabled {
+		return nil
+	}
+	for i := range p.Profiles {
+		if p.Profiles[i].IsMatching(current) {
+			return &p.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// IsBlockLanDefined returns 'true' when at least one profile is configured to block LAN
+func (p NetworkProfilesParams) IsBlockLanDefined() bool {
+	if !p.IsEnabled {
+		return false
+	}
+	for _, pr := range p.Profiles {
+		if pr.Actions.BlockLan {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the profiles configuration
+func (p NetworkProfilesParams) Validate() error {
+	names := make(map[string]struct{}, len(p.Profiles))
+	for _, pr := range p.Profiles {
+		name := strings.TrimSpace(pr.Name)
+		if len(name) == 0 {
+			return fmt.Errorf("network profile name is empty")
+		}
+		if _, exists := names[strings.ToLower(name)]; exists {
+			return fmt.Errorf("network profile '%s' is defined more than once", name)
+		}
+		names[strings.ToLower(name)] = struct{}{}
+
+		if pr.Match.IsEmpty() {
+			return fmt.Errorf("network profile '%s': no network identity defined", name)
+		}
+		if len(pr.Match.GatewayMAC) > 0 {
+			if _, err := net.ParseMAC(pr.Match.GatewayMAC); err != nil {
+				return fmt.Errorf("network profile '%s': bad gateway MAC address '%s'", name, pr.Match.GatewayMAC)
+			}
+		}
+		if _, err := path.Match(pr.Match.InterfaceName, ""); err != nil {
+			return fmt.Errorf("network profile '%s': bad interface name pattern '%s'", name, pr.Match.InterfaceName)
+		}
+
+		a := pr.Actions
+		if a.ConnectVpn && a.DisconnectVpn {
+			return fmt.Errorf("network profile '%s': VPN can not be connected and disconnected at the same time", name)
+		}
+		if a.EnableFirewall && a.DisableFirewall {
+			return fmt.Errorf("network profile '%s': firewall can not be enabled and disabled at the same time", name)
+		}
+		if a.BlockLan && !a.EnableFirewall {
+			return fmt.Errorf("network profile '%s': blocking LAN requires enabling the firewall", name)
+		}
+	}
+	return nil
+}
+
+func isSameMAC(a, b string) bool {
+	macA, errA := net.ParseMAC(a)
+	macB, errB := net.ParseMAC(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b)
+	}
+	return macA.String() == macB.String()
+}
+
+func normalizeDomain(d string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(d), "."))
+}
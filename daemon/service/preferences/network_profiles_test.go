@@ -0,0 +1,88 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package preferences
+
+import "testing"
+
+func TestNetworkProfileMatching(t *testing.T) {
+	office := NetworkProfile{Name: "office", Match: NetworkIdentity{GatewayMAC: "A4-91-B1-00-00-01", DHCPDomain: "corp.example.com."}}
+	wired := NetworkProfile{Name: "wired", Match: NetworkIdentity{InterfaceName: "enp*"}}
+	home := NetworkProfile{Name: "home", Match: NetworkIdentity{SSID: "HomeNet"}}
+
+	params := NetworkProfilesParams{IsEnabled: true, Profiles: []NetworkProfile{office, wired, home}}
+
+	tests := []struct {
+		id   NetworkIdentity
+		want string
+	}{
+		{NetworkIdentity{GatewayMAC: "a4:91:b1:00:00:01", DHCPDomain: "Corp.Example.com", InterfaceName: "enp3s0"}, "office"},
+		{NetworkIdentity{GatewayMAC: "a4:91:b1:00:00:02", DHCPDomain: "corp.example.com", InterfaceName: "enp3s0"}, "wired"},
+		{NetworkIdentity{SSID: "HomeNet", InterfaceName: "wlp2s0"}, "home"},
+		{NetworkIdentity{SSID: "homenet", InterfaceName: "wlp2s0"}, ""},
+		{NetworkIdentity{}, ""},
+	}
+	for _, tt := range tests {
+		name := ""
+		if p := params.MatchingProfile(tt.id); p != nil {
+			name = p.Name
+		}
+		if name != tt.want {
+			t.Errorf("%+v: matched '%s'; expected '%s'", tt.id, name, tt.want)
+		}
+	}
+
+	params.IsEnabled = false
+	if p := params.MatchingProfile(tests[0].id); p != nil {
+		t.Errorf("disabled profiles must not match")
+	}
+}
+
+func TestNetworkProfilesValidate(t *testing.T) {
+	valid := NetworkProfile{Name: "office", Match: NetworkIdentity{DHCPDomain: "corp.example.com"}}
+	valid.Actions.ConnectVpn = true
+	valid.Actions.EnableFirewall = true
+	valid.Actions.BlockLan = true
+
+	if err := (NetworkProfilesParams{Profiles: []NetworkProfile{valid}}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := []NetworkProfile{
+		{Name: "", Match: valid.Match},
+		{Name: "empty"},
+		{Name: "mac", Match: NetworkIdentity{GatewayMAC: "not-a-mac"}},
+		{Name: "iface", Match: NetworkIdentity{InterfaceName: "en["}},
+		{Name: "vpn", Match: valid.Match, Actions: NetworkProfileActions{ConnectVpn: true, DisconnectVpn: true}},
+		{Name: "lan", Match: valid.Match, Actions: NetworkProfileActions{BlockLan: true}},
+	}
+	for _, p := range bad {
+		if err := (NetworkProfilesParams{Profiles: []NetworkProfile{p}}).Validate(); err == nil {
+			t.Errorf("profile '%s': expected validation error", p.Name)
+		}
+	}
+
+	dup := NetworkProfilesParams{Profiles: []NetworkProfile{valid, {Name: "Office", Match: valid.Match}}}
+	if err := dup.Validate(); err == nil {
+		t.Error("expected error for duplicate profile names")
+	}
+}
@@ -39,6 +39,7 @@ import (
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/obfsproxy"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
 	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/splittun"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/version"
 )
 
@@ -58,6 +59,10 @@ type LinuxSpecificUserPrefs struct {
 	// If true - use old style DNS management mechanism
 	// by direct modifying file '/etc/resolv.conf'
 	IsDnsMgmtOldStyle bool
+
+	// If true - use native nftables firewall backend (rules are applied over netlink)
+	// instead of the 'firewall.sh' script (iptables)
+	IsFwNftablesBackend bool
 }
 
 // UserPreferences - IVPN service preferences which can be exposed to client
@@ -65,6 +70,12 @@ type UserPreferences struct {
 	// NOTE: update this type when adding new preferences which can be exposed for clients
 	// ...
 
+	// Tunnel health monitor
+	HealthMonitor HealthMonitorParams
+
+	// Automatic port/protocol/obfuscation fallback when the connection is blocked
+	ConnectionFallback ConnectionFallbackParams
+
 	// The platform-specific preferences
 	Linux LinuxSpecificUserPrefs
 }
@@ -102,15 +113,30 @@ type Preferences struct {
 	SplitTunnelAnyDns         bool // (only for Inverse Split Tunnel) When false: Allow only DNS servers specified by the IVPN application
 	SplitTunnelAllowWhenNoVpn bool // (only for Inverse Split Tunnel) Allow connectivity for Split Tunnel apps when VPN is disabled
 
+	// Split Tunnel rules for destination addresses: bypass the VPN or forced through the VPN (applicable for Linux)
+	SplitTunnelDestinations []splittun.DestinationRule
+
 	// last known account status
 	Session SessionStatus
 	Account AccountStatus
+	// the session which secrets were not decrypted on load (it is saved as is, until the user logs in again)
+	sealedSession *SessionStatus
 
 	// NOTE: update this type when adding new preferences which can be exposed to clients
 	UserPrefs UserPreferences
 
 	LastConnectionParams service_types.ConnectionParams
 	WiFiControl          WiFiParams
+
+	// Network profiles: actions per network (Wi-Fi or wired) identified by SSID, gateway MAC, DHCP domain or interface
+	NetworkProfiles NetworkProfilesParams
+
+	// Time-based connection schedules
+	Scheduler SchedulerParams
+
+	// Connection fallback: the attempts which succeeded on the known networks
+	// [network ID ("ssid:<SSID>" or "gw:<default gateway IP>")] attempt info
+	FallbackNetworks map[string]FallbackNetworkInfo
 }
 
 func Create() *Preferences {
@@ -121,6 +147,12 @@ func Create() *Preferences {
 		SettingsSessionUUID: uuid.New().String(),
 		IsFwAllowApiServers: true,
 		WiFiControl:         WiFiParamsCreate(),
+		NetworkProfiles:     NetworkProfilesParamsCreate(),
+		Scheduler:           SchedulerParamsCreate(),
+		UserPrefs: UserPreferences{
+			HealthMonitor:      HealthMonitorParamsCreate(),
+			ConnectionFallback: ConnectionFallbackParamsCreate(),
+		},
 	}
 }
 
@@ -141,8 +173,8 @@ func (p *Preferences) SetSession(accountInfo AccountStatus,
 	session string,
 	wgPublicKey string,
 	wgPrivateKey string,
-	wgLocalIP string) {
-	//wgPreSharedKey string
+	wgLocalIP string,
+	wgPresharedKey string) {
 
 	if len(session) == 0 || len(accountID) == 0 {
 		p.Account = AccountStatus{}
@@ -150,7 +182,7 @@ func (p *Preferences) SetSession(accountInfo AccountStatus,
 		p.Account = accountInfo
 	}
 
-	p.setSession(accountID, session, wgPublicKey, wgPrivateKey, wgLocalIP) //, wgPreSharedKey)
+	p.setSession(accountID, session, wgPublicKey, wgPrivateKey, wgLocalIP, wgPresharedKey)
 	p.SavePreferences()
 }
 
@@ -163,8 +195,8 @@ func (p *Preferences) UpdateAccountInfo(acc AccountStatus) {
 }
 
 // UpdateWgCredentials save wireguard credentials
-func (p *Preferences) UpdateWgCredentials(wgPublicKey string, wgPrivateKey string, wgLocalIP string) { //}, wgPresharedKey string) {
-	p.Session.updateWgCredentials(wgPublicKey, wgPrivateKey, wgLocalIP) //, wgPresharedKey)
+func (p *Preferences) UpdateWgCredentials(wgPublicKey string, wgPrivateKey string, wgLocalIP string, wgPresharedKey string) {
+	p.Session.updateWgCredentials(wgPublicKey, wgPrivateKey, wgLocalIP, wgPresharedKey)
 	p.SavePreferences()
 }
 
@@ -179,7 +211,21 @@ func (p *Preferences) SavePreferences() error {
 
 	p.Version = version.Version()
 
-	data, err := json.Marshal(p)
+	// the secrets must not be saved in plaintext
+	toSave := *p
+	session := p.Session
+	if p.sealedSession != nil && !session.IsLoggedIn() {
+		// the secrets were not decrypted on load: keep them as is (the session will be restored on the next start)
+		session = *p.sealedSession
+	}
+	sealedSession, err := session.sealSecrets()
+	if err != nil {
+		log.Error(fmt.Sprintf("%v. The session secrets are not saved (re-login will be required after the daemon restart)", err))
+		sealedSession.clearSecrets()
+	}
+	toSave.Session = sealedSession
+
+	data, err := json.Marshal(toSave)
 	if err != nil {
 		return fmt.Errorf("failed to save preferences file (json marshal error): %w", err)
 	}
@@ -206,8 +252,28 @@ func (p *Preferences) SavePreferences() error {
 	return nil
 }
 
-// LoadPreferences loads preferences
+// LoadPreferences loads preferences.
+// Returns ErrSecretsUnavailable when the preferences are loaded but the session secrets can not be decrypted
+// (the session is not restored; the sealed secrets are kept in the preferences file).
 func (p *Preferences) LoadPreferences() error {
+	isNeedToSave, unsealErr, err := p.loadPreferences()
+	if err != nil {
+		return err
+	}
+
+	if isNeedToSave {
+		if err := p.SavePreferences(); err != nil {
+			log.Error(fmt.Sprintf("failed to save preferences: %v", err))
+		}
+	}
+
+	if unsealErr != nil {
+		return fmt.Errorf("%w: %v", ErrSecretsUnavailable, unsealErr)
+	}
+	return nil
+}
+
+func (p *Preferences) loadPreferences() (isNeedToSave bool, unsealErr error, retErr error) {
 	mutexRW.RLock()
 	defer mutexRW.RUnlock()
 
@@ -232,11 +298,13 @@ func (p *Preferences) LoadPreferences() error {
 		var errTmp error
 		data, errTmp = funcReadPreferences(p.getTempFilePath())
 		if errTmp != nil {
-			return err // return original error
+			return false, nil, err // return original error
 		}
 		log.Info("Preferences file was restored from temporary file")
 	}
 
+	isNeedToSave, unsealErr = p.unsealSecrets()
+
 	// init WG properties
 	if len(p.Session.WGPublicKey) == 0 || len(p.Session.WGPrivateKey) == 0 || len(p.Session.WGLocalIP) == 0 {
 		p.Session.WGKeyGenerated = time.Time{}
@@ -286,16 +354,50 @@ func (p *Preferences) LoadPreferences() error {
 		}
 	}
 
-	return nil
+	return isNeedToSave, unsealErr, nil
+}
+
+// unsealSecrets decrypts the session secrets loaded from the preferences file.
+// Returns 'true' when the preferences have to be saved:
+//   - the secrets (or some of them) are in plaintext (saved by the old version): they will be sealed on save (migration);
+//   - the secrets can never be decrypted (the key is lost or changed): the session is reset and the user has to log in again.
+//
+// Any other error (e.g. the key provider is temporarily not accessible) is returned:
+// the session is not restored but the sealed secrets are kept to be saved as is.
+func (p *Preferences) unsealSecrets() (isNeedToSave bool, err error) {
+	if !p.Session.hasSealedSecrets() {
+		return p.Session.hasSecrets(), nil
+	}
+	// some secrets are in plaintext (e.g. the account ID saved by the old version): they will be sealed on save
+	hasPlaintextSecrets := p.Session.hasPlaintextSecrets()
+
+	if err := p.Session.unsealSecrets(); err != nil {
+		if !isSecretsLost(err) {
+			log.Error(fmt.Sprintf("Unable to decrypt the session secrets: %v. The session is not restored", err))
+			sealed := p.Session
+			p.sealedSession = &sealed
+			p.Session = SessionStatus{WGKeysRegenInerval: p.Session.WGKeysRegenInerval}
+			return false, err
+		}
+
+		log.Error(fmt.Sprintf("Unable to decrypt the session secrets: %v", err))
+		log.Error("The session can not be restored (the secrets key is lost or changed). The session is reset: please log in again")
+
+		p.Session = SessionStatus{WGKeysRegenInerval: p.Session.WGKeysRegenInerval}
+		p.Account = AccountStatus{}
+		return true, nil
+	}
+	return hasPlaintextSecrets, nil
 }
 
 func (p *Preferences) setSession(accountID string,
 	session string,
 	wgPublicKey string,
 	wgPrivateKey string,
-	wgLocalIP string) {
-	//wgPreSharedKey string
+	wgLocalIP string,
+	wgPresharedKey string) {
 
+	p.sealedSession = nil // the new session replaces the one which was not decrypted on load
 	p.Session = SessionStatus{
 		AccountID:          strings.TrimSpace(accountID),
 		Session:            strings.TrimSpace(session),
@@ -305,7 +407,7 @@ func (p *Preferences) setSession(accountID string,
 		p.Session.WGKeysRegenInerval = DefaultWGKeysInterval
 	}
 
-	p.Session.updateWgCredentials(wgPublicKey, wgPrivateKey, wgLocalIP) //, wgPreSharedKey)
+	p.Session.updateWgCredentials(wgPublicKey, wgPrivateKey, wgLocalIP, wgPresharedKey)
 }
 
 // compareVersions compares two version strings in the format "XX.XX.XX..."
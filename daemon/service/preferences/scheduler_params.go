@@ -0,0 +1,130 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package preferences
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/scheduler"
+	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+)
+
+// ScheduleAction - the action of the schedule rule
+type ScheduleAction string
+
+const (
+	ScheduleConnect     ScheduleAction = "connect"
+	ScheduleDisconnect  ScheduleAction = "disconnect"
+	SchedulePause       ScheduleAction = "pause"
+	ScheduleFirewallOn  ScheduleAction = "firewall_on"
+	ScheduleFirewallOff ScheduleAction = "firewall_off"
+)
+
+// ScheduleRule - the action to apply at the time defined by cron-like specification (see package 'scheduler')
+type ScheduleRule struct {
+	Name      string
+	IsEnabled bool
+	Cron      string // e.g. "0 8 * * mon-fri" (local time)
+	Action    ScheduleAction
+	// Target (for ScheduleConnect): name of the connection target (empty - the last connection parameters)
+	Target string
+	// PauseMinutes (for SchedulePause): duration of the pause
+	PauseMinutes int
+}
+
+// ConnectionTarget - named connection parameters (can be in use by the schedule rules)
+type ConnectionTarget struct {
+	Name   string
+	Params service_types.ConnectionParams
+}
+
+// SchedulerParams - configuration of the time-based connection schedules
+type SchedulerParams struct {
+	IsEnabled bool
+	Rules     []ScheduleRule
+	Targets   []ConnectionTarget
+}
+
+func SchedulerParamsCreate() SchedulerParams {
+	return SchedulerParams{}
+}
+
+// FindTarget returns the connection target by name (nil - not found)
+func (p SchedulerParams) FindTarget(name string) *ConnectionTarget {
+	for i := range p.Targets {
+		if strings.EqualFold(p.Targets[i].Name, name) {
+			return &p.Targets[i]
+		}
+	}
+	return nil
+}
+
+// Validate checks the schedules configuration
+func (p SchedulerParams) Validate() error {
+	targets := make(map[string]struct{}, len(p.Targets))
+	for _, t := range p.Targets {
+		name := strings.ToLower(strings.TrimSpace(t.Name))
+		if len(name) == 0 {
+			return fmt.Errorf("connection target name is empty")
+		}
+		if _, exists := targets[name]; exists {
+			return fmt.Errorf("connection target '%s' is defined more than once", t.Name)
+		}
+		targets[name] = struct{}{}
+		if err := t.Params.CheckIsDefined(); err != nil {
+			return fmt.Errorf("connection target '%s': %w", t.Name, err)
+		}
+	}
+
+	rules := make(map[string]struct{}, len(p.Rules))
+	for _, r := range p.Rules {
+		name := strings.ToLower(strings.TrimSpace(r.Name))
+		if len(name) == 0 {
+			return fmt.Errorf("schedule rule name is empty")
+		}
+		if _, exists := rules[name]; exists {
+			return fmt.Errorf("schedule rule '%s' is defined more than once", r.Name)
+		}
+		rules[name] = struct{}{}
+
+		if _, err := scheduler.Parse(r.Cron); err != nil {
+			return fmt.Errorf("schedule rule '%s': %w", r.Name, err)
+		}
+
+		switch r.Action {
+		case ScheduleConnect:
+			if len(r.Target) > 0 && p.FindTarget(r.Target) == nil {
+				return fmt.Errorf("schedule rule '%s': connection target '%s' not defined", r.Name, r.Target)
+			}
+		case SchedulePause:
+			if r.PauseMinutes <= 0 {
+				return fmt.Errorf("schedule rule '%s': the duration of the pause has not been specified", r.Name)
+			}
+		case ScheduleDisconnect, ScheduleFirewallOn, ScheduleFirewallOff:
+		default:
+			return fmt.Errorf("schedule rule '%s': unsupported action '%s'", r.Name, r.Action)
+		}
+	}
+	return nil
+}
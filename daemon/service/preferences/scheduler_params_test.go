@@ -0,0 +1,72 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package preferences
+
+import (
+	"testing"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	service_types "github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+func TestSchedulerParamsValidate(t *testing.T) {
+	var params service_types.ConnectionParams
+	params.VpnType = vpn.WireGuard
+	params.WireGuardParameters.EntryVpnServer.Hosts = []api_types.ServerListItem{{Name: "nl1", Ip: "185.102.219.1"}}
+
+	valid := SchedulerParams{
+		IsEnabled: true,
+		Targets:   []ConnectionTarget{{Name: "work", Params: params}},
+		Rules: []ScheduleRule{
+			{Name: "morning", IsEnabled: true, Cron: "0 8 * * mon-fri", Action: ScheduleConnect, Target: "Work"},
+			{Name: "evening", IsEnabled: true, Cron: "0 19 * * mon-fri", Action: ScheduleDisconnect},
+			{Name: "backup", IsEnabled: true, Cron: "0 2 * * *", Action: SchedulePause, PauseMinutes: 60},
+		},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := []ScheduleRule{
+		{Name: "", Cron: "@daily", Action: ScheduleDisconnect},
+		{Name: "cron", Cron: "0 25 * * *", Action: ScheduleDisconnect},
+		{Name: "target", Cron: "@daily", Action: ScheduleConnect, Target: "home"},
+		{Name: "pause", Cron: "@daily", Action: SchedulePause},
+		{Name: "action", Cron: "@daily", Action: "reboot"},
+		{Name: "morning", Cron: "@daily", Action: ScheduleDisconnect},
+	}
+	for _, r := range bad {
+		p := valid
+		p.Rules = append(append([]ScheduleRule{}, valid.Rules...), r)
+		if err := p.Validate(); err == nil {
+			t.Errorf("rule '%s': expected validation error", r.Name)
+		}
+	}
+
+	noHosts := valid
+	noHosts.Targets = []ConnectionTarget{{Name: "work"}}
+	if err := noHosts.Validate(); err == nil {
+		t.Error("expected error for target without hosts")
+	}
+}
@@ -0,0 +1,230 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package preferences
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/helpers"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
+)
+
+// The secrets (account ID, session token, WireGuard private and preshared keys) are sealed in the preferences file using AES-256-GCM.
+// Sealed value format: "sealed:v1:<base64(nonce|ciphertext)>".
+// Values without the prefix are considered as plaintext (saved by the old versions); they are sealed on next save.
+const sealedPrefix = "sealed:v1:"
+
+const secretsKeySize = 32 // AES-256
+
+// ErrSecretsKeyNotFound - the key does not exist in the provider
+var ErrSecretsKeyNotFound = errors.New("secrets key not found")
+
+// ErrSecretsAuthFailed - the sealed value can not be authenticated (the key was changed or the value was modified)
+var ErrSecretsAuthFailed = errors.New("message authentication failed")
+
+// ErrSecretsUnavailable - the session secrets can not be decrypted now, but they are not lost (the session is not restored)
+var ErrSecretsUnavailable = errors.New("session secrets are not available")
+
+// isSecretsLost returns 'true' when the sealed secrets can never be decrypted (the key is lost or changed)
+func isSecretsLost(err error) bool {
+	return errors.Is(err, ErrSecretsKeyNotFound) || errors.Is(err, ErrSecretsAuthFailed)
+}
+
+// SecretsKeyProvider - the source of the key which is in use to seal secrets in the preferences file
+type SecretsKeyProvider interface {
+	// Name of the provider (for logging)
+	Name() string
+	// Key returns the existing key. Returns ErrSecretsKeyNotFound when the key does not exist
+	Key() ([]byte, error)
+	// CreateKey generates new key and stores it
+	CreateKey() ([]byte, error)
+}
+
+var (
+	secretsMutex       sync.Mutex
+	secretsKeyProvider SecretsKeyProvider
+	secretsKey         []byte // cached key
+)
+
+// SetSecretsKeyProvider overrides the default secrets key provider.
+// Must be called before loading preferences.
+func SetSecretsKeyProvider(provider SecretsKeyProvider) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	secretsKeyProvider = provider
+	secretsKey = nil
+}
+
+// getSecretsKey returns the key to seal/unseal secrets.
+// create - generate a new key if it does not exist yet
+func getSecretsKey(create bool) (key []byte, providerName string, err error) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+
+	if secretsKeyProvider == nil {
+		secretsKeyProvider = defaultSecretsKeyProvider()
+	}
+	providerName = secretsKeyProvider.Name()
+
+	if len(secretsKey) > 0 {
+		return secretsKey, providerName, nil
+	}
+
+	key, err = secretsKeyProvider.Key()
+	if errors.Is(err, ErrSecretsKeyNotFound) && create {
+		log.Info(fmt.Sprintf("Generating new secrets key (provider: %s)", providerName))
+		key, err = secretsKeyProvider.CreateKey()
+	}
+	if err != nil {
+		return nil, providerName, err
+	}
+	if len(key) != secretsKeySize {
+		return nil, providerName, fmt.Errorf("bad secrets key size (provider: %s)", providerName)
+	}
+
+	secretsKey = key
+	return key, providerName, nil
+}
+
+func isSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// seal encrypts the value. The 'name' is in use as additional authenticated data
+// (the sealed value can not be moved to another field).
+// The value which is already sealed is returned as is.
+func seal(key []byte, name, value string) (string, error) {
+	if len(value) == 0 || isSealed(value) {
+		return value, nil
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	data := gcm.Seal(nonce, nonce, []byte(value), []byte(name))
+	return sealedPrefix + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// unseal decrypts the value sealed by seal()
+func unseal(key []byte, name, value string) (string, error) {
+	if !isSealed(value) {
+		return value, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode sealed value '%s': %w", name, err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("failed to unseal '%s': bad data", name)
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to unseal '%s' (wrong key?): %w", name, ErrSecretsAuthFailed)
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ---------------------------------------------------
+// Key file provider
+// ---------------------------------------------------
+
+// keyFileProvider keeps the key in the file accessible only for privileged user
+type keyFileProvider struct {
+	file string
+}
+
+// NewKeyFileProvider creates the provider which keeps the key in the file (readable only for privileged user)
+func NewKeyFileProvider(file string) SecretsKeyProvider {
+	return &keyFileProvider{file: file}
+}
+
+func (kp *keyFileProvider) Name() string {
+	return "keyfile"
+}
+
+func (kp *keyFileProvider) Key() ([]byte, error) {
+	data, err := os.ReadFile(kp.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrSecretsKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to read secrets key file: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secrets key file: %w", err)
+	}
+	return key, nil
+}
+
+func (kp *keyFileProvider) CreateKey() ([]byte, error) {
+	key := make([]byte, secretsKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	// read\write only for privileged user
+	if err := helpers.WriteFile(kp.file, []byte(base64.StdEncoding.EncodeToString(key)), os.FileMode(0600)); err != nil {
+		return nil, fmt.Errorf("failed to save secrets key file: %w", err)
+	}
+	return key, nil
+}
+
+func newDefaultKeyFileProvider() SecretsKeyProvider {
+	return NewKeyFileProvider(platform.SecretsKeyFile())
+}
+
+// deriveKey converts any secret into the key of required size
+func deriveKey(secret []byte) []byte {
+	h := sha256.Sum256(secret)
+	return h[:]
+}
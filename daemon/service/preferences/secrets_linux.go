@@ -0,0 +1,158 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package preferences
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Environment variable to select the secrets key provider: "keyfile" (default), "keyring" or "systemd".
+// When the systemd credential exists - it is in use by default.
+// The "keyring" provider is opt-in only: the kernel keyring does not persist across reboots,
+// so the session secrets can not be decrypted after reboot (the session is reset and the user has to log in again).
+const envSecretsKeyProvider = "IVPN_SECRETS_KEY_PROVIDER"
+
+// systemdCredentialName - name of the systemd credential which contains the secret
+// (e.g. 'LoadCredentialEncrypted=ivpn-secrets-key:/etc/credstore.encrypted/ivpn-secrets-key' in the service unit)
+const systemdCredentialName = "ivpn-secrets-key"
+
+func defaultSecretsKeyProvider() SecretsKeyProvider {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(envSecretsKeyProvider))) {
+	case "keyring":
+		log.Warning("Secrets key provider 'keyring': the key does not persist across reboots (re-login will be required after reboot)")
+		return &keyringProvider{description: "ivpn:secrets-key"}
+	case "systemd":
+		return &systemdCredsProvider{}
+	case "keyfile":
+		return newDefaultKeyFileProvider()
+	}
+
+	if sp := (&systemdCredsProvider{}); sp.isAvailable() {
+		return sp
+	}
+	return newDefaultKeyFileProvider()
+}
+
+// ---------------------------------------------------
+// Kernel keyring provider
+// ---------------------------------------------------
+
+// keyringProvider keeps the key in the user keyring of the privileged user (kernel key retention service).
+// Note: the kernel keyring is not persistent; the key is lost after reboot
+// (the sealed secrets can not be decrypted anymore: the session is reset on the next start).
+type keyringProvider struct {
+	description string
+}
+
+func (kp *keyringProvider) Name() string {
+	return "keyring"
+}
+
+func (kp *keyringProvider) Key() ([]byte, error) {
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, "user", kp.description, 0)
+	if err != nil {
+		if errors.Is(err, unix.ENOKEY) || errors.Is(err, unix.EKEYEXPIRED) || errors.Is(err, unix.EKEYREVOKED) {
+			return nil, ErrSecretsKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to search key in kernel keyring: %w", err)
+	}
+
+	buf := make([]byte, secretsKeySize)
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, buf, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key from kernel keyring: %w", err)
+	}
+	if n != secretsKeySize {
+		return nil, fmt.Errorf("unexpected key size in kernel keyring")
+	}
+	return buf, nil
+}
+
+func (kp *keyringProvider) CreateKey() ([]byte, error) {
+	key := make([]byte, secretsKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if _, err := unix.AddKey("user", kp.description, key, unix.KEY_SPEC_USER_KEYRING); err != nil {
+		return nil, fmt.Errorf("failed to add key to kernel keyring: %w", err)
+	}
+	return key, nil
+}
+
+// ---------------------------------------------------
+// systemd credentials provider
+// ---------------------------------------------------
+
+// systemdCredsProvider reads the secret from systemd credentials ($CREDENTIALS_DIRECTORY).
+// The credential is managed by the system administrator; the daemon is not able to create it.
+type systemdCredsProvider struct{}
+
+func (sp *systemdCredsProvider) Name() string {
+	return "systemd"
+}
+
+func (sp *systemdCredsProvider) file() string {
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if len(dir) == 0 {
+		return ""
+	}
+	return filepath.Join(dir, systemdCredentialName)
+}
+
+func (sp *systemdCredsProvider) isAvailable() bool {
+	file := sp.file()
+	if len(file) == 0 {
+		return false
+	}
+	_, err := os.Stat(file)
+	return err == nil
+}
+
+func (sp *systemdCredsProvider) Key() ([]byte, error) {
+	file := sp.file()
+	if len(file) == 0 {
+		return nil, ErrSecretsKeyNotFound
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrSecretsKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to read systemd credential: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("systemd credential '%s' is empty", systemdCredentialName)
+	}
+	return deriveKey(data), nil
+}
+
+func (sp *systemdCredsProvider) CreateKey() ([]byte, error) {
+	return nil, fmt.Errorf("systemd credential '%s' is not defined (it must be configured in the service unit)", systemdCredentialName)
+}
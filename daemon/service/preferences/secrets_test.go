@@ -0,0 +1,155 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package preferences
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSealUnseal(t *testing.T) {
+	key := deriveKey([]byte("test"))
+
+	sealed, err := seal(key, "Session", "secret-value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSealed(sealed) {
+		t.Fatalf("value is not sealed: %s", sealed)
+	}
+
+	plain, err := unseal(key, "Session", sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != "secret-value" {
+		t.Errorf("unexpected unsealed value: %s", plain)
+	}
+
+	if _, err := unseal(deriveKey([]byte("wrong")), "Session", sealed); !errors.Is(err, ErrSecretsAuthFailed) {
+		t.Error("expected error for wrong key")
+	}
+	if _, err := unseal(key, "WGPrivateKey", sealed); err == nil {
+		t.Error("expected error for value moved to another field")
+	}
+
+	// plaintext values are returned as is
+	if plain, err := unseal(key, "Session", "plaintext"); err != nil || plain != "plaintext" {
+		t.Errorf("unexpected result for plaintext value: '%s' (%v)", plain, err)
+	}
+}
+
+func TestKeyFileProvider(t *testing.T) {
+	kp := NewKeyFileProvider(filepath.Join(t.TempDir(), "secrets.key"))
+
+	if _, err := kp.Key(); !errors.Is(err, ErrSecretsKeyNotFound) {
+		t.Fatalf("expected ErrSecretsKeyNotFound; got: %v", err)
+	}
+
+	created, err := kp.CreateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := kp.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(key) != string(created) || len(key) != secretsKeySize {
+		t.Error("the key read from file is not equal to created one")
+	}
+}
+
+type testKeyProvider struct {
+	key []byte
+	err error
+}
+
+func (kp *testKeyProvider) Name() string               { return "test" }
+func (kp *testKeyProvider) Key() ([]byte, error)       { return kp.key, kp.err }
+func (kp *testKeyProvider) CreateKey() ([]byte, error) { return kp.key, kp.err }
+
+func TestUnsealSecrets(t *testing.T) {
+	defer SetSecretsKeyProvider(nil)
+
+	key := deriveKey([]byte("test"))
+	SetSecretsKeyProvider(&testKeyProvider{key: key})
+	sealed, err := SessionStatus{AccountID: "i-XXXX", Session: "token", WGPrivateKey: "wgkey"}.sealSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSealed(sealed.AccountID) || !isSealed(sealed.Session) || !isSealed(sealed.WGPrivateKey) {
+		t.Fatalf("secrets are not sealed: %v", sealed)
+	}
+
+	// the key provider is temporarily not accessible: the sealed secrets are kept
+	SetSecretsKeyProvider(&testKeyProvider{err: errors.New("provider not accessible")})
+	p := Preferences{Session: sealed, Account: AccountStatus{Active: true}}
+	isNeedToSave, err := p.unsealSecrets()
+	if err == nil || isNeedToSave {
+		t.Fatalf("expected error without saving (isNeedToSave=%v, err=%v)", isNeedToSave, err)
+	}
+	if p.Session.IsLoggedIn() || !p.Account.Active {
+		t.Error("the session must not be restored and the account must be kept")
+	}
+	if toSave, err := p.sealedSession.sealSecrets(); err != nil || toSave.Session != sealed.Session || toSave.WGPrivateKey != sealed.WGPrivateKey {
+		t.Errorf("the sealed secrets must be saved as is (err=%v)", err)
+	}
+
+	// new login replaces the kept session
+	p.setSession("i-YYYY", "token2", "", "", "", "")
+	if p.sealedSession != nil {
+		t.Error("the kept session must be dropped after login")
+	}
+
+	// the key is changed: the session is reset
+	SetSecretsKeyProvider(&testKeyProvider{key: deriveKey([]byte("another"))})
+	p = Preferences{Session: sealed, Account: AccountStatus{Active: true}}
+	if isNeedToSave, err := p.unsealSecrets(); err != nil || !isNeedToSave {
+		t.Fatalf("expected reset (isNeedToSave=%v, err=%v)", isNeedToSave, err)
+	}
+	if p.Session.hasSecrets() || p.Account.Active || p.sealedSession != nil {
+		t.Error("the session and the account must be reset")
+	}
+
+	// the key is lost: the session is reset
+	SetSecretsKeyProvider(&testKeyProvider{err: ErrSecretsKeyNotFound})
+	p = Preferences{Session: sealed}
+	if isNeedToSave, err := p.unsealSecrets(); err != nil || !isNeedToSave || p.Session.hasSecrets() {
+		t.Fatalf("expected reset (isNeedToSave=%v, err=%v)", isNeedToSave, err)
+	}
+
+	// correct key
+	SetSecretsKeyProvider(&testKeyProvider{key: key})
+	p = Preferences{Session: sealed}
+	if isNeedToSave, err := p.unsealSecrets(); err != nil || isNeedToSave || p.Session.AccountID != "i-XXXX" || p.Session.Session != "token" || p.Session.WGPrivateKey != "wgkey" {
+		t.Fatalf("unexpected result (isNeedToSave=%v, err=%v): %v", isNeedToSave, err, p.Session)
+	}
+
+	// the account ID in plaintext (saved by the old version): the preferences must be saved
+	p = Preferences{Session: sealed}
+	p.Session.AccountID = "i-XXXX"
+	if isNeedToSave, err := p.unsealSecrets(); err != nil || !isNeedToSave || p.Session.AccountID != "i-XXXX" {
+		t.Fatalf("unexpected result for plaintext account ID (isNeedToSave=%v, err=%v)", isNeedToSave, err)
+	}
+}
@@ -23,6 +23,7 @@
 package preferences
 
 import (
+	"fmt"
 	"net"
 	"strings"
 	"time"
@@ -55,7 +56,7 @@ func (s *SessionStatus) IsWGCredentialsOk() bool {
 	return true
 }
 
-func (s *SessionStatus) updateWgCredentials(wgPublicKey string, wgPrivateKey string, wgLocalIP string) { //}, wgPresharedKey string) {
+func (s *SessionStatus) updateWgCredentials(wgPublicKey string, wgPrivateKey string, wgLocalIP string, wgPresharedKey string) {
 	if len(wgLocalIP) > 0 {
 		if net.ParseIP(wgLocalIP) == nil {
 			log.Error("Unable to save WG credentials (local IP has wrong format)")
@@ -65,7 +66,7 @@ func (s *SessionStatus) updateWgCredentials(wgPublicKey string, wgPrivateKey str
 
 	s.WGPublicKey = strings.TrimSpace(wgPublicKey)
 	s.WGPrivateKey = strings.TrimSpace(wgPrivateKey)
-	//s.WGPresharedKey = strings.TrimSpace(wgPresharedKey)
+	s.WGPresharedKey = strings.TrimSpace(wgPresharedKey)
 	s.WGLocalIP = strings.TrimSpace(wgLocalIP)
 
 	if len(s.WGPublicKey) > 0 && len(s.WGPrivateKey) > 0 && len(s.WGLocalIP) > 0 {
@@ -74,3 +75,83 @@ func (s *SessionStatus) updateWgCredentials(wgPublicKey string, wgPrivateKey str
 		s.WGKeyGenerated = time.Time{}
 	}
 }
+
+// secretFields returns the fields which must be sealed in the preferences file
+func (s *SessionStatus) secretFields() map[string]*string {
+	return map[string]*string{
+		"AccountID":      &s.AccountID, // the account ID is the login PIN
+		"Session":        &s.Session,
+		"WGPrivateKey":   &s.WGPrivateKey,
+		"WGPresharedKey": &s.WGPresharedKey,
+	}
+}
+
+func (s *SessionStatus) hasSecrets() bool {
+	for _, v := range s.secretFields() {
+		if len(*v) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SessionStatus) hasSealedSecrets() bool {
+	for _, v := range s.secretFields() {
+		if isSealed(*v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SessionStatus) hasPlaintextSecrets() bool {
+	for _, v := range s.secretFields() {
+		if len(*v) > 0 && !isSealed(*v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SessionStatus) clearSecrets() {
+	for _, v := range s.secretFields() {
+		*v = ""
+	}
+}
+
+// sealSecrets returns a copy of the session object with sealed secrets
+func (s SessionStatus) sealSecrets() (SessionStatus, error) {
+	if !s.hasPlaintextSecrets() {
+		return s, nil // nothing to seal (the key is not required)
+	}
+
+	key, provider, err := getSecretsKey(true)
+	if err != nil {
+		return s, fmt.Errorf("failed to get secrets key (provider: %s): %w", provider, err)
+	}
+
+	for name, v := range s.secretFields() {
+		if *v, err = seal(key, name, *v); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// unsealSecrets decrypts the sealed secrets.
+// The object is not modified when any of the secrets can not be decrypted.
+func (s *SessionStatus) unsealSecrets() error {
+	key, provider, err := getSecretsKey(false)
+	if err != nil {
+		return fmt.Errorf("failed to get secrets key (provider: %s): %w", provider, err)
+	}
+
+	ret := *s
+	for name, v := range ret.secretFields() {
+		if *v, err = unseal(key, name, *v); err != nil {
+			return err
+		}
+	}
+	*s = ret
+	return nil
+}
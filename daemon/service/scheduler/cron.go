@@ -0,0 +1,214 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+// Package scheduler implements cron-like time specifications for the connection schedules.
+//
+// The specification consists of five space-separated fields (evaluated in the local time zone):
+//
+//	minute (0-59) hour (0-23) day-of-month (1-31) month (1-12) day-of-week (0-6, 0 - Sunday; 7 is also Sunday)
+//
+// Each field accepts '*', single values, ranges ('1-5'), lists ('1,3,5') and steps ('*/15', '8-18/2').
+// Months and days of week can be defined by names ('jan', 'mon-fri').
+// As in the standard cron: when both day-of-month and day-of-week are restricted, the time matches if either of them matches.
+// Predefined specifications: @hourly, @daily (@midnight), @weekly, @monthly, @yearly (@annually).
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron - parsed time specification
+type Cron struct {
+	minute, hour, dom, month, dow uint64 // bit sets
+	isDomAny, isDowAny            bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	fieldMinute = field{name: "minute", min: 0, max: 59}
+	fieldHour   = field{name: "hour", min: 0, max: 23}
+	fieldDom    = field{name: "day-of-month", min: 1, max: 31}
+	fieldMonth  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}}
+	fieldDow = field{name: "day-of-week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}}
+)
+
+var predefined = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses the time specification
+func Parse(spec string) (Cron, error) {
+	spec = strings.TrimSpace(spec)
+	if p, ok := predefined[strings.ToLower(spec)]; ok {
+		spec = p
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return Cron{}, fmt.Errorf("bad time specification '%s': expected 5 fields, got %d", spec, len(fields))
+	}
+
+	var (
+		c   Cron
+		err error
+	)
+	if c.minute, _, err = parseField(fields[0], fieldMinute); err != nil {
+		return Cron{}, err
+	}
+	if c.hour, _, err = parseField(fields[1], fieldHour); err != nil {
+		return Cron{}, err
+	}
+	if c.dom, c.isDomAny, err = parseField(fields[2], fieldDom); err != nil {
+		return Cron{}, err
+	}
+	if c.month, _, err = parseField(fields[3], fieldMonth); err != nil {
+		return Cron{}, err
+	}
+	if c.dow, c.isDowAny, err = parseField(fields[4], fieldDow); err != nil {
+		return Cron{}, err
+	}
+	// 7 is an alias for Sunday
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	return c, nil
+}
+
+// Matches returns 'true' when the time (truncated to minutes) satisfies the specification
+func (c Cron) Matches(t time.Time) bool {
+	return c.minute&(1<<uint(t.Minute())) != 0 &&
+		c.hour&(1<<uint(t.Hour())) != 0 &&
+		c.month&(1<<uint(t.Month())) != 0 &&
+		c.isDayMatches(t)
+}
+
+// Next returns the first time after 't' which satisfies the specification
+// (zero time - when there is no such time during the next 5 years, e.g. "0 0 31 2 *")
+func (c Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.isDayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c Cron) isDayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.isDomAny || c.isDowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseField returns the bit set of the allowed values and 'true' if the field is '*' (any value)
+func parseField(s string, f field) (bits uint64, isAny bool, err error) {
+	for _, part := range strings.Split(s, ",") {
+		rangeStr, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, false, fmt.Errorf("bad %s step '%s'", f.name, part)
+			}
+		}
+
+		var from, to int
+		switch {
+		case rangeStr == "*":
+			from, to = f.min, f.max
+			if f.name == fieldDow.name {
+				to = 6
+			}
+			isAny = isAny || !hasStep
+		case strings.Contains(rangeStr, "-"):
+			fromStr, toStr, _ := strings.Cut(rangeStr, "-")
+			if from, err = f.value(fromStr); err != nil {
+				return 0, false, err
+			}
+			if to, err = f.value(toStr); err != nil {
+				return 0, false, err
+			}
+			if from > to {
+				return 0, false, fmt.Errorf("bad %s range '%s'", f.name, rangeStr)
+			}
+		default:
+			if from, err = f.value(rangeStr); err != nil {
+				return 0, false, err
+			}
+			to = from
+			if hasStep {
+				to = f.max
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, isAny, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("bad %s value '%s' (expected %d-%d)", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
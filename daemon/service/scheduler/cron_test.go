@@ -0,0 +1,99 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("'%s': expected error", spec)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	// 2024-01-15 is Monday
+	mon0800 := time.Date(2024, 1, 15, 8, 0, 0, 0, time.Local)
+	sat0800 := time.Date(2024, 1, 20, 8, 0, 0, 0, time.Local)
+	sun2330 := time.Date(2024, 1, 21, 23, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		spec string
+		t    time.Time
+		want bool
+	}{
+		{"0 8 * * 1-5", mon0800, true},
+		{"0 8 * * mon-fri", sat0800, false},
+		{"0 8 * * *", sat0800, true},
+		{"*/15 * * * *", mon0800, true},
+		{"*/15 * * * *", mon0800.Add(time.Minute), false},
+		{"30 23 * * 7", sun2330, true},
+		{"30 23 * * sun", sun2330, true},
+		{"0 8 15 jan *", mon0800, true},
+		{"0 8 1 * 1", mon0800, true},  // day-of-month OR day-of-week
+		{"0 8 1 * 2", mon0800, false}, // neither day-of-month nor day-of-week
+		{"@daily", time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local), true},
+		{"@hourly", mon0800.Add(time.Minute * 30), false},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("'%s': %v", tt.spec, err)
+		}
+		if got := c.Matches(tt.t); got != tt.want {
+			t.Errorf("'%s' at %v: got %v; expected %v", tt.spec, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2024, 1, 19, 19, 0, 0, 0, time.Local) // Friday 19:00
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"0 8 * * 1-5", time.Date(2024, 1, 22, 8, 0, 0, 0, time.Local)},
+		{"0 19 * * 1-5", time.Date(2024, 1, 22, 19, 0, 0, 0, time.Local)},
+		{"*/20 * * * *", time.Date(2024, 1, 19, 19, 20, 0, 0, time.Local)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("'%s': %v", tt.spec, err)
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("'%s': next %v; expected %v", tt.spec, got, tt.want)
+		}
+	}
+
+	c, _ := Parse("0 0 31 2 *")
+	if got := c.Next(from); !got.IsZero() {
+		t.Errorf("impossible date: got %v", got)
+	}
+}
@@ -47,16 +47,22 @@ func CreateServersUpdater(apiObj *api.API) (IServersUpdater, error) {
 
 	updater.updatedNotifyChan = make(chan struct{}, 1)
 
-	_, _ = updater.GetServers()
-	//if err == nil && servers != nil {
-	//	// save alternate API IP's
-	//	apiObj.SetAlternateIPs(servers.Config.API.IPAddresses, servers.Config.API.IPv6Addresses)
-	//}
+	if err := api.ServersListSignatureConfigError(); err != nil {
+		log.Error(fmt.Sprintf("%v. The servers cache file is not in use (the servers list is downloaded on each start)", err))
+	}
+
+	servers, err := updater.GetServers()
+	if err == nil && servers != nil {
+		// save alternate API IP's
+		apiObj.SetAlternateIPs(servers.Config.API.IPAddresses, servers.Config.API.IPv6Addresses)
+	}
 	return updater, nil
 }
 
 // GetServers - get servers list.
 // Use cached data (if exists), otherwise - download servers list.
+// If the servers list can not be downloaded - the list bundled into the package is in use (last-known-good).
+// The cache is in use only when the servers list signature verification is configured (see api.ServersListSignatureConfigError()).
 func (s *serversUpdater) GetServers() (*types.ServerListResponse, error) {
 	if s.servers != nil {
 		return s.servers, nil
@@ -81,7 +87,20 @@ func (s *serversUpdater) GetServers() (*types.ServerListResponse, error) {
 		return servers, nil
 	}
 
-	return s.updateServers()
+	servers, err = s.updateServers()
+	if err == nil {
+		return servers, nil
+	}
+
+	bundled, errBundled := readServersBundled()
+	if errBundled != nil {
+		log.Warning(errBundled)
+		return servers, err
+	}
+
+	log.Info(fmt.Sprintf("Using bundled servers list (%s)", platform.ServersFileBundled()))
+	s.servers = bundled
+	return bundled, nil
 }
 
 // GetServersForceUpdate returns servers list info (locations, hosts and host load).
@@ -116,7 +135,7 @@ func (s *serversUpdater) StartUpdater() error {
 
 // UpdateServers - download servers list
 func (s *serversUpdater) updateServers() (*types.ServerListResponse, error) {
-	servers, err := s.api.DownloadServersList()
+	servers, data, signature, err := s.api.DownloadServersList()
 	if err != nil {
 		return servers, fmt.Errorf("failed to download servers list: %w", err)
 	}
@@ -131,7 +150,10 @@ func (s *serversUpdater) updateServers() (*types.ServerListResponse, error) {
 	log.Info(fmt.Sprintf("Updated servers info (%d OpenVPN; %d WireGuard)\n", len(servers.ServerList.OpenVPNServers), len(servers.ServerList.WireGuardServers)))
 
 	s.servers = servers
-	if err := writeServersToCache(servers); err != nil {
+	// save alternate API IP's
+	s.api.SetAlternateIPs(servers.Config.API.IPAddresses, servers.Config.API.IPv6Addresses)
+
+	if err := writeServersToCache(data, signature); err != nil {
 		log.Error("failed to save servers cache file: ", err)
 	}
 
@@ -151,25 +173,22 @@ func (s *serversUpdater) UpdateNotifierChannel() chan struct{} {
 }
 
 func readServersFromCache() (svrs *types.ServerListResponse, e error) {
-
 	serversFile := platform.ServersFile()
 
-	_, err := os.Stat(serversFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("failed to read servers cache file: %w", err)
-		}
-		return nil, fmt.Errorf("failed to info about servers cache file: %w", err)
+	// fail closed: the cache is not trusted when its signature can not be verified
+	if err := api.ServersListSignatureConfigError(); err != nil {
+		return nil, fmt.Errorf("skip reading servers cache file: %w", err)
 	}
 
-	data, err := os.ReadFile(serversFile)
+	const isSignatureRequired = true
+	servers, err := readServersFile(serversFile, isSignatureRequired)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read servers cache file: %w", err)
-	}
-
-	servers := new(types.ServerListResponse)
-	if err := json.Unmarshal(data, servers); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal servers cache file: %w", err)
+		if errors.Is(err, errServersSignature) {
+			// the cached data is not trusted: remove it
+			os.Remove(serversFile)
+			os.Remove(platform.ServersSignatureFile(serversFile))
+		}
+		return nil, fmt.Errorf("skip reading servers cache file: %w", err)
 	}
 
 	// check servers.json file has correct access rights (can we use it's data?)
@@ -184,19 +203,76 @@ func readServersFromCache() (svrs *types.ServerListResponse, e error) {
 	return servers, nil
 }
 
-func writeServersToCache(servers *types.ServerListResponse) error {
-	if servers == nil {
-		return errors.New("nothing to save. Servers is null")
+func readServersBundled() (svrs *types.ServerListResponse, e error) {
+	serversFile := platform.ServersFileBundled()
+	if len(serversFile) == 0 {
+		return nil, fmt.Errorf("bundled servers list not defined")
+	}
+
+	// the bundled file is not signed: it is trusted because it is a part of the installation (writable only by the privileged user)
+	const isSignatureRequired = false
+	servers, err := readServersFile(serversFile, isSignatureRequired)
+	if err != nil {
+		return nil, fmt.Errorf("skip reading bundled servers file: %w", err)
+	}
+	return servers, nil
+}
+
+var errServersSignature = errors.New("servers list signature verification failed")
+
+// readServersFile reads servers list and verifies its detached signature (if required)
+func readServersFile(serversFile string, isSignatureRequired bool) (svrs *types.ServerListResponse, e error) {
+	_, err := os.Stat(serversFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read servers file: %w", err)
+		}
+		return nil, fmt.Errorf("failed to info about servers file: %w", err)
 	}
 
-	data, err := json.Marshal(servers)
+	data, err := os.ReadFile(serversFile)
 	if err != nil {
-		return fmt.Errorf("failed to marshal servers into a cache: %w", err)
+		return nil, fmt.Errorf("failed to read servers file: %w", err)
 	}
 
-	if data == nil {
-		return errors.New("failed to serialize servers")
+	if isSignatureRequired {
+		signature, err := os.ReadFile(platform.ServersSignatureFile(serversFile))
+		if err != nil {
+			return nil, fmt.Errorf("%w ('%s'): %s", errServersSignature, serversFile, err)
+		}
+		if err := api.VerifyServersListSignature(data, signature); err != nil {
+			return nil, fmt.Errorf("%w ('%s'): %s", errServersSignature, serversFile, err)
+		}
 	}
 
-	return os.WriteFile(platform.ServersFile(), data, filerights.DefaultFilePermissionsForConfig())
+	servers := new(types.ServerListResponse)
+	if err := json.Unmarshal(data, servers); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal servers file: %w", err)
+	}
+
+	return servers, nil
+}
+
+// writeServersToCache saves the raw servers list data (exactly as it was received from the backend) and its signature
+func writeServersToCache(data []byte, signature []byte) error {
+	if len(data) == 0 {
+		return errors.New("nothing to save. Servers is empty")
+	}
+
+	serversFile := platform.ServersFile()
+	if !api.IsServersListSignatureEnabled() {
+		// the cache is not in use without signature verification: remove the stale files (if any)
+		os.Remove(serversFile)
+		os.Remove(platform.ServersSignatureFile(serversFile))
+		return nil
+	}
+
+	if len(signature) == 0 {
+		return errors.New("nothing to save. Servers signature is empty")
+	}
+	// write the signature first: the servers file without the valid signature is ignored
+	if err := os.WriteFile(platform.ServersSignatureFile(serversFile), signature, filerights.DefaultFilePermissionsForConfig()); err != nil {
+		return fmt.Errorf("failed to save servers signature: %w", err)
+	}
+	return os.WriteFile(serversFile, data, filerights.DefaultFilePermissionsForConfig())
 }
@@ -0,0 +1,272 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+// Package serverscore ranks VPN servers by a composite score.
+// The score combines the latency (ping results), the distance to the user location,
+// the success rate of the previous connections to the server and the server load (when it is provided by the backend).
+package serverscore
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/helpers"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/history"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+// Constraints - restrictions for the servers to be ranked
+type Constraints struct {
+	// Country codes (or country names) of allowed servers (empty - all countries are allowed)
+	CountriesAllow []string `json:",omitempty"`
+	// Country codes (or country names) of servers which must be ignored
+	CountriesDeny []string `json:",omitempty"`
+	// Only premium servers
+	PremiumOnly bool `json:",omitempty"`
+	// Only servers which support IPv6
+	IPv6Capable bool `json:",omitempty"`
+}
+
+// IsEmpty returns true when no constraints defined
+func (c Constraints) IsEmpty() bool {
+	return len(c.CountriesAllow) == 0 && len(c.CountriesDeny) == 0 && !c.PremiumOnly && !c.IPv6Capable
+}
+
+// IsAllowed returns true when the host satisfies the constraints
+func (c Constraints) IsAllowed(h api_types.ServerListItem) bool {
+	if c.PremiumOnly && !h.Premium {
+		return false
+	}
+	if c.IPv6Capable && len(strings.TrimSpace(h.IPv6)) == 0 {
+		return false
+	}
+	if len(c.CountriesAllow) > 0 && !isCountryInList(h, c.CountriesAllow) {
+		return false
+	}
+	if isCountryInList(h, c.CountriesDeny) {
+		return false
+	}
+	return true
+}
+
+func isCountryInList(h api_types.ServerListItem, list []string) bool {
+	for _, c := range list {
+		c = strings.TrimSpace(c)
+		if len(c) == 0 {
+			continue
+		}
+		if strings.EqualFold(c, h.CountryCode) || strings.EqualFold(c, h.Country) {
+			return true
+		}
+	}
+	return false
+}
+
+// Weights - contribution of each score component into the composite score.
+// The weights of the components for which there is no data (e.g. no ping results) are not taken into account.
+type Weights struct {
+	Latency     float64
+	Distance    float64
+	SuccessRate float64
+	Load        float64
+}
+
+// DefaultWeights returns the default weights of the score components
+func DefaultWeights() Weights {
+	return Weights{Latency: 0.45, Distance: 0.2, SuccessRate: 0.2, Load: 0.15}
+}
+
+// distance (km) at which the distance component of the score is 0.5
+const distanceHalfScoreKm = 1000.0
+
+// ConnectionStats - statistics of the previous connections to the server
+type ConnectionStats struct {
+	Attempts  int // number of connection sessions
+	Successes int // number of sessions which reached CONNECTED state
+}
+
+// SuccessRate returns the estimated probability of successful connection.
+// The estimation is smoothed, so the servers without history get 0.5
+func (s ConnectionStats) SuccessRate() float64 {
+	return float64(s.Successes+1) / float64(s.Attempts+2)
+}
+
+// StatsFromHistory calculates the connection statistics for each server from the connection history.
+// Each connection session finishes with DISCONNECTED entry; the session is successful if it reached CONNECTED state.
+// Sessions cancelled by the user before connection are not taken into account.
+// 'entries' - history entries in the order returned by history.Journal.Get() (the newest first)
+func StatsFromHistory(entries []history.Entry) map[string]ConnectionStats {
+	ret := make(map[string]ConnectionStats)
+	isConnected := false
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		switch e.State {
+		case vpn.CONNECTED.String():
+			isConnected = true
+		case vpn.DISCONNECTED.String():
+			if len(e.Server) > 0 && (isConnected || e.Reason != history.ReasonDisconnectionRequested) {
+				stats := ret[e.Server]
+				stats.Attempts++
+				if isConnected {
+					stats.Successes++
+				}
+				ret[e.Server] = stats
+			}
+			isConnected = false
+		}
+	}
+	return ret
+}
+
+// Input - data required to rank the servers
+type Input struct {
+	Servers []api_types.ServerListCountryItem
+	// Ping results: host IP -> latency (ms)
+	Pings map[string]int
+	// Location of the user (nil - unknown)
+	Location *api_types.GeoLookupResponse
+	// Previous connections statistics: server name -> stats
+	Stats       map[string]ConnectionStats
+	Constraints Constraints
+	// Locations (ServerListCountryItem.Flag) which must be ignored
+	ExcludedLocations []string
+}
+
+// Candidate - the ranked server host
+type Candidate struct {
+	Host api_types.ServerListItem
+	// Score in range [0..1] (higher is better)
+	Score float64
+
+	PingMs      int     // 0 - no ping result
+	DistanceKm  float64 // < 0 - unknown
+	SuccessRate float64
+	Attempts    int // number of the previous connections to the server
+
+	// Human-readable explanation of each score component
+	Explanation []string
+}
+
+// Rank returns the hosts which satisfy the constraints, sorted by score (the best first)
+func Rank(in Input, w Weights) []Candidate {
+	excluded := make(map[string]struct{}, len(in.ExcludedLocations))
+	for _, l := range in.ExcludedLocations {
+		excluded[l] = struct{}{}
+	}
+
+	location := in.Location
+	if location != nil && (len(location.SLatitude) == 0 || len(location.SLongitude) == 0) {
+		location = nil
+	}
+
+	var candidates []Candidate
+	for _, loc := range in.Servers {
+		if _, ok := excluded[loc.Flag]; ok {
+			continue
+		}
+		for _, h := range loc.Hosts {
+			if !in.Constraints.IsAllowed(h) {
+				continue
+			}
+			c := Candidate{Host: h, PingMs: in.Pings[h.Ip], DistanceKm: -1}
+			stats := in.Stats[h.Name]
+			c.SuccessRate, c.Attempts = stats.SuccessRate(), stats.Attempts
+			if location != nil && hasCoordinates(h) {
+				c.DistanceKm = helpers.GetDistanceFromLatLonInKm(
+					float64(location.Latitude()), float64(location.Longitude()),
+					float64(h.Latitude()), float64(h.Longitude()))
+			}
+			candidates = append(candidates, c)
+		}
+	}
+
+	// the components are taken into account only if there is data for at least one candidate
+	bestPing, hasDistance, hasLoad := 0, false, false
+	for _, c := range candidates {
+		if c.PingMs > 0 && (bestPing == 0 || c.PingMs < bestPing) {
+			bestPing = c.PingMs
+		}
+		hasDistance = hasDistance || c.DistanceKm >= 0
+		hasLoad = hasLoad || c.Host.Load > 0
+	}
+
+	for i := range candidates {
+		c := &candidates[i]
+		var sum, weights float64
+		add := func(weight, score float64, explanation string) {
+			sum += weight * score
+			weights += weight
+			c.Explanation = append(c.Explanation, fmt.Sprintf("%s: %.2f (weight %.2f)", explanation, score, weight))
+		}
+
+		if bestPing > 0 {
+			if c.PingMs > 0 {
+				add(w.Latency, float64(bestPing)/float64(c.PingMs), fmt.Sprintf("latency %d ms (best %d ms)", c.PingMs, bestPing))
+			} else {
+				add(w.Latency, 0, "no ping response")
+			}
+		}
+		if hasDistance {
+			if c.DistanceKm >= 0 {
+				add(w.Distance, distanceHalfScoreKm/(distanceHalfScoreKm+c.DistanceKm), fmt.Sprintf("distance %.0f km", c.DistanceKm))
+			} else {
+				add(w.Distance, 0, "unknown server location")
+			}
+		}
+		if c.Attempts > 0 {
+			add(w.SuccessRate, c.SuccessRate, fmt.Sprintf("success rate %.0f%% (%d connections)", c.SuccessRate*100, c.Attempts))
+		} else {
+			add(w.SuccessRate, c.SuccessRate, "no connection history")
+		}
+		if hasLoad {
+			if c.Host.Load > 0 {
+				add(w.Load, 1-math.Min(float64(c.Host.Load), 100)/100, fmt.Sprintf("load %.0f%%", c.Host.Load))
+			} else {
+				add(w.Load, 0.5, "unknown load")
+			}
+		}
+
+		if weights > 0 {
+			c.Score = sum / weights
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if ci.Score != cj.Score {
+			return ci.Score > cj.Score
+		}
+		if ci.PingMs != cj.PingMs && ci.PingMs > 0 && cj.PingMs > 0 {
+			return ci.PingMs < cj.PingMs
+		}
+		return ci.Host.Name < cj.Host.Name
+	})
+
+	return candidates
+}
+
+func hasCoordinates(h api_types.ServerListItem) bool {
+	return len(h.Location.Latitude) > 0 && len(h.Location.Longitude) > 0
+}
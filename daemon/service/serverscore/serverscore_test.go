@@ -0,0 +1,138 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package serverscore_test
+
+import (
+	"testing"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/history"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/serverscore"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+func host(name, ip, countryCode string, lat, lon string) api_types.ServerListItem {
+	h := api_types.ServerListItem{Name: name, Ip: ip, CountryCode: countryCode}
+	h.Location.Latitude, h.Location.Longitude = lat, lon
+	return h
+}
+
+func names(candidates []serverscore.Candidate) []string {
+	ret := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ret = append(ret, c.Host.Name)
+	}
+	return ret
+}
+
+func TestRank(t *testing.T) {
+	de := host("de1", "1.1.1.1", "DE", "50.11", "8.68")
+	nl := host("nl1", "2.2.2.2", "NL", "52.37", "4.89")
+	nl.Premium = true
+	nl.IPv6 = "2001:db8::1"
+	us := host("us1", "3.3.3.3", "US", "40.71", "-74.0")
+
+	servers := []api_types.ServerListCountryItem{
+		{Flag: "de", Hosts: []api_types.ServerListItem{de}},
+		{Flag: "nl", Hosts: []api_types.ServerListItem{nl}},
+		{Flag: "us", Hosts: []api_types.ServerListItem{us}},
+	}
+	location := &api_types.GeoLookupResponse{SLatitude: "50.0", SLongitude: "8.0"}
+
+	tests := []struct {
+		name string
+		in   serverscore.Input
+		want []string
+	}{
+		{"latency", serverscore.Input{Servers: servers, Pings: map[string]int{"1.1.1.1": 80, "2.2.2.2": 20, "3.3.3.3": 120}},
+			[]string{"nl1", "de1", "us1"}},
+		{"distance", serverscore.Input{Servers: servers, Location: location},
+			[]string{"de1", "nl1", "us1"}},
+		{"success rate", serverscore.Input{Servers: servers, Location: location, Pings: map[string]int{"1.1.1.1": 20, "2.2.2.2": 22, "3.3.3.3": 120},
+			Stats: map[string]serverscore.ConnectionStats{"de1": {Attempts: 10, Successes: 0}, "nl1": {Attempts: 10, Successes: 10}}},
+			[]string{"nl1", "de1", "us1"}},
+		{"allow", serverscore.Input{Servers: servers, Constraints: serverscore.Constraints{CountriesAllow: []string{"us", "de"}}},
+			[]string{"de1", "us1"}},
+		{"deny", serverscore.Input{Servers: servers, Constraints: serverscore.Constraints{CountriesDeny: []string{"DE"}}},
+			[]string{"nl1", "us1"}},
+		{"premium", serverscore.Input{Servers: servers, Constraints: serverscore.Constraints{PremiumOnly: true}},
+			[]string{"nl1"}},
+		{"ipv6", serverscore.Input{Servers: servers, Constraints: serverscore.Constraints{IPv6Capable: true}},
+			[]string{"nl1"}},
+		{"excluded", serverscore.Input{Servers: servers, ExcludedLocations: []string{"nl"}},
+			[]string{"de1", "us1"}},
+	}
+
+	for _, tc := range tests {
+		got := names(serverscore.Rank(tc.in, serverscore.DefaultWeights()))
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %v; expected %v", tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: got %v; expected %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRankLoad(t *testing.T) {
+	a := host("a1", "1.1.1.1", "DE", "", "")
+	b := host("b1", "2.2.2.2", "DE", "", "")
+	a.Load, b.Load = 90, 10
+	servers := []api_types.ServerListCountryItem{{Flag: "de", Hosts: []api_types.ServerListItem{a, b}}}
+
+	candidates := serverscore.Rank(serverscore.Input{Servers: servers}, serverscore.DefaultWeights())
+	if len(candidates) != 2 || candidates[0].Host.Name != "b1" {
+		t.Fatalf("the least loaded server expected first: %v", names(candidates))
+	}
+	if candidates[0].DistanceKm >= 0 {
+		t.Error("distance must be unknown")
+	}
+	if len(candidates[0].Explanation) == 0 {
+		t.Error("explanation expected")
+	}
+}
+
+func TestStatsFromHistory(t *testing.T) {
+	connected, disconnected := vpn.CONNECTED.String(), vpn.DISCONNECTED.String()
+	// the newest entry is the first one
+	entries := []history.Entry{
+		{State: disconnected, Server: "de1", Reason: history.ReasonDisconnectionRequested},
+		{State: disconnected, Server: "de1", Reason: "timeout"},
+		{State: disconnected, Server: "nl1", Reason: history.ReasonDisconnectionRequested},
+		{State: connected, Server: "nl1"},
+		{State: disconnected, Server: "de1", Reason: "disconnected"},
+		{State: connected, Server: "de1"},
+	}
+
+	stats := serverscore.StatsFromHistory(entries)
+	if s := stats["de1"]; s.Attempts != 2 || s.Successes != 1 {
+		t.Errorf("de1: unexpected stats %+v", s)
+	}
+	if s := stats["nl1"]; s.Attempts != 1 || s.Successes != 1 {
+		t.Errorf("nl1: unexpected stats %+v", s)
+	}
+}
@@ -25,7 +25,6 @@ package service
 import (
 	"errors"
 	"fmt"
-	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn/wireguard"
 	"net"
 	"os"
 	"reflect"
@@ -41,6 +40,7 @@ import (
 	protocolTypes "github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/firewall"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/history"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform/filerights"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/preferences"
@@ -73,6 +73,8 @@ const (
 const (
 	// SessionCheckInterval - the interval for periodical check session status
 	SessionCheckInterval time.Duration = time.Hour * 1
+	// TunnelStatsInterval - the interval for notifying clients about the tunnel statistics (while connected)
+	TunnelStatsInterval time.Duration = time.Second * 5
 )
 
 // Service - IVPN service
@@ -129,6 +131,39 @@ type Service struct {
 	// (UI may send us new connection settings while VPN is connected, e.g., when the user changes connection settings in the UI)
 	_tmpParams      types.ConnectionParams
 	_tmpParamsMutex sync.Mutex
+
+	// Connection history (journal of VPN state transitions)
+	_history        *history.Journal
+	_historySession connectionHistorySession
+
+	// The latest known geolocation (updated on each connection/disconnection)
+	_geoLookup geoLookupInfo
+
+	// Connection fallback: info about the current connection attempt
+	_fallback struct {
+		_mutex       sync.Mutex
+		_current     *fallbackAttempt // nil - the connection fallback is not in use
+		_networkID   string
+		_timeout     time.Duration
+		_isConnected bool // the connection was established
+		_isTimedOut  bool // the connection was stopped because it was not established in time
+	}
+
+	// Tunnel health monitor
+	_health struct {
+		_mutex           sync.Mutex
+		_unhealthyReason string // the reason of the reconnection requested by the health monitor (empty - not requested)
+	}
+
+	// Network profiles: detector of network changes (it is always running, independently of the VPN connection)
+	_netProfiles struct {
+		_detector INetChangeDetector
+	}
+
+	// Temporary firewall exceptions for API server IPs (in use while API request is in progress)
+	// [IP]number of requests which are using the exception
+	_apiFwExceptions      map[string]int
+	_apiFwExceptionsMutex sync.Mutex
 }
 
 // VpnSessionInfo - Additional information about current VPN connection
@@ -142,7 +177,7 @@ type VpnSessionInfo struct {
 }
 
 // CreateService - service constructor
-func CreateService(evtReceiver IServiceEventsReceiver, api *api.API, updater IServersUpdater, netChDetector INetChangeDetector, wgKeysMgr IWgKeysManager, globalEvents <-chan ServiceEventType, systemLog chan<- SystemLogMessage) (*Service, error) {
+func CreateService(evtReceiver IServiceEventsReceiver, api *api.API, updater IServersUpdater, netChDetector INetChangeDetector, netProfilesDetector INetChangeDetector, wgKeysMgr IWgKeysManager, globalEvents <-chan ServiceEventType, systemLog chan<- SystemLogMessage) (*Service, error) {
 	if updater == nil {
 		return &Service{}, fmt.Errorf("ServersUpdater is not defined")
 	}
@@ -156,9 +191,11 @@ func CreateService(evtReceiver IServiceEventsReceiver, api *api.API, updater ISe
 		_wgKeysMgr:         wgKeysMgr,
 		_globalEvents:      globalEvents,
 		_systemLog:         systemLog,
+		_history:           history.NewJournal(platform.ConnectionHistoryFile(), history.DefaultMaxFileSize),
 	}
 
 	serv._ping._singleRequestLimitSemaphore = syncSemaphore.NewWeighted(1)
+	serv._netProfiles._detector = netProfilesDetector
 
 	// register the current service as a 'Connectivity checker' for API object
 	serv._api.SetConnectivityChecker(serv)
@@ -212,12 +249,17 @@ func (s *Service) init() error {
 	if err := s._preferences.LoadPreferences(); err != nil {
 		log.Error("Failed to load service preferences: ", err)
 
-		log.Warning("Saving default values for preferences")
-		s._preferences.SavePreferences()
+		if !errors.Is(err, preferences.ErrSecretsUnavailable) {
+			log.Warning("Saving default values for preferences")
+			s._preferences.SavePreferences()
+		}
 	}
 
 	// initialize firewall functionality
-	if err := firewall.Initialize(); err != nil {
+	funcGetFwExtraSettings := func() firewall.ExtraSettings {
+		return firewall.ExtraSettings{Linux_IsNftablesBackend: s._preferences.UserPrefs.Linux.IsFwNftablesBackend}
+	}
+	if err := firewall.Initialize(funcGetFwExtraSettings); err != nil {
 		return fmt.Errorf("service initialization error : %w", err)
 	}
 
@@ -314,8 +356,14 @@ func (s *Service) init() error {
 	go func() {
 		<-_ipStackInitializationWaiter // Wait for IP stack initialization
 		s.autoConnectIfRequired(OnDaemonStarted, nil)
+
+		// 'network profiles' functionality: apply the profile actions on network changes
+		s.netProfiles_startDetector()
 	}()
 
+	// Time-based connection schedules
+	s.scheduler_start()
+
 	// Start processing power events in separate routine (Windows)
 	s.startProcessingPowerEvents()
 
@@ -359,7 +407,7 @@ func (s *Service) unInitialise() error {
 		log.Error(err)
 		updateRetErr(err)
 	}
-	if err := splittun.ApplyConfig(false, false, false, false, splittun.ConfigAddresses{}, []string{}); err != nil {
+	if err := splittun.ApplyConfig(false, false, false, false, splittun.ConfigAddresses{}, []string{}, nil); err != nil {
 		log.Error(err)
 		updateRetErr(err)
 	}
@@ -384,6 +432,50 @@ func (s *Service) IsConnectivityBlocked() error {
 	return nil
 }
 
+// AllowAPIServerAccess - temporary allows communication with the API server IP address.
+// The firewall exception is added only when the firewall is enabled and the access to API servers is allowed by the configuration.
+// Returns function which must be called to remove the exception (nil - if the exception was not added)
+func (s *Service) AllowAPIServerAccess(ip net.IP) (remove func()) {
+	if ip == nil || !s._preferences.IsFwAllowApiServers {
+		return nil
+	}
+	if enabled, err := s.FirewallEnabled(); err != nil || !enabled {
+		return nil
+	}
+
+	ipStr := ip.String()
+
+	s._apiFwExceptionsMutex.Lock()
+	defer s._apiFwExceptionsMutex.Unlock()
+
+	if s._apiFwExceptions == nil {
+		s._apiFwExceptions = make(map[string]int)
+	}
+	if s._apiFwExceptions[ipStr] == 0 {
+		const onlyForICMP = false
+		const isPersistent = true
+		if err := firewall.AddHostsToExceptions([]net.IP{ip}, onlyForICMP, isPersistent); err != nil {
+			return nil
+		}
+	}
+	s._apiFwExceptions[ipStr]++
+
+	return func() {
+		s._apiFwExceptionsMutex.Lock()
+		defer s._apiFwExceptionsMutex.Unlock()
+
+		s._apiFwExceptions[ipStr]--
+		if s._apiFwExceptions[ipStr] > 0 {
+			return
+		}
+		delete(s._apiFwExceptions, ipStr)
+
+		const onlyForICMP = false
+		const isPersistent = true
+		firewall.RemoveHostsFromExceptions([]net.IP{ip}, onlyForICMP, isPersistent)
+	}
+}
+
 func (s *Service) GetVpnSessionInfo() VpnSessionInfo {
 	s._vpnSessionInfoMutex.Lock()
 	defer s._vpnSessionInfoMutex.Unlock()
@@ -453,6 +545,16 @@ func (s *Service) ServersListForceUpdate() (*api_types.ServerListResponse, error
 	return s._serversUpdater.GetServersForceUpdate()
 }
 
+// GetAppUpdateInfo returns information about the latest application version.
+// The update info is downloaded from the backend and returned only if its signature is valid.
+func (s *Service) GetAppUpdateInfo(updateType protocolTypes.AppUpdateType) (*api_types.UpdateInfo, error) {
+	info, _, err := s._api.AppUpdateInfo(updateType)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
 // APIRequest do custom request to API
 func (s *Service) APIRequest(apiAlias string, ipTypeRequired protocolTypes.RequiredIPProtocol) (responseData []byte, err error) {
 
@@ -601,6 +703,15 @@ func (s *Service) ConnectedType() (isConnected bool, connectedVpnType vpn.Type)
 	return true, vpnObj.Type()
 }
 
+// GetTunnelStatistics returns live statistics of the active VPN tunnel
+func (s *Service) GetTunnelStatistics() (vpn.Statistics, error) {
+	vpnObj := s._vpn
+	if vpnObj == nil {
+		return vpn.Statistics{}, fmt.Errorf("VPN is not connected")
+	}
+	return vpnObj.GetStatistics()
+}
+
 // FirewallEnabled returns firewall state (enabled\disabled)
 // (in use, for example, by WireGuard keys manager, to know is it have sense to make API requests.)
 func (s *Service) FirewallEnabled() (bool, error) {
@@ -1110,8 +1221,8 @@ func (s *Service) applyKillSwitchAllowLAN(wifiInfoPtr *wifiNotifier.WifiInfo) er
 	prefs := s._preferences
 
 	isAllowLAN := prefs.IsFwAllowLAN
-	if isAllowLAN && s.isTrustedWifiForcingToBlockLan(wifiInfoPtr) {
-		log.Info("Firewall (block LAN): according to configuration for Untrusted WiFi")
+	if isAllowLAN && s.isNetworkForcingToBlockLan(wifiInfoPtr) {
+		log.Info("Firewall (block LAN): according to configuration for the current network (Untrusted WiFi or network profile)")
 		isAllowLAN = false
 	}
 
@@ -1205,11 +1316,23 @@ func (s *Service) SetUserPreferences(userPrefs preferences.UserPreferences) erro
 		return err
 	}
 
+	if probe := userPrefs.HealthMonitor.ProbeTarget; len(probe) > 0 {
+		if _, _, err := net.SplitHostPort(probe); err != nil {
+			return fmt.Errorf("bad health monitor probe target '%s' (expected format 'host:port'): %w", probe, err)
+		}
+	}
+
+	if err := userPrefs.ConnectionFallback.Validate(); err != nil {
+		return err
+	}
+
 	prefs := s._preferences
 	prefs.UserPrefs = userPrefs
 	s.setPreferences(prefs)
 
-	return nil
+	// Re-initialize firewall according to user settings
+	// It is applicable, for example for Linux: when the user changed the firewall backend
+	return firewall.ApplyUserSettings()
 }
 
 // Preferences returns preferences
@@ -1221,7 +1344,7 @@ func (s *Service) ResetPreferences() error {
 	s._preferences = *preferences.Create()
 
 	// erase ST config
-	s.SplitTunnelling_SetConfig(false, false, false, false, true)
+	s.SplitTunnelling_SetConfig(false, false, false, false, true, nil, nil)
 	return nil
 }
 
@@ -1343,12 +1466,16 @@ func (s *Service) SplitTunnelling_GetStatus() (protocolTypes.SplitTunnelStatus,
 		IsAllowWhenNoVpn:            isAllowWhenNoVpn,
 		IsCanGetAppIconForBinary:    oshelpers.IsCanGetAppIconForBinary(),
 		SplitTunnelApps:             prefs.SplitTunnelApps,
-		RunningApps:                 runningProcesses}
+		RunningApps:                 runningProcesses,
+		Destinations:                splittun.GetDestinationsStatus()}
 
 	return ret, nil
 }
 
-func (s *Service) SplitTunnelling_SetConfig(isEnabled, isInversed, isAnyDns, isAllowWhenNoVpn, reset bool) error {
+// SplitTunnelling_SetConfig sets the split-tunnelling configuration
+// destinations - rules for destination addresses (nil - keep the current rules)
+// apps - (Linux) application rules: binary paths or names (nil - keep the current rules)
+func (s *Service) SplitTunnelling_SetConfig(isEnabled, isInversed, isAnyDns, isAllowWhenNoVpn, reset bool, destinations []splittun.DestinationRule, apps []string) error {
 	if reset {
 		return s.splitTunnelling_Reset()
 	}
@@ -1359,6 +1486,17 @@ func (s *Service) SplitTunnelling_SetConfig(isEnabled, isInversed, isAnyDns, isA
 	if isInversed && stInverseErr != nil {
 		return stInverseErr
 	}
+	if destinations != nil {
+		if err := splittun.ValidateDestinations(destinations); err != nil {
+			return err
+		}
+	}
+	if apps != nil {
+		var err error
+		if apps, err = splittun.ValidateAppRules(apps); err != nil {
+			return err
+		}
+	}
 
 	if isEnabled && isInversed {
 		// if we are going to enable INVERSE SplitTunneling - ensure that Firewall is disabled
@@ -1384,6 +1522,12 @@ func (s *Service) SplitTunnelling_SetConfig(isEnabled, isInversed, isAnyDns, isA
 	prefs.SplitTunnelInversed = isInversed
 	prefs.SplitTunnelAnyDns = isAnyDns
 	prefs.SplitTunnelAllowWhenNoVpn = isAllowWhenNoVpn
+	if destinations != nil {
+		prefs.SplitTunnelDestinations = destinations
+	}
+	if apps != nil {
+		prefs.SplitTunnelApps = apps
+	}
 	s.setPreferences(prefs)
 
 	ret := s.splitTunnelling_ApplyConfig()
@@ -1404,6 +1548,7 @@ func (s *Service) splitTunnelling_Reset() error {
 	prefs.SplitTunnelAnyDns = false
 	prefs.SplitTunnelAllowWhenNoVpn = false
 	prefs.SplitTunnelApps = make([]string, 0)
+	prefs.SplitTunnelDestinations = nil
 	s.setPreferences(prefs)
 
 	splittun.Reset()
@@ -1479,7 +1624,7 @@ func (s *Service) splitTunnelling_ApplyConfig() (retError error) {
 	}
 
 	// Apply Split-Tun config
-	return splittun.ApplyConfig(prefs.IsSplitTunnel, prefs.IsInverseSplitTunneling(), prefs.SplitTunnelAllowWhenNoVpn, isVpnConnected, addressesCfg, prefs.SplitTunnelApps)
+	return splittun.ApplyConfig(prefs.IsSplitTunnel, prefs.IsInverseSplitTunneling(), prefs.SplitTunnelAllowWhenNoVpn, isVpnConnected, addressesCfg, prefs.SplitTunnelApps, prefs.SplitTunnelDestinations)
 }
 
 func (s *Service) SplitTunnelling_AddApp(exec string) (cmdToExecute string, isAlreadyRunning bool, err error) {
@@ -1515,14 +1660,15 @@ func (s *Service) SplitTunnelling_AddedPidInfo(pid int, exec string, cmdToExecut
 // SESSIONS
 //////////////////////////////////////////////////////////
 
-func (s *Service) setCredentials(accountInfo preferences.AccountStatus, accountID, session, wgPublicKey, wgPrivateKey, wgLocalIP string, wgKeyGenerated int64) error {
+func (s *Service) setCredentials(accountInfo preferences.AccountStatus, accountID, session, wgPublicKey, wgPrivateKey, wgLocalIP string, wgKeyGenerated int64, wgPresharedKey string) error {
 	// save session info
 	s._preferences.SetSession(accountInfo,
 		accountID,
 		session,
 		wgPublicKey,
 		wgPrivateKey,
-		wgLocalIP)
+		wgLocalIP,
+		wgPresharedKey)
 
 	// manually set info about WG keys timestamp
 	if wgKeyGenerated > 0 {
@@ -1571,23 +1717,7 @@ func (s *Service) VerifyPin(code string) (
 	//if err := s.SessionDelete(isCanDeleteSessionLocally); err != nil {
 	//	log.Error("Creating new session -> Failed to delete active session: ", err)
 	//}
-	//
-	//// Generate keys for Key Encapsulation Mechanism using post-quantum cryptographic algorithms
-	//var kemKeys api_types.KemPublicKeys
-	//kemHelper, err := kem.CreateHelper(platform.KemHelperBinaryPath(), kem.GetDefaultKemAlgorithms())
-	//if err != nil {
-	//	log.Error("Failed to generate KEM keys: ", err)
-	//} else {
-	//	kemKeys.KemPublicKey_Kyber1024, err = kemHelper.GetPublicKey(kem.AlgName_Kyber1024)
-	//	if err != nil {
-	//		log.Error(err)
-	//	}
-	//	kemKeys.KemPublicKey_ClassicMcEliece348864, err = kemHelper.GetPublicKey(kem.AlgName_ClassicMcEliece348864)
-	//	if err != nil {
-	//		log.Error(err)
-	//	}
-	//}
-	//
+
 	log.Info("Logging in...")
 	defer func() {
 		if err != nil {
@@ -1598,11 +1728,12 @@ func (s *Service) VerifyPin(code string) (
 	}()
 
 	var (
-		successResp *api_types.PinValidationResponse
-		statusCode  int
-		rawRespStr  string // RAW response
-		publicKey   string
-		privateKey  string
+		successResp    *api_types.PinValidationResponse
+		statusCode     int
+		rawRespStr     string // RAW response
+		publicKey      string
+		privateKey     string
+		wgPresharedKey string
 	)
 
 	successResp, statusCode, rawRespStr, err = s._api.VerifyPin(code)
@@ -1617,12 +1748,9 @@ func (s *Service) VerifyPin(code string) (
 		return apiCode, "", accountInfo, rawResponse, fmt.Errorf("unexpected error when creating a new session")
 	}
 	if successResp.Status == api_types.ValidPin {
-		// generate new keys for WireGuard
-		publicKey, privateKey, err = wireguard.GenerateKeys(platform.WgToolBinaryPath())
-		if err != nil {
-			log.Warning(fmt.Sprintf("Failed to generate wireguard keys for new session: %s", err.Error()))
-		}
-		wireGuardKeySet, _, err := s._api.WireGuardKeySet(successResp.Token, publicKey)
+		// generate new keys for WireGuard (and the PresharedKey using post-quantum KEM)
+		var localIP net.IP
+		publicKey, privateKey, localIP, wgPresharedKey, err = s._wgKeysMgr.RequestNewKeys(successResp.Token)
 		if err != nil {
 			return 0, "", preferences.AccountStatus{}, "", err
 		}
@@ -1631,51 +1759,13 @@ func (s *Service) VerifyPin(code string) (
 			successResp.Token,
 			publicKey,
 			privateKey,
-			wireGuardKeySet.LocalIP, 0)
+			localIP.String(), 0, wgPresharedKey)
 		accountInfo = preferences.AccountStatus{Active: true, ActiveUntil: successResp.Timestamp}
 	} else {
 		accountInfo = preferences.AccountStatus{Active: false, ActiveUntil: successResp.Timestamp}
 	}
-	//
-	//	if kemHelper != nil {
-	//		if len(successResp.WireGuard.KemCipher_Kyber1024) == 0 && len(successResp.WireGuard.KemCipher_ClassicMcEliece348864) == 0 {
-	//			log.Warning("The server did not respond with KEM ciphers. The WireGuard PresharedKey has not been initialized!")
-	//		} else {
-	//			if err := kemHelper.SetCipher(kem.AlgName_Kyber1024, successResp.WireGuard.KemCipher_Kyber1024); err != nil {
-	//				log.Error(err)
-	//			}
-	//			if err := kemHelper.SetCipher(kem.AlgName_ClassicMcEliece348864, successResp.WireGuard.KemCipher_ClassicMcEliece348864); err != nil {
-	//				log.Error(err)
-	//			}
-	//
-	//			wgPresharedKey, err = kemHelper.CalculatePresharedKey()
-	//			if err != nil {
-	//				log.Error(fmt.Sprintf("Failed to decode KEM ciphers! (%s). Retry Log-in without WireGuard PresharedKey...", err))
-	//				kemHelper = nil
-	//				kemKeys = api_types.KemPublicKeys{}
-	//				if err := s.SessionDelete(true); err != nil {
-	//					log.Error("Creating new session (retry 2) -> Failed to delete active session: ", err)
-	//				}
-	//				continue
-	//			}
-	//		}
-	//	}
-	//	break
-	//}
-	//// get account status info
-	//accountInfo = s.createAccountStatus(successResp.ServiceStatus)
-	//
-	//s.setCredentials(accountInfo,
-	//	accountID,
-	//	successResp.Token,
-	//	successResp.VpnUsername,
-	//	successResp.VpnPassword,
-	//	publicKey,
-	//	privateKey,
-	//	successResp.WireGuard.IPAddress, 0, wgPresharedKey)
-	//
-	log.Info(fmt.Sprintf("(logging in) WG keys updated (%s)", publicKey))
-	//
+	log.Info(fmt.Sprintf("(logging in) WG keys updated (%s; psk:%v)", publicKey, len(wgPresharedKey) > 0))
+
 	// Apply SplitTunnel configuration. It is applicable for Inverse mode of SplitTunnel
 	if err := s.splitTunnelling_ApplyConfig(); err != nil {
 		log.Error(err)
@@ -1899,8 +1989,8 @@ func (s *Service) stopSessionChecker() {
 //////////////////////////////////////////////////////////
 
 // WireGuardSaveNewKeys saves WG keys
-func (s *Service) WireGuardSaveNewKeys(wgPublicKey string, wgPrivateKey string, wgLocalIP string) { //}, wgPresharedKey string) {
-	s._preferences.UpdateWgCredentials(wgPublicKey, wgPrivateKey, wgLocalIP) //, wgPresharedKey)
+func (s *Service) WireGuardSaveNewKeys(wgPublicKey string, wgPrivateKey string, wgLocalIP string, wgPresharedKey string) {
+	s._preferences.UpdateWgCredentials(wgPublicKey, wgPrivateKey, wgLocalIP, wgPresharedKey)
 
 	// notify clients about session (wg keys) update
 	s._evtReceiver.OnServiceSessionChanged()
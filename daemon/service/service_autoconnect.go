@@ -44,6 +44,7 @@ const (
 	OnUiClientConnected autoConnectReason = iota
 	OnSessionLogon      autoConnectReason = iota
 	OnWifiChanged       autoConnectReason = iota
+	OnNetworkChanged    autoConnectReason = iota
 )
 
 func (cr autoConnectReason) ToString() string {
@@ -54,6 +55,8 @@ func (cr autoConnectReason) ToString() string {
 		return "UIAppLaunch"
 	case OnWifiChanged:
 		return "WiFiChanged"
+	case OnNetworkChanged:
+		return "NetworkChanged"
 	case OnSessionLogon:
 		return "UserSessionLogon"
 	default:
@@ -84,8 +87,10 @@ type automaticAction struct {
 }
 
 type lastProcessedWiFiInfo struct {
-	wifi   wifiNotifier.WifiInfo
-	params preferences.WiFiParams
+	wifi     wifiNotifier.WifiInfo
+	params   preferences.WiFiParams
+	network  preferences.NetworkIdentity
+	profiles preferences.NetworkProfilesParams
 }
 
 var autoconnectLastProcessedWifi lastProcessedWiFiInfo
@@ -102,7 +107,9 @@ func (s *Service) OnAuthenticatedClient(t protocolTypes.ClientTypeEnum) {
 	s.autoConnectIfRequired(OnUiClientConnected, nil)
 }
 
-func (s *Service) isTrustedWifiForcingToBlockLan(wifiInfoPtr *wifiNotifier.WifiInfo) bool {
+// isNetworkForcingToBlockLan returns 'true' when the configuration for the current network requires to block LAN
+// ('Untrusted WiFi' action or the action of the matching network profile)
+func (s *Service) isNetworkForcingToBlockLan(wifiInfoPtr *wifiNotifier.WifiInfo) bool {
 	prefs := s.Preferences()
 	if !prefs.Session.IsLoggedIn() {
 		return false
@@ -120,7 +127,7 @@ func (s *Service) isTrustedWifiForcingToBlockLan(wifiInfoPtr *wifiNotifier.WifiI
 		wifiInfo = *wifiInfoPtr
 	}
 
-	action := s.getActionForWifiNetwork(wifiInfo)
+	action := s.getActionForNetwork(wifiInfo, s.netProfiles_networkIdentity(wifiInfo))
 
 	return action.Firewall == FW_On_and_blockLan
 }
@@ -149,15 +156,17 @@ func (s *Service) autoConnectIfRequired(reason autoConnectReason, wifiInfoPtr *w
 		wifiInfo = *wifiInfoPtr
 	}
 
-	// Check if WiFi already processed
+	networkIdentity := s.netProfiles_networkIdentity(wifiInfo)
+
+	// Check if WiFi (network) already processed
 	isWifiProcessedAlready := false
 
-	currWiFi := lastProcessedWiFiInfo{wifi: wifiInfo, params: prefs.WiFiControl}
+	currWiFi := lastProcessedWiFiInfo{wifi: wifiInfo, params: prefs.WiFiControl, network: networkIdentity, profiles: prefs.NetworkProfiles}
 	lastWifi := autoconnectLastProcessedWifi
 
 	if reflect.DeepEqual(lastWifi, currWiFi) {
 		// this wifi network change has been processed already
-		if reason == OnWifiChanged {
+		if reason == OnWifiChanged || reason == OnNetworkChanged {
 			return nil
 		}
 		isWifiProcessedAlready = true
@@ -173,6 +182,8 @@ func (s *Service) autoConnectIfRequired(reason autoConnectReason, wifiInfoPtr *w
 		// We have to restore LAN connectivity if there is not required to block LAN for current network
 		prevSettingsBlockLan := lastWifi.params.TrustedNetworksControl && lastWifi.params.Actions.UnTrustedBlockLan
 		currSettingsBlockLan := prefs.WiFiControl.TrustedNetworksControl && prefs.WiFiControl.Actions.UnTrustedBlockLan
+		prevSettingsBlockLan = prevSettingsBlockLan || lastWifi.profiles.IsBlockLanDefined()
+		currSettingsBlockLan = currSettingsBlockLan || prefs.NetworkProfiles.IsBlockLanDefined()
 		if prefs.IsFwAllowLAN && (prevSettingsBlockLan || currSettingsBlockLan) {
 			if err := s.applyKillSwitchAllowLAN(&wifiInfo); err != nil {
 				log.Info(fmt.Sprintf("Automatic connection manager: failed to restore Firewall rules to allow LAN: %s", err.Error()))
@@ -184,8 +195,8 @@ func (s *Service) autoConnectIfRequired(reason autoConnectReason, wifiInfoPtr *w
 	// Checking if new connection required
 	//
 
-	// Check "Trusted WiFi" actions
-	action := s.getActionForWifiNetwork(wifiInfo)
+	// Check "Network profiles" and "Trusted WiFi" actions
+	action := s.getActionForNetwork(wifiInfo, networkIdentity)
 
 	isVpnOffRequired := false
 	if isWifiProcessedAlready {
@@ -196,7 +207,7 @@ func (s *Service) autoConnectIfRequired(reason autoConnectReason, wifiInfoPtr *w
 	}
 
 	if action.IsHasAction() {
-		log.Info("Automatic connection manager: applying 'Trusted-WiFi' action...")
+		log.Info("Automatic connection manager: applying 'Trusted-WiFi' (network profile) action...")
 	}
 
 	// Check "Auto-connect on APP/daemon launch" action
@@ -311,6 +322,35 @@ func (s *Service) isCanApplyWiFiActions() bool {
 	return true
 }
 
+// getActionForNetwork returns the action for the current network.
+// The action of the matching network profile has priority over the 'Trusted WiFi' action.
+func (s *Service) getActionForNetwork(wifiInfo wifiNotifier.WifiInfo, identity preferences.NetworkIdentity) automaticAction {
+	prefs := s.Preferences()
+	if !prefs.Session.IsLoggedIn() {
+		return automaticAction{}
+	}
+
+	profile := prefs.NetworkProfiles.MatchingProfile(identity)
+	if profile == nil {
+		return s.getActionForWifiNetwork(wifiInfo)
+	}
+
+	var retAction automaticAction
+	if profile.Actions.ConnectVpn {
+		retAction.Vpn = VPN_On
+	} else if profile.Actions.DisconnectVpn {
+		retAction.Vpn = VPN_Off
+	}
+	if profile.Actions.BlockLan {
+		retAction.Firewall = FW_On_and_blockLan
+	} else if profile.Actions.EnableFirewall {
+		retAction.Firewall = FW_On
+	} else if profile.Actions.DisableFirewall {
+		retAction.Firewall = FW_Off
+	}
+	return retAction
+}
+
 func (s *Service) getActionForWifiNetwork(wifiInfo wifiNotifier.WifiInfo) (retAction automaticAction) {
 	prefs := s.Preferences()
 	if !prefs.Session.IsLoggedIn() {
@@ -371,7 +411,7 @@ func (s *Service) getActionForWifiNetwork(wifiInfo wifiNotifier.WifiInfo) (retAc
 	return
 }
 
-// updateParamsAccordingToMetadata - update Entry/Exit servers if connection requires 'Fastest' or 'Random'
+// updateParamsAccordingToMetadata - update Entry/Exit servers if connection requires 'Fastest', 'Recommended' or 'Random'
 func (s *Service) updateParamsAccordingToMetadata(params types.ConnectionParams) (types.ConnectionParams, error) {
 	if params.Metadata.ServerSelectionEntry == types.Default && params.Metadata.ServerSelectionExit == types.Default {
 		return params, nil
@@ -384,9 +424,9 @@ func (s *Service) updateParamsAccordingToMetadata(params types.ConnectionParams)
 
 	// ENTRY server
 	if params.Metadata.ServerSelectionEntry != types.Default {
-		// Get countryCode of exit server (do not choose exit server from same country)
+		// Get countryCode of exit server (do not choose entry server from same country)
 		exitSvrCountryCode := ""
-		if params.IsMultiHop() && params.Metadata.ServerSelectionExit != types.Default {
+		if params.IsMultiHop() && params.Metadata.ServerSelectionExit == types.Default {
 			exitSvrCountryCode = s.getServerCountryCode(params, false)
 		}
 
@@ -417,6 +457,12 @@ func (s *Service) updateParamsAccordingToMetadata(params types.ConnectionParams)
 					return params, err
 				}
 				params.OpenVpnParameters.EntryVpnServer.Hosts = fastestSvr.Hosts
+			case types.Recommended: // RECOMMENDED SERVER (OpenVPN)
+				host, err := s.recommend_bestHost(vpn.OpenVPN, applicableEntryServers, params.Metadata)
+				if err != nil {
+					return params, err
+				}
+				params.OpenVpnParameters.EntryVpnServer.Hosts = []apiTypes.ServerListItem{host}
 			default:
 			}
 		} else {
@@ -446,11 +492,45 @@ func (s *Service) updateParamsAccordingToMetadata(params types.ConnectionParams)
 					return params, err
 				}
 				params.WireGuardParameters.EntryVpnServer.Hosts = fastestSvr.Hosts
+			case types.Recommended: // RECOMMENDED SERVER (WireGuard)
+				host, err := s.recommend_bestHost(vpn.WireGuard, applicableEntryServers, params.Metadata)
+				if err != nil {
+					return params, err
+				}
+				params.WireGuardParameters.EntryVpnServer.Hosts = []apiTypes.ServerListItem{host}
 			default:
 			}
 		}
 	}
 
+	// EXIT server ('Fastest' and 'Recommended' are not applicable for 'Exit' server)
+	if params.IsMultiHop() && params.Metadata.ServerSelectionExit == types.Random {
+		// Get countryCode of entry server (do not choose exit server from same country)
+		entrySvrCountryCode := s.getServerCountryCode(params, true)
+
+		allExitServers := allServers.ServerList.WireGuardServers
+		if params.VpnType == vpn.OpenVPN {
+			allExitServers = allServers.ServerList.OpenVPNServers
+		}
+
+		applicableExitServers := []apiTypes.ServerListCountryItem{}
+		for _, s := range allExitServers {
+			if s.Flag == entrySvrCountryCode {
+				continue // exclude exit server from the same country as Entry server
+			}
+			applicableExitServers = append(applicableExitServers, s)
+		}
+		if len(applicableExitServers) == 0 {
+			return params, fmt.Errorf("no applicable Multi-Hop exit servers found")
+		}
+
+		rndIdx, err := rand.Int(rand.Reader, big.NewInt(int64(len(applicableExitServers))))
+		if err != nil {
+			return params, err
+		}
+		params.SetExitHosts(applicableExitServers[rndIdx.Int64()].Hosts)
+	}
+
 	return params, nil
 }
 
@@ -460,16 +540,10 @@ func (s *Service) getServerCountryCode(params types.ConnectionParams, isEntrySer
 		return ""
 	}
 
-	if params.VpnType == vpn.OpenVPN {
-		if isEntryServer {
-			return getServerCountryCode(s, params.OpenVpnParameters.EntryVpnServer.Hosts)
-		}
-	} else {
-		if isEntryServer {
-			return getServerCountryCode(s, params.WireGuardParameters.EntryVpnServer.Hosts)
-		}
+	if isEntryServer {
+		return getServerCountryCode(s, params.EntryHosts())
 	}
-	return ""
+	return getServerCountryCode(s, params.ExitHosts())
 }
 
 // Return country code of server
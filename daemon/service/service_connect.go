@@ -32,6 +32,7 @@ import (
 	"sync"
 	"time"
 
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/helpers"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/netinfo"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/obfsproxy"
@@ -66,6 +67,43 @@ func (s *Service) ValidateConnectionParameters(params types.ConnectionParams, is
 			return params, fmt.Errorf("no hosts defined for OpenVPN connection")
 		}
 	}
+
+	if params.IsMultiHop() {
+		if err := s.IsCanConnectMultiHop(); err != nil {
+			if !isCanFix {
+				return params, err
+			}
+			log.Info(fmt.Sprintf("Multi-Hop connection is not allowed (%s). Converting to Single-Hop connection...", err))
+			params.SetExitHosts(nil)
+			return params, nil
+		}
+
+		// entry and exit servers must be located in different jurisdictions
+		if err := params.CheckMultiHopJurisdictions(); err != nil {
+			if !isCanFix {
+				return params, err
+			}
+			// remove exit hosts which are located in the same jurisdiction as entry hosts
+			var exitHosts []api_types.ServerListItem
+			for _, exit := range params.ExitHosts() {
+				isOk := true
+				for _, entry := range params.EntryHosts() {
+					if types.IsSameJurisdiction(entry, exit) {
+						isOk = false
+						break
+					}
+				}
+				if isOk {
+					exitHosts = append(exitHosts, exit)
+				}
+			}
+			if len(exitHosts) == 0 {
+				return params, err
+			}
+			params.SetExitHosts(exitHosts)
+		}
+	}
+
 	return params, nil
 }
 
@@ -90,6 +128,11 @@ func (s *Service) Connect(params types.ConnectionParams) (err error) {
 		}
 	}()
 
+	// check parameters before saving them as last used connection params
+	if params, err = s.ValidateConnectionParameters(params, false); err != nil {
+		return err
+	}
+
 	// keep last used connection params
 	s.setConnectionParams(params)
 
@@ -111,6 +154,9 @@ func (s *Service) Connect(params types.ConnectionParams) (err error) {
 		}
 	}
 
+	// original parameters (before normalization) are required to choose the hosts for failover
+	originalParams := params
+
 	// Normalize hosts list
 	// - in case of multiple entry hosts - take one random host from the list
 	// - in case of multiple exit hosts - take one random host from the list
@@ -118,6 +164,66 @@ func (s *Service) Connect(params types.ConnectionParams) (err error) {
 		return fmt.Errorf("failed to normalize hosts: %w", err)
 	}
 
+	// The connection is restarted when the tunnel health monitor detects that the tunnel is unhealthy:
+	// - failover disabled (or Multi-Hop connection): reconnecting to the same host;
+	// - failover enabled: connecting to the next-best host of the same location and then to the hosts of the next location.
+	//   The connection is stopped with the 'ErrorTunnelUnhealthy' error when there are no more hosts to try.
+	var failoverParams []types.ConnectionParams
+	isFailoverStarted := false
+	for {
+		err = s.fallback_connect(params)
+
+		var unhealthyErr srverrors.ErrorTunnelUnhealthy
+		isUnhealthy := errors.As(err, &unhealthyErr)
+		if err == nil || (!isUnhealthy && !isFailoverStarted) || s._requiredVpnState == Disconnect {
+			return err
+		}
+
+		if !s.health_isFailoverEnabled(params) {
+			log.Info(fmt.Sprintf("%s. Reconnecting...", err))
+			s.onVpnStateChanged(vpn.StateInfo{
+				State:               vpn.RECONNECTING,
+				Description:         fmt.Sprintf("Reconnecting: %s", err),
+				StateAdditionalInfo: vpn.ReconnectReasonTunnelUnhealthy})
+			continue
+		}
+
+		if !isFailoverStarted {
+			isFailoverStarted = true
+			failoverParams = s.health_failoverParams(originalParams, params)
+		}
+		if len(failoverParams) == 0 {
+			log.Info("Failover: no more hosts to connect")
+			if !isUnhealthy {
+				// the last failover host is not accessible
+				return srverrors.ErrorTunnelUnhealthy{Reason: fmt.Sprintf("no accessible hosts to fail over to (%s)", err)}
+			}
+			return err
+		}
+
+		params, failoverParams = failoverParams[0], failoverParams[1:]
+		nextHost := params.EntryHosts()[0].Name
+		log.Info(fmt.Sprintf("Failover: %s. Connecting to '%s'...", err, nextHost))
+		s.onVpnStateChanged(vpn.StateInfo{
+			State:               vpn.RECONNECTING,
+			Description:         fmt.Sprintf("Failover to '%s': %s", nextHost, err),
+			StateAdditionalInfo: vpn.ReconnectReasonTunnelUnhealthy})
+	}
+}
+
+// connectHost connects to the host defined in the normalized connection parameters.
+// The function returns only when the connection is stopped.
+func (s *Service) connectHost(params types.ConnectionParams) (err error) {
+	prefs := s.Preferences()
+
+	// connection history: save info about the connection; the final entry is saved when the connection is stopped
+	s.historySessionStart(params)
+	defer func() {
+		s.historySessionEnd(err)
+		// the connection is stopped (the route to the internet is changed): update the geolocation
+		s.geoLookupUpdateAsync()
+	}()
+
 	// ------------------------ Inverse Split Tunnel block start ------------------------
 	if prefs.IsInverseSplitTunneling() {
 		if params.FirewallOn || params.FirewallOnDuringConnection {
@@ -165,6 +271,15 @@ func (s *Service) Connect(params types.ConnectionParams) (err error) {
 
 		// take first host from the list (if multiple hosts were defined, the random one was taken above)
 		host := net.ParseIP(params.OpenVpnParameters.EntryVpnServer.Hosts[0].Ip)
+		hostPort := params.OpenVpnParameters.Port.Port
+
+		// Multi-Hop: connecting to the entry server using the port which is mapped to the exit server
+		multihopExitHostName := ""
+		if len(params.OpenVpnParameters.MultihopExitServer.Hosts) > 0 {
+			exitHost := params.OpenVpnParameters.MultihopExitServer.Hosts[0]
+			multihopExitHostName = exitHost.Name
+			hostPort = exitHost.MultihopPort
+		}
 
 		// nothing from supported proxy types should be in this parameter
 		proxyType := params.OpenVpnParameters.Proxy.Type
@@ -179,9 +294,9 @@ func (s *Service) Connect(params types.ConnectionParams) (err error) {
 		// CONNECTION
 		// OpenVPN connection parameters
 		var connectionParams = openvpn.CreateConnectionParams(
-			"",
+			multihopExitHostName,
 			params.OpenVpnParameters.Port.Protocol > 0, // is TCP
-			params.OpenVpnParameters.Port.Port,
+			hostPort,
 			host,
 			proxyType,
 			net.ParseIP(params.OpenVpnParameters.Proxy.Address),
@@ -209,13 +324,32 @@ func (s *Service) Connect(params types.ConnectionParams) (err error) {
 			return fmt.Errorf("WG public key is not base64 string")
 		}
 
+		hostPort := params.WireGuardParameters.Port.Port
+		hostPublicKey := hostValue.WireGuard[0].PublicKey
 		hostLocalIP := net.ParseIP(strings.Split(hostValue.WireGuard[0].LocalIP, "/")[0])
 
+		// Multi-Hop: connecting to the entry server using the port which is mapped to the exit server.
+		// The tunnel is established with the exit server (its public key and local IP in use)
+		multihopExitHostName := ""
+		if len(params.WireGuardParameters.MultihopExitServer.Hosts) > 0 {
+			exitHost := params.WireGuardParameters.MultihopExitServer.Hosts[0]
+			if len(exitHost.WireGuard) == 0 {
+				return fmt.Errorf("WireGuard configuration not defined for the Multi-Hop exit server")
+			}
+			if !helpers.ValidateBase64(exitHost.WireGuard[0].PublicKey) {
+				return fmt.Errorf("WG public key of the exit server is not base64 string")
+			}
+			multihopExitHostName = exitHost.Name
+			hostPort = exitHost.MultihopPort
+			hostPublicKey = exitHost.WireGuard[0].PublicKey
+			hostLocalIP = net.ParseIP(strings.Split(exitHost.WireGuard[0].LocalIP, "/")[0])
+		}
+
 		var connectionParams = wireguard.CreateConnectionParams(
-			"",
-			params.WireGuardParameters.Port.Port,
+			multihopExitHostName,
+			hostPort,
 			net.ParseIP(hostValue.Ip),
-			hostValue.WireGuard[0].PublicKey,
+			hostPublicKey,
 			hostLocalIP,
 			"",
 			params.WireGuardParameters.Mtu)
@@ -423,7 +557,7 @@ func (s *Service) keepConnection(originalEntryServerInfo *svrConnInfo, createVpn
 	// no delay before first reconnection
 	delayBeforeReconnect := 0 * time.Second
 
-	s._evtReceiver.OnVpnStateChanged(vpn.NewStateInfo(vpn.CONNECTING, "Connecting"))
+	s.onVpnStateChanged(vpn.NewStateInfo(vpn.CONNECTING, "Connecting"))
 	for {
 		// create new VPN object
 		vpnObj, err := createVpnObj()
@@ -455,8 +589,14 @@ func (s *Service) keepConnection(originalEntryServerInfo *svrConnInfo, createVpn
 
 		// retry, if reconnection requested
 		if s._requiredVpnState == KeepConnection {
+			// the reconnection was requested by the tunnel health monitor:
+			// stop the loop; Connect() decides which host to use for the next connection
+			if reason := s.health_takeUnhealthyReason(); len(reason) > 0 {
+				return srverrors.ErrorTunnelUnhealthy{Reason: reason}
+			}
+
 			// notifying clients about reconnection
-			s._evtReceiver.OnVpnStateChanged(vpn.NewStateInfo(vpn.RECONNECTING, "Reconnecting due to disconnection"))
+			s.onVpnStateChanged(vpn.NewStateInfo(vpn.RECONNECTING, "Reconnecting due to disconnection"))
 
 			// no delay before reconnection (if last connection was long time ago)
 			if time.Now().After(lastConnectionTryTime.Add(time.Second * 30)) {
@@ -634,11 +774,14 @@ func (s *Service) connect(originalEntryServerInfo *svrConnInfo, vpnProc vpn.Proc
 					state.IsTCP = originalEntryServerInfo.PortType > 0
 					state.V2RayProxy = originalEntryServerInfo.V2RayProxyType
 				}
+				if state.State == vpn.CONNECTED {
+					state.FallbackAttempt = s.fallback_onConnected()
+				}
 
 				//  using the inline function to process state. It is required for a correct functioning of the "defer" statement
 				func() {
 					// do not forget to forward state to 'stateChan'
-					defer s._evtReceiver.OnVpnStateChanged(state)
+					defer s.onVpnStateChanged(state)
 
 					log.Info(fmt.Sprintf("State: %v", state))
 
@@ -777,6 +920,45 @@ func (s *Service) connect(originalEntryServerInfo *svrConnInfo, vpnProc vpn.Proc
 		}
 	}()
 
+	// periodically notifying clients about the tunnel statistics
+	connectRoutinesWaiter.Add(1)
+	go func() {
+		defer connectRoutinesWaiter.Done()
+
+		ticker := time.NewTicker(TunnelStatsInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if vpnProc.IsPaused() || !s._evtReceiver.IsClientConnected(false) {
+					continue
+				}
+				stats, err := vpnProc.GetStatistics()
+				if err != nil {
+					continue // not connected yet (or the tunnel is re-establishing)
+				}
+				s._evtReceiver.OnTunnelStatistics(stats)
+			case <-stopChannel: // triggered when the stopChannel is closed
+				return
+			}
+		}
+	}()
+
+	// connection fallback: stop the connection attempt if it is not established in time
+	connectRoutinesWaiter.Add(1)
+	go func() {
+		defer connectRoutinesWaiter.Done()
+		s.fallback_attemptWatchdog(stopChannel)
+	}()
+
+	// tunnel health monitor: reconnect when the tunnel stops passing traffic
+	connectRoutinesWaiter.Add(1)
+	go func() {
+		defer connectRoutinesWaiter.Done()
+		s.health_monitor(vpnProc, stopChannel)
+	}()
+
 	// Initialize VPN: ensure everything is prepared for a new connection
 	// (e.g. correct OpenVPN version or a previously started WireGuard service is stopped)
 	log.Info("Initializing connection...")
@@ -0,0 +1,274 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/netinfo"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/preferences"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/wifiNotifier"
+)
+
+const (
+	// default time to establish the connection for each attempt
+	fallbackDefaultAttemptTimeout = time.Second * 30
+	// max number of networks to remember the succeeded attempts for
+	fallbackMaxNetworks = 64
+)
+
+// fallbackAttempt - the connection attempt and its parameters
+type fallbackAttempt struct {
+	attempt types.ConnectionAttempt
+	params  types.ConnectionParams
+	// 'true' - the attempt corresponds to the connection parameters requested by the client
+	isRequested bool
+}
+
+// fallback_connect connects to the host walking the ordered list of connection attempts (if the connection fallback is enabled).
+// The attempt which succeeded is remembered for the current network; it is the first one to try next time.
+// The order of attempts: the attempt remembered for the current network, the parameters requested by the client,
+// the attempts from the user preferences (or the default list).
+func (s *Service) fallback_connect(params types.ConnectionParams) (err error) {
+	fbPrefs := s.Preferences().UserPrefs.ConnectionFallback
+	if !fbPrefs.IsEnabled || params.IsMultiHop() {
+		return s.connectHost(params)
+	}
+
+	timeout := time.Duration(fbPrefs.AttemptTimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = fallbackDefaultAttemptTimeout
+	}
+
+	networkID := fallback_networkID()
+	attempts := s.fallback_attempts(params, networkID, fbPrefs.Attempts)
+	if len(attempts) <= 1 {
+		return s.connectHost(params)
+	}
+
+	defer func() {
+		s._fallback._mutex.Lock()
+		defer s._fallback._mutex.Unlock()
+		s._fallback._current = nil
+	}()
+
+	for i, a := range attempts {
+		a := a
+		if i > 0 {
+			description := fmt.Sprintf("Trying %s (attempt %d/%d): %s", a.attempt, i+1, len(attempts), err)
+			log.Info("Connection fallback: " + description)
+			s.onVpnStateChanged(vpn.StateInfo{
+				State:               vpn.RECONNECTING,
+				Description:         description,
+				StateAdditionalInfo: vpn.ReconnectReasonConnectionFallback})
+		}
+
+		s._fallback._mutex.Lock()
+		s._fallback._current = &a
+		s._fallback._networkID = networkID
+		s._fallback._timeout = timeout
+		s._fallback._isConnected = false
+		s._fallback._isTimedOut = false
+		s._fallback._mutex.Unlock()
+
+		err = s.connectHost(a.params)
+
+		s._fallback._mutex.Lock()
+		isConnected, isTimedOut := s._fallback._isConnected, s._fallback._isTimedOut
+		s._fallback._mutex.Unlock()
+
+		if isConnected {
+			return err // the connection was established (and now it is stopped)
+		}
+		if isTimedOut {
+			err = fmt.Errorf("connection is not established in %s", timeout)
+		} else if s._requiredVpnState == Disconnect {
+			return err // disconnection requested
+		} else if err == nil {
+			err = fmt.Errorf("connection is not established")
+		}
+		log.Info(fmt.Sprintf("Connection fallback: attempt %s failed: %s", a.attempt, err))
+	}
+
+	return err
+}
+
+// fallback_attempts returns the list of the connection attempts (with the parameters) applicable for the connection
+func (s *Service) fallback_attempts(params types.ConnectionParams, networkID string, prefsAttempts []types.ConnectionAttempt) []fallbackAttempt {
+	servers, err := s._serversUpdater.GetServers()
+	if err != nil {
+		log.Warning(fmt.Errorf("connection fallback: unable to get servers list: %w", err))
+		servers = nil
+	}
+
+	if len(prefsAttempts) == 0 {
+		prefsAttempts = types.DefaultConnectionAttempts()
+	}
+
+	requested := types.ConnectionAttemptFromParams(params)
+
+	var ret []fallbackAttempt
+	usedAttempts := map[types.ConnectionAttempt]struct{}{requested: {}}
+	add := func(a types.ConnectionAttempt, isRequested bool) {
+		if !isRequested {
+			if err := a.IsSupported(); err != nil {
+				log.Info(fmt.Sprintf("Connection fallback: attempt %s skipped: %s", a, err))
+				return
+			}
+		}
+		p, err := a.Apply(params, servers)
+		if err != nil {
+			log.Info(fmt.Sprintf("Connection fallback: attempt %s skipped: %s", a, err))
+			return
+		}
+		// the attempts with the same resulting parameters are skipped (e.g. the requested port is the same as the default one)
+		actual := types.ConnectionAttemptFromParams(p)
+		if _, exists := usedAttempts[actual]; exists {
+			return
+		}
+		usedAttempts[actual] = struct{}{}
+		ret = append(ret, fallbackAttempt{attempt: actual, params: p, isRequested: isRequested})
+	}
+
+	if info, ok := s.Preferences().FallbackNetworks[networkID]; ok && len(networkID) > 0 {
+		add(info.Attempt, false)
+	}
+	// the requested parameters are always in use as is (even if they contain unsupported obfuscation)
+	ret = append(ret, fallbackAttempt{attempt: requested, params: params, isRequested: true})
+	for _, a := range prefsAttempts {
+		add(a, false)
+	}
+	return ret
+}
+
+// fallback_onConnected must be called when the connection is established.
+// It returns the description of the succeeded connection attempt (empty if the connection fallback is not in use).
+func (s *Service) fallback_onConnected() string {
+	s._fallback._mutex.Lock()
+	current, networkID, isConnected := s._fallback._current, s._fallback._networkID, s._fallback._isConnected
+	s._fallback._isConnected = true
+	s._fallback._mutex.Unlock()
+
+	if current == nil {
+		return ""
+	}
+	if !isConnected && len(networkID) > 0 {
+		// The requested parameters are working on this network: no need to remember anything.
+		// Otherwise, remember the attempt (it will be the first one to try next time on this network).
+		if current.isRequested {
+			s.fallback_saveNetworkAttempt(networkID, nil)
+		} else {
+			s.fallback_saveNetworkAttempt(networkID, &current.attempt)
+		}
+	}
+	return current.attempt.String()
+}
+
+// fallback_attemptWatchdog stops the connection attempt when it is not established in time.
+// The function returns when the 'stopChan' is closed or the attempt timeout is reached.
+func (s *Service) fallback_attemptWatchdog(stopChan <-chan bool) {
+	s._fallback._mutex.Lock()
+	isActive := s._fallback._current != nil && !s._fallback._isConnected
+	timeout := s._fallback._timeout
+	s._fallback._mutex.Unlock()
+
+	if !isActive || timeout <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-stopChan: // triggered when the stopChan is closed
+		return
+	}
+
+	s._fallback._mutex.Lock()
+	if s._fallback._current == nil || s._fallback._isConnected {
+		s._fallback._mutex.Unlock()
+		return
+	}
+	s._fallback._isTimedOut = true
+	s._fallback._mutex.Unlock()
+
+	log.Info(fmt.Sprintf("Connection fallback: the connection is not established in %s. Stopping the attempt...", timeout))
+	// disconnect in separate routine (do not block current thread)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("PANIC: ", r)
+			}
+		}()
+		if err := s.disconnect(); err != nil {
+			log.Error(err)
+		}
+	}()
+}
+
+// fallback_saveNetworkAttempt remembers the succeeded attempt for the network (nil - forget the network)
+func (s *Service) fallback_saveNetworkAttempt(networkID string, attempt *types.ConnectionAttempt) {
+	prefs := s.Preferences()
+
+	networks := make(map[string]preferences.FallbackNetworkInfo, len(prefs.FallbackNetworks)+1)
+	for k, v := range prefs.FallbackNetworks {
+		networks[k] = v
+	}
+	if attempt == nil {
+		if _, exists := networks[networkID]; !exists {
+			return
+		}
+		delete(networks, networkID)
+	} else {
+		networks[networkID] = preferences.FallbackNetworkInfo{Attempt: *attempt, LastUsed: time.Now().Unix()}
+	}
+
+	// forget the least recently used networks
+	for len(networks) > fallbackMaxNetworks {
+		oldestID := ""
+		for k, v := range networks {
+			if len(oldestID) == 0 || v.LastUsed < networks[oldestID].LastUsed {
+				oldestID = k
+			}
+		}
+		delete(networks, oldestID)
+	}
+
+	prefs.FallbackNetworks = networks
+	s.setPreferences(prefs)
+}
+
+// fallback_networkID returns the ID of the current network: "ssid:<SSID>" for Wi-Fi networks, otherwise "gw:<default gateway IP>"
+// (empty string - if the network can not be identified)
+func fallback_networkID() string {
+	if info, err := wifiNotifier.GetCurrentWifiInfo(); err == nil && len(info.SSID) > 0 {
+		return "ssid:" + info.SSID
+	}
+	if gw, err := netinfo.DefaultGatewayIP(); err == nil && gw != nil && !gw.IsUnspecified() {
+		return "gw:" + gw.String()
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	protocolTypes "github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+)
+
+// geoLookupInfo - the latest known geolocation (separately for each IP protocol)
+type geoLookupInfo struct {
+	mutex    sync.Mutex
+	location *api_types.GeoLookupResponse // IPv4
+	locIPv6  *api_types.GeoLookupResponse // IPv6
+	// the latest known location of the user (IPv4 location received when the traffic was not going through the VPN)
+	userLocation *api_types.GeoLookupResponse
+	// incremented on each update request; results of outdated requests are ignored
+	requestIdx uint64
+}
+
+// GetGeoLookup returns the latest known geolocation (IPv4 and IPv6).
+// If there is no cached info - the geolocation is requested from the backend.
+func (s *Service) GetGeoLookup() (location, locationIPv6 *api_types.GeoLookupResponse) {
+	gl := &s._geoLookup
+	gl.mutex.Lock()
+	location, locationIPv6 = gl.location, gl.locIPv6
+	gl.mutex.Unlock()
+
+	if location == nil && locationIPv6 == nil {
+		return s.geoLookupUpdate()
+	}
+	return location, locationIPv6
+}
+
+// geoLookupUserLocation returns the latest known location of the user (not the location of the VPN server).
+// Returns nil if the location is unknown.
+func (s *Service) geoLookupUserLocation() *api_types.GeoLookupResponse {
+	gl := &s._geoLookup
+	gl.mutex.Lock()
+	location := gl.userLocation
+	gl.mutex.Unlock()
+
+	if location == nil && !s.Connected() {
+		if loc, _ := s.GetGeoLookup(); loc != nil && !loc.IsIvpnServer {
+			location = loc
+		}
+	}
+	return location
+}
+
+// geoLookupUpdateAsync erases the cached geolocation and requests the new one in background.
+// Must be called when the route to the internet was changed (e.g. VPN CONNECTED/DISCONNECTED)
+func (s *Service) geoLookupUpdateAsync() {
+	gl := &s._geoLookup
+	gl.mutex.Lock()
+	gl.location, gl.locIPv6 = nil, nil
+	gl.mutex.Unlock()
+
+	go s.geoLookupUpdate()
+}
+
+// geoLookupUpdate requests the geolocation from the backend, saves it and notifies clients
+func (s *Service) geoLookupUpdate() (location, locationIPv6 *api_types.GeoLookupResponse) {
+	gl := &s._geoLookup
+	gl.mutex.Lock()
+	gl.requestIdx++
+	requestIdx := gl.requestIdx
+	gl.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	lookup := func(ipType protocolTypes.RequiredIPProtocol, ret **api_types.GeoLookupResponse) {
+		defer wg.Done()
+		loc, _, err := s._api.GeoLookup(ipType)
+		if err != nil {
+			log.Info(fmt.Sprintf("Geolookup (%s) failed: %s", ipTypeName(ipType), err))
+			return
+		}
+		*ret = loc
+	}
+
+	vpn := s._vpn
+	isTunnelActive := vpn != nil && !vpn.IsPaused()
+
+	wg.Add(1)
+	go lookup(protocolTypes.IPv4, &location)
+	// IPv6 location makes sense only if IPv6 is routed through the tunnel (when connected)
+	if !isTunnelActive || vpn.IsIPv6InTunnel() {
+		wg.Add(1)
+		go lookup(protocolTypes.IPv6, &locationIPv6)
+	}
+	wg.Wait()
+
+	gl.mutex.Lock()
+	if requestIdx != gl.requestIdx {
+		// there is a newer request; the result is outdated
+		gl.mutex.Unlock()
+		return location, locationIPv6
+	}
+	gl.location, gl.locIPv6 = location, locationIPv6
+	if location != nil && !location.IsIvpnServer && !isTunnelActive {
+		gl.userLocation = location
+	}
+	gl.mutex.Unlock()
+
+	if location != nil || locationIPv6 != nil {
+		s._evtReceiver.OnGeoLookupChanged(location, locationIPv6)
+	}
+	return location, locationIPv6
+}
+
+func ipTypeName(ipType protocolTypes.RequiredIPProtocol) string {
+	if ipType == protocolTypes.IPv6 {
+		return "IPv6"
+	}
+	return "IPv4"
+}
@@ -0,0 +1,291 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/helpers"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+const (
+	// interval of checking the health of the connected tunnel
+	healthCheckInterval = time.Second * 10
+	// WireGuard: the tunnel is unhealthy when there was no handshake during this period
+	// (PersistentKeepalive forces a new handshake each 2 minutes; the session keys are rejected after 3 minutes)
+	healthHandshakeTimeout = time.Minute * 3
+	// OpenVPN: the tunnel is unhealthy when the data is being sent but nothing received during this period
+	healthRxStallTimeout = time.Minute
+	// in-tunnel probe: the tunnel is unhealthy after 'healthProbeMaxFailures' consecutive failures
+	healthProbeInterval    = time.Second * 30
+	healthProbeTimeout     = time.Second * 5
+	healthProbeMaxFailures = 3
+)
+
+// tunnelHealth - health state of the connected tunnel
+type tunnelHealth struct {
+	connectedTime time.Time // the time of the first check of the connected tunnel
+
+	lastRx, lastTx uint64
+	rxChangedTime  time.Time // the time when the received bytes counter was changed last time
+	txOnRxChanged  uint64    // the sent bytes counter at 'rxChangedTime'
+
+	lastProbeTime time.Time
+	probeFailures int
+}
+
+// check returns the reason why the tunnel is unhealthy (empty string if the tunnel is healthy)
+func (h *tunnelHealth) check(vpnType vpn.Type, stats vpn.Statistics, now time.Time) string {
+	if h.connectedTime.IsZero() {
+		h.connectedTime = now
+	}
+
+	if vpnType == vpn.WireGuard {
+		// Note: the traffic counters are not in use for WireGuard: PersistentKeepalive increases the sent bytes counter
+		// even for an idle tunnel, and the server does not send keepalives back
+		if stats.LastHandshake.IsZero() {
+			if age := now.Sub(h.connectedTime); age > healthHandshakeTimeout {
+				return fmt.Sprintf("no WireGuard handshake since connection (%s)", age.Round(time.Second))
+			}
+		} else if age := now.Sub(stats.LastHandshake); age > healthHandshakeTimeout {
+			return fmt.Sprintf("no WireGuard handshake for %s", age.Round(time.Second))
+		}
+		return ""
+	}
+
+	defer func() { h.lastRx, h.lastTx = stats.RxBytes, stats.TxBytes }()
+
+	// the counters could be reset (e.g. OpenVPN reconnected internally)
+	if h.rxChangedTime.IsZero() || stats.RxBytes != h.lastRx || stats.TxBytes < h.lastTx {
+		h.rxChangedTime = now
+		h.txOnRxChanged = stats.TxBytes
+		return ""
+	}
+
+	if stalled := now.Sub(h.rxChangedTime); stalled > healthRxStallTimeout && stats.TxBytes > h.txOnRxChanged {
+		return fmt.Sprintf("no data received for %s (%d bytes sent)", stalled.Round(time.Second), stats.TxBytes-h.txOnRxChanged)
+	}
+	return ""
+}
+
+// health_monitor periodically checks the health of the tunnel until 'stopChan' is closed.
+// When the tunnel is unhealthy - it saves the reason and initiates the reconnection
+// (see keepConnection(): the connection is restarted with the 'ErrorTunnelUnhealthy' error).
+func (s *Service) health_monitor(vpnProc vpn.Process, stopChan <-chan bool) {
+	s.health_takeUnhealthyReason() // erase the reason from the previous connection (if any)
+
+	// cancel the active probe on disconnection
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	var h tunnelHealth
+	for {
+		select {
+		case <-ticker.C:
+		case <-stopChan: // triggered when the stopChan is closed
+			return
+		}
+
+		params := s.Preferences().UserPrefs.HealthMonitor
+		if !params.IsEnabled || vpnProc.IsPaused() {
+			h = tunnelHealth{}
+			continue
+		}
+		stats, err := vpnProc.GetStatistics()
+		if err != nil {
+			h = tunnelHealth{} // not connected yet (or the tunnel is re-establishing)
+			continue
+		}
+
+		reason := h.check(vpnProc.Type(), stats, time.Now())
+		if len(reason) == 0 && len(params.ProbeTarget) > 0 && time.Since(h.lastProbeTime) >= healthProbeInterval {
+			h.lastProbeTime = time.Now()
+			if err := healthProbe(ctx, params.ProbeTarget); err != nil {
+				if ctx.Err() != nil {
+					return // disconnected
+				}
+				h.probeFailures++
+				log.Warning(fmt.Sprintf("Tunnel health probe failed (%d/%d): %s", h.probeFailures, healthProbeMaxFailures, err))
+				if h.probeFailures >= healthProbeMaxFailures {
+					reason = fmt.Sprintf("probe of '%s' failed: %s", params.ProbeTarget, err)
+				}
+			} else {
+				h.probeFailures = 0
+			}
+		}
+
+		if len(reason) == 0 {
+			continue
+		}
+
+		log.Info(fmt.Sprintf("Tunnel is unhealthy (%s). Reconnecting...", reason))
+		s._health._mutex.Lock()
+		s._health._unhealthyReason = reason
+		s._health._mutex.Unlock()
+
+		// reconnect in separate routine (do not block current thread)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error("PANIC: ", r)
+				}
+			}()
+			s.reconnect()
+		}()
+		return
+	}
+}
+
+// health_takeUnhealthyReason returns (and erases) the reason of the reconnection requested by the health monitor.
+// Returns empty string if the reconnection was not requested by the health monitor.
+func (s *Service) health_takeUnhealthyReason() string {
+	s._health._mutex.Lock()
+	defer s._health._mutex.Unlock()
+
+	reason := s._health._unhealthyReason
+	s._health._unhealthyReason = ""
+	return reason
+}
+
+// health_isFailoverEnabled returns 'true' when the unhealthy connection must be switched to another host
+func (s *Service) health_isFailoverEnabled(params types.ConnectionParams) bool {
+	return s.Preferences().UserPrefs.HealthMonitor.IsFailover && !params.IsMultiHop()
+}
+
+// health_failoverParams returns the list of connection parameters to fail over to when the tunnel is unhealthy:
+// the other hosts of the current location (the best ping first) and then the hosts of the next (nearest) location.
+//   - 'original' - connection parameters requested by the client (can contain multiple entry hosts)
+//   - 'current' - normalized parameters of the unhealthy connection
+func (s *Service) health_failoverParams(original, current types.ConnectionParams) []types.ConnectionParams {
+	if len(current.EntryHosts()) == 0 {
+		return nil
+	}
+	failedHost := current.EntryHosts()[0]
+
+	var locations []api_types.ServerListCountryItem
+	if servers, err := s._serversUpdater.GetServers(); err != nil {
+		log.Warning(fmt.Errorf("failover: unable to get servers list: %w", err))
+	} else if current.VpnType == vpn.OpenVPN {
+		locations = servers.ServerList.OpenVPNServers
+	} else {
+		locations = servers.ServerList.WireGuardServers
+	}
+
+	// the current location: hosts requested by the client + other hosts of the same location from the servers list
+	sameLocationHosts := append([]api_types.ServerListItem{}, original.EntryHosts()...)
+	currentLocationIdx := -1
+	for i, l := range locations {
+		for _, h := range l.Hosts {
+			if h.Ip == failedHost.Ip {
+				currentLocationIdx = i
+				sameLocationHosts = append(sameLocationHosts, l.Hosts...)
+				break
+			}
+		}
+		if currentLocationIdx >= 0 {
+			break
+		}
+	}
+
+	// the next location: the nearest one to the failed host
+	var nextLocationHosts []api_types.ServerListItem
+	minDistance := float64(-1)
+	for i, l := range locations {
+		if i == currentLocationIdx || len(l.Hosts) == 0 || (len(failedHost.Country) > 0 && l.Country == failedHost.Country) {
+			continue
+		}
+		d := helpers.GetDistanceFromLatLonInKm(
+			float64(failedHost.Latitude()), float64(failedHost.Longitude()),
+			float64(l.Hosts[0].Latitude()), float64(l.Hosts[0].Longitude()))
+		if minDistance < 0 || d < minDistance {
+			minDistance = d
+			nextLocationHosts = l.Hosts
+		}
+	}
+
+	pingResults := s.ping_getLastResults()
+	usedHosts := map[string]struct{}{failedHost.Ip: {}}
+
+	var ret []types.ConnectionParams
+	for _, hosts := range [][]api_types.ServerListItem{sameLocationHosts, nextLocationHosts} {
+		for _, h := range healthSortHostsByPing(hosts, pingResults) {
+			if _, exists := usedHosts[h.Ip]; exists {
+				continue
+			}
+			usedHosts[h.Ip] = struct{}{}
+			if current.VpnType == vpn.WireGuard && len(h.WireGuard) == 0 {
+				continue
+			}
+
+			p := current
+			p.SetEntryHosts([]api_types.ServerListItem{h})
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// healthSortHostsByPing returns copy of the hosts list sorted by the latency (hosts without ping results are the last ones)
+func healthSortHostsByPing(hosts []api_types.ServerListItem, pingResults map[string]int) []api_types.ServerListItem {
+	ret := append([]api_types.ServerListItem{}, hosts...)
+	latency := func(h api_types.ServerListItem) int {
+		if ip := net.ParseIP(h.Ip); ip != nil {
+			if l, ok := pingResults[ip.String()]; ok && l > 0 {
+				return l
+			}
+		}
+		return int(^uint(0) >> 1)
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return latency(ret[i]) < latency(ret[j]) })
+	return ret
+}
+
+// healthProbe checks the TCP connectivity to the 'target' ("host:port")
+func healthProbe(ctx context.Context, target string) error {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
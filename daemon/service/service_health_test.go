@@ -0,0 +1,163 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+type testServersUpdater struct {
+	servers *api_types.ServerListResponse
+}
+
+func (u *testServersUpdater) StartUpdater() error { return nil }
+func (u *testServersUpdater) GetServers() (*api_types.ServerListResponse, error) {
+	if u.servers == nil {
+		return nil, fmt.Errorf("no servers")
+	}
+	return u.servers, nil
+}
+func (u *testServersUpdater) GetServersForceUpdate() (*api_types.ServerListResponse, error) {
+	return u.GetServers()
+}
+func (u *testServersUpdater) UpdateNotifierChannel() chan struct{} { return nil }
+
+func testHost(name, ip, country, lat, lon string) api_types.ServerListItem {
+	h := api_types.ServerListItem{Name: name, Ip: ip, Country: country, WireGuard: []api_types.WireGuardInstance{{PublicKey: "key"}}}
+	h.Location.Latitude, h.Location.Longitude = lat, lon
+	return h
+}
+
+func TestTunnelHealthCheckWireGuard(t *testing.T) {
+	start := time.Now()
+	var h tunnelHealth
+
+	// no handshake since connection
+	if reason := h.check(vpn.WireGuard, vpn.Statistics{}, start); len(reason) > 0 {
+		t.Errorf("unexpected unhealthy state just after connection: %s", reason)
+	}
+	if reason := h.check(vpn.WireGuard, vpn.Statistics{}, start.Add(healthHandshakeTimeout-time.Second)); len(reason) > 0 {
+		t.Errorf("unexpected unhealthy state before timeout: %s", reason)
+	}
+	if reason := h.check(vpn.WireGuard, vpn.Statistics{}, start.Add(healthHandshakeTimeout+time.Second)); len(reason) == 0 {
+		t.Error("expected unhealthy state: no handshake since connection")
+	}
+
+	// the latest handshake is too old
+	h = tunnelHealth{}
+	now := start.Add(time.Hour)
+	if reason := h.check(vpn.WireGuard, vpn.Statistics{LastHandshake: now.Add(-time.Minute)}, now); len(reason) > 0 {
+		t.Errorf("unexpected unhealthy state: %s", reason)
+	}
+	if reason := h.check(vpn.WireGuard, vpn.Statistics{LastHandshake: now.Add(-healthHandshakeTimeout - time.Second)}, now); len(reason) == 0 {
+		t.Error("expected unhealthy state: handshake is too old")
+	}
+}
+
+func TestTunnelHealthCheckOpenVPN(t *testing.T) {
+	start := time.Now()
+	var h tunnelHealth
+
+	if reason := h.check(vpn.OpenVPN, vpn.Statistics{RxBytes: 100, TxBytes: 100}, start); len(reason) > 0 {
+		t.Errorf("unexpected unhealthy state: %s", reason)
+	}
+	// idle tunnel (nothing sent) is healthy
+	if reason := h.check(vpn.OpenVPN, vpn.Statistics{RxBytes: 100, TxBytes: 100}, start.Add(healthRxStallTimeout*2)); len(reason) > 0 {
+		t.Errorf("unexpected unhealthy state for idle tunnel: %s", reason)
+	}
+	// data sent but nothing received
+	if reason := h.check(vpn.OpenVPN, vpn.Statistics{RxBytes: 100, TxBytes: 500}, start.Add(healthRxStallTimeout*3)); len(reason) == 0 {
+		t.Error("expected unhealthy state: no data received")
+	}
+	// data received
+	if reason := h.check(vpn.OpenVPN, vpn.Statistics{RxBytes: 200, TxBytes: 600}, start.Add(healthRxStallTimeout*4)); len(reason) > 0 {
+		t.Errorf("unexpected unhealthy state: %s", reason)
+	}
+}
+
+func TestHealthSortHostsByPing(t *testing.T) {
+	hosts := []api_types.ServerListItem{{Name: "a", Ip: "10.0.0.1"}, {Name: "b", Ip: "10.0.0.2"}, {Name: "c", Ip: "10.0.0.3"}, {Name: "d", Ip: "bad"}}
+	pings := map[string]int{"10.0.0.2": 50, "10.0.0.3": 10, "10.0.0.1": 0}
+
+	var got []string
+	for _, h := range healthSortHostsByPing(hosts, pings) {
+		got = append(got, h.Name)
+	}
+	if want := []string{"c", "b", "a", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+	if hosts[0].Name != "a" {
+		t.Error("the original list must not be modified")
+	}
+}
+
+func TestHealthFailoverParams(t *testing.T) {
+	de1 := testHost("de1", "10.0.0.1", "Germany", "50.11", "8.68")
+	de2 := testHost("de2", "10.0.0.2", "Germany", "50.11", "8.68")
+	de3 := testHost("de3", "10.0.0.3", "Germany", "50.11", "8.68")
+	nl1 := testHost("nl1", "10.0.1.1", "Netherlands", "52.37", "4.89")
+	us1 := testHost("us1", "10.0.2.1", "United States", "40.71", "-74.00")
+
+	servers := &api_types.ServerListResponse{}
+	servers.ServerList.WireGuardServers = []api_types.ServerListCountryItem{
+		{Country: "United States", Hosts: []api_types.ServerListItem{us1}},
+		{Country: "Germany", Hosts: []api_types.ServerListItem{de1, de2, de3}},
+		{Country: "Netherlands", Hosts: []api_types.ServerListItem{nl1}},
+	}
+
+	s := &Service{_serversUpdater: &testServersUpdater{servers: servers}}
+	s._ping._result = map[string]int{"10.0.0.2": 30, "10.0.0.3": 10}
+
+	var current types.ConnectionParams
+	current.VpnType = vpn.WireGuard
+	current.SetEntryHosts([]api_types.ServerListItem{de1})
+
+	var got []string
+	for _, p := range s.health_failoverParams(current, current) {
+		got = append(got, p.EntryHosts()[0].Name)
+	}
+	// other hosts of the same location (the best ping first), then the nearest location
+	if want := []string{"de3", "de2", "nl1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+
+	// no servers list: only the hosts requested by the client
+	var original types.ConnectionParams
+	original.VpnType = vpn.WireGuard
+	original.SetEntryHosts([]api_types.ServerListItem{de1, de2})
+	s = &Service{_serversUpdater: &testServersUpdater{}}
+	got = nil
+	for _, p := range s.health_failoverParams(original, current) {
+		got = append(got, p.EntryHosts()[0].Name)
+	}
+	if want := []string{"de2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+}
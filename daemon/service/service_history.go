@@ -0,0 +1,288 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package service
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/netinfo"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/history"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/v2r"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+// interval of sampling the VPN interface byte counters
+// (the counters are not accessible after disconnection because the interface is removed)
+const historyStatsSamplingInterval = time.Second * 5
+
+// connectionHistorySession - info about the current connection, required to create connection history entries
+type connectionHistorySession struct {
+	mutex sync.Mutex
+
+	// entry with the connection parameters (server, port, obfuscation ...); used as a template for all session entries
+	template  history.Entry
+	lastState vpn.StateInfo
+
+	connectedTime    time.Time
+	iface            *net.Interface
+	rxStart, txStart uint64
+	rxLast, txLast   uint64
+	stopSampling     chan struct{}
+}
+
+// GetConnectionHistory returns the latest 'count' entries of the connection history (if count <= 0 - all entries)
+func (s *Service) GetConnectionHistory(count int) ([]history.Entry, error) {
+	return s._history.Get(count)
+}
+
+// ClearConnectionHistory erases the connection history
+func (s *Service) ClearConnectionHistory() error {
+	return s._history.Clear()
+}
+
+// historySessionStart initializes new connection history session.
+// Must be called on the beginning of the connection (when the connection parameters are final).
+func (s *Service) historySessionStart(params types.ConnectionParams) {
+	e := history.Entry{VpnType: params.VpnType, V2RayProxy: params.V2Ray()}
+	e.Port, e.IsTCP = params.Port()
+	if hosts := params.EntryHosts(); len(hosts) > 0 {
+		e.Server = hosts[0].Name
+		e.ServerIP = hosts[0].Ip
+	}
+	if hosts := params.ExitHosts(); len(hosts) > 0 {
+		e.ExitServer = hosts[0].Name
+		e.Port = hosts[0].MultihopPort
+	}
+	if params.VpnType == vpn.OpenVPN && e.V2RayProxy == v2r.None {
+		e.Obfsproxy = params.OpenVpnParameters.Obfs4proxy
+	}
+
+	hs := &s._historySession
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+
+	hs.stopStatsSampling()
+	hs.template = e
+	hs.lastState = vpn.StateInfo{}
+	hs.connectedTime = time.Time{}
+}
+
+// historySessionEnd saves the final DISCONNECTED entry of the current connection history session.
+// 'connErr' - the error which caused the disconnection (nil if no error)
+func (s *Service) historySessionEnd(connErr error) {
+	hs := &s._historySession
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+
+	e := hs.template
+	e.Time = time.Now().Unix()
+	e.State = vpn.DISCONNECTED.String()
+
+	switch {
+	case connErr != nil:
+		e.Reason = connErr.Error()
+	case hs.lastState.State == vpn.EXITING && hs.lastState.IsAuthError:
+		e.Reason = "authentication failure"
+	case s._requiredVpnState == Disconnect:
+		e.Reason = history.ReasonDisconnectionRequested
+	default:
+		e.Reason = "disconnected"
+	}
+
+	hs.finishConnectedState(&e)
+	hs.lastState = vpn.NewStateInfo(vpn.DISCONNECTED, "")
+	s.historyAdd(e)
+}
+
+// onVpnStateChanged saves the VPN state transition into the connection history
+// and forwards the state to the events receiver
+func (s *Service) onVpnStateChanged(state vpn.StateInfo) {
+	s.historyOnStateChanged(state)
+	s._evtReceiver.OnVpnStateChanged(state)
+
+	if state.State == vpn.CONNECTED {
+		s.geoLookupUpdateAsync()
+	}
+}
+
+func (s *Service) historyOnStateChanged(state vpn.StateInfo) {
+	// DISCONNECTED transitions are not forwarded to clients by the protocol.
+	// The final DISCONNECTED entry (with the disconnection reason) is saved by historySessionEnd()
+	if state.State == vpn.DISCONNECTED {
+		return
+	}
+
+	// the name of the server which is actually connected (it can differ from the requested one)
+	connectedServerName := ""
+	if state.State == vpn.CONNECTED && state.ServerIP != nil {
+		connectedServerName = s.historyServerName(state.ServerIP)
+	}
+
+	hs := &s._historySession
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+
+	if state.State == vpn.CONNECTED {
+		// the connected server info is in use for all next entries of the session
+		if state.ServerIP != nil && hs.template.ServerIP != state.ServerIP.String() {
+			hs.template.ServerIP = state.ServerIP.String()
+			if len(connectedServerName) > 0 {
+				hs.template.Server = connectedServerName
+			}
+		}
+		if state.ServerPort > 0 {
+			hs.template.Port = state.ServerPort
+		}
+		hs.template.IsTCP = state.IsTCP
+	}
+
+	e := hs.template
+	e.Time = state.Time
+	if e.Time == 0 {
+		e.Time = time.Now().Unix()
+	}
+	e.State = state.State.String()
+	e.Description = state.Description
+
+	if state.State == vpn.CONNECTED {
+		hs.stopStatsSampling()
+		hs.startConnectedState(state.ClientIP)
+	} else {
+		hs.finishConnectedState(&e)
+	}
+
+	hs.lastState = state
+	s.historyAdd(e)
+}
+
+// historyServerName returns the name of the server with the IP address (empty string if the server is unknown)
+func (s *Service) historyServerName(ip net.IP) string {
+	if s._serversUpdater == nil {
+		return ""
+	}
+	servers, err := s._serversUpdater.GetServers()
+	if err != nil {
+		return ""
+	}
+	for _, locations := range [][]api_types.ServerListCountryItem{servers.ServerList.WireGuardServers, servers.ServerList.OpenVPNServers} {
+		for _, l := range locations {
+			for _, h := range l.Hosts {
+				if ip.Equal(net.ParseIP(h.Ip)) {
+					return h.Name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func (s *Service) historyAdd(e history.Entry) {
+	if s._history == nil {
+		return
+	}
+	if err := s._history.Add(e); err != nil {
+		log.Error(fmt.Errorf("failed to save connection history: %w", err))
+	}
+}
+
+// startConnectedState starts measuring the connected state (duration and the VPN interface statistics)
+// Note: the mutex must be locked by the caller
+func (hs *connectionHistorySession) startConnectedState(clientIP net.IP) {
+	hs.connectedTime = time.Now()
+	hs.iface = nil
+	hs.rxStart, hs.txStart, hs.rxLast, hs.txLast = 0, 0, 0, 0
+
+	iface, err := netinfo.InterfaceByIPAddr(clientIP)
+	if err != nil {
+		log.Warning(fmt.Errorf("connection history: unable to detect VPN interface: %w", err))
+		return
+	}
+	rx, tx, err := netinfo.GetInterfaceStatistics(iface)
+	if err != nil {
+		log.Warning(fmt.Errorf("connection history: %w", err))
+		return
+	}
+	hs.iface = iface
+	hs.rxStart, hs.txStart, hs.rxLast, hs.txLast = rx, tx, rx, tx
+
+	stop := make(chan struct{})
+	hs.stopSampling = stop
+	go func() {
+		ticker := time.NewTicker(historyStatsSamplingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				hs.mutex.Lock()
+				hs.sampleStats()
+				hs.mutex.Unlock()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// finishConnectedState updates the entry by the duration and the traffic statistics of the connected state (if it was measured)
+// Note: the mutex must be locked by the caller
+func (hs *connectionHistorySession) finishConnectedState(e *history.Entry) {
+	if hs.connectedTime.IsZero() {
+		return
+	}
+
+	hs.sampleStats()
+	hs.stopStatsSampling()
+
+	e.Duration = int64(time.Since(hs.connectedTime) / time.Second)
+	if hs.rxLast >= hs.rxStart && hs.txLast >= hs.txStart {
+		e.BytesReceived = hs.rxLast - hs.rxStart
+		e.BytesSent = hs.txLast - hs.txStart
+	}
+
+	hs.connectedTime = time.Time{}
+	hs.iface = nil
+}
+
+// Note: the mutex must be locked by the caller
+func (hs *connectionHistorySession) sampleStats() {
+	if hs.iface == nil {
+		return
+	}
+	// ignore errors: the interface can be already removed
+	if rx, tx, err := netinfo.GetInterfaceStatistics(hs.iface); err == nil {
+		hs.rxLast, hs.txLast = rx, tx
+	}
+}
+
+// Note: the mutex must be locked by the caller
+func (hs *connectionHistorySession) stopStatsSampling() {
+	if hs.stopSampling != nil {
+		close(hs.stopSampling)
+		hs.stopSampling = nil
+	}
+}
@@ -0,0 +1,74 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package service
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/history"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+func TestHistoryConnectedServer(t *testing.T) {
+	de1 := testHost("de1", "10.0.0.1", "Germany", "50.11", "8.68")
+	de2 := testHost("de2", "10.0.0.2", "Germany", "50.11", "8.68")
+
+	servers := &api_types.ServerListResponse{}
+	servers.ServerList.WireGuardServers = []api_types.ServerListCountryItem{{Country: "Germany", Hosts: []api_types.ServerListItem{de1, de2}}}
+
+	s := &Service{
+		_serversUpdater: &testServersUpdater{servers: servers},
+		_history:        history.NewJournal(filepath.Join(t.TempDir(), "history.jsonl"), history.DefaultMaxFileSize),
+	}
+
+	var params types.ConnectionParams
+	params.VpnType = vpn.WireGuard
+	params.SetEntryHosts([]api_types.ServerListItem{de1})
+
+	// the connection is established to another host than requested
+	s.historySessionStart(params)
+	s.historyOnStateChanged(vpn.StateInfo{State: vpn.CONNECTING})
+	s.historyOnStateChanged(vpn.StateInfo{State: vpn.CONNECTED, ServerIP: net.ParseIP(de2.Ip), ServerPort: 2049})
+	s.historySessionEnd(nil)
+
+	entries, err := s.GetConnectionHistory(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("unexpected number of entries: %d", len(entries))
+	}
+	// the newest entry is the first one
+	if entries[2].Server != "de1" {
+		t.Errorf("CONNECTING: unexpected server '%s'", entries[2].Server)
+	}
+	for _, e := range entries[:2] {
+		if e.Server != "de2" || e.ServerIP != de2.Ip || e.Port != 2049 {
+			t.Errorf("%s: unexpected server '%s' (%s:%d)", e.State, e.Server, e.ServerIP, e.Port)
+		}
+	}
+}
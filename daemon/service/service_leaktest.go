@@ -0,0 +1,176 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/netinfo"
+	protocolTypes "github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/leaktest"
+)
+
+const leakTestTimeout = time.Second * 20
+
+var (
+	// well-known public resolvers: they must not be reachable while the tunnel is active
+	leakTestPublicResolvers = []net.IP{net.ParseIP("1.1.1.1"), net.ParseIP("9.9.9.9"), net.ParseIP("2606:4700:4700::1111")}
+	// the address to check IPv6 connectivity
+	leakTestIPv6ProbeAddr = "[2606:4700:4700::1111]:443"
+)
+
+// LeakTest checks the current configuration for DNS and IP leaks
+func (s *Service) LeakTest() (leaktest.Result, error) {
+	vpnObj := s._vpn
+	isTunnelActive := vpnObj != nil && !vpnObj.IsPaused()
+
+	in := leaktest.Input{
+		IsTunnelActive: isTunnelActive,
+		IPv6ProbeAddr:  leakTestIPv6ProbeAddr,
+	}
+
+	if isTunnelActive {
+		in.IsIPv6InTunnel = vpnObj.IsIPv6InTunnel()
+
+		// expected DNS: the DNS in use for the current connection (manual DNS or VPN DNS)
+		dnsCfg, err := s.GetActiveDNS()
+		if err != nil {
+			return leaktest.Result{}, fmt.Errorf("failed to get active DNS: %w", err)
+		}
+		if manualDns := dns.GetLastManualDNS(); !manualDns.IsEmpty() && manualDns.Encryption == dns.EncryptionNone {
+			in.ExpectedDNS = append(in.ExpectedDNS, manualDns.Ip())
+		}
+		if !dnsCfg.IsEmpty() && dnsCfg.Encryption == dns.EncryptionNone {
+			in.ExpectedDNS = append(in.ExpectedDNS, dnsCfg.Ip())
+		}
+		if ip := vpnObj.DefaultDNS(); ip != nil {
+			in.ExpectedDNS = append(in.ExpectedDNS, ip)
+		}
+
+		// VPN servers in use
+		params := s.GetConnectionParams()
+		for _, h := range append(params.EntryHosts(), params.ExitHosts()...) {
+			for _, addr := range []string{h.Ip, h.IPv6} {
+				if ip := net.ParseIP(addr); ip != nil {
+					in.TunnelEndpoints = append(in.TunnelEndpoints, ip)
+				}
+			}
+		}
+		if ip := vpnObj.DestinationIP(); ip != nil {
+			in.TunnelEndpoints = append(in.TunnelEndpoints, ip)
+		}
+	}
+
+	// resolvers to probe: configured in the OS, default gateway, well-known public resolvers
+	in.Resolvers = append(in.Resolvers, netinfo.GetDNSServers()...)
+	if gw, err := netinfo.DefaultGatewayIP(); err == nil && gw != nil {
+		in.Resolvers = append(in.Resolvers, gw)
+	}
+	in.Resolvers = append(in.Resolvers, leakTestPublicResolvers...)
+	for i := 0; i < len(in.Resolvers); i++ {
+		if in.Resolvers[i].IsLoopback() {
+			// local resolvers (e.g. systemd-resolved stub) are forwarding requests to the upstream servers which are probed separately
+			in.Resolvers = append(in.Resolvers[:i], in.Resolvers[i+1:]...)
+			i--
+		}
+	}
+
+	in.Interfaces = s.leakTest_interfaces()
+
+	// public IP addresses
+	lookup := func(ipType protocolTypes.RequiredIPProtocol) leaktest.PublicIP {
+		loc, _, err := s._api.GeoLookup(ipType)
+		if err != nil {
+			return leaktest.PublicIP{Error: err.Error()}
+		}
+		return leaktest.PublicIP{IP: net.ParseIP(loc.IP), IsVpnServer: loc.IsIvpnServer}
+	}
+	in.PublicIPv4 = lookup(protocolTypes.IPv4)
+	// IPv6 location makes sense only if IPv6 is routed through the tunnel (when connected)
+	// Otherwise, IPv6 connectivity is checked by 'IPv6ProbeAddr'
+	if !isTunnelActive || in.IsIPv6InTunnel {
+		in.PublicIPv6 = lookup(protocolTypes.IPv6)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), leakTestTimeout)
+	defer cancel()
+
+	ret := leaktest.Run(ctx, in)
+	if ret.IsLeakDetected() {
+		log.Warning(fmt.Sprintf("Leak test: %d leaks detected: %v", len(ret.Leaks), ret.Leaks))
+	} else {
+		log.Info("Leak test: no leaks detected")
+	}
+	return ret, nil
+}
+
+// leakTest_interfaces returns the local interfaces (with addresses) to send the DNS probes through
+func (s *Service) leakTest_interfaces() []leaktest.Interface {
+	sInf := s.GetVpnSessionInfo()
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		log.Warning("Leak test: failed to get network interfaces: ", err)
+		return nil
+	}
+
+	ret := make([]leaktest.Interface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		isTunnel := false
+		ips := make([]net.IP, 0, 2)
+		for _, a := range addrs {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok || !ipNet.IP.IsGlobalUnicast() {
+				continue
+			}
+			if ipNet.IP.Equal(sInf.VpnLocalIPv4) || ipNet.IP.Equal(sInf.VpnLocalIPv6) {
+				isTunnel = true
+			}
+			ips = append(ips, ipNet.IP)
+		}
+
+		// one address for each IP protocol is enough
+		isV4Added, isV6Added := false, false
+		for _, ip := range ips {
+			isV4 := ip.To4() != nil
+			if (isV4 && isV4Added) || (!isV4 && isV6Added) {
+				continue
+			}
+			isV4Added, isV6Added = isV4Added || isV4, isV6Added || !isV4
+			ret = append(ret, leaktest.Interface{Name: iface.Name, IP: ip, IsTunnel: isTunnel})
+		}
+	}
+	return ret
+}
@@ -52,6 +52,11 @@ func (s *Service) implIsCanApplyUserPreferences(userPrefs preferences.UserPrefer
 			return fmt.Errorf("the old-style DNS management is not applicable to the current environment: %s", dnsMgmtOldErr)
 		}
 	}
+	if userPrefs.Linux.IsFwNftablesBackend {
+		if err := firewall.NftablesCheck(); err != nil {
+			return fmt.Errorf("the nftables firewall backend is not applicable to the current environment: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -64,6 +69,9 @@ func (s *Service) implGetDisabledFuncForPlatform() protocolTypes.DisabledFunctio
 	if envs := platform.GetSnapEnvs(); envs != nil {
 		linuxFuncs.DnsMgmtOldResolvconfError = "it is not allowed to modify 'resolv.conf' from the snap environment"
 	}
+	if err := firewall.NftablesCheck(); err != nil {
+		linuxFuncs.FwNftablesError = err.Error()
+	}
 
 	return protocolTypes.DisabledFunctionalityForPlatform{Linux: linuxFuncs}
 }
@@ -103,7 +111,27 @@ func (s *Service) implSplitTunnelling_AddApp(execCmd string) (requiredCmdToExec
 }
 
 func (s *Service) implSplitTunnelling_RemoveApp(pid int, binaryPath string) (err error) {
-	return splittun.RemovePid(pid)
+	binaryPath = strings.TrimSpace(binaryPath)
+	if pid > 0 || len(binaryPath) <= 0 {
+		return splittun.RemovePid(pid)
+	}
+
+	// no PID defined: remove the application rule
+	prefs := s._preferences
+	newStApps := make([]string, 0, len(prefs.SplitTunnelApps))
+	for _, a := range prefs.SplitTunnelApps {
+		if a == binaryPath {
+			continue
+		}
+		newStApps = append(newStApps, a)
+	}
+	if len(newStApps) == len(prefs.SplitTunnelApps) {
+		return fmt.Errorf("application rule '%s' not found", binaryPath)
+	}
+
+	prefs.SplitTunnelApps = newStApps
+	s.setPreferences(prefs)
+	return nil
 }
 
 // Inform the daemon about started process in ST environment
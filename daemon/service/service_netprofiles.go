@@ -0,0 +1,124 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package service
+
+import (
+	"fmt"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/netinfo"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/preferences"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/srverrors"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/wifiNotifier"
+)
+
+// SetNetworkProfiles saves the network profiles configuration and applies the actions for the current network (if necessary)
+func (s *Service) SetNetworkProfiles(params preferences.NetworkProfilesParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	prefs := s._preferences
+	for _, p := range params.Profiles {
+		if p.Actions.ConnectVpn {
+			if e := prefs.LastConnectionParams.CheckIsDefined(); e != nil {
+				return srverrors.ErrorBackgroundConnectionNoParams{}
+			}
+			break
+		}
+	}
+
+	// Save settings
+	prefs.NetworkProfiles = params
+	s.setPreferences(prefs)
+
+	// 'network profiles' functionality: apply actions for the current network (if necessary)
+	s.autoConnectIfRequired(OnNetworkChanged, nil)
+	return nil
+}
+
+// GetNetworkIdentity returns the identity of the current network and the name of the matching network profile (if any)
+func (s *Service) GetNetworkIdentity() (identity preferences.NetworkIdentity, profileName string) {
+	identity = getCurrentNetworkIdentity(s.GetWiFiCurrentState())
+	if p := s.Preferences().NetworkProfiles.MatchingProfile(identity); p != nil {
+		profileName = p.Name
+	}
+	return identity, profileName
+}
+
+// getCurrentNetworkIdentity returns the identity of the network in use by the default route
+func getCurrentNetworkIdentity(wifiInfo wifiNotifier.WifiInfo) preferences.NetworkIdentity {
+	id := preferences.NetworkIdentity{SSID: wifiInfo.SSID}
+
+	info, err := netinfo.GetDefaultNetworkInfo()
+	if err != nil {
+		log.Warning(fmt.Sprintf("Network profiles: unable to obtain default network info: %v", err))
+		return id
+	}
+	id.InterfaceName = info.InterfaceName
+	id.DHCPDomain = info.DHCPDomain
+	if info.GatewayMAC != nil {
+		id.GatewayMAC = info.GatewayMAC.String()
+	}
+	return id
+}
+
+// netProfiles_networkIdentity returns the identity of the current network
+// (empty identity - when network profiles are not in use: no need to spend time on obtaining the network info)
+func (s *Service) netProfiles_networkIdentity(wifiInfo wifiNotifier.WifiInfo) preferences.NetworkIdentity {
+	params := s.Preferences().NetworkProfiles
+	if !params.IsEnabled || len(params.Profiles) == 0 {
+		return preferences.NetworkIdentity{}
+	}
+	return getCurrentNetworkIdentity(wifiInfo)
+}
+
+// netProfiles_startDetector starts detection of network changes (e.g. switching between wired networks)
+// The actions of the network profiles are re-evaluated on each change.
+func (s *Service) netProfiles_startDetector() {
+	detector := s._netProfiles._detector
+	if detector == nil {
+		return
+	}
+
+	changed := make(chan struct{}, 1)
+	if err := detector.Init(changed, changed, nil); err != nil {
+		log.Error("Network profiles: failed to initialize network change detector: ", err)
+		return
+	}
+	if err := detector.Start(); err != nil {
+		log.Error("Network profiles: failed to start network change detector: ", err)
+		return
+	}
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("Network profiles: PANIC (recovered): ", r)
+			}
+		}()
+
+		for range changed {
+			s.autoConnectIfRequired(OnNetworkChanged, nil)
+		}
+	}()
+}
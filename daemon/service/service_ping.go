@@ -37,6 +37,7 @@ import (
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/helpers"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/ping"
+	protocolTypes "github.com/tahirmahm123/vpn-desktop-app/daemon/protocol/types"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 )
 
@@ -142,7 +143,7 @@ func (s *Service) PingServers(firstPhaseTimeoutMs int, vpnTypePrioritized vpn.Ty
 	onGeoLookupChan := make(chan *types.GeoLookupResponse, 1)
 	if firstPhaseTimeoutMs >= 2000 {
 		go func() {
-			geoLocation, _, err := s._api.GeoLookup()
+			geoLocation, _, err := s._api.GeoLookup(protocolTypes.IPvAny)
 			if err != nil {
 				log.Warning("(pinging) unable to obtain geo-location (fastest server detection could be not accurate):", err)
 				return
@@ -0,0 +1,100 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/serverscore"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+// RecommendServers returns the servers ranked by the composite score (the best first).
+// Each candidate contains the explanation of its score.
+// count - max number of candidates to return (<= 0 - all applicable servers)
+func (s *Service) RecommendServers(vpnType vpn.Type, constraints serverscore.Constraints, count int) ([]serverscore.Candidate, error) {
+	servers, err := s.ServersList()
+	if err != nil {
+		return nil, err
+	}
+
+	locations := servers.ServerList.WireGuardServers
+	if vpnType == vpn.OpenVPN {
+		locations = servers.ServerList.OpenVPNServers
+	}
+
+	candidates := s.recommend_rank(vpnType, locations, constraints, nil)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no servers satisfy the constraints")
+	}
+	if count > 0 && len(candidates) > count {
+		candidates = candidates[:count]
+	}
+	return candidates, nil
+}
+
+// recommend_bestHost returns the best host (according to the composite score) from the locations
+func (s *Service) recommend_bestHost(vpnType vpn.Type, locations []api_types.ServerListCountryItem, metadata types.ConnectMetadata) (api_types.ServerListItem, error) {
+	// the gateway ID in use to exclude locations: "us-tx.wg.ivpn.net" => "us-tx"
+	excluded := make([]string, 0, len(metadata.FastestGatewaysExcludeList))
+	for _, gw := range metadata.FastestGatewaysExcludeList {
+		excluded = append(excluded, strings.Split(gw, ".")[0])
+	}
+
+	candidates := s.recommend_rank(vpnType, locations, metadata.ServerConstraints, excluded)
+	if len(candidates) == 0 {
+		return api_types.ServerListItem{}, fmt.Errorf("no servers satisfy the constraints")
+	}
+	best := candidates[0]
+	log.Info(fmt.Sprintf("Recommended server: %s (score %.2f)", best.Host.Name, best.Score))
+	return best.Host, nil
+}
+
+func (s *Service) recommend_rank(vpnType vpn.Type, locations []api_types.ServerListCountryItem, constraints serverscore.Constraints, excludedLocations []string) []serverscore.Candidate {
+	pings, err := s.PingServers(4000, vpnType, true)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Servers ranking: %s (using the last known ping results)", err))
+		pings = s.ping_getLastResults()
+	}
+
+	var stats map[string]serverscore.ConnectionStats
+	if s._history != nil {
+		entries, err := s._history.Get(0)
+		if err != nil {
+			log.Warning(fmt.Sprintf("Servers ranking: unable to read connection history: %s", err))
+		}
+		stats = serverscore.StatsFromHistory(entries)
+	}
+
+	return serverscore.Rank(serverscore.Input{
+		Servers:           locations,
+		Pings:             pings,
+		Location:          s.geoLookupUserLocation(),
+		Stats:             stats,
+		Constraints:       constraints,
+		ExcludedLocations: excludedLocations,
+	}, serverscore.DefaultWeights())
+}
@@ -0,0 +1,148 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/preferences"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/scheduler"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/srverrors"
+)
+
+// SetSchedulerSettings saves the configuration of the time-based connection schedules
+func (s *Service) SetSchedulerSettings(params preferences.SchedulerParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	prefs := s._preferences
+	for _, r := range params.Rules {
+		if r.IsEnabled && r.Action == preferences.ScheduleConnect && len(r.Target) == 0 {
+			if e := prefs.LastConnectionParams.CheckIsDefined(); e != nil {
+				return srverrors.ErrorBackgroundConnectionNoParams{}
+			}
+			break
+		}
+	}
+
+	prefs.Scheduler = params
+	s.setPreferences(prefs)
+	return nil
+}
+
+// scheduler_start starts the routine which applies the schedule rules.
+// The rules are checked at the beginning of each minute; the rules which were missed (e.g. the computer was sleeping) are not applied.
+func (s *Service) scheduler_start() {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("Scheduler: PANIC (recovered): ", r)
+			}
+		}()
+
+		log.Info("Scheduler started")
+		for {
+			now := time.Now()
+			time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+			s.scheduler_process(time.Now().Truncate(time.Minute))
+		}
+	}()
+}
+
+// scheduler_process applies the rules which match the time
+func (s *Service) scheduler_process(t time.Time) {
+	prefs := s.Preferences()
+	if !prefs.Scheduler.IsEnabled || !prefs.Session.IsLoggedIn() {
+		return
+	}
+
+	for _, r := range prefs.Scheduler.Rules {
+		if !r.IsEnabled {
+			continue
+		}
+		cron, err := scheduler.Parse(r.Cron)
+		if err != nil || !cron.Matches(t) {
+			continue
+		}
+
+		if !s._evtReceiver.IsCanDoBackgroundAction() {
+			log.Info(fmt.Sprintf("Scheduler: rule '%s' skipped (background actions not allowed)", r.Name))
+			continue
+		}
+
+		log.Info(fmt.Sprintf("Scheduler: applying rule '%s' (%s)", r.Name, r.Action))
+		if err := s.scheduler_apply(prefs, r); err != nil {
+			log.Error(fmt.Sprintf("Scheduler: rule '%s' failed: %v", r.Name, err))
+		}
+	}
+}
+
+func (s *Service) scheduler_apply(prefs preferences.Preferences, r preferences.ScheduleRule) error {
+	switch r.Action {
+	case preferences.ScheduleConnect:
+		if s.Connected() {
+			log.Info("Scheduler: VPN is already connected")
+			return nil
+		}
+
+		connParams := prefs.LastConnectionParams
+		if len(r.Target) > 0 {
+			target := prefs.Scheduler.FindTarget(r.Target)
+			if target == nil {
+				return fmt.Errorf("connection target '%s' not defined", r.Target)
+			}
+			connParams = target.Params
+		}
+
+		connParams, err := s.updateParamsAccordingToMetadata(connParams)
+		if err != nil {
+			log.Info("[WARNING] Scheduler: failed updating connection parameters: ", err)
+		}
+		const canFixParams bool = true
+		if connParams, err = s.ValidateConnectionParameters(connParams, canFixParams); err != nil {
+			return fmt.Errorf("error validating connection parameters: %w", err)
+		}
+		return s._evtReceiver.RegisterConnectionRequest(connParams)
+
+	case preferences.ScheduleDisconnect:
+		if !s.Connected() {
+			return nil
+		}
+		return s.Disconnect()
+
+	case preferences.SchedulePause:
+		if !s.Connected() || s.IsPaused() {
+			return nil
+		}
+		return s.Pause(uint32(r.PauseMinutes * 60))
+
+	case preferences.ScheduleFirewallOn:
+		return s.SetKillSwitchState(true)
+
+	case preferences.ScheduleFirewallOff:
+		return s.SetKillSwitchState(false)
+	}
+	return fmt.Errorf("unsupported action '%s'", r.Action)
+}
@@ -36,3 +36,12 @@ type ErrorBackgroundConnectionNoParams struct {
 func (e ErrorBackgroundConnectionNoParams) Error() string {
 	return "parameters for background connection are not defined; please manually connect the VPN once to initialize the default connection settings"
 }
+
+// ErrorTunnelUnhealthy - the connection was stopped by the tunnel health monitor (the tunnel does not pass traffic)
+type ErrorTunnelUnhealthy struct {
+	Reason string
+}
+
+func (e ErrorTunnelUnhealthy) Error() string {
+	return "tunnel is unhealthy: " + e.Reason
+}
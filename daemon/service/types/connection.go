@@ -26,10 +26,12 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"strings"
 
 	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/obfsproxy"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/serverscore"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/v2r"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 )
@@ -40,6 +42,8 @@ const (
 	Default ServerSelectionEnum = iota // Server is manually defined
 	Fastest ServerSelectionEnum = iota // Fastest server in use (only for 'Entry' server)
 	Random  ServerSelectionEnum = iota // Random server in use
+	// The best server according to the composite score (latency, distance, success rate, load) in use (only for 'Entry' server)
+	Recommended ServerSelectionEnum = iota
 )
 
 type AntiTrackerMetadata struct {
@@ -59,8 +63,10 @@ func (a AntiTrackerMetadata) Equal(b AntiTrackerMetadata) bool {
 type ConnectMetadata struct {
 	// How the entry server was chosen
 	ServerSelectionEntry ServerSelectionEnum
-	// How the exit server was chosen ('Fastest' is not applicable for 'Exit' server)
+	// How the exit server was chosen ('Fastest' and 'Recommended' are not applicable for 'Exit' server)
 	ServerSelectionExit ServerSelectionEnum
+	// (only if Recommended server in use) Constraints for the server selection
+	ServerConstraints serverscore.Constraints
 
 	AntiTracker AntiTrackerMetadata
 
@@ -99,6 +105,12 @@ type ConnectionParams struct {
 			Hosts []api_types.ServerListItem
 		}
 
+		MultihopExitServer struct {
+			// ExitSrvID (server name) just in use to keep clients notified about connected MH exit server
+			ExitSrvID string
+			Hosts     []api_types.ServerListItem
+		}
+
 		Mtu int // Set 0 to use default MTU value
 
 		V2RayProxy v2r.V2RayTransportType // V2Ray config
@@ -108,6 +120,13 @@ type ConnectionParams struct {
 		EntryVpnServer struct {
 			Hosts []api_types.ServerListItem
 		}
+
+		MultihopExitServer struct {
+			// ExitSrvID (server name) just in use to keep clients notified about connected MH exit server
+			ExitSrvID string
+			Hosts     []api_types.ServerListItem
+		}
+
 		Proxy struct {
 			Type     string
 			Address  string
@@ -128,11 +147,88 @@ type ConnectionParams struct {
 }
 
 func (p ConnectionParams) IsMultiHop() bool {
-	//if p.VpnType == vpn.OpenVPN {
-	//	return len(p.OpenVpnParameters.MultihopExitServer.Hosts) > 0
-	//}
-	//return len(p.WireGuardParameters.MultihopExitServer.Hosts) > 0
-	return false
+	if p.VpnType == vpn.OpenVPN {
+		return len(p.OpenVpnParameters.MultihopExitServer.Hosts) > 0
+	}
+	return len(p.WireGuardParameters.MultihopExitServer.Hosts) > 0
+}
+
+// EntryHosts returns the entry server hosts for the current VPN type
+func (p ConnectionParams) EntryHosts() []api_types.ServerListItem {
+	if p.VpnType == vpn.OpenVPN {
+		return p.OpenVpnParameters.EntryVpnServer.Hosts
+	}
+	return p.WireGuardParameters.EntryVpnServer.Hosts
+}
+
+// SetEntryHosts updates the entry server hosts for the current VPN type
+func (p *ConnectionParams) SetEntryHosts(hosts []api_types.ServerListItem) {
+	if p.VpnType == vpn.OpenVPN {
+		p.OpenVpnParameters.EntryVpnServer.Hosts = hosts
+		return
+	}
+	p.WireGuardParameters.EntryVpnServer.Hosts = hosts
+}
+
+// ExitHosts returns the Multi-Hop exit server hosts for the current VPN type
+func (p ConnectionParams) ExitHosts() []api_types.ServerListItem {
+	if p.VpnType == vpn.OpenVPN {
+		return p.OpenVpnParameters.MultihopExitServer.Hosts
+	}
+	return p.WireGuardParameters.MultihopExitServer.Hosts
+}
+
+// SetExitHosts updates the Multi-Hop exit server hosts for the current VPN type
+// (empty list converts the connection to Single-Hop)
+func (p *ConnectionParams) SetExitHosts(hosts []api_types.ServerListItem) {
+	if p.VpnType == vpn.OpenVPN {
+		p.OpenVpnParameters.MultihopExitServer.Hosts = hosts
+		if len(hosts) == 0 {
+			p.OpenVpnParameters.MultihopExitServer.ExitSrvID = ""
+		}
+		return
+	}
+	p.WireGuardParameters.MultihopExitServer.Hosts = hosts
+	if len(hosts) == 0 {
+		p.WireGuardParameters.MultihopExitServer.ExitSrvID = ""
+	}
+}
+
+// CheckMultiHopJurisdictions returns error when any of exit hosts located in the same jurisdiction (country) as any of entry hosts
+func (p ConnectionParams) CheckMultiHopJurisdictions() error {
+	if !p.IsMultiHop() {
+		return nil
+	}
+	for _, entry := range p.EntryHosts() {
+		for _, exit := range p.ExitHosts() {
+			if IsSameJurisdiction(entry, exit) {
+				return fmt.Errorf("entry and exit servers of a Multi-Hop connection must be located in different jurisdictions (%s: %s, %s)", Jurisdiction(entry), entry.Name, exit.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Jurisdiction returns the jurisdiction (country) identifier of the server
+func Jurisdiction(h api_types.ServerListItem) string {
+	ret := strings.TrimSpace(h.CountryCode)
+	if len(ret) == 0 {
+		ret = strings.TrimSpace(h.Flag)
+	}
+	if len(ret) == 0 {
+		ret = strings.TrimSpace(h.Country)
+	}
+	return strings.ToUpper(ret)
+}
+
+// IsSameJurisdiction returns true when both servers are located in the same jurisdiction (country).
+// Servers with unknown jurisdiction are considered to be in the same jurisdiction.
+func IsSameJurisdiction(a, b api_types.ServerListItem) bool {
+	ja, jb := Jurisdiction(a), Jurisdiction(b)
+	if len(ja) == 0 || len(jb) == 0 {
+		return true
+	}
+	return ja == jb
 }
 
 func (p ConnectionParams) CheckIsDefined() error {
@@ -165,32 +261,39 @@ func (p ConnectionParams) V2Ray() v2r.V2RayTransportType {
 // NormalizeHosts - normalize hosts list
 // 1) in case of multiple entry hosts - take random host from the list
 // 2) in case of multiple exit hosts - take random host from the list
-// 3) (WireGuard) filter entry hosts: use IPv6 hosts
-// 4) (WireGuard) filter exit servers (Multi-Hop connection):
-// 4.1) each exit server must have initialized 'multihop_port' field
-// 4.2) (in case of IPv6Only) IPv6 local address should be defined
+// 3) filter exit servers (Multi-Hop connection): each exit server must have initialized 'multihop_port' field
+// 4) (WireGuard) filter exit servers (Multi-Hop connection): each exit server must have WireGuard configuration
 func (p *ConnectionParams) NormalizeHosts() error {
 
 	if vpn.Type(p.VpnType) == vpn.OpenVPN {
 		// in case of multiple entry hosts - take random host from the list
-		entryHosts := p.OpenVpnParameters.EntryVpnServer.Hosts
-		if len(entryHosts) > 1 {
-			rndHost := entryHosts[0]
-			if rnd, err := rand.Int(rand.Reader, big.NewInt(int64(len(entryHosts)))); err == nil {
-				rndHost = entryHosts[rnd.Int64()]
+		p.OpenVpnParameters.EntryVpnServer.Hosts = randomHost(p.OpenVpnParameters.EntryVpnServer.Hosts)
+
+		// in case of multiple exit hosts - take random host from the list
+		if len(p.OpenVpnParameters.MultihopExitServer.Hosts) > 0 {
+			exitHosts := filterHosts(p.OpenVpnParameters.MultihopExitServer.Hosts, func(h api_types.ServerListItem) bool {
+				return h.MultihopPort > 0
+			})
+			if len(exitHosts) == 0 {
+				return fmt.Errorf("no applicable hosts for the Multi-Hop exit server")
 			}
-			p.OpenVpnParameters.EntryVpnServer.Hosts = []api_types.ServerListItem{rndHost}
+			p.OpenVpnParameters.MultihopExitServer.Hosts = randomHost(exitHosts)
 		}
 
 	} else if vpn.Type(p.VpnType) == vpn.WireGuard {
 
 		// in case of multiple entry hosts - take random host from the list
-		if len(p.WireGuardParameters.EntryVpnServer.Hosts) > 1 {
-			rndHost := p.WireGuardParameters.EntryVpnServer.Hosts[0]
-			if rnd, err := rand.Int(rand.Reader, big.NewInt(int64(len(p.WireGuardParameters.EntryVpnServer.Hosts)))); err == nil {
-				rndHost = p.WireGuardParameters.EntryVpnServer.Hosts[rnd.Int64()]
+		p.WireGuardParameters.EntryVpnServer.Hosts = randomHost(p.WireGuardParameters.EntryVpnServer.Hosts)
+
+		// in case of multiple exit hosts - take random host from the list
+		if len(p.WireGuardParameters.MultihopExitServer.Hosts) > 0 {
+			exitHosts := filterHosts(p.WireGuardParameters.MultihopExitServer.Hosts, func(h api_types.ServerListItem) bool {
+				return h.MultihopPort > 0 && len(h.WireGuard) > 0
+			})
+			if len(exitHosts) == 0 {
+				return fmt.Errorf("no applicable hosts for the Multi-Hop exit server")
 			}
-			p.WireGuardParameters.EntryVpnServer.Hosts = []api_types.ServerListItem{rndHost}
+			p.WireGuardParameters.MultihopExitServer.Hosts = randomHost(exitHosts)
 		}
 
 	} else {
@@ -199,3 +302,25 @@ func (p *ConnectionParams) NormalizeHosts() error {
 
 	return nil
 }
+
+// randomHost returns list with one random host from the 'hosts' (or the original list if it contains less than two elements)
+func randomHost(hosts []api_types.ServerListItem) []api_types.ServerListItem {
+	if len(hosts) <= 1 {
+		return hosts
+	}
+	rndHost := hosts[0]
+	if rnd, err := rand.Int(rand.Reader, big.NewInt(int64(len(hosts)))); err == nil {
+		rndHost = hosts[rnd.Int64()]
+	}
+	return []api_types.ServerListItem{rndHost}
+}
+
+func filterHosts(hosts []api_types.ServerListItem, isApplicable func(h api_types.ServerListItem) bool) []api_types.ServerListItem {
+	ret := make([]api_types.ServerListItem, 0, len(hosts))
+	for _, h := range hosts {
+		if isApplicable(h) {
+			ret = append(ret, h)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,221 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/obfsproxy"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/v2r"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+// ConnectionAttempt - port/protocol/obfuscation settings of one connection attempt.
+// When the connection fallback is enabled, the daemon walks the ordered list of attempts until the connection is established.
+type ConnectionAttempt struct {
+	VpnType vpn.Type
+	IsTCP   bool
+	// Port number (0 - the port from the connection parameters or the default port for the VPN type)
+	Port       int
+	V2RayProxy v2r.V2RayTransportType
+	Obfsproxy  obfsproxy.Config // applicable only for OpenVPN
+}
+
+// DefaultConnectionAttempts returns the default ordered list of connection attempts.
+// It contains only the attempts supported by the daemon (see IsSupported()).
+//
+// Known gap: the intended list is WireGuard UDP -> WireGuard over V2Ray (TCP) -> OpenVPN TCP 443 -> OpenVPN over obfs4,
+// but the V2Ray and obfs4 steps are not available in this build: the V2Ray connection path is not implemented
+// (see connectHost()) and the servers list contains neither the V2Ray configuration nor the obfs4 ports and keys of the hosts.
+// The steps must be added back when the backend provides this data.
+func DefaultConnectionAttempts() []ConnectionAttempt {
+	return []ConnectionAttempt{
+		{VpnType: vpn.WireGuard},
+		{VpnType: vpn.OpenVPN, IsTCP: true, Port: 443},
+	}
+}
+
+// IsSupported returns error when the connection attempt can not be used by the connection fallback
+func (a ConnectionAttempt) IsSupported() error {
+	if a.VpnType != vpn.WireGuard && a.VpnType != vpn.OpenVPN {
+		return fmt.Errorf("unknown VPN type: %d", a.VpnType)
+	}
+	if a.V2RayProxy != v2r.None {
+		// the servers list does not contain the V2Ray configuration
+		return fmt.Errorf("V2Ray configuration is not available")
+	}
+	if a.VpnType == vpn.OpenVPN && a.Obfsproxy.IsObfsproxy() {
+		// the servers list does not contain the obfsproxy ports and the obfs4 keys of the hosts
+		return fmt.Errorf("obfsproxy configuration is not available")
+	}
+	if a.VpnType == vpn.WireGuard && a.IsTCP {
+		return fmt.Errorf("WireGuard over TCP is possible only using V2Ray")
+	}
+	if a.Port < 0 || a.Port > 65535 {
+		return fmt.Errorf("bad port number: %d", a.Port)
+	}
+	return nil
+}
+
+// ConnectionAttemptFromParams returns the connection attempt which corresponds to the connection parameters
+func ConnectionAttemptFromParams(p ConnectionParams) ConnectionAttempt {
+	a := ConnectionAttempt{VpnType: p.VpnType, V2RayProxy: p.V2Ray()}
+	a.Port, a.IsTCP = p.Port()
+	if p.VpnType == vpn.OpenVPN && a.V2RayProxy == v2r.None {
+		a.Obfsproxy = p.OpenVpnParameters.Obfs4proxy
+	}
+	return a
+}
+
+func (a ConnectionAttempt) String() string {
+	protocol := "UDP"
+	if a.IsTCP {
+		protocol = "TCP"
+	}
+
+	ret := fmt.Sprintf("%s %s", a.VpnType, protocol)
+	if a.Port > 0 {
+		ret += fmt.Sprintf(" %d", a.Port)
+	}
+	if a.V2RayProxy != v2r.None {
+		ret += fmt.Sprintf(" (V2Ray %s)", a.V2RayProxy.ToString())
+	} else if a.VpnType == vpn.OpenVPN && a.Obfsproxy.IsObfsproxy() {
+		ret += fmt.Sprintf(" (%s)", a.Obfsproxy.ToString())
+	}
+	return ret
+}
+
+// Apply returns the connection parameters converted according to the attempt.
+// The 'servers' list is in use to find the hosts of the same location for another VPN type and the default ports.
+func (a ConnectionAttempt) Apply(p ConnectionParams, servers *api_types.ServerListResponse) (ConnectionParams, error) {
+	if p.IsMultiHop() {
+		return p, fmt.Errorf("not applicable for Multi-Hop connections")
+	}
+	if a.VpnType != vpn.WireGuard && a.VpnType != vpn.OpenVPN {
+		return p, fmt.Errorf("unknown VPN type: %d", a.VpnType)
+	}
+	if a.VpnType == vpn.WireGuard && a.IsTCP && a.V2RayProxy == v2r.None {
+		return p, fmt.Errorf("WireGuard over TCP is possible only using V2Ray")
+	}
+
+	ret := p
+	if a.VpnType != p.VpnType {
+		hosts := sameLocationHosts(p.EntryHosts(), a.VpnType, servers)
+		if len(hosts) == 0 {
+			return p, fmt.Errorf("no %s servers in the location", a.VpnType)
+		}
+		ret.VpnType = a.VpnType
+		ret.SetEntryHosts(hosts)
+	}
+
+	port := a.Port
+	if port <= 0 {
+		if curPort, curIsTCP := p.Port(); p.VpnType == a.VpnType && curIsTCP == a.IsTCP && curPort > 0 {
+			port = curPort
+		} else if port = defaultPort(a.VpnType, a.IsTCP, servers); port <= 0 {
+			return p, fmt.Errorf("default port is not defined")
+		}
+	}
+
+	protocol := 0 // UDP
+	if a.IsTCP {
+		protocol = 1
+	}
+
+	if a.VpnType == vpn.WireGuard {
+		ret.WireGuardParameters.Port.Port = port
+		ret.WireGuardParameters.Port.Protocol = protocol
+		ret.WireGuardParameters.V2RayProxy = a.V2RayProxy
+	} else {
+		ret.OpenVpnParameters.Port.Port = port
+		ret.OpenVpnParameters.Port.Protocol = protocol
+		ret.OpenVpnParameters.V2RayProxy = a.V2RayProxy
+		ret.OpenVpnParameters.Obfs4proxy = a.Obfsproxy
+	}
+	return ret, nil
+}
+
+// sameLocationHosts returns the hosts (from the servers list for required VPN type) which correspond to the 'hosts'
+func sameLocationHosts(hosts []api_types.ServerListItem, vpnType vpn.Type, servers *api_types.ServerListResponse) []api_types.ServerListItem {
+	var locations []api_types.ServerListCountryItem
+	if servers != nil {
+		if vpnType == vpn.WireGuard {
+			locations = servers.ServerList.WireGuardServers
+		} else {
+			locations = servers.ServerList.OpenVPNServers
+		}
+	}
+
+	isApplicable := func(h api_types.ServerListItem) bool {
+		if vpnType == vpn.WireGuard {
+			return len(h.WireGuard) > 0
+		}
+		return len(h.OpenVPN) > 0
+	}
+
+	find := func(host api_types.ServerListItem) (api_types.ServerListItem, bool) {
+		for _, l := range locations {
+			for _, h := range l.Hosts {
+				if (len(host.Ip) > 0 && h.Ip == host.Ip) || (len(host.Name) > 0 && h.Name == host.Name) {
+					return h, true
+				}
+			}
+		}
+		// the host from the list of another VPN type can contain the configuration for the required VPN type
+		return host, isApplicable(host)
+	}
+
+	var ret []api_types.ServerListItem
+	for _, host := range hosts {
+		if h, ok := find(host); ok {
+			ret = append(ret, h)
+		}
+	}
+	return ret
+}
+
+// defaultPort returns the first port from the servers configuration for the VPN type and protocol (0 - if not defined)
+func defaultPort(vpnType vpn.Type, isTCP bool, servers *api_types.ServerListResponse) int {
+	if servers == nil {
+		return 0
+	}
+	if vpnType == vpn.WireGuard {
+		if len(servers.WireGuard) > 0 {
+			return servers.WireGuard[0]
+		}
+		return 0
+	}
+
+	protocol := "udp"
+	if isTCP {
+		protocol = "tcp"
+	}
+	for _, p := range servers.OpenVPN.Ports {
+		if strings.EqualFold(p.Protocol, protocol) {
+			return p.Port
+		}
+	}
+	return 0
+}
@@ -0,0 +1,155 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package types_test
+
+import (
+	"testing"
+
+	api_types "github.com/tahirmahm123/vpn-desktop-app/daemon/api/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/types"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
+)
+
+func TestCheckMultiHopJurisdictions(t *testing.T) {
+	de := api_types.ServerListItem{Name: "de1", CountryCode: "DE", MultihopPort: 20001}
+	de2 := api_types.ServerListItem{Name: "de2", CountryCode: "de", MultihopPort: 20002}
+	nl := api_types.ServerListItem{Name: "nl1", CountryCode: "NL", MultihopPort: 20003}
+	unknown := api_types.ServerListItem{Name: "xx1", MultihopPort: 20004}
+
+	tests := []struct {
+		entry   api_types.ServerListItem
+		exit    []api_types.ServerListItem
+		isError bool
+	}{
+		{entry: de, exit: nil, isError: false}, // single-hop
+		{entry: de, exit: []api_types.ServerListItem{nl}, isError: false},
+		{entry: de, exit: []api_types.ServerListItem{de2}, isError: true},
+		{entry: de, exit: []api_types.ServerListItem{nl, de2}, isError: true},
+		{entry: de, exit: []api_types.ServerListItem{unknown}, isError: true},
+	}
+
+	for _, vpnType := range []vpn.Type{vpn.WireGuard, vpn.OpenVPN} {
+		for i, tc := range tests {
+			p := types.ConnectionParams{VpnType: vpnType}
+			if vpnType == vpn.WireGuard {
+				p.WireGuardParameters.EntryVpnServer.Hosts = []api_types.ServerListItem{tc.entry}
+			} else {
+				p.OpenVpnParameters.EntryVpnServer.Hosts = []api_types.ServerListItem{tc.entry}
+			}
+			p.SetExitHosts(tc.exit)
+
+			if p.IsMultiHop() != (len(tc.exit) > 0) {
+				t.Errorf("[%v:%d] unexpected IsMultiHop() result", vpnType, i)
+			}
+			if err := p.CheckMultiHopJurisdictions(); (err != nil) != tc.isError {
+				t.Errorf("[%v:%d] unexpected result: %v", vpnType, i, err)
+			}
+		}
+	}
+}
+
+func TestNormalizeHostsMultiHop(t *testing.T) {
+	p := types.ConnectionParams{VpnType: vpn.WireGuard}
+	p.WireGuardParameters.EntryVpnServer.Hosts = []api_types.ServerListItem{{Name: "de1"}, {Name: "de2"}}
+	p.SetExitHosts([]api_types.ServerListItem{
+		{Name: "nl1"},                      // no multihop port
+		{Name: "nl2", MultihopPort: 20002}, // no WireGuard configuration
+		{Name: "nl3", MultihopPort: 20003, WireGuard: []api_types.WireGuardInstance{{PublicKey: "key"}}},
+	})
+
+	if err := p.NormalizeHosts(); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.WireGuardParameters.EntryVpnServer.Hosts) != 1 {
+		t.Errorf("expected one entry host")
+	}
+	if exit := p.ExitHosts(); len(exit) != 1 || exit[0].Name != "nl3" {
+		t.Errorf("unexpected exit hosts: %v", exit)
+	}
+
+	p.SetExitHosts([]api_types.ServerListItem{{Name: "nl1"}})
+	if err := p.NormalizeHosts(); err == nil {
+		t.Errorf("expected error for exit hosts without multihop port")
+	}
+}
+
+func TestConnectionAttemptApply(t *testing.T) {
+	wgHost := api_types.ServerListItem{Name: "de1", Ip: "10.0.0.1", WireGuard: []api_types.WireGuardInstance{{PublicKey: "key"}}}
+	ovpnHost := api_types.ServerListItem{Name: "de1", Ip: "10.0.0.1", OpenVPN: []api_types.OpenVPNInstance{{Protocol: "tcp", Port: 443}}}
+
+	servers := &api_types.ServerListResponse{WireGuard: []int{2049}}
+	servers.ServerList.OpenVPNServers = []api_types.ServerListCountryItem{{Country: "Germany", Hosts: []api_types.ServerListItem{ovpnHost}}}
+	servers.OpenVPN.Ports = append(servers.OpenVPN.Ports, struct {
+		Protocol string `json:"protocol"`
+		Port     int    `json:"port"`
+	}{Protocol: "tcp", Port: 1443})
+
+	p := types.ConnectionParams{VpnType: vpn.WireGuard}
+	p.WireGuardParameters.EntryVpnServer.Hosts = []api_types.ServerListItem{wgHost}
+	p.WireGuardParameters.Port.Port = 53
+
+	// same VPN type: the requested port is kept
+	ret, err := types.ConnectionAttempt{VpnType: vpn.WireGuard}.Apply(p, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port, isTCP := ret.Port(); port != 53 || isTCP {
+		t.Errorf("unexpected WireGuard port: %d (TCP:%v)", port, isTCP)
+	}
+
+	// another VPN type: the host of the same location and the port from the attempt
+	ret, err = types.ConnectionAttempt{VpnType: vpn.OpenVPN, IsTCP: true, Port: 443}.Apply(p, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.VpnType != vpn.OpenVPN || len(ret.EntryHosts()) != 1 || len(ret.EntryHosts()[0].OpenVPN) == 0 {
+		t.Errorf("unexpected OpenVPN parameters: %v", ret.EntryHosts())
+	}
+	if port, isTCP := ret.Port(); port != 443 || !isTCP {
+		t.Errorf("unexpected OpenVPN port: %d (TCP:%v)", port, isTCP)
+	}
+	if a := types.ConnectionAttemptFromParams(ret); a.String() != "OpenVPN TCP 443" {
+		t.Errorf("unexpected attempt description: %s", a)
+	}
+
+	// default port from the servers configuration
+	ret, err = types.ConnectionAttempt{VpnType: vpn.OpenVPN, IsTCP: true}.Apply(p, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port, _ := ret.Port(); port != 1443 {
+		t.Errorf("unexpected default OpenVPN port: %d", port)
+	}
+
+	// not applicable attempts
+	if _, err := (types.ConnectionAttempt{VpnType: vpn.WireGuard, IsTCP: true}).Apply(p, servers); err == nil {
+		t.Errorf("expected error for WireGuard over TCP without V2Ray")
+	}
+	if _, err := (types.ConnectionAttempt{VpnType: vpn.OpenVPN}).Apply(p, servers); err == nil {
+		t.Errorf("expected error for undefined default UDP port")
+	}
+	p.SetExitHosts([]api_types.ServerListItem{{Name: "nl1", MultihopPort: 20001}})
+	if _, err := (types.ConnectionAttempt{VpnType: vpn.WireGuard}).Apply(p, servers); err == nil {
+		t.Errorf("expected error for Multi-Hop connection")
+	}
+}
@@ -50,7 +50,7 @@ func init() {
 
 // IWgKeysChangeReceiver WG key update handler
 type IWgKeysChangeReceiver interface {
-	WireGuardSaveNewKeys(wgPublicKey string, wgPrivateKey string, wgLocalIP string) //, wgPreSharedKey string)
+	WireGuardSaveNewKeys(wgPublicKey string, wgPrivateKey string, wgLocalIP string, wgPresharedKey string)
 	WireGuardGetKeys() (session, wgPublicKey, wgPrivateKey, wgLocalIP string, generatedTime time.Time, updateInterval time.Duration)
 	FirewallEnabled() (bool, error)
 	Connected() bool
@@ -244,11 +244,59 @@ func (m *KeysManager) generateKeys(onlyUpdateIfNecessary bool) (retErr error) {
 		activePublicKey = ""
 	}
 
+	pub, priv, localIP, wgPresharedKey, err := m.requestKeys(session)
+	if err != nil {
+		if len(activePublicKey) == 0 {
+			// IMPORTANT! As soon as server receive request with empty 'activePublicKey' - it clears all keys
+			// Therefore, we have to ensure that local keys are not using anymore (we have to clear them independently from we received response or not)
+			m.service.WireGuardSaveNewKeys("", "", "", "")
+		}
+		log.Info("WG keys not updated: ", err)
+
+		var e types.APIError
+		if errors.As(err, &e) {
+			if e.ErrorCode == types.SessionNotFound {
+				m.service.OnSessionNotFound()
+				return fmt.Errorf("WG keys not updated (session not found)")
+			}
+		}
+		return fmt.Errorf("WG keys not updated. Please check your internet connection")
+	}
+
+	// notify service about new keys
+	m.service.WireGuardSaveNewKeys(pub, priv, localIP.String(), wgPresharedKey)
+
+	log.Info(fmt.Sprintf("WG keys updated (%s:%s; psk:%v) ", localIP.String(), pub, len(wgPresharedKey) > 0))
+
+	// Keys updated. Start keys rotation only if it not started yet or keys rotation interval changed
+	if m.activeRotationInterval != interval || m.stop == nil {
+		go m.StartKeysRotation() // run in routine to avoid deadlock
+	}
+
+	return nil
+}
+
+// RequestNewKeys generates new WG keys and registers them on the backend for the new session.
+// Returns the keys, local IP and the PresharedKey (empty if KEM is not available).
+// Note: the keys are not saved; it is up to the caller to save them.
+func (m *KeysManager) RequestNewKeys(session string) (pub, priv string, localIP net.IP, wgPresharedKey string, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.requestKeys(session)
+}
+
+// requestKeys generates new WG keys and sends the public key to the backend.
+// The public keys for Key Encapsulation Mechanism (post-quantum cryptographic algorithms) are sent together with the WG key.
+// The backend responds with KEM ciphers which are in use to calculate the WG PresharedKey.
+// If the KEM ciphers can not be decoded - the keys are regenerated without PresharedKey.
+func (m *KeysManager) requestKeys(session string) (pub, priv string, localIP net.IP, wgPresharedKey string, err error) {
 	// Generate keys for Key Encapsulation Mechanism using post-quantum cryptographic algorithms
 	var kemKeys types.KemPublicKeys
 	kemHelper, err := createKemHelper()
 	if err != nil {
 		log.Error("Failed to generate KEM keys: ", err)
+		kemHelper = nil
 	} else {
 		kemKeys.KemPublicKey_Kyber1024, err = kemHelper.GetPublicKey(kem.AlgName_Kyber1024)
 		if err != nil {
@@ -260,78 +308,45 @@ func (m *KeysManager) generateKeys(onlyUpdateIfNecessary bool) (retErr error) {
 		}
 	}
 
-	var (
-		pub  string
-		priv string
-
-		wgPresharedKey string
-		localIP        net.IP
-		resp           *types.WGKeysUpdateResponse
-	)
 	for {
+		wgPresharedKey = ""
+
 		pub, priv, err = wireguard.GenerateKeys(m.wgToolBinPath)
 		if err != nil {
-			return err
+			return "", "", nil, "", err
 		}
 
-		// trying to update WG keys with notifying API about current active public key (if it exists)
-		resp, _, err = m.api.WireGuardKeySet(session, pub) //, activePublicKey, kemKeys)
-		if err == nil {
-			localIP = net.ParseIP(strings.Split("/", resp.LocalIP)[0])
-			if localIP == nil {
-				err = fmt.Errorf("failed to set WG key (failed to parse local IP in API response)")
-			}
+		resp, _, err := m.api.WireGuardKeySet(session, pub, kemKeys)
+		if err != nil {
+			return "", "", nil, "", err
 		}
 
-		if err != nil {
-			if len(activePublicKey) == 0 {
-				// IMPORTANT! As soon as server receive request with empty 'activePublicKey' - it clears all keys
-				// Therefore, we have to ensure that local keys are not using anymore (we have to clear them independently from we received response or not)
-				m.service.WireGuardSaveNewKeys("", "", "")
-			}
-			log.Info("WG keys not updated: ", err)
+		localIP = net.ParseIP(strings.Split(resp.LocalIP, "/")[0])
+		if localIP == nil {
+			return "", "", nil, "", fmt.Errorf("failed to set WG key (failed to parse local IP in API response)")
+		}
+
+		if kemHelper != nil {
+			if len(resp.KemCipher_Kyber1024) == 0 && len(resp.KemCipher_ClassicMcEliece348864) == 0 {
+				log.Warning("The server did not respond with KEM ciphers. The WireGuard PresharedKey has not been initialized!")
+			} else {
+				if err := kemHelper.SetCipher(kem.AlgName_Kyber1024, resp.KemCipher_Kyber1024); err != nil {
+					log.Error(err)
+				}
+				if err := kemHelper.SetCipher(kem.AlgName_ClassicMcEliece348864, resp.KemCipher_ClassicMcEliece348864); err != nil {
+					log.Error(err)
+				}
 
-			var e types.APIError
-			if errors.As(err, &e) {
-				if e.ErrorCode == types.SessionNotFound {
-					m.service.OnSessionNotFound()
-					return fmt.Errorf("WG keys not updated (session not found)")
+				wgPresharedKey, err = kemHelper.CalculatePresharedKey()
+				if err != nil {
+					log.Error(fmt.Sprintf("Failed to decode KEM ciphers! (%s). Generating new keys without PresharedKey...", err))
+					kemHelper = nil
+					kemKeys = types.KemPublicKeys{}
+					continue
 				}
 			}
-			return fmt.Errorf("WG keys not updated. Please check your internet connection")
 		}
 
-		//if kemHelper != nil {
-		//	if len(resp.KemCipher_Kyber1024) == 0 && len(resp.KemCipher_ClassicMcEliece348864) == 0 {
-		//		log.Warning("The server did not respond with KEM ciphers. The WireGuard PresharedKey has not been initialized!")
-		//	} else {
-		//		if err := kemHelper.SetCipher(kem.AlgName_Kyber1024, resp.KemCipher_Kyber1024); err != nil {
-		//			log.Error(err)
-		//		}
-		//		if err := kemHelper.SetCipher(kem.AlgName_ClassicMcEliece348864, resp.KemCipher_ClassicMcEliece348864); err != nil {
-		//			log.Error(err)
-		//		}
-		//
-		//		wgPresharedKey, err = kemHelper.CalculatePresharedKey()
-		//		if err != nil {
-		//			log.Error(fmt.Sprintf("Failed to decode KEM ciphers! (%s). Generating new keys without PresharedKey...", err))
-		//			kemHelper = nil
-		//			kemKeys = types.KemPublicKeys{}
-		//			continue
-		//		}
-		//	}
-		//}
-		break
+		return pub, priv, localIP, wgPresharedKey, nil
 	}
-	// notify service about new keys
-	m.service.WireGuardSaveNewKeys(pub, priv, localIP.String()) //, wgPresharedKey)
-
-	log.Info(fmt.Sprintf("WG keys updated (%s:%s; psk:%v) ", localIP.String(), pub, len(wgPresharedKey) > 0))
-
-	// Keys updated. Start keys rotation only if it not started yet or keys rotation interval changed
-	if m.activeRotationInterval != interval || m.stop == nil {
-		go m.StartKeysRotation() // run in routine to avoid deadlock
-	}
-
-	return nil
 }
@@ -0,0 +1,125 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package splittun
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Application rules (applicable for Linux).
+// Processes are moved into the Split Tunnel environment automatically when they are started, if their binary matches the rule.
+// The rule is:
+//   - full path to the binary (e.g. '/usr/bin/firefox'); symlinks are resolved ('/usr/lib/firefox/firefox').
+//   - name of the binary (e.g. 'firefox'); the name is compared to the name of the executable file.
+//
+// Only the actual executable file of the process is in use (the command line can be set to any value by the process itself).
+// Note: for the scripts the executable file is the interpreter (e.g. '/usr/bin/python3').
+
+// IsAppRuleName returns 'true' when the rule is the name of the binary (not the path)
+func IsAppRuleName(rule string) bool {
+	return !strings.Contains(rule, "/")
+}
+
+// ValidateAppRules returns normalized rules (trimmed, duplicates removed)
+// or error if any rule is not valid or the functionality is not applicable
+func ValidateAppRules(rules []string) ([]string, error) {
+	ret := make([]string, 0, len(rules))
+	if len(rules) == 0 {
+		return ret, nil
+	}
+
+	if err := implAppRulesNotAvailableError(); err != nil {
+		return nil, err
+	}
+
+	ownBinary, _ := os.Executable()
+	exists := make(map[string]struct{}, len(rules))
+	for _, r := range rules {
+		r = strings.TrimSpace(r)
+		if len(r) == 0 {
+			continue
+		}
+		if !IsAppRuleName(r) {
+			if !filepath.IsAbs(r) {
+				return nil, fmt.Errorf("bad application rule '%s': expected full path to the binary or the binary name", r)
+			}
+			r = filepath.Clean(r)
+		}
+		if len(ownBinary) > 0 && (r == ownBinary || r == filepath.Base(ownBinary)) {
+			return nil, fmt.Errorf("Split-Tunnelling for IVPN binaries is forbidden (%s)", r)
+		}
+		if _, ok := exists[r]; ok {
+			continue
+		}
+		exists[r] = struct{}{}
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+// appMatcher checks if the process binary matches any of application rules
+type appMatcher struct {
+	paths map[string]string // map[<binary path>]<rule>
+	names map[string]string // map[<binary name>]<rule>
+}
+
+// newAppMatcher creates the matcher for the rules.
+// resolvePath - returns the real path of the binary (symlinks evaluated) or an empty string
+func newAppMatcher(rules []string, resolvePath func(string) string) *appMatcher {
+	m := &appMatcher{paths: map[string]string{}, names: map[string]string{}}
+	for _, r := range rules {
+		if IsAppRuleName(r) {
+			m.names[r] = r
+			continue
+		}
+		m.paths[r] = r
+		if resolvePath != nil {
+			if real := resolvePath(r); len(real) > 0 {
+				m.paths[real] = r
+			}
+		}
+	}
+	return m
+}
+
+func (m *appMatcher) isEmpty() bool {
+	return m == nil || (len(m.paths) == 0 && len(m.names) == 0)
+}
+
+// match returns the rule which matches the process
+// exe - the actual pathname of the executed command
+func (m *appMatcher) match(exe string) (rule string, ok bool) {
+	if m.isEmpty() || len(exe) == 0 {
+		return "", false
+	}
+	if rule, ok = m.paths[exe]; ok {
+		return rule, true
+	}
+	if rule, ok = m.names[filepath.Base(exe)]; ok {
+		return rule, true
+	}
+	return "", false
+}
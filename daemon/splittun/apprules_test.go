@@ -0,0 +1,63 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package splittun
+
+import (
+	"testing"
+)
+
+func TestAppMatcher(t *testing.T) {
+	resolve := func(path string) string {
+		if path == "/usr/bin/firefox" {
+			return "/usr/lib/firefox/firefox"
+		}
+		return ""
+	}
+	m := newAppMatcher([]string{"/usr/bin/firefox", "telegram-desktop", "/opt/app/run"}, resolve)
+
+	tests := []struct {
+		exe  string
+		rule string
+	}{
+		{"/usr/bin/firefox", "/usr/bin/firefox"},
+		{"/usr/lib/firefox/firefox", "/usr/bin/firefox"},
+		{"/usr/bin/telegram-desktop", "telegram-desktop"},
+		{"/opt/telegram/telegram-desktop", "telegram-desktop"},
+		{"/opt/app/run", "/opt/app/run"},
+		{"/usr/bin/python3", ""}, // script '/opt/app/run' started by the interpreter
+		{"/usr/bin/chromium", ""},
+		{"/opt/app/run2", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		rule, ok := m.match(tt.exe)
+		if ok != (len(tt.rule) > 0) || rule != tt.rule {
+			t.Errorf("match(%q) = %q, %v; expected %q", tt.exe, rule, ok, tt.rule)
+		}
+	}
+
+	if _, ok := newAppMatcher(nil, resolve).match("/usr/bin/firefox"); ok {
+		t.Error("empty matcher must not match any process")
+	}
+}
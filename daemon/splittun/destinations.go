@@ -0,0 +1,309 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package splittun
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DestinationAction - what to do with the traffic to the destination
+type DestinationAction string
+
+const (
+	DestinationBypassVpn DestinationAction = "bypass" // the traffic bypasses the VPN (uses the default connection)
+	DestinationViaVpn    DestinationAction = "vpn"    // the traffic is forced through the VPN (even for apps in Split Tunnel environment)
+)
+
+// Interval of resolving domain names of the destination rules
+const destinationsResolveInterval = time.Minute * 2
+
+// Max number of addresses learned for the wildcard domain (the oldest addresses are removed)
+const destinationsWildcardMaxAddresses = 256
+
+// DestinationRule - Split Tunnel rule for the destination address
+type DestinationRule struct {
+	// IP address, subnet ('10.0.0.0/8'), domain name ('host.example.com') or wildcard domain ('*.example.com').
+	// Domain names are resolved continuously.
+	// DNS does not allow enumerating the subdomains, so the addresses of the wildcard domain (any subdomain of 'example.com')
+	// are learned from DNS responses observed on the host (see 'learnDestinations()').
+	Destination string
+	Action      DestinationAction
+}
+
+// DestinationStatus - the state of the destination rule
+type DestinationStatus struct {
+	DestinationRule
+	Addresses []string // resolved addresses (applicable for domain names)
+	Error     string   `json:",omitempty"`
+}
+
+// IsDomain returns 'true' when the destination is a domain name
+func (r DestinationRule) IsDomain() bool {
+	_, err := r.network()
+	return err != nil
+}
+
+// IsWildcard returns 'true' when the destination is a wildcard domain ('*.example.com')
+func (r DestinationRule) IsWildcard() bool {
+	return r.IsDomain() && strings.HasPrefix(r.lookupName(), "*.")
+}
+
+// matchesName returns 'true' when the domain name matches the destination
+// (the wildcard domain '*.example.com' matches any subdomain of 'example.com', but not 'example.com' itself)
+func (r DestinationRule) matchesName(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if r.IsWildcard() {
+		return strings.HasSuffix(name, r.lookupName()[1:])
+	}
+	return r.IsDomain() && name == r.lookupName()
+}
+
+// Validate returns error if the rule is not valid
+func (r DestinationRule) Validate() error {
+	if r.Action != DestinationBypassVpn && r.Action != DestinationViaVpn {
+		return fmt.Errorf("unknown action '%s' for destination '%s'", r.Action, r.Destination)
+	}
+	if _, err := r.network(); err == nil {
+		return nil
+	}
+
+	name := strings.TrimPrefix(r.lookupName(), "*.")
+	if strings.Contains(name, "*") {
+		return fmt.Errorf("bad destination '%s': wildcard is allowed only as the first label ('*.example.com')", r.Destination)
+	}
+	if r.IsWildcard() && !strings.Contains(name, ".") {
+		return fmt.Errorf("bad destination '%s': wildcard for the top-level domain is not allowed", r.Destination)
+	}
+	if len(name) == 0 || len(name) > 253 {
+		return fmt.Errorf("bad destination '%s': expected IP address, subnet or domain name", r.Destination)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) == 0 || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("bad domain name '%s'", r.Destination)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return fmt.Errorf("bad domain name '%s'", r.Destination)
+			}
+		}
+	}
+	return nil
+}
+
+// network returns the subnet of the IP (or subnet) destination
+func (r DestinationRule) network() (net.IPNet, error) {
+	dst := strings.TrimSpace(r.Destination)
+	if ip := net.ParseIP(dst); ip != nil {
+		return ipToNet(ip), nil
+	}
+	_, n, err := net.ParseCIDR(dst)
+	if err != nil {
+		return net.IPNet{}, err
+	}
+	return *n, nil
+}
+
+// lookupName returns the domain name to resolve
+func (r DestinationRule) lookupName() string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(r.Destination), "."))
+}
+
+// ValidateDestinations returns error if any rule is not valid (or defined more than once)
+func ValidateDestinations(rules []DestinationRule) error {
+	if len(rules) > 0 {
+		if err := implDestinationsNotAvailableError(); err != nil {
+			return err
+		}
+	}
+
+	known := make(map[string]struct{}, len(rules))
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		key := strings.ToLower(strings.TrimSpace(r.Destination))
+		if _, ok := known[key]; ok {
+			return fmt.Errorf("destination '%s' is defined more than once", r.Destination)
+		}
+		known[key] = struct{}{}
+	}
+	return nil
+}
+
+// GetDestinationsStatus returns the state of the destination rules which are currently applied
+func GetDestinationsStatus() []DestinationStatus {
+	return implGetDestinationsStatus()
+}
+
+// destinationEntry - the subnet to apply the action to
+type destinationEntry struct {
+	Net    net.IPNet
+	Action DestinationAction
+}
+
+// String returns the entry in the format expected by the split-tunnel script: '<action>:<subnet>'
+func (e destinationEntry) String() string {
+	return string(e.Action) + ":" + e.Net.String()
+}
+
+// destinationEntries converts the rules (and resolved addresses of domain names) to the list of subnets.
+// The entries are sorted by the prefix length (the most specific first),
+// so the rule for the particular host has priority over the rule for the whole subnet.
+func destinationEntries(rules []DestinationRule, resolved map[string][]net.IP) []destinationEntry {
+	ret := make([]destinationEntry, 0, len(rules))
+	known := make(map[string]struct{}, len(rules))
+	add := func(n net.IPNet, action DestinationAction) {
+		if _, ok := known[n.String()]; ok {
+			return
+		}
+		known[n.String()] = struct{}{}
+		ret = append(ret, destinationEntry{Net: n, Action: action})
+	}
+
+	// rules for IP addresses have priority over the addresses of domain names
+	for _, r := range rules {
+		if n, err := r.network(); err == nil {
+			add(n, r.Action)
+		}
+	}
+	for _, r := range rules {
+		if r.IsDomain() {
+			for _, ip := range resolved[r.lookupName()] {
+				add(ipToNet(ip), r.Action)
+			}
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		oi, _ := ret[i].Net.Mask.Size()
+		oj, _ := ret[j].Net.Mask.Size()
+		return oi > oj
+	})
+	return ret
+}
+
+// resolveDestinations resolves the domain names of the rules.
+// When the name can not be resolved, the addresses known before ('prevResolved') are kept
+// (e.g. the DNS server is temporarily not reachable).
+func resolveDestinations(rules []DestinationRule, prevResolved map[string][]net.IP) (resolved map[string][]net.IP, errs map[string]error) {
+	resolved = make(map[string][]net.IP)
+	errs = make(map[string]error)
+	for _, r := range rules {
+		if !r.IsDomain() || r.IsWildcard() {
+			continue // the addresses of the wildcard domains are learned from DNS responses
+		}
+		name := r.lookupName()
+		if _, ok := resolved[name]; ok {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		ips, err := net.DefaultResolver.LookupIP(ctx, "ip", name)
+		cancel()
+		if err != nil {
+			errs[name] = err
+			ips = prevResolved[name]
+		}
+		resolved[name] = ips
+	}
+	return resolved, errs
+}
+
+// learnDestinations adds the addresses from the DNS response to the wildcard domains which match the response names.
+// 'names' - the names of the response (the question and the owners of the answer records, e.g. CNAME chain);
+// 'ips' - the addresses from the answer records.
+// It returns the new map (the 'resolved' map is not modified) and 'true' if any addresses were added.
+func learnDestinations(rules []DestinationRule, resolved map[string][]net.IP, names []string, ips []net.IP) (map[string][]net.IP, bool) {
+	if len(ips) == 0 {
+		return resolved, false
+	}
+
+	var ret map[string][]net.IP
+	for _, r := range rules {
+		if !r.IsWildcard() || !isAnyNameMatches(r, names) {
+			continue
+		}
+
+		key := r.lookupName()
+		known := resolved[key]
+		if ret != nil {
+			known = ret[key]
+		}
+		added := make([]net.IP, 0, len(ips))
+		for _, ip := range ips {
+			if !isIPInList(known, ip) && !isIPInList(added, ip) {
+				added = append(added, ip)
+			}
+		}
+		if len(added) == 0 {
+			continue
+		}
+
+		if ret == nil {
+			ret = make(map[string][]net.IP, len(resolved)+1)
+			for k, v := range resolved {
+				ret[k] = v
+			}
+		}
+		addrs := append(append([]net.IP{}, known...), added...)
+		if len(addrs) > destinationsWildcardMaxAddresses {
+			addrs = addrs[len(addrs)-destinationsWildcardMaxAddresses:]
+		}
+		ret[key] = addrs
+	}
+
+	if ret == nil {
+		return resolved, false
+	}
+	return ret, true
+}
+
+func isAnyNameMatches(r DestinationRule, names []string) bool {
+	for _, n := range names {
+		if r.matchesName(n) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIPInList(list []net.IP, ip net.IP) bool {
+	for _, v := range list {
+		if v.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func ipToNet(ip net.IP) net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
@@ -0,0 +1,156 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package splittun
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestDestinationRuleValidate(t *testing.T) {
+	tests := []struct {
+		rule    DestinationRule
+		isValid bool
+	}{
+		{DestinationRule{"10.0.0.0/8", DestinationBypassVpn}, true},
+		{DestinationRule{"192.168.1.10", DestinationViaVpn}, true},
+		{DestinationRule{"fd00::/8", DestinationBypassVpn}, true},
+		{DestinationRule{"host.example.com", DestinationBypassVpn}, true},
+		{DestinationRule{"*.internal.example.com", DestinationViaVpn}, true},
+		{DestinationRule{"*.com", DestinationViaVpn}, false},
+		{DestinationRule{"*", DestinationViaVpn}, false},
+		{DestinationRule{"*.*.example.com", DestinationViaVpn}, false},
+		{DestinationRule{"Host.Example.COM.", DestinationViaVpn}, true},
+		{DestinationRule{"10.0.0.0/8", "unknown"}, false},
+		{DestinationRule{"", DestinationBypassVpn}, false},
+		{DestinationRule{"10.0.0.0/33", DestinationBypassVpn}, false},
+		{DestinationRule{"internal.*.example.com", DestinationBypassVpn}, false},
+		{DestinationRule{"bad..example.com", DestinationBypassVpn}, false},
+		{DestinationRule{"-bad.example.com", DestinationBypassVpn}, false},
+		{DestinationRule{"bad host.example.com", DestinationBypassVpn}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.rule.Validate()
+		if tt.isValid && err != nil {
+			t.Errorf("'%s': unexpected error: %v", tt.rule.Destination, err)
+		}
+		if !tt.isValid && err == nil {
+			t.Errorf("'%s': expected error", tt.rule.Destination)
+		}
+	}
+}
+
+func TestValidateDestinationsDuplicates(t *testing.T) {
+	rules := []DestinationRule{
+		{"10.0.0.0/8", DestinationBypassVpn},
+		{"10.0.0.0/8", DestinationViaVpn},
+	}
+	if err := ValidateDestinations(rules); err == nil {
+		t.Error("expected error for duplicated destination")
+	}
+}
+
+func TestDestinationEntries(t *testing.T) {
+	rules := []DestinationRule{
+		{"10.0.0.0/8", DestinationBypassVpn},
+		{"internal.example.com", DestinationViaVpn},
+		{"10.1.2.3", DestinationBypassVpn},
+		{"unresolved.example.com", DestinationBypassVpn},
+		{"fd00::/8", DestinationBypassVpn},
+	}
+	resolved := map[string][]net.IP{
+		"internal.example.com": {net.ParseIP("10.2.0.1"), net.ParseIP("10.1.2.3"), net.ParseIP("fd00::1")},
+	}
+
+	var got []string
+	for _, e := range destinationEntries(rules, resolved) {
+		got = append(got, e.String())
+	}
+	want := []string{"vpn:fd00::1/128", "bypass:10.1.2.3/32", "vpn:10.2.0.1/32", "bypass:10.0.0.0/8", "bypass:fd00::/8"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+}
+
+func TestResolveDestinationsKeepsLastKnown(t *testing.T) {
+	rules := []DestinationRule{{"host.invalid", DestinationBypassVpn}, {"10.0.0.0/8", DestinationViaVpn}}
+	prev := map[string][]net.IP{"host.invalid": {net.ParseIP("10.2.0.1")}}
+
+	resolved, errs := resolveDestinations(rules, prev)
+	if errs["host.invalid"] == nil {
+		t.Fatal("expected resolving error")
+	}
+	if !reflect.DeepEqual(resolved["host.invalid"], prev["host.invalid"]) {
+		t.Errorf("the last known addresses are not kept: %v", resolved["host.invalid"])
+	}
+	if len(resolved) != 1 {
+		t.Errorf("unexpected resolved names: %v", resolved)
+	}
+}
+
+func TestLearnDestinations(t *testing.T) {
+	rules := []DestinationRule{
+		{"*.internal.example.com", DestinationBypassVpn},
+		{"internal.example.com", DestinationViaVpn},
+		{"10.0.0.0/8", DestinationViaVpn},
+	}
+	resolved := map[string][]net.IP{"internal.example.com": {net.ParseIP("10.2.0.1")}}
+
+	// CNAME chain: the question name matches the wildcard
+	learned, isChanged := learnDestinations(rules, resolved, []string{"App.Internal.Example.com.", "lb.example.net."}, []net.IP{net.ParseIP("10.3.0.1")})
+	if !isChanged {
+		t.Fatal("expected learned addresses")
+	}
+	if got := learned["*.internal.example.com"]; len(got) != 1 || !got[0].Equal(net.ParseIP("10.3.0.1")) {
+		t.Errorf("unexpected learned addresses: %v", got)
+	}
+	if len(resolved) != 1 {
+		t.Error("the original map must not be modified")
+	}
+
+	if _, isChanged := learnDestinations(rules, learned, []string{"db.internal.example.com."}, []net.IP{net.ParseIP("10.3.0.1")}); isChanged {
+		t.Error("known address must not be learned again")
+	}
+	if _, isChanged := learnDestinations(rules, learned, []string{"internal.example.com.", "otherinternal.example.com."}, []net.IP{net.ParseIP("10.4.0.1")}); isChanged {
+		t.Error("the name does not match the wildcard")
+	}
+
+	var got []string
+	for _, e := range destinationEntries(rules, learned) {
+		got = append(got, e.String())
+	}
+	want := []string{"bypass:10.3.0.1/32", "vpn:10.2.0.1/32", "vpn:10.0.0.0/8"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+
+	// the number of addresses is limited (the oldest addresses are removed)
+	for i := 0; i < destinationsWildcardMaxAddresses; i++ {
+		learned, _ = learnDestinations(rules, learned, []string{"x.internal.example.com"}, []net.IP{net.IPv4(10, 5, byte(i>>8), byte(i))})
+	}
+	if got := learned["*.internal.example.com"]; len(got) != destinationsWildcardMaxAddresses || isIPInList(got, net.ParseIP("10.3.0.1")) {
+		t.Errorf("unexpected number of learned addresses: %d", len(got))
+	}
+}
@@ -0,0 +1,190 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build linux
+// +build linux
+
+package splittun
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/bpf"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/sys/unix"
+)
+
+// The DNS responses snooper: the addresses of wildcard destination domains are learned from DNS responses observed on the host.
+// The packet socket receives UDP packets from port 53 on all interfaces (incl. 'lo': responses of the local DNS resolver).
+// Note: DNS over TCP, DoH and DoT responses are not visible (unless the local resolver forwards them as plain DNS)
+type dnsSnooper struct {
+	fd  int
+	buf []byte
+}
+
+// dnsSnooperFilter - BPF program which accepts only UDP packets with source port 53
+// (the socket is of type SOCK_DGRAM: the packets begin with the IP header)
+var dnsSnooperFilter = []bpf.Instruction{
+	/* 0 */ bpf.LoadExtension{Num: bpf.ExtProto},
+	/* 1 */ bpf.JumpIf{Cond: bpf.JumpEqual, Val: unix.ETH_P_IPV6, SkipTrue: 8},
+	/* 2 */ bpf.JumpIf{Cond: bpf.JumpEqual, Val: unix.ETH_P_IP, SkipFalse: 12},
+	// IPv4
+	/* 3 */ bpf.LoadAbsolute{Off: 9, Size: 1}, // protocol
+	/* 4 */ bpf.JumpIf{Cond: bpf.JumpEqual, Val: unix.IPPROTO_UDP, SkipFalse: 10},
+	/* 5 */ bpf.LoadAbsolute{Off: 6, Size: 2}, // flags and fragment offset
+	/* 6 */ bpf.JumpIf{Cond: bpf.JumpBitsSet, Val: 0x1fff, SkipTrue: 8}, // not the first fragment
+	/* 7 */ bpf.LoadMemShift{Off: 0}, // X = IP header length
+	/* 8 */ bpf.LoadIndirect{Off: 0, Size: 2}, // UDP source port
+	/* 9 */ bpf.Jump{Skip: 3},
+	// IPv6 (extension headers are not supported)
+	/* 10 */ bpf.LoadAbsolute{Off: 6, Size: 1}, // next header
+	/* 11 */ bpf.JumpIf{Cond: bpf.JumpEqual, Val: unix.IPPROTO_UDP, SkipFalse: 3},
+	/* 12 */ bpf.LoadAbsolute{Off: 40, Size: 2}, // UDP source port
+	// UDP source port
+	/* 13 */ bpf.JumpIf{Cond: bpf.JumpEqual, Val: 53, SkipFalse: 1},
+	/* 14 */ bpf.RetConstant{Val: 0xffff},
+	/* 15 */ bpf.RetConstant{Val: 0},
+}
+
+// newDnsSnooper starts receiving DNS responses.
+// Note: root privileges required
+func newDnsSnooper() (*dnsSnooper, error) {
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, int(htons(unix.ETH_P_ALL)))
+	if err != nil {
+		return nil, fmt.Errorf("DNS snooper socket initialization error: %w", err)
+	}
+	s := &dnsSnooper{fd: fd, buf: make([]byte, 0xffff)}
+
+	prog, err := bpf.Assemble(dnsSnooperFilter)
+	if err != nil {
+		s.close()
+		return nil, fmt.Errorf("DNS snooper filter error: %w", err)
+	}
+	filter := make([]unix.SockFilter, 0, len(prog))
+	for _, i := range prog {
+		filter = append(filter, unix.SockFilter{Code: i.Op, Jt: i.Jt, Jf: i.Jf, K: i.K})
+	}
+	if err := unix.SetsockoptSockFprog(fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}); err != nil {
+		s.close()
+		return nil, fmt.Errorf("DNS snooper filter error: %w", err)
+	}
+	return s, nil
+}
+
+func (s *dnsSnooper) close() error {
+	return unix.Close(s.fd)
+}
+
+// setReadTimeout sets the timeout for readResponse() (0 - no timeout)
+func (s *dnsSnooper) setReadTimeout(timeout time.Duration) error {
+	tv := unix.NsecToTimeval(timeout.Nanoseconds())
+	return unix.SetsockoptTimeval(s.fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv)
+}
+
+// readResponse blocks until the DNS response received and returns its names and addresses
+// (ok=false - the packet is not a DNS response or it contains no addresses)
+func (s *dnsSnooper) readResponse() (names []string, ips []net.IP, ok bool, err error) {
+	n, from, err := unix.Recvfrom(s.fd, s.buf, 0)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if ll, isLL := from.(*unix.SockaddrLinklayer); isLL && ll.Pkttype == unix.PACKET_OUTGOING {
+		return nil, nil, false, nil // the packets on 'lo' are received twice: outgoing and incoming
+	}
+	names, ips, ok = parseDnsResponsePacket(s.buf[:n])
+	return names, ips, ok, nil
+}
+
+// parseDnsResponsePacket parses IP packet with DNS response (UDP, source port 53).
+// It returns the names of the response (question and the owners of answer records, e.g. CNAME chain)
+// and the addresses from the answer records.
+func parseDnsResponsePacket(pkt []byte) (names []string, ips []net.IP, ok bool) {
+	var udp []byte
+	switch {
+	case len(pkt) >= 20 && pkt[0]>>4 == 4:
+		ihl := int(pkt[0]&0x0f) * 4
+		if pkt[9] != unix.IPPROTO_UDP || ihl < 20 || len(pkt) < ihl {
+			return nil, nil, false
+		}
+		udp = pkt[ihl:]
+	case len(pkt) >= 40 && pkt[0]>>4 == 6:
+		if pkt[6] != unix.IPPROTO_UDP {
+			return nil, nil, false
+		}
+		udp = pkt[40:]
+	default:
+		return nil, nil, false
+	}
+	if len(udp) < 8 || binary.BigEndian.Uint16(udp[0:]) != 53 {
+		return nil, nil, false
+	}
+
+	var p dnsmessage.Parser
+	hdr, err := p.Start(udp[8:])
+	if err != nil || !hdr.Response || hdr.RCode != dnsmessage.RCodeSuccess {
+		return nil, nil, false
+	}
+	questions, err := p.AllQuestions()
+	if err != nil {
+		return nil, nil, false
+	}
+	for _, q := range questions {
+		names = append(names, q.Name.String())
+	}
+
+	for {
+		h, err := p.AnswerHeader()
+		if err != nil {
+			break // dnsmessage.ErrSectionDone or bad message (the answers parsed so far are in use)
+		}
+		names = append(names, h.Name.String())
+		switch h.Type {
+		case dnsmessage.TypeA:
+			r, err := p.AResource()
+			if err != nil {
+				return names, ips, len(ips) > 0
+			}
+			ips = append(ips, net.IP(r.A[:]))
+		case dnsmessage.TypeAAAA:
+			r, err := p.AAAAResource()
+			if err != nil {
+				return names, ips, len(ips) > 0
+			}
+			ips = append(ips, net.IP(r.AAAA[:]))
+		default:
+			if err := p.SkipAnswer(); err != nil {
+				return names, ips, len(ips) > 0
+			}
+		}
+	}
+	return names, ips, len(ips) > 0
+}
+
+// htons converts the value to network byte order
+func htons(v uint16) uint16 {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return nativeEndian.Uint16(b)
+}
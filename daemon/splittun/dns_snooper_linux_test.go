@@ -0,0 +1,85 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build linux
+// +build linux
+
+package splittun
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestParseDnsResponsePacket(t *testing.T) {
+	response := func(t *testing.T, rcode dnsmessage.RCode) []byte {
+		b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, RCode: rcode})
+		b.EnableCompression()
+		b.StartQuestions()
+		b.Question(dnsmessage.Question{Name: dnsmessage.MustNewName("app.internal.example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET})
+		b.StartAnswers()
+		b.CNAMEResource(dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("app.internal.example.com."), Class: dnsmessage.ClassINET},
+			dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("lb.example.net.")})
+		b.AResource(dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("lb.example.net."), Class: dnsmessage.ClassINET},
+			dnsmessage.AResource{A: [4]byte{10, 2, 0, 1}})
+		b.AAAAResource(dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("lb.example.net."), Class: dnsmessage.ClassINET},
+			dnsmessage.AAAAResource{AAAA: [16]byte{0xfd, 0, 15: 1}})
+		msg, err := b.Finish()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	ipv4 := func(srcPort uint16, payload []byte) []byte {
+		pkt := make([]byte, 20+8+len(payload))
+		pkt[0] = 0x45
+		pkt[9] = 17 // UDP
+		binary.BigEndian.PutUint16(pkt[20:], srcPort)
+		copy(pkt[28:], payload)
+		return pkt
+	}
+
+	names, ips, ok := parseDnsResponsePacket(ipv4(53, response(t, dnsmessage.RCodeSuccess)))
+	if !ok {
+		t.Fatal("response not parsed")
+	}
+	if want := []string{"app.internal.example.com.", "app.internal.example.com.", "lb.example.net.", "lb.example.net."}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names: got %v; expected %v", names, want)
+	}
+	if len(ips) != 2 || !ips[0].Equal(net.ParseIP("10.2.0.1")) || !ips[1].Equal(net.ParseIP("fd00::1")) {
+		t.Errorf("unexpected addresses: %v", ips)
+	}
+
+	if _, _, ok := parseDnsResponsePacket(ipv4(5353, response(t, dnsmessage.RCodeSuccess))); ok {
+		t.Error("packet from other port must be ignored")
+	}
+	if _, _, ok := parseDnsResponsePacket(ipv4(53, response(t, dnsmessage.RCodeNameError))); ok {
+		t.Error("error response must be ignored")
+	}
+	if _, _, ok := parseDnsResponsePacket(ipv4(53, response(t, dnsmessage.RCodeSuccess))[:30]); ok {
+		t.Error("truncated packet must be ignored")
+	}
+}
@@ -0,0 +1,147 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build linux
+// +build linux
+
+package splittun
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// The process events connector (netlink 'proc connector').
+// The kernel notifies about each process event (fork, exec, exit ...); only 'exec' events are in use.
+// https://github.com/torvalds/linux/blob/master/include/uapi/linux/cn_proc.h
+const (
+	cnIdxProc = 0x1 // CN_IDX_PROC
+	cnValProc = 0x1 // CN_VAL_PROC
+
+	procCnMcastListen = 1 // PROC_CN_MCAST_LISTEN
+	procCnMcastIgnore = 2 // PROC_CN_MCAST_IGNORE
+
+	procEventExec = 0x00000002 // PROC_EVENT_EXEC
+
+	cnMsgSize     = 20 // struct cn_msg: id.idx(4) id.val(4) seq(4) ack(4) len(2) flags(2)
+	procEventSize = 16 // struct proc_event header: what(4) cpu(4) timestamp_ns(8)
+)
+
+// the connector messages are in host byte order
+var nativeEndian binary.ByteOrder = func() binary.ByteOrder {
+	v := uint16(1)
+	if *(*byte)(unsafe.Pointer(&v)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+type procConnector struct {
+	fd int
+}
+
+// newProcConnector subscribes to the process events.
+// Note: root privileges required
+func newProcConnector() (*procConnector, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM, syscall.NETLINK_CONNECTOR)
+	if err != nil {
+		return nil, fmt.Errorf("proc connector socket initialization error: %w", err)
+	}
+
+	c := &procConnector{fd: fd}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: cnIdxProc}); err != nil {
+		c.close()
+		return nil, fmt.Errorf("proc connector socket binding error: %w", err)
+	}
+	if err := c.setMcastOp(procCnMcastListen); err != nil {
+		c.close()
+		return nil, fmt.Errorf("failed to subscribe to process events: %w", err)
+	}
+	return c, nil
+}
+
+func (c *procConnector) close() error {
+	c.setMcastOp(procCnMcastIgnore)
+	return syscall.Close(c.fd)
+}
+
+// setReadTimeout sets the timeout for readExecEvents() (0 - no timeout)
+func (c *procConnector) setReadTimeout(timeout time.Duration) error {
+	tv := syscall.NsecToTimeval(timeout.Nanoseconds())
+	return syscall.SetsockoptTimeval(c.fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+}
+
+func (c *procConnector) setMcastOp(op uint32) error {
+	buf := make([]byte, syscall.NLMSG_HDRLEN+cnMsgSize+4)
+	nativeEndian.PutUint32(buf[0:], uint32(len(buf)))   // nlmsg_len
+	nativeEndian.PutUint16(buf[4:], syscall.NLMSG_DONE) // nlmsg_type
+
+	msg := buf[syscall.NLMSG_HDRLEN:]
+	nativeEndian.PutUint32(msg[0:], cnIdxProc)
+	nativeEndian.PutUint32(msg[4:], cnValProc)
+	nativeEndian.PutUint16(msg[16:], 4) // len (of data)
+	nativeEndian.PutUint32(msg[cnMsgSize:], op)
+
+	return syscall.Sendto(c.fd, buf, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+}
+
+// readExecEvents blocks until process events received and returns PIDs of processes which executed new binary
+func (c *procConnector) readExecEvents() ([]int, error) {
+	buf := make([]byte, 8192)
+	n, _, err := syscall.Recvfrom(c.fd, buf, 0)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, m := range msgs {
+		if pid, ok := parseProcExecEvent(m.Data); ok {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// parseProcExecEvent parses the connector message (struct cn_msg + struct proc_event)
+// and returns the PID (thread group ID) when it is the 'exec' event
+func parseProcExecEvent(data []byte) (pid int, ok bool) {
+	if len(data) < cnMsgSize+procEventSize+8 {
+		return 0, false
+	}
+	if nativeEndian.Uint32(data[0:]) != cnIdxProc || nativeEndian.Uint32(data[4:]) != cnValProc {
+		return 0, false
+	}
+	event := data[cnMsgSize:]
+	if nativeEndian.Uint32(event[0:]) != procEventExec {
+		return 0, false
+	}
+	// exec_proc_event: process_pid(4) process_tgid(4)
+	tgid := nativeEndian.Uint32(event[procEventSize+4:])
+	return int(tgid), tgid > 0
+}
@@ -0,0 +1,59 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build linux
+// +build linux
+
+package splittun
+
+import (
+	"testing"
+)
+
+func TestParseProcExecEvent(t *testing.T) {
+	event := func(what, pid, tgid uint32) []byte {
+		b := make([]byte, cnMsgSize+procEventSize+8)
+		nativeEndian.PutUint32(b[0:], cnIdxProc)
+		nativeEndian.PutUint32(b[4:], cnValProc)
+		nativeEndian.PutUint16(b[16:], procEventSize+8)
+		nativeEndian.PutUint32(b[cnMsgSize:], what)
+		nativeEndian.PutUint32(b[cnMsgSize+procEventSize:], pid)
+		nativeEndian.PutUint32(b[cnMsgSize+procEventSize+4:], tgid)
+		return b
+	}
+
+	if pid, ok := parseProcExecEvent(event(procEventExec, 1235, 1234)); !ok || pid != 1234 {
+		t.Errorf("exec event: got %d, %v; expected 1234", pid, ok)
+	}
+	if _, ok := parseProcExecEvent(event(0x1 /*PROC_EVENT_FORK*/, 1235, 1234)); ok {
+		t.Error("fork event must be ignored")
+	}
+	if _, ok := parseProcExecEvent(event(procEventExec, 1235, 1234)[:cnMsgSize+procEventSize]); ok {
+		t.Error("truncated event must be ignored")
+	}
+
+	wrongIdx := event(procEventExec, 1235, 1234)
+	nativeEndian.PutUint32(wrongIdx[0:], 0x2)
+	if _, ok := parseProcExecEvent(wrongIdx); ok {
+		t.Error("event of other connector must be ignored")
+	}
+}
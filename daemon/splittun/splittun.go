@@ -91,7 +91,10 @@ func Reset() error {
 }
 
 // ApplyConfig control split-tunnel functionality
-func ApplyConfig(isStEnabled, isStInverse, isStInverseAllowWhenNoVpn, isVpnEnabled bool, addrConfig ConfigAddresses, splitTunnelApps []string) error {
+// splitTunnelApps - Windows: binaries to be excluded from the VPN;
+// Linux: application rules (the processes are moved into the ST environment automatically when started)
+// destinations - rules for destination addresses (applicable for Linux)
+func ApplyConfig(isStEnabled, isStInverse, isStInverseAllowWhenNoVpn, isVpnEnabled bool, addrConfig ConfigAddresses, splitTunnelApps []string, destinations []DestinationRule) error {
 	mutex.Lock()
 	defer mutex.Unlock()
 
@@ -100,7 +103,7 @@ func ApplyConfig(isStEnabled, isStInverse, isStInverseAllowWhenNoVpn, isVpnEnabl
 		addrConfig.IPv6Tunnel = nil
 	}
 
-	retErr := implApplyConfig(isStEnabled, isStInverse, isStInverseAllowWhenNoVpn, isVpnEnabled, addrConfig, splitTunnelApps)
+	retErr := implApplyConfig(isStEnabled, isStInverse, isStInverseAllowWhenNoVpn, isVpnEnabled, addrConfig, splitTunnelApps, destinations)
 	if retErr != nil {
 		log.Error(retErr)
 	}
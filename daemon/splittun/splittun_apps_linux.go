@@ -0,0 +1,241 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build linux
+// +build linux
+
+package splittun
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/oshelpers/linux/netlink"
+)
+
+// Application rules state.
+// Lock order: 'mutex' (package-level) -> 'appsMutex'
+var (
+	appsMutex     sync.Mutex
+	appsRules     []string
+	appsMatcher   *appMatcher
+	appsWatcher   *procConnector // process watcher (nil - not running)
+	appsAddedPids map[int]string // processes moved into the ST environment by the watcher (map[<PID>]<rule>)
+)
+
+func implAppRulesNotAvailableError() error {
+	return funcNotAvailableError
+}
+
+// applyAppRules saves the application rules and starts (or stops) the process watcher.
+// The watcher is running only when Split Tunnel is enabled and there is at least one rule.
+// Running processes which match the rules are moved into the Split Tunnel environment immediately;
+// processes moved earlier by the rules which are removed now, are moved out of the Split Tunnel environment.
+func applyAppRules(isStEnabled bool, rules []string) error {
+	appsMutex.Lock()
+	defer appsMutex.Unlock()
+
+	appsRules = append([]string{}, rules...)
+	appsMatcher = newAppMatcher(appsRules, resolveBinaryPath)
+
+	if !isStEnabled {
+		stopAppsWatcher()
+		// the Split Tunnel environment is erased when ST disabled
+		appsAddedPids = nil
+		return nil
+	}
+
+	var retErr error
+	for pid, rule := range appsAddedPids {
+		if _, err := os.Stat(fmt.Sprintf("/proc/%d", pid)); err != nil {
+			delete(appsAddedPids, pid)
+			continue
+		}
+		if isRuleExists(appsRules, rule) {
+			continue
+		}
+		log.Info(fmt.Sprintf("Application rule '%s' removed: moving PID:%d out of Split Tunnel environment", rule, pid))
+		if err := implRemovePid(pid); err != nil && retErr == nil {
+			retErr = err
+		}
+		delete(appsAddedPids, pid)
+	}
+
+	if appsMatcher.isEmpty() {
+		stopAppsWatcher()
+		return retErr
+	}
+
+	if appsWatcher == nil {
+		c, err := newProcConnector()
+		if err != nil {
+			return fmt.Errorf("unable to start the process watcher for Split Tunnel application rules: %w", err)
+		}
+		if err := c.setReadTimeout(time.Second); err != nil {
+			c.close()
+			return fmt.Errorf("unable to start the process watcher for Split Tunnel application rules: %w", err)
+		}
+		appsWatcher = c
+		go appsWatcherRoutine(c)
+		log.Info("Split Tunnel process watcher started")
+	}
+
+	// the processes which are already running
+	addMatchingRunningProcesses()
+	return retErr
+}
+
+// stopAppsWatcher stops the process watcher (the watcher routine exits on the next read timeout)
+// Note: must be called under 'appsMutex'
+func stopAppsWatcher() {
+	if appsWatcher != nil {
+		appsWatcher = nil
+		log.Info("Split Tunnel process watcher stopped")
+	}
+}
+
+func appsWatcherRoutine(c *procConnector) {
+	defer c.close()
+
+	for {
+		pids, err := c.readExecEvents()
+
+		appsMutex.Lock()
+		isStopped := appsWatcher != c
+		if !isStopped {
+			for _, pid := range pids {
+				addProcessIfMatches(pid)
+			}
+			if err != nil && errors.Is(err, syscall.ENOBUFS) {
+				// the events were lost (too many events in a short period of time)
+				log.Warning("process events lost: checking all running processes")
+				addMatchingRunningProcesses()
+			}
+		}
+		appsMutex.Unlock()
+
+		if isStopped {
+			return
+		}
+		if err != nil && !netlink.IsTimeout(err) && !errors.Is(err, syscall.ENOBUFS) {
+			log.Error(fmt.Errorf("process watcher: %w", err))
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+// addMatchingRunningProcesses moves all running processes which match the rules into the Split Tunnel environment
+// Note: must be called under 'appsMutex'
+func addMatchingRunningProcesses() {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	inCgroup := make(map[int]struct{})
+	if data, err := os.ReadFile(stCgroupVersion.pidsFile()); err == nil {
+		for _, s := range strings.Fields(string(data)) {
+			if pid, err := strconv.Atoi(s); err == nil {
+				inCgroup[pid] = struct{}{}
+			}
+		}
+	}
+
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid <= 1 {
+			continue
+		}
+		if _, ok := inCgroup[pid]; ok {
+			continue
+		}
+		addProcessIfMatches(pid)
+	}
+}
+
+// addProcessIfMatches moves the process into the Split Tunnel environment if it matches any rule
+// Note: must be called under 'appsMutex'
+func addProcessIfMatches(pid int) {
+	if pid == os.Getpid() {
+		return
+	}
+	exe := readProcExe(pid)
+	rule, ok := appsMatcher.match(exe)
+	if !ok {
+		return
+	}
+
+	if err := savePidCgroup(pid); err != nil {
+		log.Warning(fmt.Sprintf("PID:%d (%s): unable to save the original cgroup (the process will be moved to the root cgroup on removal): %s", pid, exe, err))
+	}
+
+	// write directly to the cgroup (without the split-tunnel script): the process must be moved as fast as possible
+	f, err := os.OpenFile(stCgroupVersion.pidsFile(), os.O_WRONLY|os.O_APPEND, 0)
+	if err == nil {
+		_, err = f.WriteString(strconv.Itoa(pid))
+		f.Close()
+	}
+	if err != nil {
+		log.Warning(fmt.Sprintf("failed to move PID:%d (%s) into Split Tunnel environment: %s", pid, exe, err))
+		return
+	}
+
+	if appsAddedPids == nil {
+		appsAddedPids = make(map[int]string)
+	}
+	appsAddedPids[pid] = rule
+	log.Info(fmt.Sprintf("PID:%d (%s) moved into Split Tunnel environment (application rule '%s')", pid, exe, rule))
+}
+
+// readProcExe returns the actual pathname of the executed command (empty string if not accessible, e.g. kernel threads)
+func readProcExe(pid int) string {
+	l, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(l, " (deleted)")
+}
+
+func resolveBinaryPath(path string) string {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return ""
+	}
+	return real
+}
+
+func isRuleExists(rules []string, rule string) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build linux
+// +build linux
+
+package splittun
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// cgroupVersion - cgroup hierarchy which is in use for the Split Tunnel environment
+type cgroupVersion int
+
+const (
+	// Legacy hierarchy: the 'net_cls' controller marks sockets of the cgroup by classid
+	cgroupV1 cgroupVersion = 1
+	// Unified hierarchy: the sockets are matched by the path of the cgroup
+	// (there is no 'net_cls' controller in cgroup v2)
+	cgroupV2 cgroupVersion = 2
+)
+
+const (
+	stCgroupName   = "ivpn-exclude"
+	stCgroupRootV1 = "/sys/fs/cgroup/net_cls"
+	stCgroupRootV2 = "/sys/fs/cgroup"
+
+	// The original cgroups of the processes moved into the Split Tunnel cgroup (one file per PID: '<start time> <cgroup path>').
+	// The split-tunnel script moves the processes back to their original cgroups on removal ('removepid').
+	// Note: the same format is in use by the split-tunnel script
+	stPidsOriginFolder = "/run/ivpn-exclude-pids"
+)
+
+// The cgroup hierarchy detected by implInitialize()
+var stCgroupVersion cgroupVersion = cgroupV1
+
+func (v cgroupVersion) String() string {
+	if v == cgroupV2 {
+		return "v2 (unified hierarchy)"
+	}
+	return "v1 (net_cls)"
+}
+
+func (v cgroupVersion) root() string {
+	if v == cgroupV2 {
+		return stCgroupRootV2
+	}
+	return stCgroupRootV1
+}
+
+// pidsFile returns the path to the file which contains PIDs of all processes in the Split Tunnel cgroup
+func (v cgroupVersion) pidsFile() string {
+	return path.Join(v.root(), stCgroupName, "cgroup.procs")
+}
+
+// savePidCgroup saves the original cgroup of the process (before moving it into the Split Tunnel cgroup),
+// so the process can be moved back to it on removal
+func savePidCgroup(pid int) error {
+	cgroupData, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return err
+	}
+	statData, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return err
+	}
+
+	cgroup := parsePidCgroup(stCgroupVersion, string(cgroupData))
+	startTime := parseProcStartTime(string(statData))
+	if len(cgroup) == 0 || len(startTime) == 0 || path.Join(stCgroupVersion.root(), cgroup) == path.Dir(stCgroupVersion.pidsFile()) {
+		return nil // unknown or already in the Split Tunnel cgroup
+	}
+
+	if err := os.MkdirAll(stPidsOriginFolder, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(stPidsOriginFolder, strconv.Itoa(pid)), []byte(startTime+" "+cgroup+"\n"), 0600)
+}
+
+// parsePidCgroup returns the path of the process cgroup (relative to the cgroup root) from the content of '/proc/<PID>/cgroup'.
+// Line format: '<hierarchy ID>:<controllers>:<path>' (cgroup v2: '0::<path>'; cgroup v1: e.g. '7:net_cls,net_prio:/')
+func parsePidCgroup(v cgroupVersion, data string) string {
+	for _, line := range strings.Split(data, "\n") {
+		cols := strings.SplitN(line, ":", 3)
+		if len(cols) != 3 {
+			continue
+		}
+		if v == cgroupV2 {
+			if cols[0] == "0" && len(cols[1]) == 0 {
+				return cols[2]
+			}
+			continue
+		}
+		for _, c := range strings.Split(cols[1], ",") {
+			if c == "net_cls" {
+				return cols[2]
+			}
+		}
+	}
+	return ""
+}
+
+// parseProcStartTime returns the start time of the process (field 22) from the content of '/proc/<PID>/stat'
+// (it is in use to detect that the PID was reused by another process)
+func parseProcStartTime(stat string) string {
+	// the fields are counted after the command name (it can contain spaces)
+	idx := strings.LastIndex(stat, ") ")
+	if idx < 0 {
+		return ""
+	}
+	fields := strings.Fields(stat[idx+2:])
+	if len(fields) < 20 {
+		return ""
+	}
+	return fields[19]
+}
+
+// scriptArgs returns the arguments for the split-tunnel script
+// (the script is informed about the cgroup hierarchy to use)
+func scriptArgs(args ...string) []string {
+	if stCgroupVersion == cgroupV2 {
+		return append([]string{"-cgroup2"}, args...)
+	}
+	return args
+}
+
+// detectCgroupVersion returns the cgroup hierarchy to be used for the Split Tunnel environment
+func detectCgroupVersion() cgroupVersion {
+	data, err := os.ReadFile("/proc/self/mounts")
+	if err != nil {
+		log.Warning(fmt.Errorf("unable to detect cgroup hierarchy: %w", err))
+		return cgroupV1
+	}
+	return parseCgroupVersion(string(data))
+}
+
+// parseCgroupVersion detects the cgroup hierarchy by the content of '/proc/self/mounts'.
+// The 'net_cls' controller (cgroup v1) is preferred when it is mounted.
+// The unified hierarchy (cgroup v2) is in use only when '/sys/fs/cgroup' is the cgroup2 mount point
+// (e.g. modern Fedora, Ubuntu 22.04+, Arch); otherwise the split-tunnel script is able to mount 'net_cls' itself.
+// Line format: <device> <mount point> <fstype> <options> <dump> <pass>
+func parseCgroupVersion(mounts string) cgroupVersion {
+	isUnified := false
+	for _, line := range strings.Split(mounts, "\n") {
+		cols := strings.Fields(line)
+		if len(cols) < 4 {
+			continue
+		}
+		mountPoint, fsType, options := cols[1], cols[2], cols[3]
+
+		switch fsType {
+		case "cgroup":
+			for _, opt := range strings.Split(options, ",") {
+				if opt == "net_cls" {
+					return cgroupV1
+				}
+			}
+		case "cgroup2":
+			if mountPoint == stCgroupRootV2 {
+				isUnified = true
+			}
+		}
+	}
+
+	if isUnified {
+		return cgroupV2
+	}
+	return cgroupV1
+}
@@ -0,0 +1,88 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+package splittun
+
+import "testing"
+
+func TestParseCgroupVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		mounts string
+		want   cgroupVersion
+	}{
+		{"unified", `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+cgroup2 /sys/fs/cgroup cgroup2 rw,nosuid,nodev,noexec,relatime,nsdelegate,memory_recursiveprot 0 0
+`, cgroupV2},
+		{"legacy", `tmpfs /sys/fs/cgroup tmpfs ro,nosuid,nodev,noexec,mode=755 0 0
+cgroup /sys/fs/cgroup/net_cls,net_prio cgroup rw,nosuid,nodev,noexec,relatime,net_cls,net_prio 0 0
+`, cgroupV1},
+		{"hybrid", `tmpfs /sys/fs/cgroup tmpfs ro,nosuid,nodev,noexec,mode=755 0 0
+cgroup2 /sys/fs/cgroup/unified cgroup2 rw,nosuid,nodev,noexec,relatime,nsdelegate 0 0
+cgroup /sys/fs/cgroup/memory cgroup rw,nosuid,nodev,noexec,relatime,memory 0 0
+`, cgroupV1},
+		{"unified with net_cls mounted", `cgroup2 /sys/fs/cgroup cgroup2 rw,nosuid,nodev,noexec,relatime 0 0
+net_cls /sys/fs/cgroup/net_cls cgroup rw,relatime,net_cls 0 0
+`, cgroupV1},
+		{"no cgroups", "", cgroupV1},
+	}
+
+	for _, tt := range tests {
+		if got := parseCgroupVersion(tt.mounts); got != tt.want {
+			t.Errorf("%s: got %s; expected %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCgroupPidsFile(t *testing.T) {
+	if f := cgroupV1.pidsFile(); f != "/sys/fs/cgroup/net_cls/ivpn-exclude/cgroup.procs" {
+		t.Errorf("unexpected v1 pids file: %s", f)
+	}
+	if f := cgroupV2.pidsFile(); f != "/sys/fs/cgroup/ivpn-exclude/cgroup.procs" {
+		t.Errorf("unexpected v2 pids file: %s", f)
+	}
+}
+
+func TestParsePidCgroup(t *testing.T) {
+	v2 := "0::/user.slice/user-1000.slice/user@1000.service/app.slice/app-firefox.scope\n"
+	if got := parsePidCgroup(cgroupV2, v2); got != "/user.slice/user-1000.slice/user@1000.service/app.slice/app-firefox.scope" {
+		t.Errorf("unexpected v2 cgroup: %s", got)
+	}
+
+	v1 := "12:memory:/user.slice\n7:net_cls,net_prio:/\n1:name=systemd:/user.slice/user-1000.slice/session-2.scope\n0::/user.slice\n"
+	if got := parsePidCgroup(cgroupV1, v1); got != "/" {
+		t.Errorf("unexpected v1 cgroup: %s", got)
+	}
+	if got := parsePidCgroup(cgroupV1, "0::/user.slice\n"); got != "" {
+		t.Errorf("unexpected v1 cgroup without net_cls: %s", got)
+	}
+}
+
+func TestParseProcStartTime(t *testing.T) {
+	stat := "1234 (Web Content) S 1000 1000 1000 0 -1 4194560 100 0 0 0 10 5 0 0 20 0 30 0 987654 123456 789 18446744073709551615"
+	if got := parseProcStartTime(stat); got != "987654" {
+		t.Errorf("unexpected start time: %s", got)
+	}
+	if got := parseProcStartTime("1234 (bad"); got != "" {
+		t.Errorf("unexpected start time for bad data: %s", got)
+	}
+}
@@ -42,10 +42,22 @@ func implReset() error {
 	return notImplementedError
 }
 
-func implApplyConfig(isStEnabled, isStInversed, isStInverseAllowWhenNoVpn, isVpnEnabled bool, addrConfig ConfigAddresses, splitTunnelApps []string) error {
+func implApplyConfig(isStEnabled, isStInversed, isStInverseAllowWhenNoVpn, isVpnEnabled bool, addrConfig ConfigAddresses, splitTunnelApps []string, destinations []DestinationRule) error {
 	return notImplementedError
 }
 
+func implDestinationsNotAvailableError() error {
+	return notImplementedError
+}
+
+func implAppRulesNotAvailableError() error {
+	return notImplementedError
+}
+
+func implGetDestinationsStatus() []DestinationStatus {
+	return nil
+}
+
 func implAddPid(pid int, commandToExecute string) error {
 	return notImplementedError
 }
@@ -0,0 +1,274 @@
+//
+//  Daemon for IVPN Client Desktop
+//  https://github.com/tahirmahm123/vpn-desktop-app
+//
+//  Created by Stelnykovych Alexandr.
+//  Copyright (c) 2023 IVPN Limited.
+//
+//  This file is part of the Daemon for IVPN Client Desktop.
+//
+//  The Daemon for IVPN Client Desktop is free software: you can redistribute it and/or
+//  modify it under the terms of the GNU General Public License as published by the Free
+//  Software Foundation, either version 3 of the License, or (at your option) any later version.
+//
+//  The Daemon for IVPN Client Desktop is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+//  or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for more
+//  details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with the Daemon for IVPN Client Desktop. If not, see <https://www.gnu.org/licenses/>.
+//
+
+//go:build linux
+// +build linux
+
+package splittun
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/oshelpers/linux/netlink"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/shell"
+)
+
+// Destination rules state.
+// Lock order: 'mutex' (package-level) -> 'destMutex'
+var (
+	destMutex    sync.Mutex
+	destRules    []DestinationRule
+	destIsActive bool                // Split Tunnel is enabled (the rules can be applied)
+	destResolved map[string][]net.IP // resolved domain names and addresses learned for wildcard domains (key: domain name)
+	destErrors   map[string]error    // resolving errors (key: domain name)
+	destApplied  []string            // entries applied by the split-tunnel script (nil - not applied)
+	destSnooper  *dnsSnooper         // DNS responses snooper for wildcard domains (nil - not running)
+
+	// request to resolve the domain names and apply the rules
+	destUpdateChan = make(chan struct{}, 1)
+)
+
+func implDestinationsNotAvailableError() error {
+	return funcNotAvailableError
+}
+
+func implGetDestinationsStatus() []DestinationStatus {
+	destMutex.Lock()
+	defer destMutex.Unlock()
+
+	ret := make([]DestinationStatus, 0, len(destRules))
+	for _, r := range destRules {
+		st := DestinationStatus{DestinationRule: r}
+		if r.IsDomain() {
+			name := r.lookupName()
+			for _, ip := range destResolved[name] {
+				st.Addresses = append(st.Addresses, ip.String())
+			}
+			if err := destErrors[name]; err != nil {
+				st.Error = err.Error()
+			}
+		}
+		ret = append(ret, st)
+	}
+	return ret
+}
+
+// applyDestinations saves the destination rules and applies them (if Split Tunnel is enabled).
+// The rules are applied immediately with the addresses resolved earlier;
+// the domain names are resolved in the background (the rules are re-applied if the addresses changed).
+// Note: the package mutex must be locked.
+func applyDestinations(isStEnabled bool, rules []DestinationRule) error {
+	destMutex.Lock()
+	defer destMutex.Unlock()
+
+	destRules = append([]DestinationRule{}, rules...)
+	destIsActive = isStEnabled
+	// the split-tunnel script re-creates the rules on each 'start'
+	destApplied = nil
+
+	if !isStEnabled || !isAnyWildcard(destRules) {
+		stopDnsSnooper()
+	}
+	if !isStEnabled {
+		return nil
+	}
+
+	err := applyDestinationEntries(destinationEntries(destRules, destResolved))
+	requestDestinationsUpdate(false)
+
+	if isAnyWildcard(destRules) && destSnooper == nil {
+		s, e := newDnsSnooper()
+		if e == nil {
+			if e = s.setReadTimeout(time.Second); e != nil {
+				s.close()
+			}
+		}
+		if e != nil {
+			if err == nil {
+				err = fmt.Errorf("unable to start DNS snooper for Split Tunnel wildcard destinations: %w", e)
+			}
+		} else {
+			destSnooper = s
+			go dnsSnooperRoutine(s)
+			log.Info("Split Tunnel DNS snooper started (wildcard destinations)")
+		}
+	}
+	return err
+}
+
+// stopDnsSnooper stops the DNS snooper (the snooper routine exits on the next read timeout)
+// Note: must be called under 'destMutex'
+func stopDnsSnooper() {
+	if destSnooper != nil {
+		destSnooper = nil
+		log.Info("Split Tunnel DNS snooper stopped")
+	}
+}
+
+// dnsSnooperRoutine learns the addresses of wildcard domains from DNS responses
+// and re-applies the rules when new addresses are learned
+func dnsSnooperRoutine(s *dnsSnooper) {
+	defer s.close()
+
+	for {
+		names, ips, ok, err := s.readResponse()
+
+		isStopped := func() bool {
+			mutex.Lock()
+			defer mutex.Unlock()
+			destMutex.Lock()
+			defer destMutex.Unlock()
+
+			if destSnooper != s {
+				return true
+			}
+			if !ok || !destIsActive {
+				return false
+			}
+			resolved, isChanged := learnDestinations(destRules, destResolved, names, ips)
+			if !isChanged {
+				return false
+			}
+			destResolved = resolved
+			if err := applyDestinationEntries(destinationEntries(destRules, destResolved)); err != nil {
+				log.Error(err)
+			}
+			return false
+		}()
+
+		if isStopped {
+			return
+		}
+		if err != nil && !netlink.IsTimeout(err) && !errors.Is(err, syscall.EINTR) {
+			log.Error(fmt.Errorf("DNS snooper: %w", err))
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func isAnyWildcard(rules []DestinationRule) bool {
+	for _, r := range rules {
+		if r.IsWildcard() {
+			return true
+		}
+	}
+	return false
+}
+
+// requestDestinationsUpdate requests to resolve the domain names and update the rules.
+// isForceApply - apply the rules even if the resolved addresses not changed
+func requestDestinationsUpdate(isForceApply bool) {
+	if isForceApply {
+		destMutex.Lock()
+		destApplied = nil
+		destMutex.Unlock()
+	}
+
+	select {
+	case destUpdateChan <- struct{}{}:
+	default: // update already requested
+	}
+}
+
+// destinationsResolver resolves the domain names of destination rules continuously
+// and re-applies the rules when the addresses are changed
+func destinationsResolver() {
+	ticker := time.NewTicker(destinationsResolveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-destUpdateChan:
+		}
+
+		destMutex.Lock()
+		rules := append([]DestinationRule{}, destRules...)
+		prevResolved := destResolved // the map is never modified (only replaced)
+		isActive := destIsActive
+		destMutex.Unlock()
+
+		if !isActive {
+			continue
+		}
+
+		resolved, errs := resolveDestinations(rules, prevResolved)
+		for name, err := range errs {
+			log.Warning(fmt.Sprintf("failed to resolve destination '%s' (the last known addresses are in use): %v", name, err))
+		}
+
+		func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			destMutex.Lock()
+			defer destMutex.Unlock()
+
+			if !destIsActive || !reflect.DeepEqual(rules, destRules) {
+				return // configuration changed while resolving
+			}
+			// keep the addresses learned for wildcard domains (they could be learned while resolving)
+			for _, r := range destRules {
+				if r.IsWildcard() {
+					resolved[r.lookupName()] = destResolved[r.lookupName()]
+				}
+			}
+			destResolved, destErrors = resolved, errs
+			if err := applyDestinationEntries(destinationEntries(destRules, destResolved)); err != nil {
+				log.Error(err)
+			}
+		}()
+	}
+}
+
+// applyDestinationEntries applies the rules by the split-tunnel script (if they differ from the rules applied before).
+// Note: 'destMutex' must be locked.
+func applyDestinationEntries(entries []destinationEntry) error {
+	args := make([]string, 0, len(entries))
+	for _, e := range entries {
+		args = append(args, e.String())
+	}
+	if destApplied != nil && reflect.DeepEqual(args, destApplied) {
+		return nil
+	}
+	if destApplied == nil && len(args) == 0 {
+		// the rules are erased by the script on 'start'
+		destApplied = args
+		return nil
+	}
+
+	if len(args) > 0 {
+		log.Info("Applying destination rules: ", strings.Join(args, " "))
+	}
+	if err := shell.Exec(nil, stScriptPath, scriptArgs(append([]string{"destinations"}, args...)...)...); err != nil {
+		destApplied = nil
+		return fmt.Errorf("failed to apply Split Tunnel destination rules: %w", err)
+	}
+	destApplied = args
+	return nil
+}
@@ -51,8 +51,6 @@ var (
 // (map[<PID>]<command>)
 var _addedRootProcesses map[int]string = map[int]string{}
 
-const stPidsFile = "/sys/fs/cgroup/net_cls/ivpn-exclude/cgroup.procs"
-
 func implInitialize() error {
 	funcNotAvailableError = nil
 
@@ -68,6 +66,9 @@ func implInitialize() error {
 		return funcNotAvailableError
 	}
 
+	stCgroupVersion = detectCgroupVersion()
+	log.Info(fmt.Sprintf("Split Tunnel cgroup: %s", stCgroupVersion))
+
 	// Hardcoded text for detection of inverse mode not available error
 	const inverseModeErrorDetectionText = "Warning: Inverse mode for IVPN Split Tunnel functionality is not applicable."
 	// check if ST functionality accessible
@@ -84,7 +85,7 @@ func implInitialize() error {
 			log.Info("Split Tunnel test: " + text)
 		}
 	}
-	err := shell.ExecAndProcessOutput(nil, outProcessFunc, "", stScriptPath, "test")
+	err := shell.ExecAndProcessOutput(nil, outProcessFunc, "", stScriptPath, scriptArgs("test")...)
 	if err != nil {
 		funcNotAvailableError = err
 	}
@@ -102,6 +103,8 @@ func implInitialize() error {
 		if err := netlink.RegisterLanChangeListener(onNetChange); err != nil {
 			return err
 		}
+		go destinationsResolver()
+
 		// Wait for network chnages in sepatate routine
 		go func() {
 			var timerDelay *time.Timer
@@ -114,10 +117,12 @@ func implInitialize() error {
 					// We can receive many 'lan change' events in a short period of time
 					// but we update routes not more often than once per 2 seconds.
 					timerDelay = time.AfterFunc(time.Second*2, func() {
-						err := shell.Exec(nil, stScriptPath, "update-routes")
+						err := shell.Exec(nil, stScriptPath, scriptArgs("update-routes")...)
 						if err != nil {
 							log.Error("failed to update routes for SplitTunneling functionality")
 						}
+						// the network is changed: the domain names of destination rules may be resolved to another addresses
+						requestDestinationsUpdate(true)
 					})
 				}
 			}
@@ -134,10 +139,10 @@ func implFuncNotAvailableError() (generalStError, inversedStError error) {
 func implReset() error {
 	log.Info("Removing all PIDs")
 
-	return shell.Exec(nil, stScriptPath, "reset")
+	return shell.Exec(nil, stScriptPath, scriptArgs("reset")...)
 }
 
-func implApplyConfig(isStEnabled, isStInversed, isStInverseAllowWhenNoVpn, isVpnEnabled bool, addrConfig ConfigAddresses, splitTunnelApps []string) error {
+func implApplyConfig(isStEnabled, isStInversed, isStInverseAllowWhenNoVpn, isVpnEnabled bool, addrConfig ConfigAddresses, splitTunnelApps []string, destinations []DestinationRule) error {
 	// If VPN does not support IPv6 - block IPv6 connectivity for 'splitted' apps in inverse mode
 	vpnNoIPv6 := false
 	if isVpnEnabled && len(addrConfig.IPv6Tunnel) == 0 {
@@ -147,6 +152,20 @@ func implApplyConfig(isStEnabled, isStInversed, isStInverseAllowWhenNoVpn, isVpn
 	err := enable(isStEnabled, isStInversed, isStInverseAllowWhenNoVpn, isVpnEnabled, vpnNoIPv6)
 	if err != nil {
 		log.Error(err)
+		isStEnabled = false
+	}
+
+	if e := applyDestinations(isStEnabled, destinations); e != nil {
+		log.Error(e)
+		if err == nil {
+			err = e
+		}
+	}
+	if e := applyAppRules(isStEnabled, splitTunnelApps); e != nil {
+		log.Error(e)
+		if err == nil {
+			err = e
+		}
 	}
 	return err
 }
@@ -165,7 +184,7 @@ func implAddPid(pid int, commandToExecute string) error {
 		return fmt.Errorf("the Split Tunnel is disabled")
 	}
 
-	err = shell.Exec(nil, stScriptPath, "addpid", strconv.Itoa(pid))
+	err = shell.Exec(nil, stScriptPath, scriptArgs("addpid", strconv.Itoa(pid))...)
 	if err == nil {
 		_addedRootProcesses[pid] = commandToExecute
 	}
@@ -201,7 +220,7 @@ func implRemovePid(pid int) error {
 	// remove all required pids
 	for pidToRemove := range pids {
 		log.Info(fmt.Sprintf("Removing PID:%d", pidToRemove))
-		err := shell.Exec(nil, stScriptPath, "removepid", strconv.Itoa(pidToRemove))
+		err := shell.Exec(nil, stScriptPath, scriptArgs("removepid", strconv.Itoa(pidToRemove))...)
 		if err != nil && retErr == nil {
 			retErr = err
 		}
@@ -216,7 +235,7 @@ func implGetRunningApps() (allProcesses []RunningApp, err error) {
 	// https://man7.org/linux/man-pages/man5/proc.5.html
 
 	// read all PIDs which are active in ST environment
-	bytes, err := os.ReadFile(stPidsFile)
+	bytes, err := os.ReadFile(stCgroupVersion.pidsFile())
 	if err != nil {
 		return nil, err
 	}
@@ -366,7 +385,7 @@ func implGetRunningApps() (allProcesses []RunningApp, err error) {
 }
 
 func isEnabled() (bool, error) {
-	err := shell.Exec(nil, stScriptPath, "status")
+	err := shell.Exec(nil, stScriptPath, scriptArgs("status")...)
 	if err != nil {
 		return false, nil
 	}
@@ -379,7 +398,7 @@ func enable(isEnable, isStInversed, isStInverseAllowWhenNoVpn, isVpnConnected, v
 		if err == nil && !enabled {
 			return nil
 		}
-		err = shell.Exec(log, stScriptPath, "stop")
+		err = shell.Exec(log, stScriptPath, scriptArgs("stop")...)
 		if err != nil {
 			return fmt.Errorf("failed to disable Split Tunnel: %w", err)
 		}
@@ -398,13 +417,13 @@ func enable(isEnable, isStInversed, isStInverseAllowWhenNoVpn, isVpnConnected, v
 			}
 
 		}
-		_, outErrText, _, _, err := shell.ExecAndGetOutput(log, 1024, "", stScriptPath, "start", inversedArg, inverseBlockArg)
+		_, outErrText, _, _, err := shell.ExecAndGetOutput(log, 1024, "", stScriptPath, scriptArgs("start", inversedArg, inverseBlockArg)...)
 		if err != nil {
 			if len(outErrText) > 0 {
 				err = fmt.Errorf("(%w) %s", err, outErrText)
 			}
 			// if ST start failed - clean everything (by command 'stop')
-			shell.Exec(nil, stScriptPath, "stop")
+			shell.Exec(nil, stScriptPath, scriptArgs("stop")...)
 			return fmt.Errorf("failed to enable Split Tunnel: %w", err)
 		}
 		log.Info("Split Tunnel enabled")
@@ -461,7 +480,7 @@ func readProcEnvVarIvpnId(pid int) (int, error) {
 	}
 
 	id := 0
-	vars := strings.Split(string(bytes), string(0))
+	vars := strings.Split(string(bytes), "\x00")
 	for _, line := range vars {
 		cols := strings.Split(line, "=")
 		if len(cols) != 2 {
@@ -169,7 +169,7 @@ func implReset() error {
 	return nil
 }
 
-func implApplyConfig(isStEnabled, isStInversed, isStInverseAllowWhenNoVpn, isVpnEnabled bool, addrConfig ConfigAddresses, splitTunnelApps []string) error {
+func implApplyConfig(isStEnabled, isStInversed, isStInverseAllowWhenNoVpn, isVpnEnabled bool, addrConfig ConfigAddresses, splitTunnelApps []string, destinations []DestinationRule) error {
 	// Check if functionality available
 	splitTunErr, splitTunInversedErr := GetFuncNotAvailableError()
 	isFunctionalityNotAvailable := splitTunErr != nil || (isStInversed && splitTunInversedErr != nil)
@@ -272,6 +272,18 @@ func implApplyConfig(isStEnabled, isStInversed, isStInverseAllowWhenNoVpn, isVpn
 	return nil
 }
 
+func implDestinationsNotAvailableError() error {
+	return fmt.Errorf("Split Tunnel destination rules are not implemented for this platform")
+}
+
+func implAppRulesNotAvailableError() error {
+	return fmt.Errorf("Split Tunnel application rules are not applicable for this platform (use SplitTunnelAddApp)")
+}
+
+func implGetDestinationsStatus() []DestinationStatus {
+	return nil
+}
+
 func implAddPid(pid int, commandToExecute string) error {
 	return fmt.Errorf("operation not applicable for current platform")
 }
@@ -26,7 +26,7 @@ import (
 	"fmt"
 	"net"
 
-	"github.com/tahirmahm123/vpn-desktop-app/daemon/shell"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/netinfo"
 )
 
 func implInit() {
@@ -39,9 +39,9 @@ func (v *V2RayWrapper) implSetMainRoute(defaultGateway net.IP) error {
 		return fmt.Errorf("getting remote endpoint error : %w", err)
 	}
 
-	// /sbin/ip route add 144.217.148.72/32 via 192.168.2.1
-	if err := shell.Exec(log, "ip", "route", "add", remoteHost.String()+"/32", "via", defaultGateway.String()); err != nil {
-		return fmt.Errorf("adding route shell comand error : %w", err)
+	// the same as: /sbin/ip route add 144.217.148.72/32 via 192.168.2.1
+	if err := netinfo.AddHostRoute(remoteHost, defaultGateway); err != nil {
+		return fmt.Errorf("adding route error : %w", err)
 	}
 
 	return nil
@@ -53,6 +53,8 @@ func (v *V2RayWrapper) implDeleteMainRoute() error {
 		return fmt.Errorf("getting remote endpoint error : %w", err)
 	}
 
-	shell.Exec(log, "ip", "route", "delete", remoteHost.String()+"/32")
+	if err := netinfo.DeleteHostRoute(remoteHost); err != nil {
+		log.Info(err)
+	}
 	return nil
 }
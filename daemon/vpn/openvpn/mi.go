@@ -60,8 +60,16 @@ type ManagementInterface struct {
 
 	pushReplyCmds []string
 	pushReplyDNS  net.IP
+
+	// traffic counters (received from OpenVPN by 'bytecount' notifications)
+	bytecountMutex sync.Mutex
+	bytesIn        uint64
+	bytesOut       uint64
 }
 
+// interval (seconds) of the traffic counters notifications from OpenVPN
+const bytecountIntervalSec = 5
+
 // StartManagementInterface - starts TCP interface to communicate with IVPN application (server to listen incoming connections)
 func StartManagementInterface(miSecret string, username string, password string, stateChan chan<- vpn.StateInfo) (mi *ManagementInterface, err error) {
 	ret := &ManagementInterface{
@@ -140,6 +148,13 @@ func (i *ManagementInterface) SendDisconnect() error {
 	return i.sendResponse("signal SIGTERM")
 }
 
+// GetBytecount returns the latest known traffic counters of the tunnel
+func (i *ManagementInterface) GetBytecount() (bytesIn, bytesOut uint64) {
+	i.bytecountMutex.Lock()
+	defer i.bytecountMutex.Unlock()
+	return i.bytesIn, i.bytesOut
+}
+
 // GetRouteAddCommands - return all detected route-add command
 func (i *ManagementInterface) GetRouteAddCommands() []string {
 	i.routeAddCmdsMutex.Lock()
@@ -283,7 +298,10 @@ func (i *ManagementInterface) miCommunication() {
 			continue
 		}
 
-		i.log.Info("[<-]: ", message)
+		// traffic counters are received periodically: do not flood the log
+		if !strings.HasPrefix(message, ">BYTECOUNT:") {
+			i.log.Info("[<-]: ", message)
+		}
 
 		columns := mesRegexp.FindStringSubmatch(message)
 		if len(columns) <= 2 {
@@ -325,7 +343,22 @@ func (i *ManagementInterface) miCommunication() {
 		case "INFO":
 
 		case "HOLD":
-			i.sendResponse("state on", "log on", "hold off", "hold release")
+			i.sendResponse("state on", "log on", fmt.Sprintf("bytecount %d", bytecountIntervalSec), "hold off", "hold release")
+
+		case "BYTECOUNT":
+			// >BYTECOUNT:{BYTES_IN},{BYTES_OUT}
+			cols := strings.Split(strings.TrimSpace(msgText), ",")
+			if len(cols) != 2 {
+				continue
+			}
+			bytesIn, errIn := strconv.ParseUint(cols[0], 10, 64)
+			bytesOut, errOut := strconv.ParseUint(cols[1], 10, 64)
+			if errIn != nil || errOut != nil {
+				continue
+			}
+			i.bytecountMutex.Lock()
+			i.bytesIn, i.bytesOut = bytesIn, bytesOut
+			i.bytecountMutex.Unlock()
 
 		case "PASSWORD":
 			if strings.HasPrefix(msgText, "Verification Failed: 'Auth'") {
@@ -36,6 +36,7 @@ import (
 	"sync"
 
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/logger"
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/netinfo"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/obfsproxy"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/platform"
@@ -608,3 +609,21 @@ func (o *OpenVPN) OnRoutingChanged() error {
 func (o *OpenVPN) IsIPv6InTunnel() bool {
 	return false
 }
+
+// GetStatistics returns live statistics of the tunnel
+func (o *OpenVPN) GetStatistics() (vpn.Statistics, error) {
+	mi := o.managementInterface
+	if mi == nil || !mi.isConnected || o.state != vpn.CONNECTED {
+		return vpn.Statistics{}, fmt.Errorf("not connected")
+	}
+
+	var stats vpn.Statistics
+	stats.RxBytes, stats.TxBytes = mi.GetBytecount()
+	if o.connectParams.hostIP != nil {
+		stats.Endpoint = net.JoinHostPort(o.connectParams.hostIP.String(), strconv.Itoa(o.connectParams.hostPort))
+	}
+	if iface, err := netinfo.InterfaceByIPAddr(o.clientIP); err == nil {
+		stats.Mtu = iface.MTU
+	}
+	return stats, nil
+}
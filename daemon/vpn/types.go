@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/obfsproxy"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
@@ -146,6 +147,10 @@ type StateInfo struct {
 	ExitHostname string                 // applicable only for 'CONNECTED' state
 	Mtu          int                    // applicable only for 'CONNECTED' state (WireGuard)
 	IsAuthError  bool                   // applicable only for 'EXITING' state
+	// applicable only for 'CONNECTED' state (WireGuard): the PresharedKey (exchanged using post-quantum KEM) is in use
+	IsQuantumResistant bool
+	// applicable only for 'CONNECTED' state: the connection fallback attempt which succeeded (empty if the fallback is not in use)
+	FallbackAttempt string
 
 	// TODO: try to avoid using this protocol-specific parameter in future
 	// Currently, in use by OpenVPN connection to inform about "RECONNECTING" reason (e.g. "tls-error", "init_instance"...)
@@ -153,6 +158,13 @@ type StateInfo struct {
 	StateAdditionalInfo string
 }
 
+// StateAdditionalInfo value for the 'RECONNECTING' state: the reconnection was initiated by the tunnel health monitor
+// (the tunnel stopped passing traffic)
+const ReconnectReasonTunnelUnhealthy = "tunnel-unhealthy"
+
+// StateAdditionalInfo value for the 'RECONNECTING' state: the connection is not established; trying the next connection fallback attempt
+const ReconnectReasonConnectionFallback = "connection-fallback"
+
 // NewStateInfo - create new state object (not applicable for CONNECTED state)
 func NewStateInfo(state State, description string) StateInfo {
 	return StateInfo{
@@ -179,6 +191,15 @@ func NewStateInfoConnected(isTCP bool, clientIP net.IP, clientIPv6 net.IP, local
 	}
 }
 
+// Statistics - live statistics of the active VPN tunnel
+type Statistics struct {
+	RxBytes       uint64    // bytes received over the tunnel
+	TxBytes       uint64    // bytes sent over the tunnel
+	LastHandshake time.Time // time of the latest handshake (applicable only for WireGuard; zero value when unknown)
+	Endpoint      string    // current remote endpoint ("IP:port")
+	Mtu           int
+}
+
 // Process represents VPN object operations
 type Process interface {
 	// Type just returns VPN type
@@ -206,6 +227,9 @@ type Process interface {
 	IsIPv6InTunnel() bool
 
 	OnRoutingChanged() error
+
+	// GetStatistics returns live statistics of the tunnel (returns error when the tunnel is not established)
+	GetStatistics() (Statistics, error)
 }
 
 // ReconnectionRequiredError object can be returned by vpn.Process.Connect() function
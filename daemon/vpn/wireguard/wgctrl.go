@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
 	"golang.zx2c4.com/wireguard/wgctrl"
 )
 
@@ -138,3 +139,30 @@ func waitForWgInterfaceChan(tunnelName string, isWaitForDisconnect bool, isStopA
 
 	return retChan
 }
+
+// GetDeviceStatistics returns the traffic counters, the latest handshake time and the current endpoint of the WireGuard tunnel
+// (the MTU is not filled by this function)
+func GetDeviceStatistics(tunnelName string) (stats vpn.Statistics, err error) {
+	client, err := wgctrl.New()
+	if err != nil {
+		return stats, fmt.Errorf("failed to get WireGuard device info: %w", err)
+	}
+	defer client.Close()
+
+	dev, err := client.Device(tunnelName)
+	if err != nil {
+		return stats, fmt.Errorf("failed to get WireGuard device info for '%s': %w", tunnelName, err)
+	}
+
+	for _, peer := range dev.Peers {
+		stats.RxBytes += uint64(peer.ReceiveBytes)
+		stats.TxBytes += uint64(peer.TransmitBytes)
+		if peer.LastHandshakeTime.After(stats.LastHandshake) {
+			stats.LastHandshake = peer.LastHandshakeTime
+		}
+		if peer.Endpoint != nil && len(stats.Endpoint) == 0 {
+			stats.Endpoint = peer.Endpoint.String()
+		}
+	}
+	return stats, nil
+}
@@ -328,6 +328,7 @@ func (wg *WireGuard) newStateInfoConnected() vpn.StateInfo {
 		wg.connectParams.mtu)
 
 	si.ExitHostname = wg.connectParams.multihopExitHostname
+	si.IsQuantumResistant = len(wg.connectParams.presharedKey) > 0
 	return si
 }
 
@@ -348,3 +349,25 @@ func (wg *WireGuard) OnRoutingChanged() error {
 func (wg *WireGuard) IsIPv6InTunnel() bool {
 	return len(wg.connectParams.GetIPv6ClientLocalIP()) > 0
 }
+
+// GetStatistics returns live statistics of the tunnel
+func (wg *WireGuard) GetStatistics() (vpn.Statistics, error) {
+	if wg.isDisconnected {
+		return vpn.Statistics{}, fmt.Errorf("not connected")
+	}
+
+	stats, err := GetDeviceStatistics(wg.GetTunnelName())
+	if err != nil {
+		return stats, err
+	}
+
+	if len(stats.Endpoint) == 0 && wg.connectParams.hostIP != nil {
+		stats.Endpoint = net.JoinHostPort(wg.connectParams.hostIP.String(), strconv.Itoa(wg.connectParams.hostPort))
+	}
+
+	stats.Mtu = wg.connectParams.mtu
+	if iface, err := net.InterfaceByName(wg.GetTunnelName()); err == nil {
+		stats.Mtu = iface.MTU
+	}
+	return stats, nil
+}
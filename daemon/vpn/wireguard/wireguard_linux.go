@@ -34,6 +34,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/tahirmahm123/vpn-desktop-app/daemon/netinfo"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/service/dns"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/shell"
 	"github.com/tahirmahm123/vpn-desktop-app/daemon/vpn"
@@ -98,12 +99,8 @@ func (wg *WireGuard) init() error {
 	i, _ := net.InterfaceByName(wgInterfaceName)
 	if i != nil {
 		log.Info(fmt.Sprintf("Stopping WireGuard interface ('%s' expected to be stopped before the new connection)...", wgInterfaceName))
-		err := shell.Exec(log, "ip", "link", "set", "down", wgInterfaceName) // command: sudo ip link set down wgivpn
-		if err != nil {
-			log.Warning(err)
-		}
-		err = shell.Exec(log, "ip", "link", "delete", wgInterfaceName) // command: sudo ip link delete wgivpn
-		if err != nil {
+		// the same as: sudo ip link delete wgivpn (the routes over the interface are removed by the kernel)
+		if err := netinfo.DeleteInterface(wgInterfaceName); err != nil {
 			log.Warning(err)
 		}
 	}